@@ -5,13 +5,16 @@
 package holochain
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	. "github.com/holochain/holochain-proto/hash"
 	b58 "github.com/jbenet/go-base58"
 	ic "github.com/libp2p/go-libp2p-crypto"
 	peer "github.com/libp2p/go-libp2p-peer"
+	mh "github.com/multiformats/go-multihash"
 	"reflect"
+	"sync"
 	"time"
 )
 
@@ -69,6 +72,8 @@ type CommittingAction interface {
 	Entry() Entry
 	SetHeader(header *Header)
 	GetHeader() (header *Header)
+	// recomputes the hash of Entry() and checks it against the header's EntryLink
+	VerifyEntryLink() (err error)
 	// Low level implementation of putting to DHT (assumes validation has been done)
 	Share(h *Holochain, def *EntryDef) (err error)
 }
@@ -95,11 +100,68 @@ var ErrNotValidForDelType error = errors.New("Invalid action for Del type")
 var ErrModInvalidForLinks error = errors.New("mod: invalid for Links entry")
 var ErrModMissingHeader error = errors.New("mod: missing header")
 var ErrModReplacesHashNotDifferent error = errors.New("mod: replaces must be different from original hash")
+var ErrModMigrateOriginalNotFound error = errors.New("mod: replaced migrate entry not found")
+var ErrModMigrateAuthorMismatch error = errors.New("mod: migrate entry can only be modified by its author")
+var ErrModMigrateTypeChanged error = errors.New("mod: migrate Type can't be changed to or from open or close")
+var ErrModMigrateKeyChanged error = errors.New("mod: migrate Key can't be changed")
 var ErrEntryDefInvalid = errors.New("Invalid Entry Defintion")
 var ErrActionMissingHeader error = errors.New("Action is missing header")
 var ErrActionReceiveInvalid error = errors.New("Action receive is invalid")
+var ErrMigrateRollbackTargetInvalid error = errors.New("migrate rollback: referenced header is not a migrate entry")
+var ErrMigrateAlreadyRolledBack error = errors.New("migrate rollback: migrate entry already rolled back")
+var ErrMigrateOpenNotFirst error = errors.New("migrate: open must be the first entry after genesis")
+var ErrMigrateHashCodecUnsupported error = errors.New("migrate: DNAHash must use a hash codec that can be computed")
+var ErrMigrateHashCodecMismatch error = errors.New("migrate: Key must use the hash codec of node IDs")
+var ErrOrphanMigration error = errors.New("migrate: open does not link to a close migrate")
+var ErrMigrateForkedChain error = errors.New("migrate: source chain is forked")
+var ErrDelMigrateAuthorMismatch error = errors.New("del: migrate entry can only be deleted by its author")
+var ErrDelMigrateCloseInEffect error = errors.New("del: close migrate is still in effect, roll it back first")
 
 var ErrNilEntryInvalid error = errors.New("nil entry invalid")
+var ErrInvalidSource error = errors.New("invalid source")
+var ErrEntryLinkMismatch error = errors.New("entry hash doesn't match header EntryLink")
+
+// EntryLinkMismatchError reports an action whose entry doesn't hash to its
+// header's EntryLink
+type EntryLinkMismatchError struct {
+	EntryLink Hash
+	EntryHash Hash
+}
+
+func (e *EntryLinkMismatchError) Error() string {
+	return fmt.Sprintf("%v: header has %v, entry hashes to %v", ErrEntryLinkMismatch, e.EntryLink, e.EntryHash)
+}
+
+func (e *EntryLinkMismatchError) Unwrap() error {
+	return ErrEntryLinkMismatch
+}
+
+// verifyEntryLink recomputes the hash of the entry, using the hash codec of
+// the header's EntryLink, and checks that it matches the EntryLink
+func verifyEntryLink(header *Header, entry Entry) (err error) {
+	if header == nil {
+		err = ErrActionMissingHeader
+		return
+	}
+	if entry == nil {
+		err = ErrNilEntryInvalid
+		return
+	}
+	var spec HashSpec
+	spec, err = header.EntryLink.Codec()
+	if err != nil {
+		return
+	}
+	var hash Hash
+	hash, err = entry.Sum(spec)
+	if err != nil {
+		return
+	}
+	if !hash.Equal(header.EntryLink) {
+		err = &EntryLinkMismatchError{EntryLink: header.EntryLink, EntryHash: hash}
+	}
+	return
+}
 
 func prepareSources(sources []peer.ID) (srcs []string) {
 	srcs = make([]string, 0)
@@ -109,26 +171,85 @@ func prepareSources(sources []peer.ID) (srcs []string) {
 	return
 }
 
-// ValidateAction runs the different phases of validating an action
+// ValidateAction runs the different phases of validating an action.  The
+// signals the app's validators emit are delivered once it has passed, and
+// dropped if it fails.  If the action is rejected, it's counted against the
+// reputation of its sources.
 func (h *Holochain) ValidateAction(a ValidatingAction, entryType string, pkg *Package, sources []peer.ID) (def *EntryDef, err error) {
+	return h.validateAndDeliver(a, entryType, pkg, sources, true)
+}
+
+// validateAndDeliver is ValidateAction, only counting a rejection against the
+// sources if count is set
+func (h *Holochain) validateAndDeliver(a ValidatingAction, entryType string, pkg *Package, sources []peer.ID, count bool) (def *EntryDef, err error) {
+	vctx := h.validationContext()
+	var rejected bool
+	def, rejected, err = h.validateAction(vctx, a, entryType, pkg, sources)
+	if err == nil {
+		vctx.deliverSignals(h)
+	} else if rejected && count {
+		h.recordValidationFailure(sources)
+	}
+	return
+}
+
+// validateReceived validates an action received in msg from its sender.  A
+// put replayed by gossip isn't counted against the sender if it's rejected,
+// as the gossiper, not the sender, sent it to us.
+func (dht *DHT) validateReceived(msg *Message, a ValidatingAction, entryType string, pkg *Package) (def *EntryDef, err error) {
+	return dht.h.validateAndDeliver(a, entryType, pkg, []peer.ID{msg.From}, !msg.replayed)
+}
+
+// isAppRejection returns true if an app's validation failed the action,
+// rather than failing to run, i.e. timing out
+func isAppRejection(err error) bool {
+	return IsValidationFailedErr(err) && !errors.Is(err, ErrValidationTimeout)
+}
+
+// validateAction validates the action giving the app's validators vctx,
+// leaving the delivery of the signals they emit to the caller.  rejected is
+// set if the action failed its system or app validations, as opposed to
+// not being validated, i.e. because the source is blocked, the app's
+// validation timed out or of an internal error.
+func (h *Holochain) validateAction(vctx *ValidationContext, a ValidatingAction, entryType string, pkg *Package, sources []peer.ID) (def *EntryDef, rejected bool, err error) {
 
 	defer func() {
 		if err != nil {
 			h.dht.dlog.Logf("%T Validation failed with: %v", a, err)
+			if h.logEnabled(LogWarn) {
+				h.log(LogWarn, "validation failed", LogField{"action", a.Name()}, LogField{"type", entryType}, LogField{"err", err})
+			}
 		}
 	}()
 
+	// refuse actions from a blocked peer before doing any work on them
+	if len(sources) == 1 && h.IsBlocked(sources[0]) {
+		err = ErrSourceBlocked
+		return
+	}
+
 	var z *Zome
 	z, def, err = h.GetEntryDef(entryType)
 	if err != nil {
 		return
 	}
 
-	// run the action's system level validations
-	err = a.SysValidation(h, def, pkg, sources)
-	if err != nil {
-		h.Debugf("Sys ValidateAction(%T) err:%v\n", a, err)
-		return
+	// run the action's system level validations, unless this entry already
+	// passed them against the same package from the same sources
+	cacheKey, cacheable := validationCacheKey(h, a, entryType, pkg, sources)
+	if !cacheable || !h.validationCache.Contains(h.dnaHash, cacheKey) {
+		call := &ActionCall{Phase: PhaseSysValidation, Action: a, EntryType: entryType}
+		_, err = h.dispatchAction(call, func(h *Holochain, call *ActionCall) (interface{}, error) {
+			return nil, a.SysValidation(h, def, pkg, sources)
+		})
+		if err != nil {
+			h.Debugf("Sys ValidateAction(%T) err:%v\n", a, err)
+			rejected = true
+			return
+		}
+		if cacheable {
+			h.validationCache.Add(h.dnaHash, cacheKey)
+		}
 	}
 	if !def.IsSysEntry() {
 
@@ -138,6 +259,7 @@ func (h *Holochain) ValidateAction(a ValidatingAction, entryType string, pkg *Pa
 		if err != nil {
 			return
 		}
+		vctx.bindChain(h, vpkg, sources)
 
 		// run the action's app level validations
 		var n Ribosome
@@ -146,10 +268,14 @@ func (h *Holochain) ValidateAction(a ValidatingAction, entryType string, pkg *Pa
 			return
 		}
 
-		err = n.ValidateAction(a, def, vpkg, prepareSources(sources))
+		err = h.appValidateAction(vctx, n, a, def, vpkg, prepareSources(sources))
 		if err != nil {
 			h.Debugf("Ribosome ValidateAction(%T) err:%v\n", a, err)
 		}
+		rejected = err != nil && isAppRejection(err)
+	} else if entryType == MigrateEntryType {
+		err = h.appValidateMigrate(vctx, a, pkg, sources)
+		rejected = err != nil && isAppRejection(err)
 	}
 	return
 }
@@ -158,7 +284,15 @@ func (h *Holochain) ValidateAction(a ValidatingAction, entryType string, pkg *Pa
 // on the app's requirements
 func (h *Holochain) GetValidationResponse(a ValidatingAction, hash Hash) (resp ValidateResponse, err error) {
 	var entry Entry
-	entry, resp.Type, err = h.chain.GetEntry(hash)
+	chain := h.chain
+	entry, resp.Type, err = chain.GetEntry(hash)
+	if err == ErrHashNotFound {
+		// a self test's entry is only on its throwaway chain
+		if c := h.runningSelfTestChain(); c != nil {
+			chain = c
+			entry, resp.Type, err = chain.GetEntry(hash)
+		}
+	}
 	if err == ErrHashNotFound {
 		if hash.String() == h.nodeIDStr {
 			resp.Type = KeyEntryType
@@ -175,9 +309,9 @@ func (h *Holochain) GetValidationResponse(a ValidatingAction, hash Hash) (resp V
 	} else if err != nil {
 		return
 	} else {
-		resp.Entry = *(entry.(*GobEntry))
+		resp.Entry = asGobEntry(entry)
 		var hd *Header
-		hd, err = h.chain.GetEntryHeader(hash)
+		hd, err = chain.GetEntryHeader(hash)
 		if err != nil {
 			return
 		}
@@ -201,7 +335,14 @@ func (h *Holochain) GetValidationResponse(a ValidatingAction, hash Hash) (resp V
 	case MigrateEntryType:
 		// if migrate entry there no extra info to return in the package so do nothing
 		// TODO: later this might not be true, could return whole chain?
+	case MigrateRollbackEntryType:
+		// if migrate rollback entry there no extra info to return in the package so do nothing
+	case RevocationEntryType, GroupEntryType:
 	default:
+		if _, ok := getRegisteredSysEntryType(resp.Type); ok {
+			// registered sys entries have no extra info to return in the package
+			break
+		}
 		// app defined entry types
 		var def *EntryDef
 		var z *Zome
@@ -228,13 +369,39 @@ func (h *Holochain) GetValidationResponse(a ValidatingAction, hash Hash) (resp V
 		}
 		resp.Package, err = MakePackage(h, req)
 	}
+	// an entry signed by a group member is validated against the author's
+	// group at that entry, which the validator can't get from its own chain
+	if err == nil && resp.Header.Signer != "" {
+		resp.Package.Group, resp.Package.GroupHeader, err = h.chain.groupOfEntry(hash)
+	}
 	return
 }
 
 // MakeActionFromMessage generates an action from an action protocol messsage
 func MakeActionFromMessage(msg *Message) (a Action, err error) {
 	var t reflect.Type
-	switch msg.Type {
+	if msg.Type == ACTION_REQUEST {
+		t = reflect.TypeOf(ActionReq{})
+		req, ok := msg.Body.(ActionReq)
+		if !ok {
+			err = fmt.Errorf("Unexpected request body type '%T' in ACTION_REQUEST request, expecting %v", msg.Body, t)
+			return
+		}
+		a, err = makeNamedAction(req.Action)
+	} else if a, t = actionForMsgType(msg.Type); a == nil {
+		err = fmt.Errorf("message type %d not in holochain-action protocol", int(msg.Type))
+	}
+	if err == nil && reflect.TypeOf(msg.Body) != t {
+		err = fmt.Errorf("Unexpected request body type '%T' in %s request, expecting %v", msg.Body, a.Name(), t)
+	}
+	return
+}
+
+// actionForMsgType returns an empty action for the actions that have their own
+// message type on the action protocol, and the type of the message's body, or
+// nil if the message type isn't one of them
+func actionForMsgType(msgType MsgType) (a Action, t reflect.Type) {
+	switch msgType {
 	case APP_MESSAGE:
 		a = &ActionSend{}
 		t = reflect.TypeOf(AppMsg{})
@@ -256,15 +423,128 @@ func MakeActionFromMessage(msg *Message) (a Action, err error) {
 	case GETLINK_REQUEST:
 		a = &ActionGetLinks{}
 		t = reflect.TypeOf(LinkQuery{})
+	case GETBATCH_REQUEST:
+		a = &ActionGetBatch{}
+		t = reflect.TypeOf(GetBatchReq{})
 	case LISTADD_REQUEST:
 		a = &ActionListAdd{}
 		t = reflect.TypeOf(ListAddReq{})
-	default:
-		err = fmt.Errorf("message type %d not in holochain-action protocol", int(msg.Type))
+	case MIGRATION_STATUS_REQUEST:
+		a = &ActionMigrationStatus{}
+		t = reflect.TypeOf(MigrationStatusReq{})
+	case PUTIF_REQUEST:
+		a = &ActionPutIf{}
+		t = reflect.TypeOf(PutIfReq{})
+	case MIGRATION_TARGETS_REQUEST:
+		a = &ActionMigrationTargets{}
+		t = reflect.TypeOf(MigrationTargetsReq{})
 	}
-	if err == nil && reflect.TypeOf(msg.Body) != t {
-		err = fmt.Errorf("Unexpected request body type '%T' in %s request, expecting %v", msg.Body, a.Name(), t)
+	return
+}
+
+// msgTypeActions returns an empty action for each of the actions that have
+// their own message type
+func msgTypeActions() (actions []Action) {
+	// MIGRATION_TARGETS_REQUEST is the last message type
+	for t := PUT_REQUEST; t <= MIGRATION_TARGETS_REQUEST; t++ {
+		if a, _ := actionForMsgType(t); a != nil {
+			actions = append(actions, a)
+		}
+	}
+	return
+}
+
+// ActionReq holds a message for an action that doesn't have its own message type
+type ActionReq struct {
+	Action string
+	Body   interface{}
+}
+
+// namedActions are the actions that can only be addressed by name through an
+// ACTION_REQUEST
+var namedActions = map[string]func() Action{
+	"migrate":         func() Action { return &ActionMigrate{} },
+	"migrateRollback": func() Action { return &ActionMigrateRollback{} },
+}
+
+// makeNamedAction returns an empty action for the actions that can only be
+// addressed by name through an ACTION_REQUEST
+func makeNamedAction(name string) (a Action, err error) {
+	if makeAction, ok := namedActions[name]; ok {
+		a = makeAction()
+		return
+	}
+	if _, ok := getActionReceiver(name); ok {
+		a = &registeredAction{name: name}
+		return
+	}
+	err = fmt.Errorf("unknown action %s in ACTION_REQUEST", name)
+	return
+}
+
+// registeredAction is the action of the messages of an action an app has
+// registered a receiver for, see RegisterActionReceiver
+type registeredAction struct {
+	name string
+}
+
+func (a *registeredAction) Name() string {
+	return a.name
+}
+
+// Receive is only called if the receiver has been unregistered since the
+// message arrived
+func (a *registeredAction) Receive(dht *DHT, msg *Message) (response interface{}, err error) {
+	err = ErrActionReceiveInvalid
+	return
+}
+
+// ActionReceiverFn is the type of the functions that can be registered to
+// handle the incoming messages of an action
+type ActionReceiverFn func(dht *DHT, msg *Message) (response interface{}, err error)
+
+var actionReceivers = make(map[string]ActionReceiverFn)
+var actionReceiversLk sync.RWMutex
+
+var ErrActionReceiverBuiltIn = errors.New("can't register a receiver for a built-in action with its own message type")
+
+// RegisterActionReceiver registers a function to receive the messages of the
+// named action, which are sent as ACTION_REQUESTs naming it.  The built-in
+// actions that can only be addressed by name, i.e. migrate, can be overridden
+// as their own Receive always fails.  It returns ErrActionReceiverBuiltIn for
+// the names of the actions that have their own message type, as they keep
+// their own Receive.
+func RegisterActionReceiver(actionName string, fn ActionReceiverFn) (err error) {
+	for _, a := range msgTypeActions() {
+		if a.Name() == actionName {
+			err = ErrActionReceiverBuiltIn
+			return
+		}
 	}
+	registerActionReceiver(actionName, fn)
+	return
+}
+
+// registerActionReceiver registers a function to receive the messages of any
+// action, in place of a built-in action's own Receive, i.e. for tests to make
+// a node misbehave
+func registerActionReceiver(actionName string, fn ActionReceiverFn) {
+	actionReceiversLk.Lock()
+	defer actionReceiversLk.Unlock()
+	actionReceivers[actionName] = fn
+}
+
+// UnregisterActionReceiver removes a receive function registered for the named action
+func UnregisterActionReceiver(actionName string) {
+	actionReceiversLk.Lock()
+	defer actionReceiversLk.Unlock()
+	delete(actionReceivers, actionName)
+}
+
+func getActionReceiver(actionName string) (fn ActionReceiverFn, ok bool) {
+	actionReceiversLk.RLock()
+	defer actionReceiversLk.RUnlock()
+	fn, ok = actionReceivers[actionName]
 	return
 }
 
@@ -289,6 +569,32 @@ func argErr(typeName string, index int, arg Arg) error {
 
 // doCommit adds an entry to the local chain after validating the action it's part of
 func (h *Holochain) doCommit(a CommittingAction, change Hash) (d *EntryDef, err error) {
+	return h.doCommitAs(a, change, nil)
+}
+
+// doCommitAs is doCommit signing the header with a group member's key, or
+// with the agent's if member is nil
+func (h *Holochain) doCommitAs(a CommittingAction, change Hash, member ic.PrivKey) (d *EntryDef, err error) {
+	call := &ActionCall{Phase: PhaseCommit, Action: a, EntryType: a.EntryType()}
+	var r interface{}
+	r, err = h.dispatchAction(call, func(h *Holochain, call *ActionCall) (interface{}, error) {
+		d, err := h.commitActionAs(a, change, member)
+		return d, err
+	})
+	if err == nil {
+		d, _ = r.(*EntryDef)
+	}
+	return
+}
+
+// commitAction does the work of doCommit once the action's passed the middleware
+func (h *Holochain) commitAction(a CommittingAction, change Hash) (d *EntryDef, err error) {
+	return h.commitActionAs(a, change, nil)
+}
+
+// commitActionAs is commitAction signing the header with a group member's key,
+// which puts it in the header as its Signer
+func (h *Holochain) commitActionAs(a CommittingAction, change Hash, member ic.PrivKey) (d *EntryDef, err error) {
 
 	entryType := a.EntryType()
 	entry := a.Entry()
@@ -296,6 +602,44 @@ func (h *Holochain) doCommit(a CommittingAction, change Hash) (d *EntryDef, err
 	var hash Hash
 	var header *Header
 	var added bool
+	var vctx *ValidationContext
+
+	if h.isShuttingDown() {
+		err = ErrShuttingDown
+		return
+	}
+
+	if err = h.checkCommitGate(a); err != nil {
+		return
+	}
+
+	privKey := h.agent.PrivKey()
+	var signer string
+	if member != nil {
+		if signer, err = h.Chain().checkMember(entryType, member); err != nil {
+			return
+		}
+		privKey = member
+	}
+
+	// once closed by a migrate, only a rollback or a correction of the close
+	// can be committed
+	current := h.Chain()
+	current.lk.RLock()
+	err = current.checkCommitAfterClose(a)
+	current.lk.RUnlock()
+	if err != nil {
+		return
+	}
+
+	// the chain holds the entry in its def's format so it hashes as it's stored
+	entry, err = h.encodeEntry(entryType, entry)
+	if err != nil {
+		return
+	}
+	if s, ok := a.(entrySetter); ok {
+		s.setEntry(entry)
+	}
 
 	chain := h.Chain()
 	bundle := chain.BundleStarted()
@@ -307,14 +651,27 @@ func (h *Holochain) doCommit(a CommittingAction, change Hash) (d *EntryDef, err
 	for !added {
 		chain.lk.RLock()
 		count := len(chain.Headers)
-		l, hash, header, err = chain.prepareHeader(time.Now(), entryType, entry, h.agent.PrivKey(), change)
+		// a close migrate committed since the check above would change the
+		// count, so checking again here under the lock keeps it from being
+		// slipped past
+		err = chain.checkCommitAfterClose(a)
+		if err == nil {
+			l, hash, header, err = chain.prepareHeader(h.Now(), entryType, entry, privKey, change)
+		}
+		if err == nil && signer != "" {
+			header.Signer = signer
+			hash, _, err = header.Sum(chain.hashSpec)
+		}
 		chain.lk.RUnlock()
 		if err != nil {
 			return
 		}
 
 		a.SetHeader(header)
-		d, err = h.ValidateAction(a, entryType, nil, []peer.ID{h.nodeID})
+		// the validators' signals wait for the entry to be added, so a
+		// retry doesn't deliver them twice
+		vctx = h.validationContext()
+		d, _, err = h.validateAction(vctx, a, entryType, nil, []peer.ID{h.nodeID})
 		if err != nil {
 			return
 		}
@@ -331,19 +688,106 @@ func (h *Holochain) doCommit(a CommittingAction, change Hash) (d *EntryDef, err
 			return
 		}
 	}
+	vctx.deliverSignals(h)
+	return
+}
+
+// SharePolicy controls how sharing a committed entry to the DHT is retried
+type SharePolicy struct {
+	Attempts int           // maximum number of times to try sharing, values less than 1 mean 1
+	Backoff  time.Duration // wait before the first retry, doubled for each retry after that
+}
+
+// DefaultSharePolicy makes a single attempt at sharing
+var DefaultSharePolicy = SharePolicy{Attempts: 1}
+
+// checkCommitAfterClose returns ErrChainLockedAfterClose if the chain has
+// been closed by a migrate and the action isn't allowed after the close, for
+// callers that hold the chain's lock
+func (c *Chain) checkCommitAfterClose(a CommittingAction) (err error) {
+	var idx int
+	if idx, _, err = c.closeMigrate(); err != nil || idx < 0 {
+		return
+	}
+	if !canCommitAfterClose(a, c.Headers[idx].EntryLink) {
+		err = ErrChainLockedAfterClose
+	}
 	return
 }
 
+// canCommitAfterClose returns true for the actions allowed on a chain that has
+// been closed by the migrate whose entry hash is closeHash
+func canCommitAfterClose(a CommittingAction, closeHash Hash) bool {
+	switch t := a.(type) {
+	case *ActionMigrateRollback:
+		return true
+	case *ActionMod:
+		// the correction keeps the type of the close it replaces, so
+		// correcting it leaves the chain closed
+		return t.entryType == MigrateEntryType && t.replaces.Equal(closeHash)
+	case *ActionCommit:
+		// linking to the close that closed the chain
+		return t.entryType == MigrateLinkEntryType && migrateLinksOnlyTo(t.entry, closeHash)
+	}
+	return false
+}
+
 func (h *Holochain) commitAndShare(a CommittingAction, change Hash) (response Hash, err error) {
+	response, _, err = h.commitAndShareWithPolicy(a, change, DefaultSharePolicy)
+	return
+}
+
+// CommitAndShareCtx commits the action and then shares it, aborting with
+// ctx.Err() if ctx is done before the commit or while retrying the share.
+// N.B. once committed the entry stays on the chain even if the share is aborted.
+func (h *Holochain) CommitAndShareCtx(ctx context.Context, a CommittingAction, change Hash) (response Hash, err error) {
+	response, _, err = h.commitAndShareWithPolicyCtx(ctx, a, change, DefaultSharePolicy)
+	return
+}
+
+// commitAndShareWithPolicy commits the action and then shares it, retrying the share
+// according to the policy.  It returns the number of share attempts that were made,
+// which is zero if the share was deferred because a bundle is open.
+func (h *Holochain) commitAndShareWithPolicy(a CommittingAction, change Hash, policy SharePolicy) (response Hash, attempts int, err error) {
+	return h.commitAndShareWithPolicyCtx(context.Background(), a, change, policy)
+}
+
+// commitAndShareWithPolicyCtx is commitAndShareWithPolicy aborting with ctx.Err()
+// if ctx is done before the commit or between share attempts
+func (h *Holochain) commitAndShareWithPolicyCtx(ctx context.Context, a CommittingAction, change Hash, policy SharePolicy) (response Hash, attempts int, err error) {
+	return h.commitAndShareAs(ctx, a, change, policy, nil)
+}
+
+// commitAndShareAs is commitAndShareWithPolicyCtx signing the header with a
+// group member's key, or with the agent's if member is nil
+func (h *Holochain) commitAndShareAs(ctx context.Context, a CommittingAction, change Hash, policy SharePolicy, member ic.PrivKey) (response Hash, attempts int, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	var def *EntryDef
-	def, err = h.doCommit(a, change)
+	def, err = h.doCommitAs(a, change, member)
 	if err != nil {
 		return
 	}
 
 	bundle := h.Chain().BundleStarted()
 	if bundle == nil {
-		err = a.Share(h, def)
+		backoff := policy.Backoff
+		for {
+			attempts++
+			err = h.share(a, def)
+			if err == nil || attempts >= policy.Attempts {
+				break
+			}
+			h.Debugf("share attempt %d of %s failed with: %v", attempts, a.Name(), err)
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
 	} else {
 		bundle.sharing = append(bundle.sharing, a)
 	}
@@ -354,6 +798,26 @@ func (h *Holochain) commitAndShare(a CommittingAction, change Hash) (response Ha
 	return
 }
 
+// ValidateSources checks that an action has at least one source and that
+// each source is a well formed peer ID
+func ValidateSources(sources []peer.ID) (err error) {
+	if len(sources) == 0 {
+		err = ErrInvalidSource
+		return
+	}
+	for _, source := range sources {
+		if source == "" {
+			err = ErrInvalidSource
+			return
+		}
+		if _, e := mh.Cast([]byte(source)); e != nil {
+			err = ErrInvalidSource
+			return
+		}
+	}
+	return
+}
+
 func isValidPubKey(b58pk string) bool {
 	if len(b58pk) != 49 {
 		return false
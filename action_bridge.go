@@ -29,7 +29,7 @@ func (fn *APIFnBridge) Args() []Arg {
 func (fn *APIFnBridge) Call(h *Holochain) (response interface{}, err error) {
 	body := bytes.NewBuffer([]byte(fn.args.(string)))
 	var resp *http.Response
-	resp, err = http.Post(fmt.Sprintf("%s/bridge/%s/%s/%s", fn.url, fn.token, fn.zome, fn.function), "", body)
+	resp, err = http.Post(fmt.Sprintf("%s/bridge/%s/%s/%s?%s", fn.url, fn.token, fn.zome, fn.function, h.nextBridgeNonce().Query()), "", body)
 	if err != nil {
 		return
 	}
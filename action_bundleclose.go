@@ -54,7 +54,7 @@ func (a *APIFnCloseBundle) Call(h *Holochain) (response interface{}, err error)
 				h.dht.dlog.Logf("Error getting entry def in close bundle:%v", err)
 				err = nil
 			} else {
-				err = a.Share(h, def)
+				err = h.share(a, def)
 			}
 		}
 	}
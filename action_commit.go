@@ -60,6 +60,14 @@ func (a *ActionCommit) GetHeader() (header *Header) {
 	return a.header
 }
 
+func (a *ActionCommit) VerifyEntryLink() (err error) {
+	return verifyEntryLink(a.header, a.Entry())
+}
+
+func (a *ActionCommit) setEntry(entry Entry) {
+	a.entry = entry
+}
+
 func (a *ActionCommit) Share(h *Holochain, def *EntryDef) (err error) {
 	if def.DataFormat == DataFormatLinks {
 		// if this is a Link entry we have to send the DHT Link message
@@ -83,7 +91,7 @@ func (a *ActionCommit) Share(h *Holochain, def *EntryDef) (err error) {
 	}
 	if def.isSharingPublic() {
 		// otherwise we check to see if it's a public entry and if so send the DHT put message
-		err = h.dht.Change(a.header.EntryLink, PUT_REQUEST, HoldReq{EntryHash: a.header.EntryLink})
+		_, err = h.dht.sharePut(a.header.EntryLink, def)
 		if err == ErrEmptyRoutingTable {
 			// will still have committed locally and can gossip later
 			err = nil
@@ -93,7 +101,13 @@ func (a *ActionCommit) Share(h *Holochain, def *EntryDef) (err error) {
 }
 
 func (a *ActionCommit) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	if err = ValidateSources(sources); err != nil {
+		return
+	}
 	err = sysValidateEntry(h, def, a.entry, pkg)
+	if err == nil && def.Name == MigrateLinkEntryType {
+		err = checkMigrateLinks(h, a.entry, sources[0])
+	}
 	return
 }
 
@@ -0,0 +1,76 @@
+package holochain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+//------------------------------------------------------------
+// CrossDNAGet
+
+type APIFnCrossDNAGet struct {
+	dna    Hash
+	hash   Hash
+	header bool // also get the entry's header, responding with a BridgedEntry
+}
+
+func (fn *APIFnCrossDNAGet) Name() string {
+	return "crossDNAGet"
+}
+
+func (fn *APIFnCrossDNAGet) Args() []Arg {
+	return []Arg{{Name: "targetDNAHash", Type: HashArg}, {Name: "entryHash", Type: HashArg}}
+}
+
+// Call routes a get request through the bridge to the DHT of the target DNA
+// the bridged app decides, based on the capability of our token, whether the
+// get is permitted.  The request carries a nonce so it can't be replayed.
+func (fn *APIFnCrossDNAGet) Call(h *Holochain) (response interface{}, err error) {
+	if h.bridgeDB == nil {
+		err = ErrNoBridgeToDNA
+		return
+	}
+	var token, url string
+	token, url, err = h.GetBridgeToken(fn.dna)
+	if err != nil {
+		if err == BridgeAppNotFoundErr {
+			err = ErrNoBridgeToDNA
+		}
+		return
+	}
+
+	var resp *http.Response
+	query := h.nextBridgeNonce().Query()
+	if fn.header {
+		query += "&header=true"
+	}
+	resp, err = http.Get(fmt.Sprintf("%s/bridge-get/%s/%s?%s", url, token, fn.hash.String(), query))
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	var b []byte
+	b, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = errors.New(strings.TrimSpace(string(b)))
+		return
+	}
+	if fn.header {
+		var bridged BridgedEntry
+		if err = json.Unmarshal(b, &bridged); err != nil {
+			return
+		}
+		response = bridged
+		return
+	}
+	response = string(b)
+	return
+}
@@ -60,6 +60,10 @@ func (a *ActionDel) GetHeader() (header *Header) {
 	return a.header
 }
 
+func (a *ActionDel) VerifyEntryLink() (err error) {
+	return verifyEntryLink(a.header, a.Entry())
+}
+
 func (a *ActionDel) Share(h *Holochain, def *EntryDef) (err error) {
 	if def.isSharingPublic() {
 		// if it's a public entry send the DHT DEL & PUT messages
@@ -70,10 +74,119 @@ func (a *ActionDel) Share(h *Holochain, def *EntryDef) (err error) {
 }
 
 func (a *ActionDel) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	if err = ValidateSources(sources); err != nil {
+		return
+	}
 	if def != DelEntryDef {
 		err = ErrEntryDefInvalid
 		return
 	}
+	err = a.sysValidateDelTarget(h, sources)
+	return
+}
+
+// sysValidateDelTarget checks that the entry being deleted exists and, if it's
+// a migrate entry, that it's being deleted by its author and isn't a close
+// that is still closing the author's chain.  The target is looked up on our
+// chain and then in what we hold, which is where a received delete is
+// validated, and only fetched when committing a delete of an entry we don't
+// have.
+func (a *ActionDel) sysValidateDelTarget(h *Holochain, sources []peer.ID) (err error) {
+	var entryType string
+	_, entryType, err = h.chain.GetEntry(a.entry.Hash)
+	if err == nil {
+		if entryType != MigrateEntryType {
+			return
+		}
+		// it's on our chain so we are the author
+		if h.nodeID != sources[0] {
+			err = ErrDelMigrateAuthorMismatch
+			return
+		}
+		err = a.checkCloseNotInEffect(h)
+		return
+	}
+	if err != ErrHashNotFound {
+		return
+	}
+
+	var entry Entry
+	var authors []string
+	var status int
+	var data []byte
+	data, entryType, authors, status, err = h.dht.Get(a.entry.Hash, StatusAny, GetMaskEntry|GetMaskEntryType|GetMaskSources)
+	if err == nil {
+		if entryType != MigrateEntryType {
+			return
+		}
+		var e GobEntry
+		e, err = h.unmarshalEntry(entryType, data)
+		entry = &e
+	} else if err == ErrHashNotFound {
+		if sources[0] != h.nodeID {
+			// we don't hold it so it's for its holders to check
+			err = nil
+			return
+		}
+		req := GetReq{H: a.entry.Hash, StatusMask: StatusAny, GetMask: GetMaskEntry | GetMaskEntryType | GetMaskSources | GetMaskStatus}
+		var r interface{}
+		r, err = callGet(h, req, &GetOptions{StatusMask: req.StatusMask, GetMask: req.GetMask})
+		if err != nil {
+			return
+		}
+		resp := r.(GetResp)
+		if resp.EntryType != MigrateEntryType {
+			return
+		}
+		e := resp.Entry
+		entry, authors, status = &e, resp.Sources, resp.Status
+	}
+	if err != nil {
+		return
+	}
+
+	if len(authors) == 0 || authors[0] != peer.IDB58Encode(sources[0]) {
+		err = ErrDelMigrateAuthorMismatch
+		return
+	}
+	// a close that's been rolled back or corrected is modified
+	if status == StatusLive {
+		var migrate MigrateEntry
+		migrate, err = MigrateEntryFromJSON(entry.Content().(string))
+		if err == nil && migrate.Type == MigrateEntryTypeClose {
+			err = ErrDelMigrateCloseInEffect
+		}
+	}
+	return
+}
+
+// checkCloseNotInEffect checks that the migrate being deleted from our chain
+// isn't a close that hasn't been rolled back
+func (a *ActionDel) checkCloseNotInEffect(h *Holochain) (err error) {
+	var closeHeader Hash
+	rolledBack := make(map[Hash]bool)
+	err = h.chain.Walk(func(key *Hash, header *Header, entry Entry) (err error) {
+		switch header.Type {
+		case MigrateRollbackEntryType:
+			var rollback MigrateRollbackEntry
+			rollback, err = MigrateRollbackEntryFromJSON(entry.Content().(string))
+			if err == nil {
+				rolledBack[rollback.MigrateHeaderHash] = true
+			}
+		case MigrateEntryType:
+			if header.EntryLink.Equal(a.entry.Hash) {
+				var migrate MigrateEntry
+				migrate, err = MigrateEntryFromJSON(entry.Content().(string))
+				if err == nil && migrate.Type == MigrateEntryTypeClose {
+					closeHeader = *key
+				}
+			}
+		}
+		return
+	})
+	if err == nil && closeHeader != NullHash() && !rolledBack[closeHeader] {
+		err = ErrDelMigrateCloseInEffect
+	}
 	return
 }
 
@@ -88,12 +201,19 @@ func (a *ActionDel) Receive(dht *DHT, msg *Message) (response interface{}, err e
 
 		a := NewDelAction(delEntry)
 		//@TODO what comes back from Validate Del
-		_, err = dht.h.ValidateAction(a, resp.Type, &resp.Package, []peer.ID{msg.From})
+		_, err = dht.validateReceived(msg, a, resp.Type, &resp.Package)
 		if err != nil {
 			// how do we record an invalid DEL?
 			//@TODO store as REJECTED
 		} else {
 			err = dht.Del(msg, delEntry.Hash)
+			if err == nil && delEntry.Message != "" {
+				err = dht.PutDelReason(delEntry.Hash, delEntry.Message)
+			}
+			if err == nil {
+				// keep the header with the deleted entry for its history
+				err = dht.putEntryHeader(msg.From, t.EntryHash, &resp.Header)
+			}
 			if err == nil {
 				holdResp, err = dht.MakeHoldResp(msg, StatusLive)
 			}
@@ -95,3 +95,130 @@ func TestSysDel(t *testing.T) {
 		So(err, ShouldEqual, ErrEntryDefInvalid)
 	})
 }
+
+func TestDelMigrateSysValidate(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	sources := []peer.ID{h.nodeID}
+	other, _ := peer.IDB58Decode("QmNiCwBNA8MWDADTFVq1BonUEJbS2SvjAoNkZZrhEwcuUi")
+
+	commitMigrate := func(migrationType string) (entryHash Hash, headerHash Hash) {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Type = migrationType
+		a := &ActionMigrate{entry: entry}
+		_, err = h.doCommit(a, NullHash())
+		So(err, ShouldBeNil)
+		entryHash = a.header.EntryLink
+		headerHash = h.chain.Hashes[len(h.chain.Hashes)-1]
+		return
+	}
+
+	Convey("deleting a nonexistent hash should fail", t, func() {
+		hash, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		a := NewDelAction(DelEntry{Hash: hash, Message: "retracted"})
+		So(a.SysValidation(h, DelEntryDef, nil, sources), ShouldEqual, ErrHashNotFound)
+
+		// a received delete of an entry we don't hold is for its holders to check
+		So(a.SysValidation(h, DelEntryDef, nil, []peer.ID{other}), ShouldBeNil)
+	})
+
+	Convey("a migrate entry should only be deletable by its author", t, func() {
+		hash, _ := commitMigrate("split")
+		a := NewDelAction(DelEntry{Hash: hash, Message: "retracted"})
+		So(a.SysValidation(h, DelEntryDef, nil, sources), ShouldBeNil)
+		So(a.SysValidation(h, DelEntryDef, nil, []peer.ID{other}), ShouldEqual, ErrDelMigrateAuthorMismatch)
+
+		// held for another author
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		j, err := entry.ToJSON()
+		So(err, ShouldBeNil)
+		e := GobEntry{C: j}
+		held, err := e.Sum(h.hashSpec)
+		So(err, ShouldBeNil)
+		b, err := e.Marshal()
+		So(err, ShouldBeNil)
+		So(h.dht.Put(nil, MigrateEntryType, held, other, b, StatusLive), ShouldBeNil)
+		a = NewDelAction(DelEntry{Hash: held, Message: "retracted"})
+		So(a.SysValidation(h, DelEntryDef, nil, sources), ShouldEqual, ErrDelMigrateAuthorMismatch)
+		So(a.SysValidation(h, DelEntryDef, nil, []peer.ID{other}), ShouldBeNil)
+	})
+
+	Convey("a close migrate should not be deletable until rolled back", t, func() {
+		hash, headerHash := commitMigrate(MigrateEntryTypeClose)
+		a := NewDelAction(DelEntry{Hash: hash, Message: "retracted"})
+		So(a.SysValidation(h, DelEntryDef, nil, sources), ShouldEqual, ErrDelMigrateCloseInEffect)
+
+		rollback := &ActionMigrateRollback{entry: MigrateRollbackEntry{MigrateHeaderHash: headerHash}}
+		_, err := h.doCommit(rollback, NullHash())
+		So(err, ShouldBeNil)
+		So(a.SysValidation(h, DelEntryDef, nil, sources), ShouldBeNil)
+	})
+
+	Convey("a held close migrate should not be deletable until it's been modified", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Type = MigrateEntryTypeClose
+		j, err := entry.ToJSON()
+		So(err, ShouldBeNil)
+		e := GobEntry{C: j}
+		held, err := e.Sum(h.hashSpec)
+		So(err, ShouldBeNil)
+		b, err := e.Marshal()
+		So(err, ShouldBeNil)
+		So(h.dht.Put(nil, MigrateEntryType, held, other, b, StatusLive), ShouldBeNil)
+		a := NewDelAction(DelEntry{Hash: held, Message: "retracted"})
+		So(a.SysValidation(h, DelEntryDef, nil, []peer.ID{other}), ShouldEqual, ErrDelMigrateCloseInEffect)
+
+		rollback, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		m := h.node.NewMessage(MOD_REQUEST, HoldReq{RelatedHash: held, EntryHash: rollback})
+		So(h.dht.Mod(m, held, rollback), ShouldBeNil)
+		So(a.SysValidation(h, DelEntryDef, nil, []peer.ID{other}), ShouldBeNil)
+	})
+}
+
+func TestDelMigrateReason(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	entry.Type = "split"
+	migrate := &ActionMigrate{entry: entry}
+	if _, err = h.doCommit(migrate, NullHash()); err != nil {
+		panic(err)
+	}
+	hash := migrate.header.EntryLink
+	b, _ := migrate.Entry().Marshal()
+	if err = h.dht.Put(nil, MigrateEntryType, hash, h.nodeID, b, StatusLive); err != nil {
+		panic(err)
+	}
+
+	Convey("a deleted migrate should be tombstoned with its reason", t, func() {
+		a := NewDelAction(DelEntry{Hash: hash, Message: "retracted"})
+		_, err := h.doCommit(a, NullHash())
+		So(err, ShouldBeNil)
+		m := h.node.NewMessage(DEL_REQUEST, HoldReq{RelatedHash: hash, EntryHash: a.header.EntryLink})
+		r, err := ActionReceiver(h, m)
+		So(err, ShouldBeNil)
+		So(r.(HoldResp).Code, ShouldEqual, ReceiptOK)
+
+		_, _, _, status, _ := h.dht.Get(hash, StatusAny, GetMaskEntryType)
+		So(status, ShouldEqual, StatusDeleted)
+
+		req := GetReq{H: hash, StatusMask: StatusDeleted, GetMask: GetMaskEntry}
+		resp, err := callGet(h, req, &GetOptions{StatusMask: req.StatusMask, GetMask: req.GetMask})
+		So(err, ShouldBeNil)
+		So(resp.(GetResp).DelReason, ShouldEqual, "retracted")
+
+		req = GetReq{H: hash, StatusMask: StatusDefault, GetMask: GetMaskEntry}
+		_, err = callGet(h, req, &GetOptions{StatusMask: req.StatusMask, GetMask: req.GetMask})
+		So(err, ShouldNotBeNil)
+	})
+}
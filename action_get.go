@@ -1,6 +1,7 @@
 package holochain
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	. "github.com/holochain/holochain-proto/hash"
@@ -45,7 +46,7 @@ func (fn *APIFnGet) Call(h *Holochain) (response interface{}, err error) {
 		response, err = a.getLocal(bundle.chain)
 		return
 	}
-	rsp, err := h.dht.Query(a.req.H, GET_REQUEST, a.req)
+	rsp, err := h.queryGet(context.Background(), a.req)
 	if err != nil {
 
 		// follow the modified hash
@@ -69,6 +70,12 @@ func (fn *APIFnGet) Call(h *Holochain) (response interface{}, err error) {
 	}
 	switch t := rsp.(type) {
 	case GetResp:
+		if (a.options.GetMask & GetMaskHolders) != 0 {
+			t.Holders = h.world.Holders(a.req.H)
+		}
+		if t.EntryType == MigrateEntryType {
+			h.decryptMigrateEntry(&t.Entry)
+		}
 		response = t
 	default:
 		err = fmt.Errorf("expected GetResp response from GET_REQUEST, got: %T", t)
@@ -77,6 +84,32 @@ func (fn *APIFnGet) Call(h *Holochain) (response interface{}, err error) {
 	return
 }
 
+// decryptMigrateEntry hands back a migrate entry encrypted to this agent as if
+// it had been sent in plaintext, leaving any other entry as is
+func (h *Holochain) decryptMigrateEntry(entry *GobEntry) {
+	j, ok := entry.C.(string)
+	if !ok {
+		return
+	}
+	migrate, err := MigrateEntryFromJSON(j)
+	if err != nil || !migrate.IsEncrypted() {
+		return
+	}
+	if me, err := h.agent.EncodePubKey(); err != nil || me != migrate.Recipient {
+		return
+	}
+	migrate.Data, err = migrate.DecryptData(h.agent.PrivKey())
+	if err != nil {
+		h.Debugf("unable to decrypt migrate entry encrypted to us: %v", err)
+		return
+	}
+	migrate.Recipient = ""
+	j, err = migrate.ToJSON()
+	if err == nil {
+		entry.C = j
+	}
+}
+
 type ActionGet struct {
 	req     GetReq
 	options *GetOptions
@@ -93,23 +126,34 @@ func (a *ActionGet) getLocal(chain *Chain) (resp GetResp, err error) {
 	if err != nil {
 		return
 	}
-	resp = GetResp{Entry: *entry.(*GobEntry)}
 	mask := a.options.GetMask
+	if mask == GetMaskDefault {
+		mask = GetMaskEntry
+	}
+	// as with a DHT get the entry type is always returned, but the entry
+	// itself only if asked for
 	resp.EntryType = entryType
 	if (mask & GetMaskEntry) != 0 {
-		resp.Entry = *entry.(*GobEntry)
-		resp.EntryType = entryType
+		resp.Entry = asGobEntry(entry)
+	}
+	if (mask & GetMaskStatus) != 0 {
+		// the chain doesn't track mods or dels, only the DHT does
+		resp.Status = StatusLive
+	}
+	if (mask & GetMaskHeader) != 0 {
+		resp.Header, err = chain.GetEntryHeader(a.req.H)
 	}
 	return
 }
 
 func (a *ActionGet) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	err = ValidateSources(sources)
 	return
 }
 
 func (a *ActionGet) Receive(dht *DHT, msg *Message) (response interface{}, err error) {
 	var entryData []byte
-	//var status int
+	var status int
 	req := msg.Body.(GetReq)
 	mask := req.GetMask
 	if mask == GetMaskDefault {
@@ -117,8 +161,18 @@ func (a *ActionGet) Receive(dht *DHT, msg *Message) (response interface{}, err e
 	}
 	resp := GetResp{}
 	// always get the entry type despite what the mas says because we need it for the switch below.
-	entryData, resp.EntryType, resp.Sources, _, err = dht.Get(req.H, req.StatusMask, req.GetMask|GetMaskEntryType)
+	entryData, resp.EntryType, resp.Sources, status, err = dht.Get(req.H, req.StatusMask, req.GetMask|GetMaskEntryType)
 	if err == nil {
+		if status == StatusDeleted {
+			resp.DelReason, err = dht.GetDelReason(req.H)
+			if err == ErrHashNotFound {
+				// deleted without a reason
+				err = nil
+			}
+			if err != nil {
+				return
+			}
+		}
 		if (mask & GetMaskEntry) != 0 {
 			switch resp.EntryType {
 			case DNAEntryType:
@@ -128,12 +182,27 @@ func (a *ActionGet) Receive(dht *DHT, msg *Message) (response interface{}, err e
 			case KeyEntryType:
 				resp.Entry = GobEntry{C: string(entryData)}
 			default:
-				var e GobEntry
-				err = e.Unmarshal(entryData)
+				resp.Entry, err = dht.h.unmarshalEntry(resp.EntryType, entryData)
 				if err != nil {
 					return
 				}
-				resp.Entry = e
+			}
+			// project the entry here to save sending what wasn't asked for,
+			// leaving what isn't a JSON object for the requester to refuse
+			if len(req.Fields) > 0 {
+				if c, e := projectContent(resp.Entry.C, req.Fields, true); e == nil {
+					resp.Entry.C = c
+				}
+			}
+		}
+		if (mask & GetMaskStatus) != 0 {
+			resp.Status = status
+		}
+		if (mask & GetMaskHeader) != 0 {
+			resp.Header, err = dht.getEntryHeader(req.H)
+			if err == ErrHashNotFound {
+				// not all held entries have headers, i.e. the virtual key entries
+				err = nil
 			}
 		}
 	} else {
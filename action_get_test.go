@@ -95,6 +95,22 @@ func TestActionGetLocal(t *testing.T) {
 		So(getResp.Entry.Content().(string), ShouldEqual, "31415")
 	})
 
+	Convey("it should get just the type of local values", t, func() {
+		req := GetReq{H: hash, GetMask: GetMaskEntryType}
+		rsp, err := callGet(h, req, &GetOptions{GetMask: req.GetMask, Local: true})
+		So(err, ShouldBeNil)
+		getResp := rsp.(GetResp)
+		So(getResp.EntryType, ShouldEqual, "secret")
+		So(getResp.Entry.C, ShouldBeNil)
+
+		req = GetReq{H: hash, GetMask: GetMaskEntry + GetMaskEntryType}
+		rsp, err = callGet(h, req, &GetOptions{GetMask: req.GetMask, Local: true})
+		So(err, ShouldBeNil)
+		getResp = rsp.(GetResp)
+		So(getResp.EntryType, ShouldEqual, "secret")
+		So(getResp.Entry.Content().(string), ShouldEqual, "31415")
+	})
+
 	Convey("it should get local bundle values", t, func() {
 		_, err := NewStartBundleAction(0, "myBundle").Call(h)
 		So(err, ShouldBeNil)
@@ -108,3 +124,144 @@ func TestActionGetLocal(t *testing.T) {
 		So(getResp.Entry.Content().(string), ShouldEqual, "3141")
 	})
 }
+
+func TestActionGetHeader(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	hash := commit(h, "oddNumbers", "3")
+	header, err := h.chain.GetEntryHeader(hash)
+	if err != nil {
+		panic(err)
+	}
+
+	Convey("get with just GetMaskEntry should not return the header", t, func() {
+		req := GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry}
+		rsp, err := callGet(h, req, &GetOptions{GetMask: req.GetMask})
+		So(err, ShouldBeNil)
+		So(rsp.(GetResp).Header, ShouldBeNil)
+	})
+
+	Convey("get with GetMaskHeader should return the entry's header", t, func() {
+		req := GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry | GetMaskHeader}
+		rsp, err := callGet(h, req, &GetOptions{GetMask: req.GetMask})
+		So(err, ShouldBeNil)
+		getResp := rsp.(GetResp)
+		So(getResp.Entry.Content().(string), ShouldEqual, "3")
+		So(getResp.Header, ShouldNotBeNil)
+		So(getResp.Header.EntryLink.String(), ShouldEqual, hash.String())
+		So(getResp.Header.Sig.Equal(header.Sig), ShouldBeTrue)
+
+		rsp, err = callGet(h, req, &GetOptions{GetMask: req.GetMask, Local: true})
+		So(err, ShouldBeNil)
+		So(rsp.(GetResp).Header, ShouldEqual, header)
+	})
+
+	Convey("the header should roundtrip through gob in a GetResp", t, func() {
+		resp := GetResp{Entry: GobEntry{C: "3"}, Header: header}
+		b, err := ByteEncoder(&resp)
+		So(err, ShouldBeNil)
+		var decoded GetResp
+		err = ByteDecoder(b, &decoded)
+		So(err, ShouldBeNil)
+		So(decoded.Header.EntryLink.String(), ShouldEqual, header.EntryLink.String())
+		So(decoded.Header.HeaderLink.String(), ShouldEqual, header.HeaderLink.String())
+		So(decoded.Header.TypeLink.String(), ShouldEqual, header.TypeLink.String())
+		So(decoded.Header.Time.Equal(header.Time), ShouldBeTrue)
+		So(decoded.Header.Sig.Equal(header.Sig), ShouldBeTrue)
+
+		resp.Header = nil
+		b, err = ByteEncoder(&resp)
+		So(err, ShouldBeNil)
+		decoded = GetResp{}
+		err = ByteDecoder(b, &decoded)
+		So(err, ShouldBeNil)
+		So(decoded.Header, ShouldBeNil)
+	})
+}
+
+func TestActionGetHolders(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	hash := commit(h, "oddNumbers", "5")
+	req := GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry | GetMaskHolders}
+
+	Convey("get without GetMaskHolders should not return holders", t, func() {
+		rsp, err := callGet(h, GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry}, &GetOptions{GetMask: GetMaskEntry})
+		So(err, ShouldBeNil)
+		So(rsp.(GetResp).Holders, ShouldBeNil)
+	})
+
+	Convey("get should return an empty list of holders when we are the only holder", t, func() {
+		rsp, err := callGet(h, req, &GetOptions{GetMask: req.GetMask})
+		So(err, ShouldBeNil)
+		getResp := rsp.(GetResp)
+		So(getResp.Entry.Content().(string), ShouldEqual, "5")
+		So(getResp.Holders, ShouldNotBeNil)
+		So(len(getResp.Holders), ShouldEqual, 0)
+	})
+
+	Convey("get should return the nodes the world model records as holding the entry", t, func() {
+		if h.world == nil {
+			h.world = NewWorld(h.node.HashAddr, h.dht, &h.Config.Loggers.World)
+		}
+		nodes := testAddNodesToWorld(h.world, 0, 2)
+		err := h.world.SetNodeHolding(nodes[1].HashAddr, hash)
+		So(err, ShouldBeNil)
+
+		rsp, err := callGet(h, req, &GetOptions{GetMask: req.GetMask})
+		So(err, ShouldBeNil)
+		holders := rsp.(GetResp).Holders
+		So(len(holders), ShouldEqual, 1)
+		So(holders[0], ShouldEqual, nodes[1].HashAddr)
+	})
+
+	Convey("get should return the holders sorted", t, func() {
+		nodes := testAddNodesToWorld(h.world, 2, 4)
+		for _, n := range nodes {
+			So(h.world.SetNodeHolding(n.HashAddr, hash), ShouldBeNil)
+		}
+		rsp, err := callGet(h, req, &GetOptions{GetMask: req.GetMask})
+		So(err, ShouldBeNil)
+		holders := rsp.(GetResp).Holders
+		So(len(holders), ShouldEqual, 5)
+		for i := 1; i < len(holders); i++ {
+			So(string(holders[i-1]), ShouldBeLessThan, string(holders[i]))
+		}
+	})
+}
+
+func TestActionGetStatus(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	hash := commit(h, "oddNumbers", "3")
+
+	Convey("get without GetMaskStatus should not return the status", t, func() {
+		req := GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry}
+		rsp, err := callGet(h, req, &GetOptions{GetMask: req.GetMask})
+		So(err, ShouldBeNil)
+		So(rsp.(GetResp).Status, ShouldEqual, StatusDefault)
+	})
+
+	Convey("get with GetMaskStatus should return the status of a live entry", t, func() {
+		req := GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskStatus}
+		rsp, err := callGet(h, req, &GetOptions{GetMask: req.GetMask})
+		So(err, ShouldBeNil)
+		So(rsp.(GetResp).Status, ShouldEqual, StatusLive)
+	})
+
+	Convey("a modified entry should report StatusModified along with its entry", t, func() {
+		newHash := commit(h, "oddNumbers", "5")
+		m := h.node.NewMessage(MOD_REQUEST, HoldReq{RelatedHash: hash, EntryHash: newHash})
+		So(h.dht.Mod(m, hash, newHash), ShouldBeNil)
+
+		req := GetReq{H: hash, StatusMask: StatusAny, GetMask: GetMaskEntry | GetMaskStatus}
+		rsp, err := callGet(h, req, &GetOptions{StatusMask: req.StatusMask, GetMask: req.GetMask})
+		So(err, ShouldBeNil)
+		getResp := rsp.(GetResp)
+		So(getResp.Status, ShouldEqual, StatusModified)
+		So(getResp.Entry.Content().(string), ShouldEqual, "3")
+	})
+}
@@ -0,0 +1,52 @@
+package holochain
+
+import (
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+//------------------------------------------------------------
+// GetBatch
+
+type ActionGetBatch struct {
+	req GetBatchReq
+}
+
+func (a *ActionGetBatch) Name() string {
+	return "getBatch"
+}
+
+func (a *ActionGetBatch) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	err = ValidateSources(sources)
+	return
+}
+
+// Receive answers each of the hashes in the batch as if it were a separate
+// GET_REQUEST, collecting any per hash errors in the response rather than failing
+func (a *ActionGetBatch) Receive(dht *DHT, msg *Message) (response interface{}, err error) {
+	req := msg.Body.(GetBatchReq)
+	resp := GetBatchResp{Responses: make(map[string]GetResp), Errors: make(map[string]ErrorResponse)}
+	get := ActionGet{}
+	for _, hash := range req.Hashes {
+		m := *msg
+		m.Type = GET_REQUEST
+		m.Body = GetReq{H: hash, StatusMask: req.StatusMask, GetMask: req.GetMask}
+		r, e := get.Receive(dht, &m)
+		getResp, ok := r.(GetResp)
+		if e == nil && !ok {
+			// we only got closer peers, so as far as this batch goes we don't have it
+			e = ErrHashNotFound
+		}
+		if ok {
+			resp.Responses[hash.String()] = getResp
+		}
+		if e != nil {
+			resp.Errors[hash.String()] = NewErrorResponse(e)
+		}
+	}
+	response = resp
+	return
+}
+
+func (a *ActionGetBatch) CheckValidationRequest(def *EntryDef) (err error) {
+	return
+}
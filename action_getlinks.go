@@ -6,6 +6,7 @@ import (
 	. "github.com/holochain/holochain-proto/hash"
 	peer "github.com/libp2p/go-libp2p-peer"
 	"reflect"
+	"sort"
 )
 
 //------------------------------------------------------------
@@ -88,6 +89,7 @@ func (a *ActionGetLinks) Name() string {
 }
 
 func (a *ActionGetLinks) SysValidation(h *Holochain, d *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	err = ValidateSources(sources)
 	//@TODO what sys level getlinks validation?  That they are all valid hash format for the DNA?
 	return
 }
@@ -96,6 +98,18 @@ func (a *ActionGetLinks) Receive(dht *DHT, msg *Message) (response interface{},
 	lq := msg.Body.(LinkQuery)
 	var r LinkQueryResp
 	r.Links, err = dht.GetLinks(lq.Base, lq.T, lq.StatusMask)
+	if err == nil && lq.Pagination != (Pagination{}) {
+		// order by hash then tag so that pages are stable
+		sort.SliceStable(r.Links, func(i, j int) bool {
+			if r.Links[i].H != r.Links[j].H {
+				return r.Links[i].H < r.Links[j].H
+			}
+			return r.Links[i].T < r.Links[j].T
+		})
+		start, end, next := lq.Pagination.page(len(r.Links))
+		r.Links = r.Links[start:end]
+		r.NextOffset = next
+	}
 	response = &r
 
 	return
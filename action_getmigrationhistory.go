@@ -0,0 +1,118 @@
+package holochain
+
+import (
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//------------------------------------------------------------
+// GetMigrationHistory
+
+type APIFnGetMigrationHistory struct {
+	DNAHash    Hash
+	Key        Hash
+	Pagination Pagination
+}
+
+func (fn *APIFnGetMigrationHistory) Name() string {
+	return "getMigrationHistory"
+}
+
+func (fn *APIFnGetMigrationHistory) Args() []Arg {
+	return []Arg{{Name: "DNAHash", Type: HashArg}, {Name: "Key", Type: HashArg}, {Name: "options", Type: MapArg, MapType: reflect.TypeOf(Pagination{}), Optional: true}}
+}
+
+// Call returns a JSON array of the migrate entries for the given DNA and agent
+// key ordered by header time.  The migrates are found in the DHT through the
+// links from this agent's entry to its migrates, so only migrates committed
+// with createLink are included.  Entries that can't be retrieved or
+// unmarshaled are skipped.  If paginated it returns a
+// JSON object with the page's Entries and the NextOffset, which is zero after
+// the last page.
+func (fn *APIFnGetMigrationHistory) Call(h *Holochain) (response interface{}, err error) {
+	var entries []string
+	var next int
+	entries, next, err = fn.page(h)
+	if err != nil {
+		return
+	}
+	history := "[" + strings.Join(entries, ",") + "]"
+	if fn.Pagination == (Pagination{}) {
+		response = history
+	} else {
+		response = fmt.Sprintf(`{"Entries":%s,"NextOffset":%d}`, history, next)
+	}
+	return
+}
+
+// migrateGot is a migrate entry retrieved from the DHT with its header
+type migrateGot struct {
+	header  *Header
+	content string
+}
+
+// page returns the requested page of the migrate entries and the offset of
+// the next page.  Entries are ordered by header time and then by hash, so
+// pages are stable whatever order the links come back in.
+func (fn *APIFnGetMigrationHistory) page(h *Holochain) (entries []string, next int, err error) {
+	entries = make([]string, 0)
+	options := GetLinksOptions{StatusMask: StatusLive}
+	query := &LinkQuery{Base: h.AgentHash(), T: MigrateLinkTag, StatusMask: options.StatusMask}
+	getLinks := &APIFnGetLinks{action: *NewGetLinksAction(query, &options)}
+	var r interface{}
+	r, err = getLinks.Call(h)
+	if err == ErrHashNotFound {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	var migrates []migrateGot
+	for _, l := range r.(*LinkQueryResp).Links {
+		hash, e := NewHash(l.H)
+		if e != nil {
+			h.Debugf("getMigrationHistory: skipping %v: %v", l.H, e)
+			continue
+		}
+		req := GetReq{H: hash, StatusMask: StatusAny, GetMask: GetMaskEntry | GetMaskHeader}
+		rsp, e := callGet(h, req, &GetOptions{StatusMask: req.StatusMask, GetMask: req.GetMask})
+		if e != nil {
+			h.Debugf("getMigrationHistory: skipping %v: %v", hash, e)
+			continue
+		}
+		getResp, ok := rsp.(GetResp)
+		if !ok || getResp.Header == nil {
+			continue
+		}
+		content, ok := getResp.Entry.Content().(string)
+		if !ok {
+			continue
+		}
+		migrate, e := MigrateEntryFromJSON(content)
+		if e != nil {
+			h.Debugf("getMigrationHistory: skipping %v: %v", hash, e)
+			continue
+		}
+		if migrate.DNAHash.Equal(fn.DNAHash) && migrate.Key.Equal(fn.Key) {
+			migrates = append(migrates, migrateGot{header: getResp.Header, content: content})
+		}
+	}
+	sort.Slice(migrates, func(i, j int) bool {
+		ti, tj := migrates[i].header.Time, migrates[j].header.Time
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return migrates[i].header.EntryLink.String() < migrates[j].header.EntryLink.String()
+	})
+	var start, end int
+	start, end, next = fn.Pagination.page(len(migrates))
+	for _, m := range migrates[start:end] {
+		entries = append(entries, m.content)
+	}
+	return
+}
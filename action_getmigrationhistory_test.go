@@ -0,0 +1,93 @@
+package holochain
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestAPIFnGetMigrationHistory(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	dnaHash, err := GenTestStringHash()
+	if err != nil {
+		panic(err)
+	}
+	key, err := GenTestStringHash()
+	if err != nil {
+		panic(err)
+	}
+
+	Convey("it should return an empty array when there are no migrations", t, func() {
+		fn := &APIFnGetMigrationHistory{DNAHash: dnaHash, Key: key}
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		So(response, ShouldEqual, "[]")
+	})
+
+	Convey("it should return the agent's migrations in order", t, func() {
+		first := MigrateEntry{Type: MigrateEntryTypeOpen, DNAHash: dnaHash, Key: key, Data: "first"}
+		second := MigrateEntry{Type: "split", DNAHash: dnaHash, Key: key, Data: "second"}
+		other, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		other.Type = "split"
+
+		for _, entry := range []MigrateEntry{first, other, second} {
+			fn := &APIFnMigrate{action: ActionMigrate{entry: entry}, createLink: true}
+			_, err = fn.Call(h)
+			So(err, ShouldBeNil)
+		}
+
+		firstJSON, err := first.ToJSON()
+		So(err, ShouldBeNil)
+		secondJSON, err := second.ToJSON()
+		So(err, ShouldBeNil)
+
+		fn := &APIFnGetMigrationHistory{DNAHash: dnaHash, Key: key}
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		So(response, ShouldEqual, "["+firstJSON+","+secondJSON+"]")
+	})
+
+	Convey("it should only find migrates linked from the agent's entry", t, func() {
+		unlinked := MigrateEntry{Type: "split", DNAHash: dnaHash, Key: key, Data: "unlinked"}
+		fn := &APIFnMigrate{action: ActionMigrate{entry: unlinked}}
+		_, err := fn.Call(h)
+		So(err, ShouldBeNil)
+
+		all, _, err := (&APIFnGetMigrationHistory{DNAHash: dnaHash, Key: key}).page(h)
+		So(err, ShouldBeNil)
+		So(len(all), ShouldEqual, 2)
+	})
+
+	Convey("it should page through the agent's migrations", t, func() {
+		var paged []string
+		for _, data := range []string{"third", "fourth"} {
+			entry := MigrateEntry{Type: "split", DNAHash: dnaHash, Key: key, Data: data}
+			fn := &APIFnMigrate{action: ActionMigrate{entry: entry}, createLink: true}
+			_, err := fn.Call(h)
+			So(err, ShouldBeNil)
+		}
+		all, _, err := (&APIFnGetMigrationHistory{DNAHash: dnaHash, Key: key}).page(h)
+		So(err, ShouldBeNil)
+		So(len(all), ShouldEqual, 4)
+
+		fn := &APIFnGetMigrationHistory{DNAHash: dnaHash, Key: key, Pagination: Pagination{Limit: 3}}
+		for {
+			entries, next, err := fn.page(h)
+			So(err, ShouldBeNil)
+			paged = append(paged, entries...)
+			if next == 0 {
+				break
+			}
+			So(next, ShouldEqual, 3)
+			fn.Pagination.Offset = next
+		}
+		So(paged, ShouldResemble, all)
+
+		fn.Pagination.Offset = 3
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		So(response, ShouldEqual, `{"Entries":[`+all[3]+`],"NextOffset":0}`)
+	})
+}
@@ -0,0 +1,149 @@
+package holochain
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+//------------------------------------------------------------
+// GetMigrationTargets
+
+type APIFnGetMigrationTargets struct {
+	SourceDNAHash Hash
+}
+
+func (fn *APIFnGetMigrationTargets) Name() string {
+	return "getMigrationTargets"
+}
+
+func (fn *APIFnGetMigrationTargets) Args() []Arg {
+	return []Arg{{Name: "sourceDNAHash", Type: HashArg}}
+}
+
+// Call returns a JSON object mapping each DNA that agents have migrated to from
+// the source DNA to the number of such migrations.  A close held in this DHT is
+// a migration from this DNA to its DNAHash, and an open a migration from its
+// DNAHash to this DNA.  The migrates are aggregated from the part of the DHT
+// each peer in our routing table holds, so with sharding the counts are of the
+// migrates held by the peers we know of.  Peers that can't be reached are
+// skipped.
+func (fn *APIFnGetMigrationTargets) Call(h *Holochain) (response interface{}, err error) {
+	migrates := h.dht.heldMigrationTargets(fn.SourceDNAHash)
+	for _, p := range h.node.routingTable.ListPeers() {
+		held, e := h.dht.MigrationTargets(p, fn.SourceDNAHash)
+		if e != nil {
+			h.Debugf("getMigrationTargets: skipping %v: %v", p, e)
+			continue
+		}
+		for hash, target := range held {
+			migrates[hash] = target
+		}
+	}
+
+	targets := make(map[string]int)
+	for _, target := range migrates {
+		targets[target]++
+	}
+
+	var j []byte
+	j, err = json.Marshal(targets)
+	if err != nil {
+		return
+	}
+	response = string(j)
+	return
+}
+
+// heldMigrationTargets returns the DNA each live migrate this node holds out of
+// the source DNA migrated to, keyed by the migrate's hash, excluding migrates
+// to the source itself
+func (dht *DHT) heldMigrationTargets(sourceDNAHash Hash) (targets map[string]string) {
+	targets = make(map[string]string)
+	var hashes []Hash
+	dht.Iterate(func(hash Hash) bool {
+		hashes = append(hashes, hash)
+		return true
+	})
+
+	for _, hash := range hashes {
+		data, entryType, _, _, e := dht.Get(hash, StatusLive, GetMaskEntry|GetMaskEntryType)
+		if e != nil || entryType != MigrateEntryType {
+			continue
+		}
+		var entry GobEntry
+		if e = entry.Unmarshal(data); e != nil {
+			continue
+		}
+		content, ok := entry.Content().(string)
+		if !ok {
+			continue
+		}
+		migrate, e := MigrateEntryFromJSON(content)
+		if e != nil {
+			dht.dlog.Logf("migration targets: skipping %v: %v", hash, e)
+			continue
+		}
+
+		var source, target Hash
+		switch migrate.Type {
+		case MigrateEntryTypeClose:
+			source, target = dht.h.dnaHash, migrate.DNAHash
+		case MigrateEntryTypeOpen:
+			source, target = migrate.DNAHash, dht.h.dnaHash
+		default:
+			// other types don't say which way the migration went
+			continue
+		}
+		if !source.Equal(sourceDNAHash) || target.Equal(sourceDNAHash) {
+			continue
+		}
+		targets[hash.String()] = target.String()
+	}
+	return
+}
+
+//------------------------------------------------------------
+// MigrationTargets
+
+type ActionMigrationTargets struct {
+	req MigrationTargetsReq
+}
+
+func (a *ActionMigrationTargets) Name() string {
+	return "migrationTargets"
+}
+
+func (a *ActionMigrationTargets) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	err = ValidateSources(sources)
+	return
+}
+
+// Receive answers with the migrates out of the source DNA this node holds
+func (a *ActionMigrationTargets) Receive(dht *DHT, msg *Message) (response interface{}, err error) {
+	req := msg.Body.(MigrationTargetsReq)
+	response = MigrationTargetsResp{Targets: dht.heldMigrationTargets(req.SourceDNAHash)}
+	return
+}
+
+func (a *ActionMigrationTargets) CheckValidationRequest(def *EntryDef) (err error) {
+	return
+}
+
+// MigrationTargets asks a node for the migrates out of the source DNA it holds
+func (dht *DHT) MigrationTargets(to peer.ID, sourceDNAHash Hash) (targets map[string]string, err error) {
+	msg := dht.h.node.NewMessage(MIGRATION_TARGETS_REQUEST, MigrationTargetsReq{SourceDNAHash: sourceDNAHash})
+	var response interface{}
+	response, err = dht.send(nil, to, msg)
+	if err != nil {
+		return
+	}
+	resp, ok := response.(MigrationTargetsResp)
+	if !ok {
+		err = fmt.Errorf("expected MigrationTargetsResp response from MIGRATION_TARGETS_REQUEST, got: %T", response)
+		return
+	}
+	targets = resp.Targets
+	return
+}
@@ -0,0 +1,104 @@
+package holochain
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestAPIFnGetMigrationTargets(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	hold := func(migrationType string, dnaHash Hash, data string) {
+		key, err := GenTestStringHash()
+		if err != nil {
+			panic(err)
+		}
+		migrate := MigrateEntry{Type: migrationType, DNAHash: dnaHash, Key: key, Data: data}
+		j, _ := migrate.ToJSON()
+		entry := GobEntry{C: j}
+		hash, _ := entry.Sum(h.hashSpec)
+		b, _ := entry.Marshal()
+		if err = h.dht.Put(nil, MigrateEntryType, hash, h.nodeID, b, StatusLive); err != nil {
+			panic(err)
+		}
+	}
+	call := func(source Hash) (targets map[string]int) {
+		fn := &APIFnGetMigrationTargets{SourceDNAHash: source}
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		So(json.Unmarshal([]byte(response.(string)), &targets), ShouldBeNil)
+		return
+	}
+
+	target1, _ := GenTestStringHash()
+	target2, _ := GenTestStringHash()
+	source, _ := GenTestStringHash()
+
+	hold(MigrateEntryTypeClose, target1, "a")
+	hold(MigrateEntryTypeClose, target1, "b")
+	hold(MigrateEntryTypeClose, target2, "c")
+	hold(MigrateEntryTypeClose, h.dnaHash, "a migration to ourselves")
+	hold(MigrateEntryTypeOpen, source, "")
+	hold("split", target2, "")
+
+	Convey("it should know the name and args", t, func() {
+		fn := &APIFnGetMigrationTargets{}
+		So(fn.Name(), ShouldEqual, "getMigrationTargets")
+		So(fn.Args(), ShouldResemble, []Arg{{Name: "sourceDNAHash", Type: HashArg}})
+	})
+
+	Convey("it should count the closes from this DNA by target", t, func() {
+		So(call(h.dnaHash), ShouldResemble, map[string]int{target1.String(): 2, target2.String(): 1})
+	})
+
+	Convey("it should count the opens from another DNA into this one", t, func() {
+		So(call(source), ShouldResemble, map[string]int{h.dnaHash.String(): 1})
+	})
+
+	Convey("it should return an empty object for an unknown source", t, func() {
+		So(call(target1), ShouldResemble, map[string]int{})
+	})
+}
+
+func TestMigrationTargetsAcrossTheDHT(t *testing.T) {
+	n := 3
+	mt := setupMultiNodeTesting(n)
+	defer mt.cleanupMultiNodeTesting()
+	fullConnect(t, mt.ctx, mt.nodes, n)
+	h := mt.nodes[0]
+
+	target, _ := GenTestStringHash()
+	hold := func(holders []*Holochain, data string) {
+		key, _ := GenTestStringHash()
+		migrate := MigrateEntry{Type: MigrateEntryTypeClose, DNAHash: target, Key: key, Data: data}
+		j, _ := migrate.ToJSON()
+		entry := GobEntry{C: j}
+		hash, _ := entry.Sum(h.hashSpec)
+		b, _ := entry.Marshal()
+		for _, holder := range holders {
+			if err := holder.dht.Put(nil, MigrateEntryType, hash, holder.nodeID, b, StatusLive); err != nil {
+				panic(err)
+			}
+		}
+	}
+	hold(mt.nodes[1:2], "held by one peer")
+	hold(mt.nodes[1:3], "held by two peers")
+	hold(mt.nodes[0:3], "held by all")
+
+	Convey("a MIGRATION_TARGETS_REQUEST should answer with the migrates the node holds", t, func() {
+		targets, err := h.dht.MigrationTargets(mt.nodes[2].nodeID, h.dnaHash)
+		So(err, ShouldBeNil)
+		So(len(targets), ShouldEqual, 2)
+	})
+
+	Convey("it should count the migrates held across the DHT once each", t, func() {
+		fn := &APIFnGetMigrationTargets{SourceDNAHash: h.dnaHash}
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		So(response, ShouldEqual, fmt.Sprintf(`{"%s":3}`, target.String()))
+	})
+}
@@ -25,6 +25,9 @@ func (a *ActionLink) Name() string {
 }
 
 func (a *ActionLink) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	if err = ValidateSources(sources); err != nil {
+		return
+	}
 	if def.DataFormat != DataFormatLinks {
 		err = errors.New("action only valid for links entry type")
 	}
@@ -45,7 +48,7 @@ func (a *ActionLink) Receive(dht *DHT, msg *Message) (response interface{}, err
 
 		a := NewLinkAction(resp.Type, le.Links)
 		a.validationBase = t.RelatedHash
-		_, err = dht.h.ValidateAction(a, a.entryType, &resp.Package, []peer.ID{msg.From})
+		_, err = dht.validateReceived(msg, a, a.entryType, &resp.Package)
 		//@TODO this is "one bad apple spoils the lot" because the app
 		// has no way to tell us not to link certain of the links.
 		// we need to extend the return value of the app to be able to
@@ -11,6 +11,8 @@ import (
 //------------------------------------------------------------
 // MakeHash
 
+// APIFnMakeHash computes the hash that an entry would be committed with,
+// without touching the chain or the DHT
 type APIFnMakeHash struct {
 	entryType string
 	entry     Entry
@@ -25,11 +27,54 @@ func (a *APIFnMakeHash) Args() []Arg {
 }
 
 func (a *APIFnMakeHash) Call(h *Holochain) (response interface{}, err error) {
+	var entry Entry
+	entry, err = committedEntry(a.entryType, a.entry)
+	if err != nil {
+		return
+	}
+	entry, err = h.encodeEntry(a.entryType, entry)
+	if err != nil {
+		return
+	}
 	var hash Hash
-	hash, err = a.entry.Sum(h.hashSpec)
+	hash, err = entry.Sum(h.hashSpec)
 	if err != nil {
 		return
 	}
 	response = hash
 	return
 }
+
+// committedEntry returns the entry as the commit path would commit it for the
+// entry type.  Migrate and revocation entries are re-encoded by their actions,
+// so their JSON is normalized here to hash the same regardless of the order
+// of its keys.
+func committedEntry(entryType string, e Entry) (entry Entry, err error) {
+	content, _ := e.Content().(string)
+	switch entryType {
+	case MigrateEntryType:
+		var migrate MigrateEntry
+		migrate, err = MigrateEntryFromJSON(content)
+		if err != nil {
+			return
+		}
+		entry = (&ActionMigrate{entry: migrate}).Entry()
+	case MigrateRollbackEntryType:
+		var rollback MigrateRollbackEntry
+		rollback, err = MigrateRollbackEntryFromJSON(content)
+		if err != nil {
+			return
+		}
+		entry = (&ActionMigrateRollback{entry: rollback}).Entry()
+	case RevocationEntryType:
+		var revocation RevocationEntry
+		revocation, err = RevocationEntryFromJSON(content)
+		if err != nil {
+			return
+		}
+		entry = (&ActionRevokeKey{entry: revocation}).Entry()
+	default:
+		entry = e
+	}
+	return
+}
@@ -0,0 +1,48 @@
+package holochain
+
+import (
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestAPIFnMakeHash(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should match the hash of the committed entry", t, func() {
+		fn := &APIFnMakeHash{entryType: "evenNumbers", entry: &GobEntry{C: "2"}}
+		r, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		l := h.ChainLength()
+		So(r.(Hash).String(), ShouldEqual, commit(h, "evenNumbers", "2").String())
+		So(h.ChainLength(), ShouldEqual, l+1)
+
+		l = h.ChainLength()
+		_, err = fn.Call(h)
+		So(err, ShouldBeNil)
+		So(h.ChainLength(), ShouldEqual, l)
+	})
+
+	Convey("it should match the hash of a migrate whatever the key order", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Type = "split"
+		j := fmt.Sprintf(`{"Data":"%s","Key":"%s","DNAHash":"%s","Type":"%s"}`, entry.Data, entry.Key.String(), entry.DNAHash.String(), entry.Type)
+		fn := &APIFnMakeHash{entryType: MigrateEntryType, entry: &GobEntry{C: j}}
+		r, err := fn.Call(h)
+		So(err, ShouldBeNil)
+
+		migrate := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+		hash, err := migrate.Call(h)
+		So(err, ShouldBeNil)
+		So(r.(Hash).String(), ShouldEqual, hash.(Hash).String())
+	})
+
+	Convey("it should reject a migrate that isn't valid JSON", t, func() {
+		fn := &APIFnMakeHash{entryType: MigrateEntryType, entry: &GobEntry{C: "{"}}
+		_, err := fn.Call(h)
+		So(err, ShouldNotBeNil)
+	})
+}
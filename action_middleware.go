@@ -0,0 +1,88 @@
+package holochain
+
+// ActionPhase is the part of the handling of an action that a middleware wraps
+type ActionPhase int
+
+const (
+	// PhaseCommit is the commit of an action's entry to the local chain,
+	// including its validation
+	PhaseCommit ActionPhase = iota
+
+	// PhaseSysValidation is the system level validation of an action,
+	// whether it's being committed or was received
+	PhaseSysValidation
+
+	// PhaseReceive is the handling of an action received from another node
+	PhaseReceive
+)
+
+// ActionCall describes the handling of an action as it's passed down the
+// middleware chain
+type ActionCall struct {
+	Phase     ActionPhase
+	Action    Action
+	EntryType string   // not set for PhaseReceive, the action isn't populated yet
+	Msg       *Message // the message received, only set for PhaseReceive
+}
+
+// ActionHandler handles an action, returning the response of the phase; the
+// entry def for PhaseCommit, nil for PhaseSysValidation and the response to
+// send for PhaseReceive
+type ActionHandler func(h *Holochain, call *ActionCall) (response interface{}, err error)
+
+// ActionMiddleware wraps the handling of actions, it should call next to carry
+// on handling the action or return an error to refuse it
+type ActionMiddleware func(next ActionHandler) ActionHandler
+
+// UseActionMiddleware adds a middleware that wraps the commit, system
+// validation and receipt of all actions.  Middleware runs in the order it was
+// added, so the first added sees the action first.
+func (h *Holochain) UseActionMiddleware(fn func(next ActionHandler) ActionHandler) {
+	h.middlewareLk.Lock()
+	defer h.middlewareLk.Unlock()
+	h.actionMiddleware = append(h.actionMiddleware, ActionMiddleware(fn))
+}
+
+// CommitGate is consulted before any action is committed to the chain, returning
+// an error refuses the commit with that error
+type CommitGate func(action Action) error
+
+// SetCommitGate sets a single app wide check on every commit, i.e. to refuse
+// all migrates during an upgrade.  The gate is given the concrete action, so
+// it can, for instance, assert an *ActionMigrate to inspect its entry.
+// Setting a nil gate removes it.
+func (h *Holochain) SetCommitGate(fn func(action Action) error) {
+	h.middlewareLk.Lock()
+	defer h.middlewareLk.Unlock()
+	h.commitGate = CommitGate(fn)
+}
+
+// checkCommitGate returns the commit gate's error for the action, if there is a gate
+func (h *Holochain) checkCommitGate(a Action) (err error) {
+	h.middlewareLk.RLock()
+	gate := h.commitGate
+	h.middlewareLk.RUnlock()
+	if gate != nil {
+		err = gate(a)
+	}
+	return
+}
+
+// dispatchAction passes an action through the middleware chain to handler
+func (h *Holochain) dispatchAction(call *ActionCall, handler ActionHandler) (response interface{}, err error) {
+	h.middlewareLk.RLock()
+	middleware := h.actionMiddleware
+	sink := h.auditSink
+	h.middlewareLk.RUnlock()
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	if h.logEnabled(LogDebug) {
+		h.log(LogDebug, "action dispatch", LogField{"phase", call.Phase}, LogField{"action", call.Action.Name()}, LogField{"type", call.EntryType})
+	}
+	response, err = handler(h, call)
+	if sink != nil && call.Phase != PhaseSysValidation {
+		h.audit(sink, call, err)
+	}
+	return
+}
@@ -0,0 +1,111 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestActionMiddleware(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	var seen []string
+	record := func(name string) func(next ActionHandler) ActionHandler {
+		return func(next ActionHandler) ActionHandler {
+			return func(h *Holochain, call *ActionCall) (interface{}, error) {
+				seen = append(seen, name+":"+call.Action.Name())
+				return next(h, call)
+			}
+		}
+	}
+	h.UseActionMiddleware(record("first"))
+	h.UseActionMiddleware(record("second"))
+
+	Convey("middleware should run in registration order around commits", t, func() {
+		seen = nil
+		_, err := h.commitAndShare(NewCommitAction("evenNumbers", &GobEntry{C: "2"}), NullHash())
+		So(err, ShouldBeNil)
+		So(len(seen) >= 4, ShouldBeTrue)
+		So(seen[:4], ShouldResemble, []string{"first:commit", "second:commit", "first:commit", "second:commit"})
+	})
+
+	Convey("middleware should see received actions", t, func() {
+		hash := commit(h, "evenNumbers", "4")
+		seen = nil
+		_, err := ActionReceiver(h, h.node.NewMessage(GET_REQUEST, GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry}))
+		So(err, ShouldBeNil)
+		So(seen, ShouldResemble, []string{"first:get", "second:get"})
+	})
+
+	errReadOnly := errors.New("read-only mode")
+	var phases []ActionPhase
+	h.UseActionMiddleware(func(next ActionHandler) ActionHandler {
+		return func(h *Holochain, call *ActionCall) (interface{}, error) {
+			if _, ok := call.Action.(*ActionMigrate); ok {
+				phases = append(phases, call.Phase)
+				return nil, errReadOnly
+			}
+			return next(h, call)
+		}
+	})
+
+	Convey("middleware should be able to veto migrate commits", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		l := h.ChainLength()
+		_, err = h.doCommit(&ActionMigrate{entry: entry}, NullHash())
+		So(err, ShouldEqual, errReadOnly)
+		So(h.ChainLength(), ShouldEqual, l)
+		So(phases, ShouldResemble, []ActionPhase{PhaseCommit})
+	})
+
+	Convey("middleware should be able to veto received migrates", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		header, err := GenTestHeader()
+		So(err, ShouldBeNil)
+		phases = nil
+		_, err = h.ValidateAction(&ActionMigrate{entry: entry, header: header}, MigrateEntryType, &Package{}, []peer.ID{h.nodeID})
+		So(err, ShouldEqual, errReadOnly)
+		So(phases, ShouldResemble, []ActionPhase{PhaseSysValidation})
+	})
+}
+
+func TestCommitGate(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	errMaintenance := errors.New("app is in maintenance")
+	h.SetCommitGate(func(action Action) error {
+		if _, ok := action.(*ActionMigrate); ok {
+			return errMaintenance
+		}
+		return nil
+	})
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+
+	Convey("the gate should refuse the actions it rejects", t, func() {
+		l := h.chain.Length()
+		_, err := (&APIFnMigrate{action: ActionMigrate{entry: entry}}).Call(h)
+		So(err, ShouldEqual, errMaintenance)
+		So(h.chain.Length(), ShouldEqual, l)
+	})
+
+	Convey("the gate should let other actions commit", t, func() {
+		_, err := h.commitAndShare(NewCommitAction("evenNumbers", &GobEntry{C: "2"}), NullHash())
+		So(err, ShouldBeNil)
+	})
+
+	Convey("removing the gate should allow everything again", t, func() {
+		h.SetCommitGate(nil)
+		_, err := (&APIFnMigrate{action: ActionMigrate{entry: entry}}).Call(h)
+		So(err, ShouldBeNil)
+	})
+}
@@ -1,20 +1,28 @@
 // Copyright (C) 2013-2018, The MetaCurrency Project (Eric Harris-Braun, Arthur Brock, et. al.)
 // Use of this source code is governed by GPLv3 found in the LICENSE file
-//----------------------------------------------------------------------------------------
-//
+// ----------------------------------------------------------------------------------------
 package holochain
 
 import (
+	"encoding/json"
+	"fmt"
 	. "github.com/holochain/holochain-proto/hash"
 	peer "github.com/libp2p/go-libp2p-peer"
+	"time"
+)
+
+const (
+	DefaultMigrateQuorumTimeout = time.Second * 30
+	MigrateQuorumPollInterval   = time.Millisecond * 100
 )
 
 //------------------------------------------------------------
 // Migrate Action
 
 type ActionMigrate struct {
-	entry  MigrateEntry
-	header *Header
+	entry   MigrateEntry
+	header  *Header
+	shareOp string // the id of the operation sending its put to peers
 }
 
 func (a *ActionMigrate) Name() string {
@@ -41,14 +49,21 @@ func (a *ActionMigrate) GetHeader() (header *Header) {
 	return a.header
 }
 
+func (a *ActionMigrate) VerifyEntryLink() (err error) {
+	return verifyEntryLink(a.header, a.Entry())
+}
+
 func (action *ActionMigrate) Share(h *Holochain, def *EntryDef) (err error) {
-	err = h.dht.Change(action.header.EntryLink, PUT_REQUEST, HoldReq{EntryHash: action.header.EntryLink})
+	action.shareOp, err = h.dht.sharePut(action.header.EntryLink, def)
 	return
 }
 
 func (action *ActionMigrate) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	if err = ValidateSources(sources); err != nil {
+		return
+	}
 	// correct entry def
-	if def != MigrateEntryDef {
+	if def.Name != MigrateEntryType {
 		err = ErrEntryDefInvalid
 		return
 	}
@@ -59,10 +74,186 @@ func (action *ActionMigrate) SysValidation(h *Holochain, def *EntryDef, pkg *Pac
 	}
 	// entry is valid
 	err = sysValidateEntry(h, def, action.Entry(), pkg)
+	if err != nil {
+		return
+	}
+	// cross-codec hashes are rejected early rather than failing to decode
+	if err = checkMigrateHashCodecs(&action.entry); err != nil {
+		return
+	}
+	// an open must start the chain, i.e. come right after genesis
+	if action.entry.Type == MigrateEntryTypeOpen {
+		err = h.chain.Walk(func(key *Hash, header *Header, entry Entry) error {
+			if header.Type != DNAEntryType && header.Type != AgentEntryType {
+				return ErrMigrateOpenNotFirst
+			}
+			return nil
+		})
+	}
+	// only the chain's own agent or a member of its group may migrate it
+	if err == nil && action.header.Signer != "" {
+		err = checkHeaderSigner(h, action.header, pkg, sources[0])
+	}
+	// a forked chain is an invalid base to migrate from
+	if err == nil {
+		err = checkNotForked(h, sources[0])
+	}
 	// @TODO should migration only be valid if peer ID is node owner?
 	return
 }
 
+// checkNotForked refuses a migrate by an author whose chain the DHT shows to be
+// forked, or whose chain can't be checked
+func checkNotForked(h *Holochain, author peer.ID) (err error) {
+	forked, conflicting, err := h.dht.DetectFork(HashFromPeerID(author))
+	if err != nil {
+		return
+	}
+	if forked {
+		h.dht.dlog.Logf("migrate: %v's chain is forked at %v", author, conflicting)
+		err = ErrMigrateForkedChain
+	}
+	return
+}
+
+// OrphanMigrationError reports why an open migrate doesn't link to a close
+type OrphanMigrationError struct {
+	CloseHash Hash
+	Reason    string
+}
+
+func (e *OrphanMigrationError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrOrphanMigration, e.Reason)
+}
+
+func (e *OrphanMigrationError) Unwrap() error {
+	return ErrOrphanMigration
+}
+
+// sysValidateMigrateData checks the Data of a migrate entry against its def:
+// encrypted data only structurally, otherwise against the def's DataSchema if
+// it has one, and that an open continues a close if the def requires it
+func sysValidateMigrateData(h *Holochain, def *EntryDef, entry *MigrateEntry) (err error) {
+	// encrypted data can only be checked structurally, the schema would need
+	// the plaintext
+	if entry.IsEncrypted() {
+		if err = entry.validateEncryption(); err != nil {
+			return
+		}
+	} else if def.dataValidator != nil {
+		// if the def has a schema for the data, it must parse and conform to it
+		var data interface{}
+		if err = json.Unmarshal([]byte(entry.Data), &data); err != nil {
+			err = validationFieldFailed("Data", fmt.Sprintf("Error (%s) when decoding Data value '%s'", err.Error(), entry.Data), err)
+			return
+		}
+		if err = def.dataValidator.Validate(data); err != nil {
+			err = validationFieldFailed("Data", fmt.Sprintf("Error (%s) when validating Data value '%s'", err.Error(), entry.Data), err)
+			return
+		}
+	}
+	if def.RequireMigrationChain && entry.Type == MigrateEntryTypeOpen {
+		// an encrypted Data can't be the close hash the chain is checked by
+		if entry.IsEncrypted() {
+			err = &OrphanMigrationError{Reason: "Data is encrypted"}
+			return
+		}
+		err = checkMigrationChain(h, entry)
+	}
+	return
+}
+
+// checkMigrationChain checks that the Data of an open is the hash of a close
+// migrate in the DHT for the same Key
+func checkMigrationChain(h *Holochain, entry *MigrateEntry) (err error) {
+	closeHash, e := NewHash(entry.Data)
+	if e != nil {
+		err = &OrphanMigrationError{Reason: "Data is not a hash"}
+		return
+	}
+	req := GetReq{H: closeHash, StatusMask: StatusDefault, GetMask: GetMaskEntry}
+	r, e := callGet(h, req, &GetOptions{StatusMask: req.StatusMask, GetMask: req.GetMask})
+	if e != nil {
+		err = &OrphanMigrationError{CloseHash: closeHash, Reason: fmt.Sprintf("close not found (%v)", e)}
+		return
+	}
+	resp := r.(GetResp)
+	if resp.EntryType != MigrateEntryType {
+		err = &OrphanMigrationError{CloseHash: closeHash, Reason: "not a migrate entry"}
+		return
+	}
+	closing, e := MigrateEntryFromJSON(resp.Entry.C.(string))
+	if e != nil || closing.Type != MigrateEntryTypeClose {
+		err = &OrphanMigrationError{CloseHash: closeHash, Reason: "not a close migrate"}
+		return
+	}
+	if !closing.Key.Equal(entry.Key) {
+		err = &OrphanMigrationError{CloseHash: closeHash, Reason: "close has a different Key"}
+	}
+	return
+}
+
+// migrateValidationZome returns the zome that declares app validation of
+// migrate entries, by listing the migrate entry type among its entries, or
+// nil if no zome does
+func (h *Holochain) migrateValidationZome() (zome *Zome, def *EntryDef) {
+	for i := range h.nucleus.dna.Zomes {
+		z := &h.nucleus.dna.Zomes[i]
+		d, err := z.GetEntryDef(MigrateEntryType)
+		if err == nil {
+			zome = z
+			def = d
+			return
+		}
+	}
+	return
+}
+
+// appValidateMigrate calls the app's validateCommit or validatePut for a
+// migrate entry that has passed system validation, if the DNA declares a
+// handler for it.  Rejections are returned as validation errors.
+func (h *Holochain) appValidateMigrate(vctx *ValidationContext, a ValidatingAction, pkg *Package, sources []peer.ID) (err error) {
+	z, def := h.migrateValidationZome()
+	if z == nil {
+		return
+	}
+	var action Action
+	switch t := a.(type) {
+	case *ActionMigrate:
+		c := NewCommitAction(MigrateEntryType, t.Entry())
+		c.header = t.header
+		action = c
+	case *ActionCommit, *ActionPut:
+		action = a
+	default:
+		return
+	}
+
+	// the app always gets the migrate entry as parsed JSON
+	appDef := *def
+	appDef.DataFormat = MigrateEntryDef.DataFormat
+
+	var vpkg *ValidationPackage
+	vpkg, err = MakeValidationPackage(h, pkg)
+	if err != nil {
+		return
+	}
+	vctx.bindChain(h, vpkg, sources)
+	var n Ribosome
+	n, err = z.MakeRibosome(h)
+	if err != nil {
+		return
+	}
+	err = h.appValidateAction(vctx, n, action, &appDef, vpkg, prepareSources(sources))
+	if err != nil {
+		h.Debugf("Ribosome ValidateAction(%T) for migrate err:%v\n", a, err)
+		if !IsValidationFailedErr(err) {
+			err = &ValidationError{Reason: err.Error(), Underlying: err}
+		}
+	}
+	return
+}
+
 func (a *ActionMigrate) CheckValidationRequest(def *EntryDef) (err error) {
 	// intentionally left blank ;)
 	return
@@ -78,7 +269,11 @@ func (a *ActionMigrate) Receive(dht *DHT, msg *Message) (response interface{}, e
 // Migrate API fn
 
 type APIFnMigrate struct {
-	action ActionMigrate
+	action         ActionMigrate
+	quorum         int
+	quorumTimeout  time.Duration
+	createLink     bool
+	idempotencyKey string
 }
 
 func (fn *APIFnMigrate) Name() string {
@@ -93,11 +288,171 @@ func (fn *APIFnMigrate) Args() []Arg {
 		{Name: "Key",
 			Type: HashArg},
 		{Name: "data",
-			Type: StringArg}}
+			Type: StringArg},
+		{Name: "quorum",
+			Type: IntArg, Optional: true},
+		{Name: "createLink",
+			Type: BoolArg, Optional: true},
+		{Name: "idempotencyKey",
+			Type: StringArg, Optional: true}}
 }
 
+// Call commits and shares the migrate entry.  If a quorum was requested a
+// migrateConfirmed signal is emitted once that many nodes are known to hold the
+// entry, or a migrateTimeout signal if that doesn't happen before the timeout.
+// If createLink is set the migrate is also linked to from the agent's entry.
+// If an idempotencyKey is given, a repeat of a call with the same key and
+// args returns the hash of the migrate the first call committed.
 func (fn *APIFnMigrate) Call(h *Holochain) (response interface{}, err error) {
 	var hash Hash
-	response, err = h.commitAndShare(&fn.action, hash)
+	if fn.idempotencyKey != "" {
+		h.idempotencyLk.Lock()
+		defer h.idempotencyLk.Unlock()
+		var found bool
+		hash, found, err = fn.idempotentHash(h)
+		if err != nil {
+			return
+		}
+		if found {
+			response = hash
+			return
+		}
+	}
+	if fn.createLink {
+		hash, err = fn.commitAndShareWithLink(h)
+	} else {
+		hash, err = h.commitAndShare(&fn.action, hash)
+	}
+	if err != nil {
+		return
+	}
+	if fn.idempotencyKey != "" {
+		if err = fn.recordIdempotencyKey(h, hash); err != nil {
+			return
+		}
+	}
+	response = hash
+	if fn.quorum > 0 {
+		timeout := fn.quorumTimeout
+		if timeout == 0 {
+			timeout = DefaultMigrateQuorumTimeout
+		}
+		go watchMigrateQuorum(h, h.dht, h.closingCh(), hash, fn.quorum, timeout)
+	}
 	return
 }
+
+// commitAndShareWithLink commits the migrate together with a link to it from
+// the agent's entry, in a bundle so that either both or neither are committed,
+// and then shares the migrate followed by its link.  If the migrate's share
+// fails neither is on the DHT and both are left waiting to be retried with
+// ShareHash.  If the link's share fails the migrate is already on the DHT, so
+// it's rolled back rather than left there without its link, and the link
+// error is returned.  If a bundle is already open both are left to its close.
+func (fn *APIFnMigrate) commitAndShareWithLink(h *Holochain) (hash Hash, err error) {
+	a := &fn.action
+	chain := h.Chain()
+	bundle := chain.BundleStarted()
+	inBundle := bundle != nil
+	if !inBundle {
+		if err = chain.StartBundle(a.Name()); err != nil {
+			return
+		}
+		bundle = chain.BundleStarted()
+	}
+	var migrateDef, linkDef *EntryDef
+	var link *ActionCommit
+	migrateDef, err = h.doCommit(a, NullHash())
+	if err == nil {
+		hash = a.header.EntryLink
+		var entry Entry
+		entry, err = h.migrateLinkEntry(hash)
+		if err == nil {
+			link = NewCommitAction(MigrateLinkEntryType, entry)
+			linkDef, err = h.doCommit(link, NullHash())
+		}
+	}
+	if inBundle {
+		if err == nil {
+			bundle.sharing = append(bundle.sharing, a, link)
+		}
+		return
+	}
+	if err != nil {
+		chain.CloseBundle(false)
+		return
+	}
+	if err = chain.CloseBundle(true); err != nil {
+		return
+	}
+
+	h.addPendingShare(a, migrateDef)
+	linkHash := h.addPendingShare(link, linkDef)
+	if err = h.ShareHash(hash); err != nil {
+		return
+	}
+	if err = h.ShareHash(linkHash); err != nil {
+		h.rollbackUnlinkedMigrate(a, linkHash)
+	}
+	return
+}
+
+// rollbackUnlinkedMigrate rolls back a shared migrate whose link couldn't be
+// shared, dropping the link's pending share as there's no longer a migrate
+// for it to lead to.  If the rollback fails the link stays pending.
+func (h *Holochain) rollbackUnlinkedMigrate(a *ActionMigrate, linkHash Hash) {
+	headerHash, _, err := a.header.Sum(h.hashSpec)
+	if err == nil {
+		rollback := &APIFnMigrateRollback{action: *NewMigrateRollbackAction(MigrateRollbackEntry{MigrateHeaderHash: headerHash})}
+		_, err = rollback.Call(h)
+	}
+	if err != nil {
+		h.Debugf("unable to roll back migrate %v whose link wasn't shared: %v", a.header.EntryLink, err)
+		return
+	}
+	h.pendingSharesLk.Lock()
+	delete(h.pendingShares, linkHash)
+	h.pendingSharesLk.Unlock()
+}
+
+// ShareOp returns the id of the operation sending the migrate's put to peers,
+// which is still pending after Call returns as the put is sent from a queue.
+// WaitOp with it returns ErrOpCanceled if the operation was canceled with
+// CancelOp.  It's empty if the call didn't share a migrate, i.e. a repeat of
+// an idempotent call.
+func (fn *APIFnMigrate) ShareOp() string {
+	return fn.action.shareOp
+}
+
+// heldCount returns the number of nodes known to be holding a hash, including us
+func heldCount(h *Holochain, dht *DHT, hash Hash) (count int) {
+	count = len(h.world.Holders(hash))
+	if dht.Exists(hash, StatusLive) == nil {
+		count++
+	}
+	return
+}
+
+// watchMigrateQuorum signals migrateConfirmed once a quorum of nodes hold the
+// migrate, or migrateTimeout if they don't before the timeout, unless the
+// holochain is closed first
+func watchMigrateQuorum(h *Holochain, dht *DHT, closing <-chan struct{}, hash Hash, quorum int, timeout time.Duration) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(MigrateQuorumPollInterval)
+	defer poll.Stop()
+	for {
+		if heldCount(h, dht, hash) >= quorum {
+			h.Signal(SignalMigrateConfirmed, hash)
+			return
+		}
+		select {
+		case <-closing:
+			return
+		case <-deadline.C:
+			h.Signal(SignalMigrateTimeout, hash)
+			return
+		case <-poll.C:
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+//------------------------------------------------------------
+// MigrateRollback Action
+
+type ActionMigrateRollback struct {
+	entry  MigrateRollbackEntry
+	header *Header
+}
+
+func NewMigrateRollbackAction(entry MigrateRollbackEntry) *ActionMigrateRollback {
+	a := ActionMigrateRollback{entry: entry}
+	return &a
+}
+
+func (a *ActionMigrateRollback) Name() string {
+	return "migrateRollback"
+}
+
+func (a *ActionMigrateRollback) Entry() Entry {
+	j, err := a.entry.ToJSON()
+	if err != nil {
+		panic(err)
+	}
+	return &GobEntry{C: j}
+}
+
+func (a *ActionMigrateRollback) EntryType() string {
+	return MigrateRollbackEntryType
+}
+
+func (a *ActionMigrateRollback) SetHeader(header *Header) {
+	a.header = header
+}
+
+func (a *ActionMigrateRollback) GetHeader() (header *Header) {
+	return a.header
+}
+
+func (a *ActionMigrateRollback) VerifyEntryLink() (err error) {
+	return verifyEntryLink(a.header, a.Entry())
+}
+
+// Share PUTs the rollback entry and marks the original migrate entry (recorded
+// as the header's Change) as modified by it
+func (action *ActionMigrateRollback) Share(h *Holochain, def *EntryDef) (err error) {
+	err = h.dht.Change(action.header.EntryLink, PUT_REQUEST, HoldReq{EntryHash: action.header.EntryLink})
+	if err != nil {
+		return
+	}
+	err = h.dht.Change(action.header.Change, MOD_REQUEST, HoldReq{RelatedHash: action.header.Change, EntryHash: action.header.EntryLink})
+	return
+}
+
+func (action *ActionMigrateRollback) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	if err = ValidateSources(sources); err != nil {
+		return
+	}
+	// correct entry def
+	if def != MigrateRollbackEntryDef {
+		err = ErrEntryDefInvalid
+		return
+	}
+	// has a header
+	if action.header == nil {
+		err = ErrActionMissingHeader
+		return
+	}
+	// entry is valid
+	err = sysValidateEntry(h, def, action.Entry(), pkg)
+	if err != nil {
+		return
+	}
+
+	// the migrate being rolled back must be on our chain
+	var header *Header
+	header, err = h.chain.Get(action.entry.MigrateHeaderHash)
+	if err != nil {
+		return
+	}
+	if header.Type != MigrateEntryType {
+		err = ErrMigrateRollbackTargetInvalid
+		return
+	}
+
+	// and must not have been rolled back already
+	err = h.chain.Walk(func(key *Hash, header *Header, entry Entry) (err error) {
+		if header.Type == MigrateRollbackEntryType {
+			var rollback MigrateRollbackEntry
+			rollback, err = MigrateRollbackEntryFromJSON(entry.Content().(string))
+			if err != nil {
+				return
+			}
+			if rollback.MigrateHeaderHash.Equal(action.entry.MigrateHeaderHash) {
+				err = ErrMigrateAlreadyRolledBack
+			}
+		}
+		return
+	})
+	return
+}
+
+func (a *ActionMigrateRollback) CheckValidationRequest(def *EntryDef) (err error) {
+	return
+}
+
+func (a *ActionMigrateRollback) Receive(dht *DHT, msg *Message) (response interface{}, err error) {
+	// this is always an error because there is no action message for migrate rollback
+	err = ErrActionReceiveInvalid
+	return
+}
+
+//------------------------------------------------------------
+// MigrateRollback API fn
+
+type APIFnMigrateRollback struct {
+	action ActionMigrateRollback
+}
+
+func (fn *APIFnMigrateRollback) Name() string {
+	return fn.action.Name()
+}
+
+func (fn *APIFnMigrateRollback) Args() []Arg {
+	return []Arg{{Name: "migrateHeaderHash", Type: HashArg}}
+}
+
+func (fn *APIFnMigrateRollback) Call(h *Holochain) (response interface{}, err error) {
+	var header *Header
+	header, err = h.chain.Get(fn.action.entry.MigrateHeaderHash)
+	if err != nil {
+		return
+	}
+	response, err = h.commitAndShare(&fn.action, header.EntryLink)
+	return
+}
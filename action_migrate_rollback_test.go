@@ -0,0 +1,80 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestMigrateRollbackName(t *testing.T) {
+	Convey("migrate rollback action should have the right name", t, func() {
+		a := ActionMigrateRollback{}
+		So(a.Name(), ShouldEqual, "migrateRollback")
+		So(a.EntryType(), ShouldEqual, MigrateRollbackEntryType)
+	})
+}
+
+func TestMigrateRollbackSysValidation(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	migrate := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+	_, err = migrate.Call(h)
+	if err != nil {
+		panic(err)
+	}
+	migrateHeaderHash := h.chain.Hashes[len(h.chain.Hashes)-1]
+
+	Convey("it should invalidate DNAEntryDef", t, func() {
+		action := ActionMigrateRollback{}
+		err := action.SysValidation(h, DNAEntryDef, nil, []peer.ID{h.nodeID})
+		So(err, ShouldEqual, ErrEntryDefInvalid)
+	})
+
+	Convey("it should return an ErrActionMissingHeader error if header is missing", t, func() {
+		action := ActionMigrateRollback{}
+		err := action.SysValidation(h, action.entry.Def(), nil, []peer.ID{h.nodeID})
+		So(err, ShouldEqual, ErrActionMissingHeader)
+	})
+
+	Convey("it should fail if the migrate entry doesn't exist", t, func() {
+		header, err := GenTestHeader()
+		So(err, ShouldBeNil)
+		missing, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		action := ActionMigrateRollback{header: header, entry: MigrateRollbackEntry{MigrateHeaderHash: missing}}
+		err = action.SysValidation(h, action.entry.Def(), nil, []peer.ID{h.nodeID})
+		So(err, ShouldEqual, ErrHashNotFound)
+	})
+
+	Convey("it should fail if the referenced header isn't a migrate", t, func() {
+		header, err := GenTestHeader()
+		So(err, ShouldBeNil)
+		action := ActionMigrateRollback{header: header, entry: MigrateRollbackEntry{MigrateHeaderHash: h.chain.Hashes[0]}}
+		err = action.SysValidation(h, action.entry.Def(), nil, []peer.ID{h.nodeID})
+		So(err, ShouldEqual, ErrMigrateRollbackTargetInvalid)
+	})
+
+	Convey("it should commit a rollback of an existing migrate only once", t, func() {
+		fn := &APIFnMigrateRollback{action: ActionMigrateRollback{entry: MigrateRollbackEntry{MigrateHeaderHash: migrateHeaderHash}}}
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		hash := response.(Hash)
+
+		header, err := h.chain.GetEntryHeader(hash)
+		So(err, ShouldBeNil)
+		So(header.Type, ShouldEqual, MigrateRollbackEntryType)
+		migrateHeader, err := h.chain.Get(migrateHeaderHash)
+		So(err, ShouldBeNil)
+		So(header.Change.String(), ShouldEqual, migrateHeader.EntryLink.String())
+
+		fn = &APIFnMigrateRollback{action: ActionMigrateRollback{entry: MigrateRollbackEntry{MigrateHeaderHash: migrateHeaderHash}}}
+		_, err = fn.Call(h)
+		So(err, ShouldEqual, ErrMigrateAlreadyRolledBack)
+	})
+}
@@ -1,11 +1,14 @@
 package holochain
 
 import (
+	"errors"
 	"fmt"
 	. "github.com/holochain/holochain-proto/hash"
 	peer "github.com/libp2p/go-libp2p-peer"
+	mh "github.com/multiformats/go-multihash"
 	. "github.com/smartystreets/goconvey/convey"
 	"testing"
+	"time"
 )
 
 // ActionMigrate
@@ -24,10 +27,10 @@ func TestMigrateEntry(t *testing.T) {
 	})
 
 	Convey("entries with vals work with Entry()", t, func() {
-		dnaHash, err := genTestStringHash()
+		dnaHash, err := GenTestStringHash()
 		So(err, ShouldBeNil)
 
-		key, err := genTestStringHash()
+		key, err := GenTestStringHash()
 		So(err, ShouldBeNil)
 
 		entry := MigrateEntry{DNAHash: dnaHash, Key: key}
@@ -52,7 +55,7 @@ func TestMigrateHeaderSetGet(t *testing.T) {
 
 	Convey("migrate action should be able to set and get header", t, func() {
 		action := ActionMigrate{}
-		header, err := genTestHeader()
+		header, err := GenTestHeader()
 		So(err, ShouldBeNil)
 
 		So(action.GetHeader(), ShouldEqual, nil)
@@ -63,6 +66,36 @@ func TestMigrateHeaderSetGet(t *testing.T) {
 	})
 }
 
+func TestMigrateVerifyEntryLink(t *testing.T) {
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	action := ActionMigrate{entry: entry}
+
+	Convey("it should need a header", t, func() {
+		So(action.VerifyEntryLink(), ShouldEqual, ErrActionMissingHeader)
+	})
+
+	Convey("it should pass when the header links to the entry", t, func() {
+		action.header = &Header{}
+		action.header.EntryLink, err = action.Entry().Sum(HashSpec{Code: mh.SHA2_256, Length: -1})
+		So(err, ShouldBeNil)
+		So(action.VerifyEntryLink(), ShouldBeNil)
+	})
+
+	Convey("it should report both hashes when they don't match", t, func() {
+		linked := action.header.EntryLink
+		action.entry.Data = "changed"
+		err := action.VerifyEntryLink()
+		So(errors.Is(err, ErrEntryLinkMismatch), ShouldBeTrue)
+		mismatch := err.(*EntryLinkMismatchError)
+		So(mismatch.EntryLink.String(), ShouldEqual, linked.String())
+		hash, _ := action.Entry().Sum(HashSpec{Code: mh.SHA2_256, Length: -1})
+		So(mismatch.EntryHash.String(), ShouldEqual, hash.String())
+	})
+}
+
 func TestMigrateCallShare(t *testing.T) {
 	n := 3
 	mt := setupMultiNodeTesting(n)
@@ -70,9 +103,11 @@ func TestMigrateCallShare(t *testing.T) {
 	defer mt.cleanupMultiNodeTesting()
 
 	Convey("ActionMigrate should share as a PUT on the DHT and roundtrip as JSON", t, func() {
+		So(mt.WaitConnected(), ShouldBeNil)
+
 		var err error
-		header, err := genTestHeader()
-		entry, err := genTestMigrateEntry()
+		header, err := GenTestHeader()
+		entry, err := GenTestMigrateEntry()
 		So(err, ShouldBeNil)
 
 		action := ActionMigrate{header: header, entry: entry}
@@ -84,12 +119,14 @@ func TestMigrateCallShare(t *testing.T) {
 		dhtHash, ok := callResponse.(Hash)
 		So(ok, ShouldBeTrue)
 		So(err, ShouldBeNil)
+		So(fn.action.VerifyEntryLink(), ShouldBeNil)
+		So(mt.WaitPropagated(dhtHash), ShouldBeNil)
 
 		// Can get the PUT MigrateEntry from any node
 		for i := 0; i < n; i++ {
 			fmt.Printf("\nTesting retrieval of MigrateEntry PUT from node %d\n", i)
 
-			request := GetReq{H: dhtHash, StatusMask: StatusLive, GetMask: GetMaskEntry}
+			request := GetReq{H: dhtHash, StatusMask: StatusLive, GetMask: GetMaskEntry | GetMaskHeader}
 			response, err := callGet(mt.nodes[i], request, &GetOptions{GetMask: request.GetMask})
 			r, ok := response.(GetResp)
 
@@ -97,6 +134,234 @@ func TestMigrateCallShare(t *testing.T) {
 			So(err, ShouldBeNil)
 
 			So(&r.Entry, ShouldResemble, action.Entry())
+			So(NewPutAction(MigrateEntryType, &r.Entry, r.Header).VerifyEntryLink(), ShouldBeNil)
+		}
+	})
+}
+
+func TestMigrateCallCreateLink(t *testing.T) {
+	n := 3
+	mt := setupMultiNodeTesting(n)
+	ringConnect(t, mt.ctx, mt.nodes, n)
+	defer mt.cleanupMultiNodeTesting()
+	h := mt.nodes[0]
+
+	Convey("a migrate should be linked to from the agent's entry", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Type = "split"
+		l := h.ChainLength()
+
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}, createLink: true}
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		hash := response.(Hash)
+		So(h.ChainLength(), ShouldEqual, l+2)
+		So(h.Chain().BundleStarted(), ShouldBeNil)
+		So(h.chain.Headers[l].EntryLink.Equal(hash), ShouldBeTrue)
+		So(h.chain.Headers[l+1].Type, ShouldEqual, MigrateLinkEntryType)
+		So(len(h.PendingShares()), ShouldEqual, 0)
+
+		options := GetLinksOptions{StatusMask: StatusLive}
+		query := &LinkQuery{Base: h.AgentHash(), T: MigrateLinkTag, StatusMask: options.StatusMask}
+		var links []TaggedHash
+		for i := 0; i < 50 && len(links) == 0; i++ {
+			fn := &APIFnGetLinks{action: *NewGetLinksAction(query, &options)}
+			r, err := fn.Call(mt.nodes[1])
+			So(err, ShouldBeNil)
+			links = r.(*LinkQueryResp).Links
+			if len(links) == 0 {
+				time.Sleep(time.Millisecond * 20)
+			}
+		}
+		So(len(links), ShouldEqual, 1)
+		So(links[0].H, ShouldEqual, hash.String())
+	})
+
+	Convey("if the migrate is invalid neither it nor the link should be committed", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Type = "split"
+		entry.DNAHash = NullHash()
+		l := h.ChainLength()
+
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}, createLink: true}
+		_, err = fn.Call(h)
+		So(err, ShouldNotBeNil)
+		So(h.ChainLength(), ShouldEqual, l)
+		So(h.Chain().BundleStarted(), ShouldBeNil)
+	})
+
+	Convey("migrate links must be from the author's agent entry to the author's migrate", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Type = "split"
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		migrateHash := response.(Hash).String()
+		other := commit(h, "evenNumbers", "2").String()
+		agent := h.AgentHash().String()
+
+		So(checkHeldMigrateLink(h, Link{Base: agent, Link: migrateHash, Tag: MigrateLinkTag}, h.nodeID), ShouldBeNil)
+		So(checkOwnMigrateLink(h, Link{Base: agent, Link: migrateHash, Tag: MigrateLinkTag}), ShouldBeNil)
+
+		err = checkHeldMigrateLink(h, Link{Base: agent, Link: migrateHash, Tag: MigrateLinkTag}, mt.nodes[1].nodeID)
+		So(err, ShouldNotBeNil)
+		So(err.(*ValidationError).Field, ShouldEqual, "Base")
+		err = checkHeldMigrateLink(h, Link{Base: agent, Link: other, Tag: MigrateLinkTag}, h.nodeID)
+		So(err.(*ValidationError).Field, ShouldEqual, "Link")
+		err = checkOwnMigrateLink(h, Link{Base: other, Link: migrateHash, Tag: MigrateLinkTag})
+		So(err.(*ValidationError).Field, ShouldEqual, "Base")
+		err = checkOwnMigrateLink(h, Link{Base: agent, Link: other, Tag: MigrateLinkTag})
+		So(err.(*ValidationError).Field, ShouldEqual, "Link")
+
+		a := NewCommitAction(MigrateLinkEntryType, &GobEntry{C: fmt.Sprintf(`{"Links":[{"Base":"%s","Link":"%s","Tag":"%s"}]}`, agent, other, MigrateLinkTag)})
+		_, err = h.doCommit(a, NullHash())
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+	})
+
+	Convey("links entries of the migrate link type must be tagged as migrations", t, func() {
+		r, ok := getRegisteredSysEntryType(MigrateLinkEntryType)
+		So(ok, ShouldBeTrue)
+		So(r.def, ShouldEqual, MigrateLinkEntryDef)
+		a := NewCommitAction(MigrateLinkEntryType, &GobEntry{C: fmt.Sprintf(`{"Links":[{"Base":"%s","Link":"%s","Tag":"other"}]}`, h.AgentHash(), h.AgentHash())})
+		_, err := h.doCommit(a, NullHash())
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+	})
+}
+
+func TestMigrateCallCreateLinkRollsBack(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	entry.Type = "split"
+	migrateHash, err := (&ActionMigrate{entry: entry}).Entry().Sum(h.hashSpec)
+	if err != nil {
+		panic(err)
+	}
+	linkEntry, err := h.migrateLinkEntry(migrateHash)
+	if err != nil {
+		panic(err)
+	}
+	linkHash, err := linkEntry.Sum(h.hashSpec)
+	if err != nil {
+		panic(err)
+	}
+
+	// refuse to hold the link
+	errNoLink := errors.New("no link")
+	h.UseActionMiddleware(func(next ActionHandler) ActionHandler {
+		return func(h *Holochain, call *ActionCall) (interface{}, error) {
+			if call.Phase == PhaseReceive && call.Msg.Type == PUT_REQUEST {
+				if req, ok := call.Msg.Body.(HoldReq); ok && req.EntryHash.Equal(linkHash) {
+					return nil, errNoLink
+				}
+			}
+			return next(h, call)
+		}
+	})
+
+	Convey("a migrate whose link can't be shared should be rolled back", t, func() {
+		l := h.ChainLength()
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}, createLink: true}
+		_, err := fn.Call(h)
+		So(err, ShouldEqual, errNoLink)
+		So(h.ChainLength(), ShouldEqual, l+3)
+		So(h.chain.Top().Type, ShouldEqual, MigrateRollbackEntryType)
+		So(h.dht.Exists(migrateHash, StatusModified), ShouldBeNil)
+		So(len(h.PendingShares()), ShouldEqual, 0)
+	})
+}
+
+func TestMigrateCallQuorum(t *testing.T) {
+	n := 3
+	mt := setupMultiNodeTesting(n)
+	ringConnect(t, mt.ctx, mt.nodes, n)
+	defer mt.cleanupMultiNodeTesting()
+	h := mt.nodes[0]
+
+	signals := make(chan Signal, 10)
+	h.AddSignalHandler(func(signal Signal) {
+		signals <- signal
+	})
+
+	waitSignal := func() (signal Signal) {
+		select {
+		case signal = <-signals:
+		case <-time.After(time.Second * 5):
+		}
+		return
+	}
+
+	Convey("migrate should signal migrateConfirmed when the quorum is reached", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Type = "split"
+
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}, quorum: 1}
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+
+		signal := waitSignal()
+		So(signal.Name, ShouldEqual, SignalMigrateConfirmed)
+		So(signal.Body.(Hash).String(), ShouldEqual, response.(Hash).String())
+	})
+
+	Convey("migrate should signal migrateTimeout when the quorum isn't reached", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Type = "split"
+
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}, quorum: n + 1, quorumTimeout: time.Millisecond * 300}
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+
+		signal := waitSignal()
+		So(signal.Name, ShouldEqual, SignalMigrateTimeout)
+		So(signal.Body.(Hash).String(), ShouldEqual, response.(Hash).String())
+	})
+
+	Convey("closing the holochain should stop the quorum watcher", t, func() {
+		d, _, h := PrepareTestChain("test")
+		defer CleanupTestChain(h, d)
+		closeSignals := make(chan Signal, 1)
+		h.AddSignalHandler(func(signal Signal) {
+			closeSignals <- signal
+		})
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Type = "split"
+
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}, quorum: 2, quorumTimeout: MigrateQuorumPollInterval * 2}
+		_, err = fn.Call(h)
+		So(err, ShouldBeNil)
+		h.Close()
+
+		select {
+		case signal := <-closeSignals:
+			So(signal.Name, ShouldBeEmpty)
+		case <-time.After(MigrateQuorumPollInterval * 4):
+		}
+	})
+
+	Convey("migrate without a quorum should not signal", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Type = "split"
+
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+		_, err = fn.Call(h)
+		So(err, ShouldBeNil)
+
+		select {
+		case signal := <-signals:
+			So(signal.Name, ShouldBeEmpty)
+		case <-time.After(MigrateQuorumPollInterval * 3):
 		}
 	})
 }
@@ -105,6 +370,14 @@ func TestMigrateActionSysValidation(t *testing.T) {
 	d, _, h := PrepareTestChain("test")
 	defer CleanupTestChain(h, d)
 
+	Convey("it should fail fast on invalid sources", t, func() {
+		action := ActionMigrate{}
+		err := action.SysValidation(h, MigrateEntryDef, nil, []peer.ID{})
+		So(err, ShouldEqual, ErrInvalidSource)
+		err = action.SysValidation(h, MigrateEntryDef, nil, []peer.ID{""})
+		So(err, ShouldEqual, ErrInvalidSource)
+	})
+
 	Convey("it should invalidate DNAEntryDef", t, func() {
 		action := ActionMigrate{}
 		err := action.SysValidation(h, DNAEntryDef, nil, []peer.ID{h.nodeID})
@@ -118,18 +391,385 @@ func TestMigrateActionSysValidation(t *testing.T) {
 	})
 
 	Convey("ActionMigrate SysValidation should validate the entry", t, func() {
-		header, err := genTestHeader()
+		header, err := GenTestHeader()
 		So(err, ShouldBeNil)
 
 		action := ActionMigrate{header: header}
 		err = action.SysValidation(h, action.entry.Def(), nil, []peer.ID{h.nodeID})
 		// the entry is empty so there should be validation complaints
 		So(err.Error(), ShouldEqual, "Validation Failed: Error (input isn't valid multihash) when decoding DNAHash value ''")
+		var validationErr *ValidationError
+		So(errors.As(err, &validationErr), ShouldBeTrue)
+		So(validationErr.Field, ShouldEqual, "DNAHash")
+		So(validationErr.Underlying, ShouldNotBeNil)
+		So(validationErr.Underlying.Error(), ShouldEqual, "input isn't valid multihash")
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+
+		action.entry, err = GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+
+		err = action.SysValidation(h, action.entry.Def(), nil, []peer.ID{h.nodeID})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("ActionMigrate SysValidation should accept a DNAHash hashed differently from the Key", t, func() {
+		header, err := GenTestHeader()
+		So(err, ShouldBeNil)
+
+		action := ActionMigrate{header: header}
+		action.entry, err = GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		action.entry.DNAHash, err = Sum(HashSpec{Code: mh.Names["blake2b-256"], Length: -1}, []byte("a blake2b DNA"))
+		So(err, ShouldBeNil)
+		So(action.entry.DNAHash.Compatible(action.entry.Key), ShouldBeFalse)
+
+		err = action.SysValidation(h, action.entry.Def(), nil, []peer.ID{h.nodeID})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("ActionMigrate SysValidation should reject a Key not hashed like node IDs", t, func() {
+		header, err := GenTestHeader()
+		So(err, ShouldBeNil)
+
+		action := ActionMigrate{header: header}
+		action.entry, err = GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		action.entry.Key, err = Sum(HashSpec{Code: mh.SHA2_512, Length: -1}, []byte("some key"))
+		So(err, ShouldBeNil)
+		So(action.entry.DNAHash.Compatible(action.entry.Key), ShouldBeFalse)
 
-		action.entry, err = genTestMigrateEntry()
+		err = action.SysValidation(h, action.entry.Def(), nil, []peer.ID{h.nodeID})
+		So(err, ShouldEqual, ErrMigrateHashCodecMismatch)
+	})
+
+	Convey("ActionMigrate SysValidation should reject a DNAHash with a codec that can't be computed", t, func() {
+		header, err := GenTestHeader()
 		So(err, ShouldBeNil)
 
+		action := ActionMigrate{header: header}
+		action.entry, err = GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		for _, code := range mh.Names {
+			if !hashCodeComputable(code) {
+				var b mh.Multihash
+				b, err = mh.Encode(make([]byte, 32), code)
+				So(err, ShouldBeNil)
+				action.entry.DNAHash = Hash(b)
+				break
+			}
+		}
+
 		err = action.SysValidation(h, action.entry.Def(), nil, []peer.ID{h.nodeID})
+		So(err, ShouldEqual, ErrMigrateHashCodecUnsupported)
+	})
+}
+
+func TestMigrateActionSysValidationDataSchema(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	header, err := GenTestHeader()
+	if err != nil {
+		panic(err)
+	}
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	action := ActionMigrate{header: header, entry: entry}
+	sources := []peer.ID{h.nodeID}
+
+	Convey("without a data schema Data should pass through unchecked", t, func() {
+		_, def, err := h.GetEntryDef(MigrateEntryType)
+		So(err, ShouldBeNil)
+		So(def.dataValidator, ShouldBeNil)
+		So(action.SysValidation(h, def, nil, sources), ShouldBeNil)
+	})
+
+	zomes := h.nucleus.dna.Zomes
+	defer func() { h.nucleus.dna.Zomes = zomes }()
+	h.nucleus.dna.Zomes = append(zomes, Zome{
+		Name:         "migrationRules",
+		RibosomeType: JSRibosomeType,
+		Entries: []EntryDef{{
+			Name:                  MigrateEntryType,
+			DataFormat:            DataFormatJSON,
+			DataSchema:            `{"type":"object","properties":{"balance":{"type":"integer"}},"required":["balance"]}`,
+			RequireMigrationChain: true,
+			Redundancy:            3,
+		}},
+	})
+
+	Convey("the DNA's migrate entry type should configure the migrate def", t, func() {
+		So(h.nucleus.dna.check(), ShouldBeNil)
+		_, def, err := h.GetEntryDef(MigrateEntryType)
+		So(err, ShouldBeNil)
+		So(def.dataValidator, ShouldNotBeNil)
+		So(def.RequireMigrationChain, ShouldBeTrue)
+		So(def.Redundancy, ShouldEqual, 3)
+		So(def.validator, ShouldEqual, MigrateEntryDef.validator)
+		So(MigrateEntryDef.dataValidator, ShouldBeNil)
+		So(h.EntryDefs()[MigrateEntryType].Redundancy, ShouldEqual, 3)
+
+		_, again, err := h.GetEntryDef(MigrateEntryType)
+		So(err, ShouldBeNil)
+		So(again.dataValidator, ShouldEqual, def.dataValidator)
+	})
+
+	Convey("with a data schema Data should be validated against it", t, func() {
+		_, def, err := h.GetEntryDef(MigrateEntryType)
+		So(err, ShouldBeNil)
+		def.RequireMigrationChain = false
+
+		action.entry.Data = "not json"
+		err = action.SysValidation(h, def, nil, sources)
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "when decoding Data value 'not json'")
+
+		action.entry.Data = `{"members":3}`
+		err = action.SysValidation(h, def, nil, sources)
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "when validating Data value")
+
+		action.entry.Data = `{"balance":3}`
+		So(action.SysValidation(h, def, nil, sources), ShouldBeNil)
+	})
+
+	Convey("the data schema and migration chain should be enforced on puts too", t, func() {
+		_, def, err := h.GetEntryDef(MigrateEntryType)
+		So(err, ShouldBeNil)
+		put := func(data string) error {
+			e := entry
+			e.Data = data
+			j, err := e.ToJSON()
+			So(err, ShouldBeNil)
+			a := NewPutAction(MigrateEntryType, &GobEntry{C: j}, header)
+			return a.SysValidation(h, def, nil, sources)
+		}
+		err = put(`{"members":3}`)
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "when validating Data value")
+
+		err = put(`{"balance":3}`)
+		So(errors.Is(err, ErrOrphanMigration), ShouldBeTrue)
+	})
+
+	Convey("only a valid schema on the migrate entry type should pass the DNA check", t, func() {
+		dna := DNA{Zomes: []Zome{{Entries: []EntryDef{{Name: "evenNumbers", DataSchema: `{"type":"object"}`}}}}}
+		err := dna.check()
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, ErrEntryDefNotMigrate.Error()+": evenNumbers")
+
+		dna = DNA{Zomes: []Zome{{Entries: []EntryDef{{Name: "evenNumbers", RequireMigrationChain: true}}}}}
+		So(dna.check(), ShouldNotBeNil)
+
+		dna = DNA{Zomes: []Zome{{Entries: []EntryDef{{Name: MigrateEntryType, DataSchema: `{"type":12}`}}}}}
+		So(dna.check(), ShouldNotBeNil)
+	})
+}
+
+func TestMigrateAppValidation(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	header, err := GenTestHeader()
+	if err != nil {
+		panic(err)
+	}
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	action := ActionMigrate{header: header, entry: entry}
+	sources := []peer.ID{h.nodeID}
+
+	Convey("without a declared handler only sys validation should run", t, func() {
+		_, err := h.ValidateAction(&action, MigrateEntryType, nil, sources)
+		So(err, ShouldBeNil)
+	})
+
+	zomes := h.nucleus.dna.Zomes
+	defer func() { h.nucleus.dna.Zomes = zomes }()
+	h.nucleus.dna.Zomes = append(zomes, Zome{
+		Name:         "migrationRules",
+		RibosomeType: JSRibosomeType,
+		Entries:      []EntryDef{{Name: MigrateEntryType, DataFormat: DataFormatJSON}},
+		Code: `function validateCommit(entryType,entry,header,pkg,sources) {
+  return entry.Data == "bad standing" ? "member not in good standing" : "";
+}
+function validatePut(entryType,entry,header,pkg,sources) {
+  return entry.Data == "bad standing" ? "member not in good standing" : "";
+}`,
+	})
+
+	Convey("a declared handler should be called for commits after sys validation", t, func() {
+		_, err := h.ValidateAction(&action, MigrateEntryType, nil, sources)
+		So(err, ShouldBeNil)
+
+		bad := ActionMigrate{header: header, entry: entry}
+		bad.entry.Data = "bad standing"
+		_, err = h.ValidateAction(&bad, MigrateEntryType, nil, sources)
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "member not in good standing")
+
+		bad.header = nil
+		_, err = h.ValidateAction(&bad, MigrateEntryType, nil, sources)
+		So(err, ShouldEqual, ErrActionMissingHeader)
+	})
+
+	Convey("a declared handler should be called for puts", t, func() {
+		bad := ActionMigrate{header: header, entry: entry}
+		bad.entry.Data = "bad standing"
+		a := NewPutAction(MigrateEntryType, bad.Entry(), header)
+		_, err := h.ValidateAction(a, MigrateEntryType, &Package{}, sources)
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "member not in good standing")
+	})
+}
+
+func TestMigrateActionSysValidationMigrationChain(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	header, err := GenTestHeader()
+	if err != nil {
+		panic(err)
+	}
+	open, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	action := ActionMigrate{header: header, entry: open}
+	sources := []peer.ID{h.nodeID}
+
+	// hold a migrate from the source chain in our DHT
+	hold := func(migrate MigrateEntry) Hash {
+		j, err := migrate.ToJSON()
+		So(err, ShouldBeNil)
+		e := GobEntry{C: j}
+		hash, err := e.Sum(h.hashSpec)
+		So(err, ShouldBeNil)
+		b, err := e.Marshal()
+		So(err, ShouldBeNil)
+		So(h.dht.Put(nil, MigrateEntryType, hash, h.nodeID, b, StatusLive), ShouldBeNil)
+		return hash
+	}
+
+	Convey("without the flag Data should not need to link to a close", t, func() {
+		So(action.SysValidation(h, MigrateEntryDef, nil, sources), ShouldBeNil)
+	})
+
+	Convey("with the flag an open must link to a held close with the same Key", t, func() {
+		MigrateEntryDef.RequireMigrationChain = true
+		defer func() { MigrateEntryDef.RequireMigrationChain = false }()
+
+		err := action.SysValidation(h, MigrateEntryDef, nil, sources)
+		So(errors.Is(err, ErrOrphanMigration), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "Data is not a hash")
+
+		closing := MigrateEntry{Type: MigrateEntryTypeClose, DNAHash: h.dnaHash, Key: open.Key}
+		closeHash := hold(closing)
+		missing, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		action.entry.Data = missing.String()
+		err = action.SysValidation(h, MigrateEntryDef, nil, sources)
+		So(errors.Is(err, ErrOrphanMigration), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "close not found")
+
+		other := closing
+		other.Type = "split"
+		action.entry.Data = hold(other).String()
+		err = action.SysValidation(h, MigrateEntryDef, nil, sources)
+		So(errors.Is(err, ErrOrphanMigration), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "not a close migrate")
+
+		other = closing
+		other.Key = missing
+		action.entry.Data = hold(other).String()
+		err = action.SysValidation(h, MigrateEntryDef, nil, sources)
+		So(errors.Is(err, ErrOrphanMigration), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "different Key")
+
+		action.entry.Data = closeHash.String()
+		So(action.SysValidation(h, MigrateEntryDef, nil, sources), ShouldBeNil)
+	})
+
+	Convey("with the flag only opens should need to link to a close", t, func() {
+		MigrateEntryDef.RequireMigrationChain = true
+		defer func() { MigrateEntryDef.RequireMigrationChain = false }()
+
+		split := ActionMigrate{header: header, entry: open}
+		split.entry.Type = "split"
+		So(split.SysValidation(h, MigrateEntryDef, nil, sources), ShouldBeNil)
+	})
+}
+
+func TestMigrateOpenClose(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	migrate := func(migrationType string) (hash Hash, err error) {
+		entry, err := GenTestMigrateEntry()
+		if err != nil {
+			return
+		}
+		entry.Type = migrationType
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+		var response interface{}
+		response, err = fn.Call(h)
+		if err == nil {
+			hash = response.(Hash)
+		}
+		return
+	}
+
+	Convey("an open migrate should only be allowed right after genesis", t, func() {
+		_, err := migrate(MigrateEntryTypeOpen)
+		So(err, ShouldBeNil)
+
+		commit(h, "oddNumbers", "7")
+		_, err = migrate(MigrateEntryTypeOpen)
+		So(err, ShouldEqual, ErrMigrateOpenNotFirst)
+	})
+
+	Convey("free-form migrate types should skip positional checks", t, func() {
+		_, err := migrate("split")
+		So(err, ShouldBeNil)
+		So(h.chain.ClosedByMigrate(), ShouldBeFalse)
+	})
+
+	Convey("a close migrate should lock the chain until rolled back", t, func() {
+		_, err := migrate(MigrateEntryTypeClose)
+		So(err, ShouldBeNil)
+		So(h.chain.ClosedByMigrate(), ShouldBeTrue)
+		closeHeaderHash := h.chain.Hashes[len(h.chain.Hashes)-1]
+
+		entry := GobEntry{C: "9"}
+		fn := &APIFnCommit{}
+		fn.SetAction(NewCommitAction("oddNumbers", &entry))
+		_, err = fn.Call(h)
+		So(err, ShouldEqual, ErrChainLockedAfterClose)
+
+		_, err = migrate(MigrateEntryTypeClose)
+		So(err, ShouldEqual, ErrChainLockedAfterClose)
+
+		// only a link to the close may follow it
+		closeHash := h.chain.Headers[len(h.chain.Headers)-1].EntryLink
+		other, err := h.migrateLinkEntry(h.AgentHash())
+		So(err, ShouldBeNil)
+		_, err = h.doCommit(NewCommitAction(MigrateLinkEntryType, other), NullHash())
+		So(err, ShouldEqual, ErrChainLockedAfterClose)
+		link, err := h.migrateLinkEntry(closeHash)
+		So(err, ShouldBeNil)
+		_, err = h.doCommit(NewCommitAction(MigrateLinkEntryType, link), NullHash())
+		So(err, ShouldBeNil)
+
+		rollback := &APIFnMigrateRollback{action: ActionMigrateRollback{entry: MigrateRollbackEntry{MigrateHeaderHash: closeHeaderHash}}}
+		_, err = rollback.Call(h)
+		So(err, ShouldBeNil)
+		So(h.chain.ClosedByMigrate(), ShouldBeFalse)
+
+		_, err = fn.Call(h)
 		So(err, ShouldBeNil)
 	})
 }
@@ -174,7 +814,13 @@ func TestAPIFnMigrateArgs(t *testing.T) {
 			{Name: "Key",
 				Type: HashArg},
 			{Name: "data",
-				Type: StringArg}}
+				Type: StringArg},
+			{Name: "quorum",
+				Type: IntArg, Optional: true},
+			{Name: "createLink",
+				Type: BoolArg, Optional: true},
+			{Name: "idempotencyKey",
+				Type: StringArg, Optional: true}}
 		So(fn.Args(), ShouldResemble, expected)
 	})
 }
@@ -0,0 +1,68 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+//------------------------------------------------------------
+// MigrateCheck API fn
+
+// MigrateCheckResult holds the entry that a migrate would commit and the
+// validation error, if any, that committing it would produce
+type MigrateCheckResult struct {
+	Entry string
+	Error string
+}
+
+// APIFnMigrateCheck runs a migrate as a dry run, i.e. it validates the migrate
+// entry but neither commits it to the source chain nor puts it to the DHT
+type APIFnMigrateCheck struct {
+	action ActionMigrate
+}
+
+func (fn *APIFnMigrateCheck) Name() string {
+	return "migrateCheck"
+}
+
+func (fn *APIFnMigrateCheck) Args() []Arg {
+	return []Arg{{Name: "migrationType",
+		Type: StringArg},
+		{Name: "DNAHash",
+			Type: HashArg},
+		{Name: "Key",
+			Type: HashArg},
+		{Name: "data",
+			Type: StringArg}}
+}
+
+func (fn *APIFnMigrateCheck) Call(h *Holochain) (response interface{}, err error) {
+	a := &fn.action
+	var result MigrateCheckResult
+	result.Entry, err = a.entry.ToJSON()
+	if err != nil {
+		return
+	}
+
+	var verr error
+	if h.Chain().ClosedByMigrate() {
+		verr = ErrChainLockedAfterClose
+	} else {
+		chain := h.Chain()
+		var header *Header
+		chain.lk.RLock()
+		_, _, header, verr = chain.prepareHeader(h.Now(), a.EntryType(), a.Entry(), h.agent.PrivKey(), NullHash())
+		chain.lk.RUnlock()
+		if verr == nil {
+			a.SetHeader(header)
+			// the signals the validators emit are dropped with their
+			// context, as nothing is committed
+			_, _, verr = h.validateAction(h.validationContext(), a, a.EntryType(), nil, []peer.ID{h.nodeID})
+		}
+	}
+	if verr != nil {
+		result.Error = verr.Error()
+	}
+	response = result
+	return
+}
@@ -0,0 +1,49 @@
+package holochain
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestAPIFnMigrateCheckName(t *testing.T) {
+	Convey("APIFnMigrateCheck should have the right name", t, func() {
+		fn := APIFnMigrateCheck{}
+		So(fn.Name(), ShouldEqual, "migrateCheck")
+	})
+}
+
+func TestAPIFnMigrateCheckCall(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("a dry run of a valid migrate should return the entry without committing it", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entryJSON, err := entry.ToJSON()
+		So(err, ShouldBeNil)
+		length := h.chain.Length()
+
+		fn := &APIFnMigrateCheck{action: ActionMigrate{entry: entry}}
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		result := response.(MigrateCheckResult)
+		So(result.Entry, ShouldEqual, entryJSON)
+		So(result.Error, ShouldEqual, "")
+		So(h.chain.Length(), ShouldEqual, length)
+
+		hash := fn.action.GetHeader().EntryLink
+		_, _, _, _, err = h.dht.Get(hash, StatusAny, GetMaskDefault)
+		So(err, ShouldEqual, ErrHashNotFound)
+	})
+
+	Convey("a dry run of an invalid migrate should return the validation error without committing", t, func() {
+		length := h.chain.Length()
+
+		fn := &APIFnMigrateCheck{action: ActionMigrate{entry: MigrateEntry{Type: MigrateEntryTypeClose}}}
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		result := response.(MigrateCheckResult)
+		So(result.Error, ShouldEqual, "Validation Failed: Error (input isn't valid multihash) when decoding DNAHash value ''")
+		So(h.chain.Length(), ShouldEqual, length)
+	})
+}
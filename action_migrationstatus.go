@@ -0,0 +1,65 @@
+package holochain
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+var ErrMigrationStatusWrongDNA = errors.New("migration status requested for a different DNA")
+
+//------------------------------------------------------------
+// MigrationStatus
+
+type ActionMigrationStatus struct {
+	req MigrationStatusReq
+}
+
+func (a *ActionMigrationStatus) Name() string {
+	return "migrationStatus"
+}
+
+func (a *ActionMigrationStatus) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	err = ValidateSources(sources)
+	return
+}
+
+// Receive answers whether this node's chain has been closed by a migrate
+func (a *ActionMigrationStatus) Receive(dht *DHT, msg *Message) (response interface{}, err error) {
+	req := msg.Body.(MigrationStatusReq)
+	if !req.DNAHash.Equal(dht.h.dnaHash) {
+		err = ErrMigrationStatusWrongDNA
+		return
+	}
+	var resp MigrationStatusResp
+	resp.Closed, resp.TargetDNA, err = dht.h.MigrationStatus()
+	if err != nil {
+		return
+	}
+	response = resp
+	return
+}
+
+func (a *ActionMigrationStatus) CheckValidationRequest(def *EntryDef) (err error) {
+	return
+}
+
+// MigrationStatus asks a node whether its chain has been closed by a migrate,
+// i.e. before attempting to interact with it
+func (dht *DHT) MigrationStatus(to peer.ID) (closed bool, targetDNA Hash, err error) {
+	msg := dht.h.node.NewMessage(MIGRATION_STATUS_REQUEST, MigrationStatusReq{DNAHash: dht.h.dnaHash})
+	var response interface{}
+	response, err = dht.send(nil, to, msg)
+	if err != nil {
+		return
+	}
+	resp, ok := response.(MigrationStatusResp)
+	if !ok {
+		err = fmt.Errorf("expected MigrationStatusResp response from MIGRATION_STATUS_REQUEST, got: %T", response)
+		return
+	}
+	closed = resp.Closed
+	targetDNA = resp.TargetDNA
+	return
+}
@@ -0,0 +1,50 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestMigrationStatus(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("an open chain should report not closed with the null hash", t, func() {
+		closed, targetDNA, err := h.MigrationStatus()
+		So(err, ShouldBeNil)
+		So(closed, ShouldBeFalse)
+		So(targetDNA.IsNullHash(), ShouldBeTrue)
+	})
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	entry.Type = MigrateEntryTypeClose
+
+	Convey("a closed chain should report the DNA it migrated to", t, func() {
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+		_, err := fn.Call(h)
+		So(err, ShouldBeNil)
+
+		closed, targetDNA, err := h.MigrationStatus()
+		So(err, ShouldBeNil)
+		So(closed, ShouldBeTrue)
+		So(targetDNA.Equal(entry.DNAHash), ShouldBeTrue)
+	})
+
+	Convey("a MIGRATION_STATUS_REQUEST should answer with the status", t, func() {
+		closed, targetDNA, err := h.dht.MigrationStatus(h.nodeID)
+		So(err, ShouldBeNil)
+		So(closed, ShouldBeTrue)
+		So(targetDNA.Equal(entry.DNAHash), ShouldBeTrue)
+	})
+
+	Convey("a MIGRATION_STATUS_REQUEST for another DNA should fail", t, func() {
+		other, _ := NewHash("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
+		m := h.node.NewMessage(MIGRATION_STATUS_REQUEST, MigrationStatusReq{DNAHash: other})
+		_, err := ActionReceiver(h, m)
+		So(err, ShouldEqual, ErrMigrationStatusWrongDNA)
+	})
+}
@@ -22,8 +22,38 @@ func (a *ActionPut) Name() string {
 	return "put"
 }
 
+func (a *ActionPut) Entry() Entry {
+	return a.entry
+}
+
+func (a *ActionPut) GetHeader() (header *Header) {
+	return a.header
+}
+
+func (a *ActionPut) VerifyEntryLink() (err error) {
+	return verifyEntryLink(a.header, a.entry)
+}
+
 func (a *ActionPut) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	if err = ValidateSources(sources); err != nil {
+		return
+	}
 	err = sysValidateEntry(h, def, a.entry, pkg)
+	// a held entry signed by a group member must be from a member of the
+	// author's group
+	if err == nil && a.header != nil && a.header.Signer != "" {
+		err = checkHeaderSigner(h, a.header, pkg, sources[0])
+	}
+	if err == nil && def.Name == MigrateLinkEntryType {
+		err = checkMigrateLinks(h, a.entry, sources[0])
+	}
+	if err == nil && def == RevocationEntryDef {
+		var revocation RevocationEntry
+		revocation, err = RevocationEntryFromJSON(a.entry.Content().(string))
+		if err == nil {
+			err = revocation.Verify(sources[0])
+		}
+	}
 	return
 }
 
@@ -44,8 +74,15 @@ func (a *ActionPut) Receive(dht *DHT, msg *Message) (response interface{}, err e
 	}
 
 	err = RunValidationPhase(dht.h, msg.From, VALIDATE_PUT_REQUEST, t.EntryHash, func(resp ValidateResponse) error {
-		a := NewPutAction(resp.Type, &resp.Entry, &resp.Header)
-		_, err := dht.h.ValidateAction(a, a.entryType, &resp.Package, []peer.ID{msg.From})
+		entry, err := dht.h.encodeEntry(resp.Type, &resp.Entry)
+		if err != nil {
+			return err
+		}
+		if err := dht.checkNotExpired(resp.Type, &resp.Header); err != nil {
+			return err
+		}
+		a := NewPutAction(resp.Type, entry, &resp.Header)
+		_, err = dht.validateReceived(msg, a, a.entryType, &resp.Package)
 
 		var status int
 		if err != nil {
@@ -54,12 +91,14 @@ func (a *ActionPut) Receive(dht *DHT, msg *Message) (response interface{}, err e
 		} else {
 			status = StatusLive
 		}
-		entry := resp.Entry
 		var b []byte
 		b, err = entry.Marshal()
 		if err == nil {
 			err = dht.Put(msg, resp.Type, t.EntryHash, msg.From, b, status)
 		}
+		if err == nil {
+			err = dht.putEntryHeader(msg.From, t.EntryHash, &resp.Header)
+		}
 		if err == nil {
 			holdResp, err = dht.MakeHoldResp(msg, status)
 		}
@@ -0,0 +1,168 @@
+package holochain
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+var ErrStatusMismatch = errors.New("current status doesn't match the expected status")
+var ErrPutIfHashMismatch = errors.New("putIf: hash doesn't match the entry")
+
+// PutIfReq holds the data of a conditional put
+type PutIfReq struct {
+	H              Hash
+	ExpectedStatus int
+}
+
+//------------------------------------------------------------
+// PutIf
+
+type ActionPutIf struct {
+	req PutIfReq
+}
+
+func (a *ActionPutIf) Name() string {
+	return "putIf"
+}
+
+func (a *ActionPutIf) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	err = ValidateSources(sources)
+	return
+}
+
+// Receive puts the entry only if this node's current status for the hash is
+// the expected one, where StatusDefault means the hash isn't held at all.
+// The entry is fetched from the requester and validated as a regular put is.
+// Conditional puts are handled one at a time so that of any number of
+// competing requests with the same expected status only the first succeeds.
+func (a *ActionPutIf) Receive(dht *DHT, msg *Message) (response interface{}, err error) {
+	t := msg.Body.(PutIfReq)
+
+	dht.putIfLk.Lock()
+	defer dht.putIfLk.Unlock()
+
+	var status int
+	_, _, _, status, err = dht.Get(t.H, StatusAny, GetMaskEntryType)
+	if err == ErrHashNotFound {
+		status = StatusDefault
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+	if status != t.ExpectedStatus {
+		dht.dlog.Logf("putIf %v rejected: status %d, expected %d", t.H, status, t.ExpectedStatus)
+		err = ErrStatusMismatch
+		return
+	}
+
+	var holdResp *HoldResp
+	err = RunValidationPhase(dht.h, msg.From, VALIDATE_PUT_REQUEST, t.H, func(resp ValidateResponse) error {
+		entry, err := dht.h.encodeEntry(resp.Type, &resp.Entry)
+		if err != nil {
+			return err
+		}
+		hash, err := entry.Sum(dht.h.hashSpec)
+		if err != nil {
+			return err
+		}
+		if !hash.Equal(t.H) {
+			return ErrPutIfHashMismatch
+		}
+		if err := dht.checkNotExpired(resp.Type, &resp.Header); err != nil {
+			return err
+		}
+		a := NewPutAction(resp.Type, entry, &resp.Header)
+		_, verr := dht.validateReceived(msg, a, a.entryType, &resp.Package)
+		status := StatusLive
+		if verr != nil {
+			dht.dlog.Logf("putIf %v rejected: %v", t.H, verr)
+			status = StatusRejected
+		}
+		var b []byte
+		b, err = entry.Marshal()
+		if err == nil {
+			err = dht.Put(msg, resp.Type, t.H, msg.From, b, status)
+		}
+		if err == nil {
+			err = dht.putEntryHeader(msg.From, t.H, &resp.Header)
+		}
+		if err == nil {
+			holdResp, err = dht.MakeHoldResp(msg, status)
+		}
+		if err == nil {
+			err = verr
+		}
+		return err
+	})
+	if err != nil {
+		return
+	}
+	response = *holdResp
+	return
+}
+
+func (a *ActionPutIf) CheckValidationRequest(def *EntryDef) (err error) {
+	return
+}
+
+// PutIf puts an entry committed to our chain to the DHT only if the node
+// responsible for its hash currently sees it with expectedStatus,
+// StatusDefault meaning not held.  It returns ErrStatusMismatch otherwise, so
+// of any number of agents racing to put the same entry with the same
+// expectation only one will succeed, i.e. when closing a shared chain.
+// The responsible node is the closest to the hash in the world model, so
+// agents that know of the same nodes pick the same one to decide the race.
+// The entry is validated by that node as a regular put is.  It returns
+// ErrPutIfHashMismatch if entry isn't the entry committed with hash.
+func (dht *DHT) PutIf(hash Hash, entry Entry, expectedStatus int) (err error) {
+	if err = dht.checkPutIfEntry(hash, entry); err != nil {
+		return
+	}
+	var peers []peer.ID
+	peers, err = dht.h.ResponsiblePeers(hash, 1)
+	if err != nil {
+		return
+	}
+	to := dht.h.nodeID
+	if len(peers) > 0 {
+		to = peers[0]
+	}
+
+	msg := dht.h.node.NewMessage(PUTIF_REQUEST, PutIfReq{H: hash, ExpectedStatus: expectedStatus})
+	var response interface{}
+	response, err = dht.send(nil, to, msg)
+	if err != nil {
+		return
+	}
+	if _, ok := response.(HoldResp); !ok {
+		err = fmt.Errorf("expected HoldResp response from PUTIF_REQUEST, got: %T", response)
+	}
+	return
+}
+
+// checkPutIfEntry returns ErrPutIfHashMismatch if the entry, encoded as its
+// type's def asks, doesn't hash to the hash it was committed to our chain with
+func (dht *DHT) checkPutIfEntry(hash Hash, entry Entry) (err error) {
+	var entryType string
+	if _, entryType, err = dht.h.chain.GetEntry(hash); err != nil {
+		return
+	}
+	if entry, err = dht.h.encodeEntry(entryType, entry); err != nil {
+		return
+	}
+	var spec HashSpec
+	if spec, err = hash.Codec(); err != nil {
+		return
+	}
+	var sum Hash
+	if sum, err = entry.Sum(spec); err != nil {
+		return
+	}
+	if !sum.Equal(hash) {
+		err = ErrPutIfHashMismatch
+	}
+	return
+}
@@ -0,0 +1,129 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync"
+	"testing"
+)
+
+// racePutIf has each of the nodes try to put the same entry at the same time
+// and returns their errors
+func racePutIf(nodes []*Holochain, hash Hash, entry Entry, expectedStatus int) (errs []error) {
+	errs = make([]error, len(nodes))
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i, h := range nodes {
+		wg.Add(1)
+		go func(i int, h *Holochain) {
+			defer wg.Done()
+			<-start
+			errs[i] = h.dht.PutIf(hash, entry, expectedStatus)
+		}(i, h)
+	}
+	close(start)
+	wg.Wait()
+	return
+}
+
+func countPutIfResults(errs []error) (ok int, mismatched int) {
+	for _, err := range errs {
+		if err == nil {
+			ok++
+		} else if err == ErrStatusMismatch {
+			mismatched++
+		}
+	}
+	return
+}
+
+// commitPutIfEntry commits an entry to the chain without sharing it, so that
+// it can be put with PutIf
+func commitPutIfEntry(h *Holochain, content string) Hash {
+	hash, err := h.CommitLocal(NewCommitAction("evenNumbers", &GobEntry{C: content}))
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
+
+func TestPutIf(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	hash := commitPutIfEntry(h, "42")
+	entry := &GobEntry{C: "42"}
+
+	Convey("it should put an entry that isn't held when expecting StatusDefault", t, func() {
+		So(h.dht.PutIf(hash, entry, StatusDefault), ShouldBeNil)
+		data, entryType, _, status, err := h.dht.Get(hash, StatusAny, GetMaskEntry|GetMaskEntryType)
+		So(err, ShouldBeNil)
+		So(status, ShouldEqual, StatusLive)
+		So(entryType, ShouldEqual, "evenNumbers")
+		var e GobEntry
+		So(e.Unmarshal(data), ShouldBeNil)
+		So(e.C, ShouldEqual, "42")
+		header, err := h.dht.getEntryHeader(hash)
+		So(err, ShouldBeNil)
+		So(header.EntryLink.String(), ShouldEqual, hash.String())
+	})
+
+	Convey("it should fail with ErrStatusMismatch when the status is different", t, func() {
+		So(h.dht.PutIf(hash, entry, StatusDefault), ShouldEqual, ErrStatusMismatch)
+		So(h.dht.PutIf(hash, entry, StatusLive), ShouldBeNil)
+	})
+
+	Convey("it should fail for an entry the requester can't show was committed", t, func() {
+		other := &GobEntry{C: "something else"}
+		otherHash, err := other.Sum(h.hashSpec)
+		So(err, ShouldBeNil)
+		So(h.dht.PutIf(otherHash, other, StatusDefault), ShouldNotBeNil)
+		So(h.dht.Exists(otherHash, StatusAny), ShouldEqual, ErrHashNotFound)
+	})
+
+	Convey("it should fail for an entry other than the one committed with the hash", t, func() {
+		unheld := commitPutIfEntry(h, "46")
+		So(h.dht.PutIf(unheld, &GobEntry{C: "48"}, StatusDefault), ShouldEqual, ErrPutIfHashMismatch)
+		So(h.dht.Exists(unheld, StatusAny), ShouldEqual, ErrHashNotFound)
+	})
+
+	Convey("only one of competing puts should succeed", t, func() {
+		raceHash := commitPutIfEntry(h, "44")
+		errs := racePutIf([]*Holochain{h, h, h, h}, raceHash, &GobEntry{C: "44"}, StatusDefault)
+		ok, mismatched := countPutIfResults(errs)
+		So(ok, ShouldEqual, 1)
+		So(mismatched, ShouldEqual, 3)
+	})
+}
+
+func TestPutIfMultiNode(t *testing.T) {
+	nodesCount := 3
+	mt := setupMultiNodeTesting(nodesCount)
+	defer mt.cleanupMultiNodeTesting()
+	nodes := mt.nodes
+	fullConnect(t, mt.ctx, nodes, nodesCount)
+
+	var hash Hash
+	for _, h := range nodes[1:] {
+		hash = commitPutIfEntry(h, "42")
+	}
+
+	Convey("of two nodes racing to put an entry only one should succeed", t, func() {
+		errs := racePutIf(nodes[1:], hash, &GobEntry{C: "42"}, StatusDefault)
+		ok, mismatched := countPutIfResults(errs)
+		So(ok, ShouldEqual, 1)
+		So(mismatched, ShouldEqual, 1)
+	})
+
+	Convey("the nodes should agree on which node decides the race", t, func() {
+		var arbiters []peer.ID
+		for _, h := range nodes {
+			peers, err := h.ResponsiblePeers(hash, 1)
+			So(err, ShouldBeNil)
+			arbiters = append(arbiters, peers[0])
+		}
+		So(arbiters[1], ShouldEqual, arbiters[0])
+		So(arbiters[2], ShouldEqual, arbiters[0])
+	})
+}
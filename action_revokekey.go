@@ -0,0 +1,152 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+var ErrNotNextKey = errors.New("new key isn't the agent's next key")
+
+//------------------------------------------------------------
+// RevokeKey Action
+
+type ActionRevokeKey struct {
+	entry  RevocationEntry
+	header *Header
+}
+
+func (a *ActionRevokeKey) Name() string {
+	return "revokeKey"
+}
+
+func (a *ActionRevokeKey) Entry() Entry {
+	j, err := a.entry.ToJSON()
+	if err != nil {
+		panic(err)
+	}
+	return &GobEntry{C: j}
+}
+
+func (a *ActionRevokeKey) EntryType() string {
+	return RevocationEntryType
+}
+
+func (a *ActionRevokeKey) SetHeader(header *Header) {
+	a.header = header
+}
+
+func (a *ActionRevokeKey) GetHeader() (header *Header) {
+	return a.header
+}
+
+func (a *ActionRevokeKey) VerifyEntryLink() (err error) {
+	return verifyEntryLink(a.header, a.Entry())
+}
+
+// Share PUTs the revocation entry and marks the revoked key as modified by it,
+// so that getting the old key leads to its revocation
+func (action *ActionRevokeKey) Share(h *Holochain, def *EntryDef) (err error) {
+	err = h.dht.Change(action.header.EntryLink, PUT_REQUEST, HoldReq{EntryHash: action.header.EntryLink})
+	if err != nil {
+		return
+	}
+	var oldKey Hash
+	oldKey, err = action.entry.OldKeyHash()
+	if err != nil {
+		return
+	}
+	err = h.dht.Change(oldKey, MOD_REQUEST, HoldReq{RelatedHash: oldKey, EntryHash: action.header.EntryLink})
+	return
+}
+
+func (action *ActionRevokeKey) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	if err = ValidateSources(sources); err != nil {
+		return
+	}
+	// correct entry def
+	if def != RevocationEntryDef {
+		err = ErrEntryDefInvalid
+		return
+	}
+	// has a header
+	if action.header == nil {
+		err = ErrActionMissingHeader
+		return
+	}
+	// entry is valid
+	err = sysValidateEntry(h, def, action.Entry(), pkg)
+	if err != nil {
+		return
+	}
+	// and signed by the key it revokes, which must be the author's
+	err = action.entry.Verify(sources[0])
+	return
+}
+
+func (a *ActionRevokeKey) CheckValidationRequest(def *EntryDef) (err error) {
+	return
+}
+
+func (a *ActionRevokeKey) Receive(dht *DHT, msg *Message) (response interface{}, err error) {
+	// this is always an error because there is no action message for revoke key
+	err = ErrActionReceiveInvalid
+	return
+}
+
+//------------------------------------------------------------
+// RevokeKey API fn
+
+type APIFnRevokeKey struct {
+	newKey Hash
+	proof  string
+	action ActionRevokeKey
+}
+
+func (fn *APIFnRevokeKey) Name() string {
+	return fn.action.Name()
+}
+
+func (fn *APIFnRevokeKey) Args() []Arg {
+	return []Arg{{Name: "newKey", Type: HashArg}, {Name: "proof", Type: StringArg}}
+}
+
+// Call revokes the agent's current key in favor of the new key, which must be
+// the one saved in the chain's directory by SaveNextKey, as updateAgent does
+// for a revocation, returning the hash of the revocation.  The revocation is
+// committed and signed by the current key before the agent switches to the
+// new key, and the current key is marked as modified by the revocation rather
+// than by the new key so others can check it was revoked.  We only stop
+// trusting the current key once the agent has switched from it.
+func (fn *APIFnRevokeKey) Call(h *Holochain) (response interface{}, err error) {
+	var newAgent LibP2PAgent = *h.agent.(*LibP2PAgent)
+	newAgent.priv, err = loadNextKey(h.rootPath)
+	if err != nil {
+		return
+	}
+	newAgent.pub = newAgent.priv.GetPublic()
+	var newKey Hash
+	if newKey, err = keyHash(newAgent.pub); err != nil {
+		return
+	}
+	if !newKey.Equal(fn.newKey) {
+		err = ErrNotNextKey
+		return
+	}
+	var revocation *SelfRevocation
+	revocation, err = NewSelfRevocation(h.agent.PrivKey(), newAgent.PrivKey(), []byte(fn.proof))
+	if err != nil {
+		return
+	}
+	fn.action.entry = RevocationEntry{SelfRevocation: *revocation}
+	response, err = h.commitAndShare(&fn.action, NullHash())
+	if err != nil {
+		return
+	}
+	oldKey := HashFromPeerID(h.nodeID)
+	if _, _, _, err = h.switchAgent(&newAgent, revocation); err != nil {
+		return
+	}
+	h.setKeyTrust(oldKey, true)
+	return
+}
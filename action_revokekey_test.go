@@ -0,0 +1,217 @@
+package holochain
+
+import (
+	"crypto/rand"
+	. "github.com/holochain/holochain-proto/hash"
+	ic "github.com/libp2p/go-libp2p-crypto"
+	peer "github.com/libp2p/go-libp2p-peer"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestRevokeKeyName(t *testing.T) {
+	Convey("revoke key action should have the right name", t, func() {
+		a := ActionRevokeKey{}
+		So(a.Name(), ShouldEqual, "revokeKey")
+		So(a.EntryType(), ShouldEqual, RevocationEntryType)
+		So(RevocationEntryType, ShouldEqual, "%revocation")
+	})
+}
+
+// genTestRevocation returns a revocation of a freshly generated key in favor
+// of another, signed by both
+func genTestRevocation() (revocation RevocationEntry, author peer.ID) {
+	priv, pub, err := ic.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	newPriv, _, err := ic.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	author, err = peer.IDFromPublicKey(pub)
+	if err != nil {
+		panic(err)
+	}
+	r, err := NewSelfRevocation(priv, newPriv, []byte("lost my laptop"))
+	if err != nil {
+		panic(err)
+	}
+	revocation = RevocationEntry{SelfRevocation: *r}
+	return
+}
+
+func TestRevokeKeySysValidation(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	header, err := GenTestHeader()
+	if err != nil {
+		panic(err)
+	}
+	revocation, author := genTestRevocation()
+
+	Convey("it should invalidate other entry defs", t, func() {
+		action := ActionRevokeKey{header: header, entry: revocation}
+		So(action.SysValidation(h, DNAEntryDef, nil, []peer.ID{author}), ShouldEqual, ErrEntryDefInvalid)
+	})
+
+	Convey("it should return an ErrActionMissingHeader error if header is missing", t, func() {
+		action := ActionRevokeKey{entry: revocation}
+		So(action.SysValidation(h, RevocationEntryDef, nil, []peer.ID{author}), ShouldEqual, ErrActionMissingHeader)
+	})
+
+	Convey("it should accept a revocation signed by the author's key", t, func() {
+		action := ActionRevokeKey{header: header, entry: revocation}
+		So(action.SysValidation(h, RevocationEntryDef, nil, []peer.ID{author}), ShouldBeNil)
+	})
+
+	Convey("it should reject a revocation not signed by the author's current key", t, func() {
+		action := ActionRevokeKey{header: header, entry: revocation}
+		So(action.SysValidation(h, RevocationEntryDef, nil, []peer.ID{h.nodeID}), ShouldEqual, ErrRevocationNotByCurrentKey)
+
+		forged := revocation
+		forged.OldSig = append([]byte{}, forged.OldSig...)
+		forged.OldSig[0]++
+		action = ActionRevokeKey{header: header, entry: forged}
+		So(action.SysValidation(h, RevocationEntryDef, nil, []peer.ID{author}), ShouldEqual, SelfRevocationDoesNotVerify)
+	})
+
+	Convey("it should reject a revocation whose new key is the old key", t, func() {
+		priv, _, err := ic.GenerateEd25519Key(rand.Reader)
+		So(err, ShouldBeNil)
+		r, err := NewSelfRevocation(priv, priv, []byte("lost my laptop"))
+		So(err, ShouldBeNil)
+		id, _ := peer.IDFromPublicKey(priv.GetPublic())
+		action := ActionRevokeKey{header: header, entry: RevocationEntry{SelfRevocation: *r}}
+		So(action.SysValidation(h, RevocationEntryDef, nil, []peer.ID{id}), ShouldEqual, ErrRevocationNewKeySame)
+	})
+
+	Convey("it should reject a revocation without its keys", t, func() {
+		action := ActionRevokeKey{header: header, entry: RevocationEntry{SelfRevocation: SelfRevocation{Data: []byte{40, 1}, OldSig: revocation.OldSig, NewSig: revocation.NewSig}}}
+		err := action.SysValidation(h, RevocationEntryDef, nil, []peer.ID{author})
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+	})
+}
+
+func TestRevokeKey(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	oldPeer := h.nodeID
+	oldKey := HashFromPeerID(oldPeer)
+	pubKey, _ := h.agent.EncodePubKey()
+	sig, err := h.Sign([]byte("some data"))
+	if err != nil {
+		panic(err)
+	}
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	sourceHash, _ := NewHash("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
+	entry.Data = sourceHash.String()
+	_, err = (&APIFnMigrate{action: ActionMigrate{entry: entry}}).Call(h)
+	if err != nil {
+		panic(err)
+	}
+	openHash, _ := h.chain.TopType(MigrateEntryType)
+	receipt, err := h.NewMigrationReceipt(*openHash, sourceHash)
+	if err != nil {
+		panic(err)
+	}
+
+	verify := func() interface{} {
+		fn := &APIFnVerifySignature{b58signature: sig.B58String(), data: "some data", b58pubKey: pubKey}
+		r, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		return r
+	}
+
+	Convey("an unrevoked key should be trusted", t, func() {
+		revocation, err := h.KeyRevocation(oldKey)
+		So(err, ShouldBeNil)
+		So(revocation, ShouldBeNil)
+		So(verify(), ShouldBeTrue)
+		So(h.VerifyTrustedMigrationReceipt(receipt, sourceHash, pubKey), ShouldBeNil)
+	})
+
+	nextKey, err := SaveNextKey(h.rootPath, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	Convey("revoking in favor of a key other than the next key should fail and keep trusting the key", t, func() {
+		fn := &APIFnRevokeKey{newKey: oldKey, proof: "lost my laptop"}
+		_, err := fn.Call(h)
+		So(err, ShouldEqual, ErrNotNextKey)
+		So(h.nodeID, ShouldEqual, oldPeer)
+		So(verify(), ShouldBeTrue)
+	})
+
+	Convey("revoking the key should commit a revocation signed by it and switch to the new key", t, func() {
+		fn := &APIFnRevokeKey{newKey: nextKey, proof: "lost my laptop"}
+		hash, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		So(h.nodeID, ShouldNotEqual, oldPeer)
+		So(HashFromPeerID(h.nodeID).Equal(nextKey), ShouldBeTrue)
+
+		e, entryType, err := h.chain.GetEntry(hash.(Hash))
+		So(err, ShouldBeNil)
+		So(entryType, ShouldEqual, RevocationEntryType)
+		revocation, err := RevocationEntryFromJSON(e.Content().(string))
+		So(err, ShouldBeNil)
+		So(revocation.Verify(oldPeer), ShouldBeNil)
+		revoked, err := revocation.OldKeyHash()
+		So(err, ShouldBeNil)
+		So(revoked.Equal(oldKey), ShouldBeTrue)
+		newKey, err := revocation.NewKeyHash()
+		So(err, ShouldBeNil)
+		So(newKey.Equal(HashFromPeerID(h.nodeID)), ShouldBeTrue)
+		proof, err := revocation.Proof()
+		So(err, ShouldBeNil)
+		So(proof, ShouldEqual, "lost my laptop")
+
+		header := h.chain.Top()
+		So(header.Type, ShouldEqual, AgentEntryType)
+	})
+
+	Convey("the revocation should be found on the chain and the DHT", t, func() {
+		newKey := HashFromPeerID(h.nodeID)
+		revocation, err := h.KeyRevocation(oldKey)
+		So(err, ShouldBeNil)
+		found, _ := revocation.NewKeyHash()
+		So(found.Equal(newKey), ShouldBeTrue)
+
+		chain := h.chain
+		h.chain = nil
+		revocation, err = h.KeyRevocation(oldKey)
+		h.chain = chain
+		So(err, ShouldBeNil)
+		So(revocation, ShouldNotBeNil)
+		found, _ = revocation.NewKeyHash()
+		So(found.Equal(newKey), ShouldBeTrue)
+
+		revocation, err = h.KeyRevocation(newKey)
+		So(err, ShouldBeNil)
+		So(revocation, ShouldBeNil)
+	})
+
+	Convey("signatures by the revoked key should no longer be trusted", t, func() {
+		So(verify(), ShouldBeFalse)
+		So(VerifyMigrationReceipt(receipt, sourceHash, pubKey), ShouldBeNil)
+		So(h.VerifyTrustedMigrationReceipt(receipt, sourceHash, pubKey), ShouldEqual, ErrKeyRevoked)
+	})
+
+	Convey("a key found not to be revoked should be cached until the lookup expires", t, func() {
+		h.setKeyTrust(oldKey, false)
+		So(verify(), ShouldBeTrue)
+
+		h.keyTrustLk.Lock()
+		h.keyTrust[oldKey] = keyTrust{checked: time.Now().Add(-KeyTrustCacheTTL)}
+		h.keyTrustLk.Unlock()
+		So(verify(), ShouldBeFalse)
+	})
+}
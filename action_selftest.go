@@ -0,0 +1,158 @@
+package holochain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	"time"
+)
+
+var ErrSelfTestRunning = errors.New("self test already running")
+var ErrSelfTestEntryMismatch = errors.New("self test entry retrieved doesn't match the one committed")
+
+//------------------------------------------------------------
+// SelfTest
+
+type APIFnSelfTest struct {
+}
+
+// SelfTestReport is the result of a self test, durations are in nanoseconds
+type SelfTestReport struct {
+	OK           bool
+	Hash         string
+	Commit       time.Duration
+	Share        time.Duration
+	Get          time.Duration
+	RoundTrip    time.Duration
+	Cleanup      time.Duration
+	Peers        int
+	Bootstrapped bool
+	Error        string
+}
+
+func (fn *APIFnSelfTest) Name() string {
+	return "selfTest"
+}
+
+func (fn *APIFnSelfTest) Args() []Arg {
+	return []Arg{}
+}
+
+// Call commits a self test entry to a throwaway chain, shares it, gets it
+// back and then deletes our copy, returning a JSON SelfTestReport of how it
+// went.  The agent's chain is left untouched, so it can be run on a chain
+// that's closed by a migrate, and the other nodes holding the entry drop it
+// after SelfTestEntryTTL.  A failing step is reported rather than returned as
+// an error so the report always includes the peer count, and the node is
+// considered bootstrapped if it has any peers in its routing table.
+func (fn *APIFnSelfTest) Call(h *Holochain) (response interface{}, err error) {
+	report := h.selfTest()
+	var j []byte
+	j, err = json.Marshal(report)
+	if err != nil {
+		return
+	}
+	response = string(j)
+	return
+}
+
+func (h *Holochain) selfTest() (report SelfTestReport) {
+	report.Peers = h.node.routingTable.Size()
+	report.Bootstrapped = report.Peers > 0
+
+	hash, err := h.runSelfTest(&report)
+	if err != nil {
+		report.Error = err.Error()
+		return
+	}
+	report.Hash = hash.String()
+	report.OK = true
+	return
+}
+
+func (h *Holochain) runSelfTest(report *SelfTestReport) (hash Hash, err error) {
+	chain := NewChain(h.hashSpec)
+	h.selfTestLk.Lock()
+	if h.selfTestChain != nil {
+		h.selfTestLk.Unlock()
+		err = ErrSelfTestRunning
+		return
+	}
+	h.selfTestChain = chain
+	h.selfTestLk.Unlock()
+	defer func() {
+		h.selfTestLk.Lock()
+		h.selfTestChain = nil
+		h.selfTestLk.Unlock()
+	}()
+
+	// make each run's entry unique so it can be run repeatedly
+	content := fmt.Sprintf("%s %d", h.nodeIDStr, time.Now().UnixNano())
+	a := NewCommitAction(SelfTestEntryType, &GobEntry{C: content})
+
+	start := time.Now()
+	var def *EntryDef
+	def, err = h.commitSelfTest(chain, a)
+	if err != nil {
+		return
+	}
+	hash = a.GetHeader().EntryLink
+	report.Commit = time.Since(start)
+
+	t := time.Now()
+	err = h.share(a, def)
+	if err != nil {
+		return
+	}
+	report.Share = time.Since(t)
+
+	t = time.Now()
+	req := GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry}
+	var rsp interface{}
+	rsp, err = callGet(h, req, &GetOptions{StatusMask: req.StatusMask, GetMask: req.GetMask})
+	if err != nil {
+		return
+	}
+	report.Get = time.Since(t)
+	report.RoundTrip = time.Since(start)
+	if got, ok := rsp.(GetResp); !ok || got.Entry.Content() != content {
+		err = ErrSelfTestEntryMismatch
+		return
+	}
+
+	t = time.Now()
+	_, err = h.dht.expire(hash)
+	if err != nil {
+		return
+	}
+	report.Cleanup = time.Since(t)
+	return
+}
+
+// commitSelfTest validates the self test's commit and adds it to its chain
+// as doCommit would to the agent's
+func (h *Holochain) commitSelfTest(chain *Chain, a *ActionCommit) (def *EntryDef, err error) {
+	chain.lk.Lock()
+	defer chain.lk.Unlock()
+	l, hash, header, err := chain.prepareHeader(h.Now(), a.EntryType(), a.Entry(), h.agent.PrivKey(), NullHash())
+	if err != nil {
+		return
+	}
+	a.SetHeader(header)
+	def, err = h.ValidateAction(a, a.EntryType(), nil, []peer.ID{h.nodeID})
+	if err != nil {
+		return
+	}
+	err = chain.addEntry(l, hash, header, a.Entry())
+	return
+}
+
+// runningSelfTestChain returns the throwaway chain of the self test that's
+// running, if any
+func (h *Holochain) runningSelfTestChain() *Chain {
+	h.selfTestLk.Lock()
+	defer h.selfTestLk.Unlock()
+	return h.selfTestChain
+}
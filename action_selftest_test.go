@@ -0,0 +1,87 @@
+package holochain
+
+import (
+	"encoding/json"
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestAPIFnSelfTest(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	call := func() (report SelfTestReport) {
+		fn := &APIFnSelfTest{}
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		So(json.Unmarshal([]byte(response.(string)), &report), ShouldBeNil)
+		return
+	}
+
+	Convey("it should commit, share, get and clean up a self test entry without touching the chain", t, func() {
+		l := h.ChainLength()
+		report := call()
+		So(report.Error, ShouldEqual, "")
+		So(report.OK, ShouldBeTrue)
+		So(report.RoundTrip, ShouldBeGreaterThan, 0)
+		So(report.RoundTrip, ShouldBeGreaterThanOrEqualTo, report.Commit+report.Share+report.Get)
+		So(report.Peers, ShouldEqual, 0)
+		So(report.Bootstrapped, ShouldBeFalse)
+		So(h.ChainLength(), ShouldEqual, l)
+
+		hash, err := NewHash(report.Hash)
+		So(err, ShouldBeNil)
+		So(h.dht.Exists(hash, StatusLive), ShouldEqual, ErrHashDeleted)
+		_, _, err = h.chain.GetEntry(hash)
+		So(err, ShouldEqual, ErrHashNotFound)
+		So(h.runningSelfTestChain(), ShouldBeNil)
+	})
+
+	Convey("its entry type should be a registered sys type that expires", t, func() {
+		r, ok := getRegisteredSysEntryType(SelfTestEntryType)
+		So(ok, ShouldBeTrue)
+		So(r.def.TTL, ShouldEqual, SelfTestEntryTTL)
+	})
+
+	Convey("it should be safe to run repeatedly", t, func() {
+		first := call()
+		second := call()
+		So(first.OK, ShouldBeTrue)
+		So(second.OK, ShouldBeTrue)
+		So(first.Hash, ShouldNotEqual, second.Hash)
+	})
+
+	Convey("it should run while a bundle is open", t, func() {
+		_, err := NewStartBundleAction(0, "selfTest").Call(h)
+		So(err, ShouldBeNil)
+		report := call()
+		_, err = (&APIFnCloseBundle{commit: false}).Call(h)
+		So(err, ShouldBeNil)
+		So(report.Error, ShouldEqual, "")
+		So(report.OK, ShouldBeTrue)
+	})
+
+	Convey("it should report a failing step", t, func() {
+		h.selfTestChain = NewChain(h.hashSpec)
+		report := call()
+		h.selfTestChain = nil
+		So(report.OK, ShouldBeFalse)
+		So(report.Error, ShouldEqual, ErrSelfTestRunning.Error())
+	})
+
+	Convey("it should run on a chain closed by a migrate", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Type = MigrateEntryTypeClose
+		_, err = (&APIFnMigrate{action: ActionMigrate{entry: entry}}).Call(h)
+		So(err, ShouldBeNil)
+		So(h.Chain().ClosedByMigrate(), ShouldBeTrue)
+
+		l := h.ChainLength()
+		report := call()
+		So(report.Error, ShouldEqual, "")
+		So(report.OK, ShouldBeTrue)
+		So(h.ChainLength(), ShouldEqual, l)
+	})
+}
@@ -19,6 +19,11 @@ func (a *APIFnSign) Args() []Arg {
 	return []Arg{{Name: "data", Type: StringArg}}
 }
 
+// Call signs the data with the agent's private key, the same key the node signs
+// headers with.  The bytes signed are exactly the data as given, i.e. the
+// UTF-8 bytes of the string, without any hashing or framing, and the signature
+// is returned base58 encoded, so any node signing the same string with the same
+// key produces the same signature.
 func (a *APIFnSign) Call(h *Holochain) (response interface{}, err error) {
 	var sig Signature
 	sig, err = h.Sign(a.data)
@@ -46,17 +51,33 @@ func (a *APIFnVerifySignature) Args() []Arg {
 	return []Arg{{Name: "signature", Type: StringArg}, {Name: "data", Type: StringArg}, {Name: "pubKey", Type: StringArg}}
 }
 
+// Call returns true if the base58 encoded signature is of the data's UTF-8 bytes
+// by the private key of the base58 encoded public key, which is encoded as in
+// an agent's key entry, i.e. what sign produces for that agent.  It returns
+// false if the key has been revoked.
 func (a *APIFnVerifySignature) Call(h *Holochain) (response interface{}, err error) {
 	var b bool
 	var pubKey ic.PubKey
 	sig := SignatureFromB58String(a.b58signature)
 
 	pubKey, err = DecodePubKey(a.b58pubKey)
+	if err != nil {
+		return
+	}
 
 	b, err = h.VerifySignature(sig, a.data, pubKey)
 	if err != nil {
 		return
 	}
+	if b {
+		err = h.checkKeyTrusted(pubKey)
+		if err == ErrKeyRevoked {
+			b = false
+			err = nil
+		} else if err != nil {
+			return
+		}
+	}
 	response = b
 	return
 }
@@ -41,4 +41,18 @@ func TestActionSigning(t *testing.T) {
 		So(err, ShouldBeNil)
 		So(result.(bool), ShouldBeFalse)
 	})
+
+	Convey("verify signature action should reject a bad public key", t, func() {
+		fn := &APIFnVerifySignature{b58signature: b58sig, data: "3", b58pubKey: "notakey"}
+		_, err := fn.Call(h)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("sign should use the key that signs headers", t, func() {
+		header := h.chain.Headers[len(h.chain.Headers)-1]
+		fn := &APIFnSign{[]byte(header.EntryLink)}
+		result, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		So(result.(string), ShouldEqual, header.Sig.B58String())
+	})
 }
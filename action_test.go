@@ -1,11 +1,13 @@
 package holochain
 
 import (
+	"context"
 	"fmt"
 	. "github.com/holochain/holochain-proto/hash"
 	peer "github.com/libp2p/go-libp2p-peer"
 	. "github.com/smartystreets/goconvey/convey"
 	"testing"
+	"time"
 )
 
 func TestValidateAction(t *testing.T) {
@@ -208,3 +210,155 @@ func TestCheckArgCount(t *testing.T) {
 		So(err, ShouldEqual, ErrWrongNargs)
 	})
 }
+
+// flakyShareAction is a migrate whose first shares fail
+type flakyShareAction struct {
+	*ActionMigrate
+	failures int
+}
+
+func (a *flakyShareAction) Share(h *Holochain, def *EntryDef) (err error) {
+	if a.failures > 0 {
+		a.failures--
+		return ErrNotAcceptedByAnyRemoteNode
+	}
+	return a.ActionMigrate.Share(h, def)
+}
+
+func newFlakyShareAction(failures int) *flakyShareAction {
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	entry.Type = "split"
+	return &flakyShareAction{ActionMigrate: &ActionMigrate{entry: entry}, failures: failures}
+}
+
+func TestCommitAndShareWithPolicy(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("the default policy should make a single share attempt", t, func() {
+		a := newFlakyShareAction(1)
+		_, attempts, err := h.commitAndShareWithPolicy(a, NullHash(), DefaultSharePolicy)
+		So(err, ShouldEqual, ErrNotAcceptedByAnyRemoteNode)
+		So(attempts, ShouldEqual, 1)
+	})
+
+	Convey("a policy should retry sharing until it succeeds", t, func() {
+		a := newFlakyShareAction(2)
+		hash, attempts, err := h.commitAndShareWithPolicy(a, NullHash(), SharePolicy{Attempts: 5, Backoff: time.Millisecond})
+		So(err, ShouldBeNil)
+		So(attempts, ShouldEqual, 3)
+		So(hash.String(), ShouldEqual, a.GetHeader().EntryLink.String())
+	})
+
+	Convey("a policy should give up after the maximum attempts", t, func() {
+		a := newFlakyShareAction(5)
+		_, attempts, err := h.commitAndShareWithPolicy(a, NullHash(), SharePolicy{Attempts: 2, Backoff: time.Millisecond})
+		So(err, ShouldEqual, ErrNotAcceptedByAnyRemoteNode)
+		So(attempts, ShouldEqual, 2)
+	})
+
+	Convey("a canceled context should abort before committing", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		l := h.ChainLength()
+		_, err := h.CommitAndShareCtx(ctx, newFlakyShareAction(0), NullHash())
+		So(err, ShouldEqual, context.Canceled)
+		So(h.ChainLength(), ShouldEqual, l)
+	})
+
+	Convey("a context's deadline should abort share retries", t, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		a := newFlakyShareAction(5)
+		_, attempts, err := h.commitAndShareWithPolicyCtx(ctx, a, NullHash(), SharePolicy{Attempts: 5, Backoff: time.Second})
+		So(err, ShouldEqual, context.DeadlineExceeded)
+		So(attempts, ShouldEqual, 1)
+	})
+}
+
+func TestRegisterActionReceiver(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	m := h.node.NewMessage(ACTION_REQUEST, ActionReq{Action: "migrate", Body: "ping"})
+
+	Convey("ACTION_REQUEST should fail for unknown actions", t, func() {
+		_, err := ActionReceiver(h, h.node.NewMessage(ACTION_REQUEST, ActionReq{Action: "bogus"}))
+		So(err.Error(), ShouldEqual, "unknown action bogus in ACTION_REQUEST")
+	})
+
+	Convey("ACTION_REQUEST should fail if body isn't an action request", t, func() {
+		_, err := ActionReceiver(h, h.node.NewMessage(ACTION_REQUEST, "foo"))
+		So(err.Error(), ShouldEqual, "Unexpected request body type 'string' in ACTION_REQUEST request, expecting holochain.ActionReq")
+	})
+
+	Convey("without a registered receiver migrate should keep its invalid receive", t, func() {
+		_, err := ActionReceiver(h, m)
+		So(err, ShouldEqual, ErrActionReceiveInvalid)
+	})
+
+	Convey("registering a receiver for a built-in action with its own message type should fail", t, func() {
+		noop := func(dht *DHT, msg *Message) (response interface{}, err error) {
+			return
+		}
+		So(RegisterActionReceiver("get", noop), ShouldEqual, ErrActionReceiverBuiltIn)
+		So(RegisterActionReceiver("put", noop), ShouldEqual, ErrActionReceiverBuiltIn)
+	})
+
+	Convey("a registered receiver should override migrate's invalid receive", t, func() {
+		err := RegisterActionReceiver("migrate", func(dht *DHT, msg *Message) (response interface{}, err error) {
+			response = "pong:" + msg.Body.(ActionReq).Body.(string)
+			return
+		})
+		So(err, ShouldBeNil)
+		defer UnregisterActionReceiver("migrate")
+
+		r, err := ActionReceiver(h, m)
+		So(err, ShouldBeNil)
+		So(r, ShouldEqual, "pong:ping")
+	})
+
+	Convey("unregistering should restore migrate's invalid receive", t, func() {
+		_, err := ActionReceiver(h, m)
+		So(err, ShouldEqual, ErrActionReceiveInvalid)
+	})
+
+	ping := h.node.NewMessage(ACTION_REQUEST, ActionReq{Action: "ping", Body: "ping"})
+
+	Convey("a registered receiver should handle its action's messages", t, func() {
+		err := RegisterActionReceiver("ping", func(dht *DHT, msg *Message) (response interface{}, err error) {
+			response = "pong:" + msg.Body.(ActionReq).Body.(string)
+			return
+		})
+		So(err, ShouldBeNil)
+		defer UnregisterActionReceiver("ping")
+
+		r, err := ActionReceiver(h, ping)
+		So(err, ShouldBeNil)
+		So(r, ShouldEqual, "pong:ping")
+	})
+
+	Convey("unregistering should make the action unknown again", t, func() {
+		_, err := ActionReceiver(h, ping)
+		So(err.Error(), ShouldEqual, "unknown action ping in ACTION_REQUEST")
+	})
+}
+
+func TestValidateSources(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should accept a single valid source", t, func() {
+		So(ValidateSources([]peer.ID{h.nodeID}), ShouldBeNil)
+	})
+
+	Convey("it should reject missing, empty and malformed sources", t, func() {
+		So(ValidateSources(nil), ShouldEqual, ErrInvalidSource)
+		So(ValidateSources([]peer.ID{}), ShouldEqual, ErrInvalidSource)
+		So(ValidateSources([]peer.ID{h.nodeID, ""}), ShouldEqual, ErrInvalidSource)
+		So(ValidateSources([]peer.ID{peer.ID("not a peer id")}), ShouldEqual, ErrInvalidSource)
+	})
+}
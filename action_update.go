@@ -58,6 +58,14 @@ func (a *ActionMod) GetHeader() (header *Header) {
 	return a.header
 }
 
+func (a *ActionMod) VerifyEntryLink() (err error) {
+	return verifyEntryLink(a.header, a.Entry())
+}
+
+func (a *ActionMod) setEntry(entry Entry) {
+	a.entry = entry
+}
+
 func (a *ActionMod) Share(h *Holochain, def *EntryDef) (err error) {
 	if def.isSharingPublic() {
 		// if it's a public entry send the DHT MOD & PUT messages
@@ -69,6 +77,9 @@ func (a *ActionMod) Share(h *Holochain, def *EntryDef) (err error) {
 }
 
 func (a *ActionMod) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	if err = ValidateSources(sources); err != nil {
+		return
+	}
 	switch def.Name {
 	case DNAEntryType:
 		err = ErrNotValidForDNAType
@@ -115,9 +126,110 @@ func (a *ActionMod) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sou
 			}
 		}*/
 	err = sysValidateEntry(h, def, a.entry, pkg)
+	if err != nil {
+		return
+	}
+	if def.Name == MigrateEntryType {
+		err = a.sysValidateMigrateMod(h, sources)
+	} else if def == RevocationEntryDef {
+		err = a.sysValidateRevocationMod(sources)
+	}
+	return
+}
+
+// sysValidateRevocationMod checks that a revocation is replacing the key it
+// revokes and that it was signed by, and authored by, the holder of that key
+func (a *ActionMod) sysValidateRevocationMod(sources []peer.ID) (err error) {
+	var revocation RevocationEntry
+	revocation, err = RevocationEntryFromJSON(a.entry.Content().(string))
+	if err != nil {
+		return
+	}
+	var oldKey Hash
+	oldKey, err = revocation.OldKeyHash()
+	if err != nil {
+		return
+	}
+	if !oldKey.Equal(a.replaces) {
+		err = ErrRevocationNotByCurrentKey
+		return
+	}
+	err = revocation.Verify(sources[0])
+	return
+}
+
+// sysValidateMigrateMod checks that a migrate entry is replacing a migrate
+// entry by the same author, that it isn't changing a close into an open or
+// vice versa, and that it's for the same agent
+func (a *ActionMod) sysValidateMigrateMod(h *Holochain, sources []peer.ID) (err error) {
+	var orig Entry
+	var origType string
+	orig, origType, err = h.chain.GetEntry(a.replaces)
+	if err == nil {
+		// it's on our chain so we are the author
+		if sources[0] != h.nodeID {
+			err = ErrModMigrateAuthorMismatch
+			return
+		}
+	} else if err == ErrHashNotFound {
+		// otherwise we must be holding it for its author
+		var data []byte
+		data, origType, _, _, err = h.dht.Get(a.replaces, StatusAny, GetMaskEntry|GetMaskEntryType)
+		if err != nil {
+			if err == ErrHashNotFound {
+				err = ErrModMigrateOriginalNotFound
+			}
+			return
+		}
+		var e GobEntry
+		if err = e.Unmarshal(data); err != nil {
+			return
+		}
+		orig = &e
+		var author peer.ID
+		author, err = h.dht.Source(a.replaces)
+		if err != nil {
+			return
+		}
+		if author != sources[0] {
+			err = ErrModMigrateAuthorMismatch
+			return
+		}
+	} else {
+		return
+	}
+	if origType != MigrateEntryType {
+		err = ErrModMigrateOriginalNotFound
+		return
+	}
+
+	var origMigrate, newMigrate MigrateEntry
+	origMigrate, err = MigrateEntryFromJSON(orig.Content().(string))
+	if err != nil {
+		return
+	}
+	newMigrate, err = MigrateEntryFromJSON(a.entry.Content().(string))
+	if err != nil {
+		return
+	}
+	if origMigrate.Type != newMigrate.Type && (isReservedMigrateType(origMigrate.Type) || isReservedMigrateType(newMigrate.Type)) {
+		err = ErrModMigrateTypeChanged
+		return
+	}
+	// the agent a migrate is for can't be corrected, only its target
+	if !newMigrate.Key.Equal(origMigrate.Key) {
+		err = ErrModMigrateKeyChanged
+		return
+	}
+	// as with a migrate, the hashes must be comparable across DNAs
+	err = checkMigrateHashCodecs(&newMigrate)
 	return
 }
 
+func isReservedMigrateType(migrationType string) bool {
+	return migrationType == MigrateEntryTypeOpen || migrationType == MigrateEntryTypeClose
+}
+
 func (a *ActionMod) Receive(dht *DHT, msg *Message) (response interface{}, err error) {
 	//var hashStatus int
 	t := msg.Body.(HoldReq)
@@ -128,12 +240,16 @@ func (a *ActionMod) Receive(dht *DHT, msg *Message) (response interface{}, err e
 		a.header = &resp.Header
 
 		//@TODO what comes back from Validate Mod
-		_, err = dht.h.ValidateAction(a, resp.Type, &resp.Package, []peer.ID{msg.From})
+		_, err = dht.validateReceived(msg, a, resp.Type, &resp.Package)
 		if err != nil {
 			// how do we record an invalid Mod?
 			//@TODO store as REJECTED?
 		} else {
 			err = dht.Mod(msg, t.RelatedHash, t.EntryHash)
+			if err == nil {
+				// keep the header with the modified entry for its history
+				err = dht.putEntryHeader(msg.From, t.EntryHash, a.header)
+			}
 			if err == nil {
 				holdResp, err = dht.MakeHoldResp(msg, StatusLive)
 			}
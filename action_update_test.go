@@ -1,7 +1,9 @@
 package holochain
 
 import (
+	. "github.com/holochain/holochain-proto/hash"
 	peer "github.com/libp2p/go-libp2p-peer"
+	mh "github.com/multiformats/go-multihash"
 	. "github.com/smartystreets/goconvey/convey"
 	"testing"
 )
@@ -51,3 +53,100 @@ func TestSysValidateMod(t *testing.T) {
 	})
 
 }
+
+func TestModMigrate(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	evenHash := commit(h, "evenNumbers", "2")
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	note := entry
+	note.Type = "note"
+	response, err := (&APIFnMigrate{action: ActionMigrate{entry: note}}).Call(h)
+	if err != nil {
+		panic(err)
+	}
+	noteHash := response.(Hash)
+
+	entry.Type = MigrateEntryTypeClose
+	fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+	response, err = fn.Call(h)
+	if err != nil {
+		panic(err)
+	}
+	closeHash := response.(Hash)
+
+	modAction := func(migrate MigrateEntry, replaces Hash) *ActionMod {
+		j, _ := migrate.ToJSON()
+		a := NewModAction(MigrateEntryType, &GobEntry{C: j}, replaces)
+		a.header = &Header{EntryLink: evenHash}
+		return a
+	}
+
+	Convey("it should reject a mod that changes a close into an open", t, func() {
+		corrected := entry
+		corrected.Type = MigrateEntryTypeOpen
+		err := modAction(corrected, closeHash).SysValidation(h, MigrateEntryDef, nil, []peer.ID{h.nodeID})
+		So(err, ShouldEqual, ErrModMigrateTypeChanged)
+	})
+
+	Convey("it should reject a mod that changes the Key", t, func() {
+		corrected := entry
+		corrected.Key, err = GenTestStringHash()
+		So(err, ShouldBeNil)
+		err := modAction(corrected, closeHash).SysValidation(h, MigrateEntryDef, nil, []peer.ID{h.nodeID})
+		So(err, ShouldEqual, ErrModMigrateKeyChanged)
+	})
+
+	Convey("it should accept a mod that corrects the DNAHash to a blake2b DNA", t, func() {
+		corrected := entry
+		corrected.DNAHash, err = Sum(HashSpec{Code: mh.Names["blake2b-256"], Length: -1}, []byte("a blake2b DNA"))
+		So(err, ShouldBeNil)
+		err := modAction(corrected, closeHash).SysValidation(h, MigrateEntryDef, nil, []peer.ID{h.nodeID})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("it should reject a mod of something that isn't a migrate", t, func() {
+		err := modAction(entry, evenHash).SysValidation(h, MigrateEntryDef, nil, []peer.ID{h.nodeID})
+		So(err, ShouldEqual, ErrModMigrateOriginalNotFound)
+
+		missing, _ := NewHash("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
+		err = modAction(entry, missing).SysValidation(h, MigrateEntryDef, nil, []peer.ID{h.nodeID})
+		So(err, ShouldEqual, ErrModMigrateOriginalNotFound)
+	})
+
+	Convey("it should reject a mod by someone other than the author", t, func() {
+		other, _ := peer.IDB58Decode("QmNiCwBNA8MWDADTFVq1BonUEJbS2SvjAoNkZZrhEwcuUi")
+		err := modAction(entry, closeHash).SysValidation(h, MigrateEntryDef, nil, []peer.ID{other})
+		So(err, ShouldEqual, ErrModMigrateAuthorMismatch)
+	})
+
+	Convey("it should allow correcting the DNAHash of a close on a closed chain", t, func() {
+		corrected := entry
+		corrected.DNAHash, err = GenTestStringHash()
+		So(err, ShouldBeNil)
+		j, _ := corrected.ToJSON()
+		modFn := &APIFnMod{action: *NewModAction(MigrateEntryType, &GobEntry{C: j}, closeHash)}
+		response, err := modFn.Call(h)
+		So(err, ShouldBeNil)
+		So(response.(Hash).Equal(closeHash), ShouldBeFalse)
+		So(h.Chain().ClosedByMigrate(), ShouldBeTrue)
+
+		// anything else is still locked out
+		_, err = h.commitAndShare(NewCommitAction("evenNumbers", &GobEntry{C: "4"}), NullHash())
+		So(err, ShouldEqual, ErrChainLockedAfterClose)
+	})
+
+	Convey("it should refuse correcting a migrate other than the close on a closed chain", t, func() {
+		corrected := note
+		corrected.Data = "corrected"
+		j, _ := corrected.ToJSON()
+		modFn := &APIFnMod{action: *NewModAction(MigrateEntryType, &GobEntry{C: j}, noteHash)}
+		_, err := modFn.Call(h)
+		So(err, ShouldEqual, ErrChainLockedAfterClose)
+	})
+}
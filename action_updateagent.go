@@ -46,47 +46,22 @@ func (fn *APIFnModAgent) Call(h *Holochain) (response interface{}, err error) {
 		err = errors.New("expecting identity and/or revocation option")
 	} else {
 
-		//TODO: synchronize this, what happens if two new agent request come in back to back?
-		h.agent = &newAgent
-		// add a new agent entry and update
-		var agentHash Hash
-		_, agentHash, err = h.AddAgentEntry(revocation)
+		var agentHash, oldKey Hash
+		var oldPeer peer.ID
+		agentHash, oldKey, oldPeer, err = h.switchAgent(&newAgent, revocation)
 		if err != nil {
 			return
 		}
-		h.agentTopHash = agentHash
 
-		// if there was a revocation put the new key to the DHT and then reset the node ID data
-		// TODO make sure this doesn't introduce race conditions in the DHT between new and old identity #284
+		// if there was a revocation mark the old key as replaced by the new one
+		// and warrant it
 		if revocation != nil {
-			err = h.dht.putKey(&newAgent)
-			if err != nil {
-				return
-			}
-
-			// send the modification request for the old key
-			var oldKey, newKey Hash
-			oldPeer := h.nodeID
-			oldKey, err = NewHash(h.nodeIDStr)
-			if err != nil {
-				panic(err)
-			}
-
-			h.nodeID, h.nodeIDStr, err = h.agent.NodeID()
-			if err != nil {
-				return
-			}
-
+			var newKey Hash
 			newKey, err = NewHash(h.nodeIDStr)
 			if err != nil {
 				panic(err)
 			}
 
-			// close the old node and add the new node
-			// TODO currently ignoring the error from node.Close() is this OK?
-			h.node.Close()
-			h.createNode()
-
 			h.dht.Change(oldKey, MOD_REQUEST, HoldReq{RelatedHash: oldKey, EntryHash: newKey})
 
 			warrant, _ := NewSelfRevocationWarrant(revocation)
@@ -111,3 +86,45 @@ func (fn *APIFnModAgent) Call(h *Holochain) (response interface{}, err error) {
 	}
 	return
 }
+
+// switchAgent makes the new agent ours, adding its agent entry to the chain.
+// If the agent's key was revoked the new key is put to the DHT and the node is
+// recreated with it, returning the old key and node ID for the caller to mark
+// as revoked.
+func (h *Holochain) switchAgent(newAgent *LibP2PAgent, revocation *SelfRevocation) (agentHash, oldKey Hash, oldPeer peer.ID, err error) {
+	//TODO: synchronize this, what happens if two new agent request come in back to back?
+	h.agent = newAgent
+	// add a new agent entry and update
+	_, agentHash, err = h.AddAgentEntry(revocation)
+	if err != nil {
+		return
+	}
+	h.agentTopHash = agentHash
+
+	// if there was a revocation put the new key to the DHT and then reset the node ID data
+	// TODO make sure this doesn't introduce race conditions in the DHT between new and old identity #284
+	if revocation == nil {
+		return
+	}
+	err = h.dht.putKey(newAgent)
+	if err != nil {
+		return
+	}
+
+	oldPeer = h.nodeID
+	oldKey, err = NewHash(h.nodeIDStr)
+	if err != nil {
+		panic(err)
+	}
+
+	h.nodeID, h.nodeIDStr, err = h.agent.NodeID()
+	if err != nil {
+		return
+	}
+
+	// close the old node and add the new node
+	// TODO currently ignoring the error from node.Close() is this OK?
+	h.node.Close()
+	h.createNode()
+	return
+}
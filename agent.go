@@ -9,6 +9,7 @@ import (
 	"crypto/rand"
 	"errors"
 	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
 	b58 "github.com/jbenet/go-base58"
 	ic "github.com/libp2p/go-libp2p-crypto"
 	peer "github.com/libp2p/go-libp2p-peer"
@@ -200,3 +201,39 @@ func LoadAgent(path string) (agent Agent, err error) {
 	agent = &a
 	return
 }
+
+// SaveNextKey generates the key that revokeKey switches the agent to and
+// saves it to the given directory, returning its hash to pass to revokeKey as
+// the new key
+func SaveNextKey(path string, seed io.Reader) (hash Hash, err error) {
+	if FileExists(path, NextKeyFileName) {
+		err = errors.New("next key already exists")
+		return
+	}
+	var a LibP2PAgent
+	if err = a.GenKeys(seed); err != nil {
+		return
+	}
+	var k []byte
+	k, err = a.priv.Bytes()
+	if err != nil {
+		return
+	}
+	if err = WriteFile(k, path, NextKeyFileName); err != nil {
+		return
+	}
+	os.Chmod(filepath.Join(path, NextKeyFileName), OS_USER_R)
+	hash, err = keyHash(a.pub)
+	return
+}
+
+// loadNextKey gets the key saved by SaveNextKey from the given directory
+func loadNextKey(path string) (priv ic.PrivKey, err error) {
+	var k []byte
+	k, err = ReadFile(path, NextKeyFileName)
+	if err != nil {
+		return
+	}
+	priv, err = ic.UnmarshalPrivateKey(k)
+	return
+}
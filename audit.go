@@ -0,0 +1,165 @@
+package holochain
+
+import (
+	"encoding/json"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// AuditOutcomeOK is the outcome of an action that was handled
+	AuditOutcomeOK = "ok"
+
+	// AuditOutcomeRejected is the outcome of an action that failed validation
+	AuditOutcomeRejected = "rejected"
+
+	// AuditOutcomeFailed is the outcome of an action that failed otherwise,
+	// i.e. refused by a middleware or the commit gate
+	AuditOutcomeFailed = "failed"
+)
+
+// AuditRecord is the record of one action attempted on this node
+type AuditRecord struct {
+	Time      time.Time
+	Phase     string // "commit" for our own actions, "receive" for another node's
+	Action    string
+	EntryType string `json:",omitempty"`
+	From      string `json:",omitempty"` // the sending node of a received action
+	Outcome   string
+	Error     string `json:",omitempty"`
+}
+
+// AuditSink takes the record of every action committed or received, whether
+// it succeeded or not, unlike the chain which only holds what was committed
+type AuditSink interface {
+	Record(rec AuditRecord) error
+}
+
+// SetAuditSink sets where the records of attempted actions go, a nil sink
+// turns auditing off.  The sink is called synchronously as each action
+// finishes, so it shouldn't be slow.  If it's an io.Closer it's closed when
+// the holochain is.
+func (h *Holochain) SetAuditSink(sink AuditSink) {
+	h.middlewareLk.Lock()
+	defer h.middlewareLk.Unlock()
+	h.auditSink = sink
+}
+
+// EnableAuditLog audits actions to a file of JSON lines alongside the chain,
+// appending to it if it already exists
+func (h *Holochain) EnableAuditLog() (err error) {
+	var sink *FileAuditSink
+	if sink, err = NewFileAuditSink(filepath.Join(h.DBPath(), AuditLogFileName)); err != nil {
+		return
+	}
+	h.SetAuditSink(sink)
+	return
+}
+
+// closeAuditSink removes the audit sink, closing it if it can be
+func (h *Holochain) closeAuditSink() (err error) {
+	h.middlewareLk.Lock()
+	sink := h.auditSink
+	h.auditSink = nil
+	h.middlewareLk.Unlock()
+	if c, ok := sink.(io.Closer); ok {
+		err = c.Close()
+	}
+	return
+}
+
+// audit builds the record of a dispatched action and gives it to the sink,
+// errors from the sink are logged as they mustn't fail the action
+func (h *Holochain) audit(sink AuditSink, call *ActionCall, err error) {
+	rec := AuditRecord{Time: h.Now(), Action: call.Action.Name(), EntryType: call.EntryType, Outcome: AuditOutcomeOK}
+	switch call.Phase {
+	case PhaseCommit:
+		rec.Phase = "commit"
+	case PhaseReceive:
+		rec.Phase = "receive"
+		if call.Msg != nil {
+			rec.From = peer.IDB58Encode(call.Msg.From)
+			h.auditReceivedEntry(&rec, call.Msg)
+		}
+	}
+	if err != nil {
+		rec.Error = err.Error()
+		if IsValidationFailedErr(err) {
+			rec.Outcome = AuditOutcomeRejected
+		} else {
+			rec.Outcome = AuditOutcomeFailed
+		}
+	}
+	if e := sink.Record(rec); e != nil {
+		h.Debugf("audit sink failed to record %v: %v", rec, e)
+	}
+}
+
+// auditReceivedEntry fills in the type of the entry a received message is
+// about from what we hold.  A put that fails validation is held as rejected
+// rather than refused, so its outcome is taken from the held status.
+func (h *Holochain) auditReceivedEntry(rec *AuditRecord, msg *Message) {
+	var hash Hash
+	switch t := msg.Body.(type) {
+	case HoldReq:
+		hash = t.EntryHash
+	case PutIfReq:
+		hash = t.H
+	case GetReq:
+		hash = t.H
+	default:
+		return
+	}
+	if h.dht == nil {
+		return
+	}
+	_, entryType, _, status, err := h.dht.Get(hash, StatusAny, GetMaskEntryType)
+	if err != nil {
+		return
+	}
+	rec.EntryType = entryType
+	if status == StatusRejected && (msg.Type == PUT_REQUEST || msg.Type == PUTIF_REQUEST) {
+		rec.Outcome = AuditOutcomeRejected
+	}
+}
+
+// FileAuditSink writes audit records to a file as JSON lines
+type FileAuditSink struct {
+	f  *os.File
+	lk sync.Mutex
+}
+
+// NewFileAuditSink opens the file for appending audit records to, creating
+// it if need be
+func NewFileAuditSink(path string) (sink *FileAuditSink, err error) {
+	var f *os.File
+	if f, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600); err != nil {
+		return
+	}
+	sink = &FileAuditSink{f: f}
+	return
+}
+
+// Record implements AuditSink
+func (sink *FileAuditSink) Record(rec AuditRecord) (err error) {
+	var b []byte
+	if b, err = json.Marshal(rec); err != nil {
+		return
+	}
+	sink.lk.Lock()
+	defer sink.lk.Unlock()
+	_, err = sink.f.Write(append(b, '\n'))
+	return
+}
+
+// Close closes the file
+func (sink *FileAuditSink) Close() error {
+	sink.lk.Lock()
+	defer sink.lk.Unlock()
+	return sink.f.Close()
+}
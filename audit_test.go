@@ -0,0 +1,146 @@
+package holochain
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type memAuditSink struct {
+	records []AuditRecord
+}
+
+func (sink *memAuditSink) Record(rec AuditRecord) error {
+	sink.records = append(sink.records, rec)
+	return nil
+}
+
+func TestAuditSink(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	sink := &memAuditSink{}
+	h.SetAuditSink(sink)
+
+	Convey("a commit should be audited as ok", t, func() {
+		sink.records = nil
+		_, err := h.doCommit(NewCommitAction("evenNumbers", &GobEntry{C: "2"}), NullHash())
+		So(err, ShouldBeNil)
+		So(len(sink.records), ShouldEqual, 1)
+		r := sink.records[0]
+		So(r.Phase, ShouldEqual, "commit")
+		So(r.Action, ShouldEqual, "commit")
+		So(r.EntryType, ShouldEqual, "evenNumbers")
+		So(r.Outcome, ShouldEqual, AuditOutcomeOK)
+		So(r.Error, ShouldEqual, "")
+	})
+
+	Convey("a commit failing validation should be audited as rejected", t, func() {
+		sink.records = nil
+		a := NewCommitAction(MigrateLinkEntryType, &GobEntry{C: fmt.Sprintf(`{"Links":[{"Base":"%s","Link":"%s","Tag":"other"}]}`, h.AgentHash(), h.AgentHash())})
+		_, err := h.doCommit(a, NullHash())
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+		So(len(sink.records), ShouldEqual, 1)
+		So(sink.records[0].Outcome, ShouldEqual, AuditOutcomeRejected)
+		So(sink.records[0].Error, ShouldEqual, err.Error())
+	})
+
+	Convey("a commit refused by the gate should be audited as failed", t, func() {
+		sink.records = nil
+		refused := errors.New("no commits right now")
+		h.SetCommitGate(func(a Action) error { return refused })
+		defer h.SetCommitGate(nil)
+		_, err := h.doCommit(NewCommitAction("evenNumbers", &GobEntry{C: "4"}), NullHash())
+		So(err, ShouldEqual, refused)
+		So(len(sink.records), ShouldEqual, 1)
+		So(sink.records[0].Outcome, ShouldEqual, AuditOutcomeFailed)
+		So(sink.records[0].Error, ShouldEqual, refused.Error())
+	})
+
+	Convey("a received action should be audited with its sender", t, func() {
+		hash := commit(h, "evenNumbers", "6")
+		sink.records = nil
+		_, err := ActionReceiver(h, h.node.NewMessage(GET_REQUEST, GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry}))
+		So(err, ShouldBeNil)
+		So(len(sink.records), ShouldEqual, 1)
+		So(sink.records[0].Phase, ShouldEqual, "receive")
+		So(sink.records[0].Action, ShouldEqual, "get")
+		So(sink.records[0].From, ShouldEqual, h.nodeIDStr)
+		So(sink.records[0].EntryType, ShouldEqual, "evenNumbers")
+	})
+
+	Convey("a received put that fails validation should be audited as rejected", t, func() {
+		hash, err := h.CommitLocal(NewCommitAction("evenNumbers", &GobEntry{C: "10"}))
+		So(err, ShouldBeNil)
+		reject := true
+		h.UseActionMiddleware(func(next ActionHandler) ActionHandler {
+			return func(h *Holochain, call *ActionCall) (interface{}, error) {
+				if _, ok := call.Action.(*ActionPut); ok && reject && call.Phase == PhaseSysValidation {
+					return nil, validationFieldFailed("Entry", "refused for the test", nil)
+				}
+				return next(h, call)
+			}
+		})
+		defer func() { reject = false }()
+
+		sink.records = nil
+		_, err = ActionReceiver(h, h.node.NewMessage(PUT_REQUEST, HoldReq{EntryHash: hash}))
+		So(err, ShouldBeNil)
+		So(h.dht.Exists(hash, StatusRejected), ShouldBeNil)
+		var received []AuditRecord
+		for _, r := range sink.records {
+			if r.Phase == "receive" {
+				received = append(received, r)
+			}
+		}
+		So(len(received), ShouldEqual, 1)
+		So(received[0].Action, ShouldEqual, "put")
+		So(received[0].EntryType, ShouldEqual, "evenNumbers")
+		So(received[0].Outcome, ShouldEqual, AuditOutcomeRejected)
+	})
+
+	Convey("nothing should be audited without a sink", t, func() {
+		h.SetAuditSink(nil)
+		sink.records = nil
+		commit(h, "evenNumbers", "8")
+		So(len(sink.records), ShouldEqual, 0)
+	})
+}
+
+func TestFileAuditSink(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("the audit log should hold a JSON line per action", t, func() {
+		So(h.EnableAuditLog(), ShouldBeNil)
+		commit(h, "evenNumbers", "2")
+		_, err := h.doCommit(NewCommitAction(MigrateLinkEntryType, &GobEntry{C: `{"Links":[]}`}), NullHash())
+		So(err, ShouldNotBeNil)
+		So(h.closeAuditSink(), ShouldBeNil)
+
+		f, err := os.Open(filepath.Join(h.DBPath(), AuditLogFileName))
+		So(err, ShouldBeNil)
+		defer f.Close()
+		var records []AuditRecord
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var r AuditRecord
+			So(json.Unmarshal(scanner.Bytes(), &r), ShouldBeNil)
+			if r.Phase == "commit" {
+				records = append(records, r)
+			}
+		}
+		So(len(records), ShouldEqual, 2)
+		So(records[0].EntryType, ShouldEqual, "evenNumbers")
+		So(records[0].Outcome, ShouldEqual, AuditOutcomeOK)
+		So(records[1].EntryType, ShouldEqual, MigrateLinkEntryType)
+		So(records[1].Outcome, ShouldNotEqual, AuditOutcomeOK)
+		So(records[1].Error, ShouldNotEqual, "")
+	})
+}
@@ -8,6 +8,7 @@ package holochain
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,6 +18,7 @@ import (
 	"net/http"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // BridgeApp describes a data necessary for bridging
@@ -41,6 +43,12 @@ type Bridge struct {
 type BridgeSpec map[string]map[string]bool
 
 var BridgeAppNotFoundErr = errors.New("bridge app not found")
+var ErrNoBridgeToDNA = errors.New("no bridge to DNA")
+var ErrBridgeGetNotPermitted = errors.New("cross-DNA get not bridged")
+
+// BridgeGetFunc is the reserved name that a zome lists in its BridgeFuncs
+// to allow bridged apps to read entries from this app's DHT
+const BridgeGetFunc = "%get"
 
 // AddBridgeAsCallee registers a token for allowing bridged calls from some other app
 // and calls bridgeGenesis in any zomes with bridge functions
@@ -62,7 +70,15 @@ func (h *Holochain) AddBridgeAsCallee(fromDNA Hash, appData string) (token strin
 		}
 	}
 
-	capability, err = NewCapability(h.bridgeDB, string(bridgeSpecB), nil)
+	capability, err = NewCapabilityTTL(h.bridgeDB, string(bridgeSpecB), nil, h.Config.BridgeTokenTTL)
+	if err != nil {
+		return
+	}
+	// remember who the token was granted to so the bridge can be listed
+	err = h.bridgeDB.Update(func(tx *buntdb.Tx) (e error) {
+		_, _, e = tx.Set("from:"+capability.Token, fromDNA.String(), nil)
+		return
+	})
 	if err != nil {
 		return
 	}
@@ -100,6 +116,16 @@ func checkBridgeSpec(spec BridgeSpec, zomeType string, function string) bool {
 	return ok
 }
 
+// bridgeSpecAllowsGet returns true if any zome in the spec permits cross-DNA gets
+func bridgeSpecAllowsGet(spec BridgeSpec) bool {
+	for zomeType := range spec {
+		if checkBridgeSpec(spec, zomeType, BridgeGetFunc) {
+			return true
+		}
+	}
+	return false
+}
+
 func (h *Holochain) makeBridgeSpec() (spec BridgeSpec) {
 	var funcs map[string]bool
 	for _, z := range h.nucleus.dna.Zomes {
@@ -153,6 +179,119 @@ func (h *Holochain) BridgeCall(zomeType string, function string, arguments inter
 	return
 }
 
+// BridgeGet retrieves an entry from the DHT on behalf of a bridged app, if the
+// capability of the token permits cross-DNA gets
+func (h *Holochain) BridgeGet(hash Hash, token string) (result interface{}, err error) {
+	if err = h.checkBridgeGet(token); err != nil {
+		return
+	}
+	var resp GetResp
+	resp, err = bridgeGet(h, hash, StatusLive, GetMaskEntry)
+	if err != nil {
+		err = errors.New("bridging error: " + err.Error())
+		return
+	}
+	result = resp.Entry.Content()
+	return
+}
+
+// checkBridgeGet checks that the capability of the token permits cross-DNA gets
+func (h *Holochain) checkBridgeGet(token string) (err error) {
+	if h.bridgeDB == nil {
+		err = errors.New("no active bridge")
+		return
+	}
+	c := Capability{Token: token, db: h.bridgeDB}
+
+	var bridgeSpecStr string
+	bridgeSpecStr, err = c.Validate(nil)
+	if err == nil && bridgeSpecStr != "*" {
+		bridgeSpec := make(BridgeSpec)
+		err = json.Unmarshal([]byte(bridgeSpecStr), &bridgeSpec)
+		if err == nil && !bridgeSpecAllowsGet(bridgeSpec) {
+			err = ErrBridgeGetNotPermitted
+			return
+		}
+	}
+	if err != nil {
+		err = errors.New("bridging error: " + err.Error())
+	}
+	return
+}
+
+// BridgedEntry is an entry's content along with the header it was committed
+// with, as returned to a bridged app that asks for the header
+type BridgedEntry struct {
+	Content    string
+	Header     *Header
+	FollowHash string // hash of the entry that modified it, if it has been
+}
+
+// BridgeGetWithHeader is BridgeGet returning the entry's header too, so the
+// bridged app can check who signed it.  As a header is proof of a commit
+// rather than of the entry being current, an entry that has been modified is
+// returned as well as a live one, with the hash of what modified it so the
+// bridged app can tell whether that undid it.
+func (h *Holochain) BridgeGetWithHeader(hash Hash, token string) (result BridgedEntry, err error) {
+	if err = h.checkBridgeGet(token); err != nil {
+		return
+	}
+	var resp GetResp
+	resp, err = bridgeGetWithHeader(h, hash)
+	if err != nil {
+		err = errors.New("bridging error: " + err.Error())
+		return
+	}
+	result.Content, _ = resp.Entry.Content().(string)
+	result.Header = resp.Header
+	result.FollowHash = resp.FollowHash
+	return
+}
+
+// bridgeGetWithHeader gets a live or modified entry along with its header,
+// setting FollowHash to what modified it if it was
+func bridgeGetWithHeader(h *Holochain, hash Hash) (resp GetResp, err error) {
+	resp, err = bridgeGet(h, hash, StatusLive|StatusModified, GetMaskEntry|GetMaskHeader|GetMaskStatus)
+	if err != nil || resp.Status != StatusModified {
+		return
+	}
+	var modified GetResp
+	modified, err = h.dht.GetCtx(context.Background(), hash, StatusDefault, GetMaskEntry)
+	if err == ErrHashModified {
+		resp.FollowHash = modified.FollowHash
+		err = nil
+	}
+	return
+}
+
+// BridgeCloseMigrate returns, to a bridged app whose capability permits
+// cross-DNA gets, the JSON of the close migrate that closed this chain, or ""
+// if it isn't closed, so the app can follow where the agent migrated to
+func (h *Holochain) BridgeCloseMigrate(token string) (result string, err error) {
+	if err = h.checkBridgeGet(token); err != nil {
+		return
+	}
+	var migrate *MigrateEntry
+	_, migrate, err = h.Chain().CloseMigrate()
+	if err == nil && migrate != nil {
+		result, err = migrate.ToJSON()
+	}
+	if err != nil {
+		err = errors.New("bridging error: " + err.Error())
+	}
+	return
+}
+
+func bridgeGet(h *Holochain, hash Hash, statusMask int, getMask int) (resp GetResp, err error) {
+	req := GetReq{H: hash, StatusMask: statusMask, GetMask: getMask}
+	var r interface{}
+	r, err = callGet(h, req, &GetOptions{StatusMask: req.StatusMask, GetMask: req.GetMask})
+	if err == nil {
+		resp = r.(GetResp)
+	}
+	return
+}
+
 // AddBridgeAsCaller associates a token with an application DNA hash and url for accessing it
 // it also runs BridgeGenesis in the bridgeZome
 func (h *Holochain) AddBridgeAsCaller(bridgeZome string, calleeDNA Hash, calleeName string, token string, url string, appData string) (err error) {
@@ -195,6 +334,26 @@ func (h *Holochain) AddBridgeAsCaller(bridgeZome string, calleeDNA Hash, calleeN
 	return
 }
 
+// RemoveBridgeToCallee removes the bridge to the callee DNA added by
+// AddBridgeAsCaller, along with the manifest the callee sent
+func (h *Holochain) RemoveBridgeToCallee(calleeDNA Hash) (err error) {
+	if h.bridgeDB == nil {
+		err = errors.New("no active bridge")
+		return
+	}
+	err = h.bridgeDB.Update(func(tx *buntdb.Tx) (e error) {
+		_, e = tx.Delete("app:" + calleeDNA.String())
+		if e == buntdb.ErrNotFound {
+			e = BridgeAppNotFoundErr
+		}
+		if e == nil {
+			e = deleteBridgeManifest(tx, calleeDNA)
+		}
+		return
+	})
+	return
+}
+
 func getBridgeAppVals(value string) (token string, url string, name string) {
 	x := strings.Split(value, "%%")
 	token = x[0]
@@ -235,7 +394,11 @@ func (h *Holochain) BuildBridgeToCaller(app *BridgeApp, port string) (err error)
 
 	h.Debugf("%s generated token %s for %s\n", h.Name(), token, app.Name)
 
-	data := map[string]string{"Type": "ToCaller", "Zome": app.BridgeZome, "DNA": h.DNAHash().String(), "Token": token, "Port": port, "Data": app.BridgeGenesisCallerData}
+	manifest, err := h.BridgeManifestJSON()
+	if err != nil {
+		return
+	}
+	data := map[string]string{"Type": "ToCaller", "Zome": app.BridgeZome, "DNA": h.DNAHash().String(), "Token": token, "Port": port, "Data": app.BridgeGenesisCallerData, "Manifest": manifest}
 	dataJSON, err := json.Marshal(data)
 	if err != nil {
 		return
@@ -251,6 +414,9 @@ func (h *Holochain) BuildBridgeToCaller(app *BridgeApp, port string) (err error)
 			err = errors.New(resp.Status)
 		}
 	}
+	if err == nil {
+		err = h.AddBridgeManifest(app.DNA, resp.Header.Get(BridgeManifestHeader))
+	}
 	if err != nil {
 		h.Debugf("adding bridge to caller %s from %s failed with %s\n", app.Name, h.Name(), err)
 	}
@@ -260,7 +426,11 @@ func (h *Holochain) BuildBridgeToCaller(app *BridgeApp, port string) (err error)
 // BuildBridgeToCallee connects h to a running app specified by BridgeApp that will be the Callee, i.e. the the BridgeCallee
 func (h *Holochain) BuildBridgeToCallee(app *BridgeApp) (err error) {
 
-	data := map[string]string{"Type": "ToCallee", "DNA": h.DNAHash().String(), "Data": app.BridgeGenesisCalleeData}
+	manifest, err := h.BridgeManifestJSON()
+	if err != nil {
+		return
+	}
+	data := map[string]string{"Type": "ToCallee", "DNA": h.DNAHash().String(), "Data": app.BridgeGenesisCalleeData, "Manifest": manifest}
 	dataJSON, err := json.Marshal(data)
 	if err != nil {
 		return
@@ -291,6 +461,11 @@ func (h *Holochain) BuildBridgeToCallee(app *BridgeApp) (err error) {
 	token := string(b)
 	h.Debugf("%s received token %s from %s\n", h.Name(), token, app.Name)
 
+	err = h.AddBridgeManifest(app.DNA, resp.Header.Get(BridgeManifestHeader))
+	if err != nil {
+		return
+	}
+
 	// the url is currently through the webserver
 	err = h.AddBridgeAsCaller(app.BridgeZome, app.DNA, app.Name, token, fmt.Sprintf("http://localhost:%s", app.Port), app.BridgeGenesisCallerData)
 	if err != nil {
@@ -335,3 +510,75 @@ func (h *Holochain) GetBridges() (bridges []Bridge, err error) {
 	}
 	return
 }
+
+// BridgeInfo describes a bridge so that an app can tell whether the other DNA
+// is reachable, i.e. for a cross-DNA get or as a migration target
+type BridgeInfo struct {
+	DNA   Hash // the DNA on the other side of the bridge
+	Side  int  // BridgeCaller if we call the other DNA, BridgeCallee if it calls us
+	Token string
+	// Scope is the zome functions the token grants, only known on the callee side
+	Scope BridgeSpec
+	// ExpiresIn is the remaining validity of a time-scoped token, zero if it
+	// doesn't expire or isn't known, as on the caller side
+	ExpiresIn time.Duration
+	// Manifest is what the other side said it supports when bridging, nil if
+	// it sent no manifest
+	Manifest *BridgeManifest
+}
+
+// Bridges returns the bridges on the holochain in both directions.  The DNA
+// of callers bridged before their DNA was recorded is the null hash.
+func (h *Holochain) Bridges() (bridges []BridgeInfo, err error) {
+	if h.bridgeDB == nil {
+		bridgeDBFile := filepath.Join(h.DBPath(), BridgeDBFileName)
+		if !FileExists(bridgeDBFile) {
+			return
+		}
+		h.bridgeDB, err = buntdb.Open(bridgeDBFile)
+		if err != nil {
+			return
+		}
+	}
+	err = h.bridgeDB.View(func(tx *buntdb.Tx) (e error) {
+		e = tx.Ascend("", func(key, value string) bool {
+			x := strings.Split(key, ":")
+			switch x[0] {
+			case "app":
+				var b BridgeInfo
+				b.Side = BridgeCaller
+				b.DNA, e = NewHash(x[1])
+				if e != nil {
+					return false
+				}
+				b.Token, _, _ = getBridgeAppVals(value)
+				if b.Manifest, e = getBridgeManifest(tx, b.DNA); e != nil {
+					return false
+				}
+				bridges = append(bridges, b)
+			case "tok":
+				b := BridgeInfo{Side: BridgeCallee, Token: x[1], DNA: NullHash()}
+				if value != "" {
+					if e = json.Unmarshal([]byte(value), &b.Scope); e != nil {
+						return false
+					}
+				}
+				if from, err := tx.Get("from:" + b.Token); err == nil {
+					if b.DNA, e = NewHash(from); e != nil {
+						return false
+					}
+				}
+				if ttl, err := tx.TTL(key); err == nil && ttl > 0 {
+					b.ExpiresIn = ttl
+				}
+				if b.Manifest, e = getBridgeManifest(tx, b.DNA); e != nil {
+					return false
+				}
+				bridges = append(bridges, b)
+			}
+			return true
+		})
+		return
+	})
+	return
+}
@@ -0,0 +1,149 @@
+package holochain
+
+import (
+	"encoding/json"
+	. "github.com/holochain/holochain-proto/hash"
+	"github.com/tidwall/buntdb"
+	"sort"
+)
+
+// BridgeManifestHeader is the http header in which the DNA answering a
+// bridge setup request returns its manifest, the body being the token
+const BridgeManifestHeader = "X-Holochain-Bridge-Manifest"
+
+// The bridge operations a DNA's node can serve to the other side of a bridge,
+// as listed in a BridgeManifest
+const (
+	BridgeActionCall          = "bridge"            // calling a bridged zome function, see BridgeCall
+	BridgeActionGet           = "bridge-get"        // getting an entry, see BridgeGet
+	BridgeActionGetWithHeader = "bridge-get-header" // getting an entry with its header, see BridgeGetWithHeader
+	BridgeActionMigration     = "bridge-migration"  // getting the close migrate, see BridgeCloseMigrate
+)
+
+// BridgeManifest lists what a DNA's node supports, exchanged when bridging so
+// that each side can check what the other can do before trying it
+type BridgeManifest struct {
+	Actions     []string // the bridge operations, i.e. BridgeActionGet
+	EntryTypes  []string // the entry types the DNA defines, system ones included
+	BridgeFuncs []string `json:",omitempty"` // the zome functions that can be bridged to, as zome.function
+}
+
+// bridgeManifestActions are the bridge operations the DNA's bridge spec lets
+// the other side use: calls if it bridges any function, and the gets if it
+// permits cross-DNA gets
+func (h *Holochain) bridgeManifestActions() (names []string) {
+	spec := h.makeBridgeSpec()
+	var calls bool
+	for _, funcs := range spec {
+		for f := range funcs {
+			calls = calls || f != BridgeGetFunc
+		}
+	}
+	if calls {
+		names = append(names, BridgeActionCall)
+	}
+	if bridgeSpecAllowsGet(spec) {
+		names = append(names, BridgeActionGet, BridgeActionGetWithHeader, BridgeActionMigration)
+	}
+	return
+}
+
+// BridgeManifest returns the manifest of this DNA that is sent to the other
+// side when bridging
+func (h *Holochain) BridgeManifest() (m BridgeManifest) {
+	m.Actions = h.bridgeManifestActions()
+	for _, d := range builtInSysEntryDefs() {
+		m.EntryTypes = append(m.EntryTypes, d.Name)
+	}
+	for _, d := range registeredSysEntryDefs() {
+		m.EntryTypes = append(m.EntryTypes, d.Name)
+	}
+	for _, z := range h.nucleus.dna.Zomes {
+		for _, d := range z.Entries {
+			m.EntryTypes = append(m.EntryTypes, d.Name)
+		}
+		for _, f := range z.BridgeFuncs {
+			m.BridgeFuncs = append(m.BridgeFuncs, z.Name+"."+f)
+		}
+	}
+	sort.Strings(m.Actions)
+	sort.Strings(m.EntryTypes)
+	sort.Strings(m.BridgeFuncs)
+	return
+}
+
+// BridgeManifestJSON returns the manifest of this DNA encoded for sending
+func (h *Holochain) BridgeManifestJSON() (string, error) {
+	b, err := json.Marshal(h.BridgeManifest())
+	return string(b), err
+}
+
+// AddBridgeManifest records the manifest the DNA on the other side of a bridge
+// sent.  An empty one, as sent by nodes that predate manifests, removes any
+// manifest recorded when bridging to the DNA before.
+func (h *Holochain) AddBridgeManifest(dna Hash, manifestJSON string) (err error) {
+	if manifestJSON != "" {
+		var m BridgeManifest
+		if err = json.Unmarshal([]byte(manifestJSON), &m); err != nil {
+			return
+		}
+	}
+	if err = h.initBridgeDB(); err != nil {
+		return
+	}
+	err = h.bridgeDB.Update(func(tx *buntdb.Tx) (e error) {
+		if manifestJSON == "" {
+			return deleteBridgeManifest(tx, dna)
+		}
+		_, _, e = tx.Set("manifest:"+dna.String(), manifestJSON, nil)
+		return
+	})
+	return
+}
+
+// deleteBridgeManifest removes the manifest recorded for the DNA, if any
+func deleteBridgeManifest(tx *buntdb.Tx, dna Hash) (err error) {
+	_, err = tx.Delete("manifest:" + dna.String())
+	if err == buntdb.ErrNotFound {
+		err = nil
+	}
+	return
+}
+
+// getBridgeManifest returns the manifest recorded for the DNA, nil if none was
+func getBridgeManifest(tx *buntdb.Tx, dna Hash) (m *BridgeManifest, err error) {
+	value, err := tx.Get("manifest:" + dna.String())
+	if err == buntdb.ErrNotFound {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	m = &BridgeManifest{}
+	err = json.Unmarshal([]byte(value), m)
+	return
+}
+
+// BridgeSupports returns true if the DNA on the other side of a bridge listed
+// the bridge operation, i.e. BridgeActionGet, in its manifest.  It's false if there's no bridge to the DNA or
+// the other side sent no manifest, i.e. it predates them.
+func (h *Holochain) BridgeSupports(dnaHash Hash, actionName string) (supported bool) {
+	if h.bridgeDB == nil {
+		return
+	}
+	h.bridgeDB.View(func(tx *buntdb.Tx) error {
+		m, err := getBridgeManifest(tx, dnaHash)
+		if err != nil || m == nil {
+			return err
+		}
+		for _, a := range m.Actions {
+			if a == actionName {
+				supported = true
+				break
+			}
+		}
+		return nil
+	})
+	return
+}
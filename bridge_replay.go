@@ -0,0 +1,158 @@
+package holochain
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBridgeNonceWindow is how far a cross-DNA request's timestamp may be
+// from the receiving node's clock by default
+const DefaultBridgeNonceWindow = 30 * time.Second
+
+const (
+	BridgeNonceParam = "nonce"
+	BridgeTimeParam  = "t"
+)
+
+var ErrReplayedMessage = errors.New("replayed or stale message")
+var ErrBridgeNonceMissing = errors.New("cross-DNA request has no nonce")
+
+// BridgeNonce is sent with each cross-DNA request so that the receiving node
+// can refuse a captured request delivered again.  Nonces are monotonic for
+// each sending holochain.
+type BridgeNonce struct {
+	Nonce uint64
+	Time  time.Time
+}
+
+// Query encodes the nonce as URL query parameters
+func (n BridgeNonce) Query() string {
+	v := url.Values{}
+	v.Set(BridgeNonceParam, strconv.FormatUint(n.Nonce, 10))
+	v.Set(BridgeTimeParam, strconv.FormatInt(n.Time.UnixNano(), 10))
+	return v.Encode()
+}
+
+// BridgeNonceFromQuery decodes a nonce from URL query parameters
+func BridgeNonceFromQuery(v url.Values) (n BridgeNonce, err error) {
+	nonce, t := v.Get(BridgeNonceParam), v.Get(BridgeTimeParam)
+	if nonce == "" || t == "" {
+		err = ErrBridgeNonceMissing
+		return
+	}
+	if n.Nonce, err = strconv.ParseUint(nonce, 10, 64); err != nil {
+		err = fmt.Errorf("bad nonce: %v", err)
+		return
+	}
+	var ns int64
+	if ns, err = strconv.ParseInt(t, 10, 64); err != nil {
+		err = fmt.Errorf("bad nonce time: %v", err)
+		return
+	}
+	n.Time = time.Unix(0, ns)
+	return
+}
+
+// ReplayGuard remembers the nonces it has seen from each sender for as long as
+// their timestamps are within its window
+type ReplayGuard struct {
+	window time.Duration
+	seen   map[string]map[uint64]time.Time
+	lk     sync.Mutex
+}
+
+// NewReplayGuard returns a guard accepting timestamps within window of now,
+// 0 means DefaultBridgeNonceWindow
+func NewReplayGuard(window time.Duration) *ReplayGuard {
+	if window <= 0 {
+		window = DefaultBridgeNonceWindow
+	}
+	return &ReplayGuard{window: window, seen: make(map[string]map[uint64]time.Time)}
+}
+
+// Check returns ErrReplayedMessage if the sender has already used the nonce or
+// if its timestamp is outside the window of now, otherwise it records it.  As
+// any unused nonce is accepted, a sender's concurrent requests may arrive in
+// any order.
+func (g *ReplayGuard) Check(sender string, n BridgeNonce, now time.Time) (err error) {
+	if n.Time.Before(now.Add(-g.window)) || n.Time.After(now.Add(g.window)) {
+		err = ErrReplayedMessage
+		return
+	}
+	g.lk.Lock()
+	defer g.lk.Unlock()
+	seen := g.seen[sender]
+	if seen == nil {
+		seen = make(map[uint64]time.Time)
+		g.seen[sender] = seen
+	}
+	// nonces whose timestamps have left the window would be refused as stale
+	// anyway so there's no need to remember them
+	for nonce, t := range seen {
+		if t.Before(now.Add(-g.window)) {
+			delete(seen, nonce)
+		}
+	}
+	if _, replayed := seen[n.Nonce]; replayed {
+		err = ErrReplayedMessage
+		return
+	}
+	seen[n.Nonce] = n.Time
+	return
+}
+
+// nextBridgeNonce returns the nonce for this holochain's next cross-DNA
+// request.  The sequence starts from the time so it keeps increasing across
+// restarts.
+func (h *Holochain) nextBridgeNonce() BridgeNonce {
+	now := h.Now()
+	atomic.CompareAndSwapUint64(&h.bridgeNonce, 0, uint64(now.UnixNano()))
+	return BridgeNonce{Nonce: atomic.AddUint64(&h.bridgeNonce, 1), Time: now}
+}
+
+// BridgeCallNonced is BridgeCall for a request carrying a nonce, failing with
+// ErrReplayedMessage as BridgeGetNonced does
+func (h *Holochain) BridgeCallNonced(zomeType string, function string, arguments interface{}, token string, nonce BridgeNonce) (result interface{}, err error) {
+	if err = h.bridgeReplay.Check(token, nonce, h.Now()); err != nil {
+		return
+	}
+	result, err = h.BridgeCall(zomeType, function, arguments, token)
+	return
+}
+
+// BridgeGetNonced is BridgeGet for a request carrying a nonce, failing with
+// ErrReplayedMessage if the nonce has already been used with the token or is
+// stale
+func (h *Holochain) BridgeGetNonced(hash Hash, token string, nonce BridgeNonce) (result interface{}, err error) {
+	if err = h.bridgeReplay.Check(token, nonce, h.Now()); err != nil {
+		return
+	}
+	result, err = h.BridgeGet(hash, token)
+	return
+}
+
+// BridgeGetWithHeaderNonced is BridgeGetWithHeader for a request carrying a
+// nonce, failing as BridgeGetNonced does
+func (h *Holochain) BridgeGetWithHeaderNonced(hash Hash, token string, nonce BridgeNonce) (result BridgedEntry, err error) {
+	if err = h.bridgeReplay.Check(token, nonce, h.Now()); err != nil {
+		return
+	}
+	result, err = h.BridgeGetWithHeader(hash, token)
+	return
+}
+
+// BridgeCloseMigrateNonced is BridgeCloseMigrate for a request carrying a
+// nonce, failing as BridgeGetNonced does
+func (h *Holochain) BridgeCloseMigrateNonced(token string, nonce BridgeNonce) (result string, err error) {
+	if err = h.bridgeReplay.Check(token, nonce, h.Now()); err != nil {
+		return
+	}
+	result, err = h.BridgeCloseMigrate(token)
+	return
+}
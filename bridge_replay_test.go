@@ -0,0 +1,182 @@
+package holochain
+
+import (
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayGuard(t *testing.T) {
+	now := time.Unix(1000, 0)
+	g := NewReplayGuard(time.Minute)
+
+	Convey("it should default the window", t, func() {
+		So(NewReplayGuard(0).window, ShouldEqual, DefaultBridgeNonceWindow)
+	})
+
+	Convey("it should accept a nonce once per sender", t, func() {
+		n := BridgeNonce{Nonce: 1, Time: now}
+		So(g.Check("a", n, now), ShouldBeNil)
+		So(g.Check("a", n, now), ShouldEqual, ErrReplayedMessage)
+		So(g.Check("b", n, now), ShouldBeNil)
+		So(g.Check("a", BridgeNonce{Nonce: 3, Time: now}, now), ShouldBeNil)
+	})
+
+	Convey("it should accept a sender's unused nonces out of order", t, func() {
+		So(g.Check("a", BridgeNonce{Nonce: 2, Time: now}, now), ShouldBeNil)
+		So(g.Check("a", BridgeNonce{Nonce: 2, Time: now}, now), ShouldEqual, ErrReplayedMessage)
+	})
+
+	Convey("it should forget nonces once they leave the window", t, func() {
+		later := now.Add(2 * time.Minute)
+		So(g.Check("a", BridgeNonce{Nonce: 10, Time: later}, later), ShouldBeNil)
+		So(len(g.seen["a"]), ShouldEqual, 1)
+	})
+
+	Convey("it should refuse timestamps outside the window", t, func() {
+		So(g.Check("a", BridgeNonce{Nonce: 4, Time: now.Add(-2 * time.Minute)}, now), ShouldEqual, ErrReplayedMessage)
+		So(g.Check("a", BridgeNonce{Nonce: 5, Time: now.Add(2 * time.Minute)}, now), ShouldEqual, ErrReplayedMessage)
+	})
+}
+
+func TestBridgeNonce(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("nonces should increase", t, func() {
+		n1 := h.nextBridgeNonce()
+		n2 := h.nextBridgeNonce()
+		So(n2.Nonce, ShouldBeGreaterThan, n1.Nonce)
+	})
+
+	Convey("it should round trip through a query", t, func() {
+		req := httptest.NewRequest("GET", "/bridge-get/token/hash?"+h.nextBridgeNonce().Query(), nil)
+		n, err := BridgeNonceFromQuery(req.URL.Query())
+		So(err, ShouldBeNil)
+		So(n.Nonce, ShouldEqual, h.bridgeNonce)
+
+		req = httptest.NewRequest("GET", "/bridge-get/token/hash", nil)
+		_, err = BridgeNonceFromQuery(req.URL.Query())
+		So(err, ShouldEqual, ErrBridgeNonceMissing)
+	})
+}
+
+func TestCrossDNAGetReplay(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	hash := commit(h, "oddNumbers", "7")
+	fakeFromApp, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHx")
+	if _, err := h.AddBridgeAsCallee(fakeFromApp, "app data"); err != nil {
+		panic(err)
+	}
+	c, err := NewCapability(h.bridgeDB, `{"jsSampleZome":{"`+BridgeGetFunc+`":true}}`, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	// serve bridge gets as the ui's webserver does, capturing the requests
+	var captured []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = append(captured, r.URL.String())
+		path := strings.Split(r.URL.Path, "/")
+		hash, err := NewHash(path[3])
+		if err == nil {
+			var nonce BridgeNonce
+			if nonce, err = BridgeNonceFromQuery(r.URL.Query()); err == nil {
+				var result interface{}
+				if result, err = h.BridgeGetNonced(hash, path[2], nonce); err == nil {
+					fmt.Fprint(w, result)
+					return
+				}
+			}
+		}
+		http.Error(w, err.Error(), 400)
+	}))
+	defer server.Close()
+
+	targetDNA, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHw")
+	if err := h.AddBridgeAsCaller("jsSampleZome", targetDNA, "target", c.Token, server.URL, ""); err != nil {
+		panic(err)
+	}
+
+	Convey("a cross-DNA get should carry a nonce", t, func() {
+		fn := &APIFnCrossDNAGet{dna: targetDNA, hash: hash}
+		result, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, "7")
+		So(len(captured), ShouldEqual, 1)
+		So(captured[0], ShouldContainSubstring, BridgeNonceParam+"=")
+
+		_, err = fn.Call(h)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("replaying a captured cross-DNA get should be rejected", t, func() {
+		resp, err := http.Get(server.URL + captured[0])
+		So(err, ShouldBeNil)
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		So(resp.StatusCode, ShouldEqual, 400)
+		So(string(b), ShouldContainSubstring, ErrReplayedMessage.Error())
+	})
+
+	Convey("a request after the window should be rejected as stale", t, func() {
+		stale := BridgeNonce{Nonce: h.nextBridgeNonce().Nonce, Time: h.Now().Add(-2 * DefaultBridgeNonceWindow)}
+		_, err := h.BridgeGetNonced(hash, c.Token, stale)
+		So(err, ShouldEqual, ErrReplayedMessage)
+	})
+}
+
+func TestBridgeCallReplay(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	fakeFromApp, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHx")
+	token, err := h.AddBridgeAsCallee(fakeFromApp, "app data")
+	if err != nil {
+		panic(err)
+	}
+
+	// serve bridge calls as the ui's webserver does, capturing the requests
+	var captured []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = append(captured, r.URL.String())
+		path := strings.Split(r.URL.Path, "/")
+		body, _ := ioutil.ReadAll(r.Body)
+		nonce, err := BridgeNonceFromQuery(r.URL.Query())
+		if err == nil {
+			var result interface{}
+			if result, err = h.BridgeCallNonced(path[3], path[4], string(body), path[2], nonce); err == nil {
+				fmt.Fprint(w, result)
+				return
+			}
+		}
+		http.Error(w, err.Error(), 400)
+	}))
+	defer server.Close()
+
+	Convey("a bridge call should carry a nonce", t, func() {
+		fn := &APIFnBridge{token: token, url: server.URL, zome: "zySampleZome", function: "testStrFn1", args: "arg1 arg2"}
+		result, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, "result: arg1 arg2")
+		So(len(captured), ShouldEqual, 1)
+		So(captured[0], ShouldContainSubstring, BridgeNonceParam+"=")
+	})
+
+	Convey("replaying a captured bridge call should be rejected", t, func() {
+		resp, err := http.Post(server.URL+captured[0], "", strings.NewReader("arg1 arg2"))
+		So(err, ShouldBeNil)
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		So(resp.StatusCode, ShouldEqual, 400)
+		So(string(b), ShouldContainSubstring, ErrReplayedMessage.Error())
+	})
+}
@@ -6,6 +6,7 @@ import (
 	. "github.com/holochain/holochain-proto/hash"
 	. "github.com/smartystreets/goconvey/convey"
 	"testing"
+	"time"
 )
 
 func TestBridgeCall(t *testing.T) {
@@ -75,6 +76,53 @@ func TestBridgeSpec(t *testing.T) {
 	Convey("it should not fail functions in the spec", t, func() {
 		So(checkBridgeSpec(spec, "bridgedZome", "bridgedFunc"), ShouldBeTrue)
 	})
+	Convey("it should only allow gets if a zome bridges the get function", t, func() {
+		So(bridgeSpecAllowsGet(spec), ShouldBeFalse)
+		spec["otherZome"] = map[string]bool{BridgeGetFunc: true}
+		So(bridgeSpecAllowsGet(spec), ShouldBeTrue)
+	})
+}
+
+func TestBridgeGet(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	hash := commit(h, "oddNumbers", "7")
+
+	Convey("it should fail when there's no bridge", t, func() {
+		_, err := h.BridgeGet(hash, "bogus token")
+		So(err.Error(), ShouldEqual, "no active bridge")
+		fn := &APIFnCrossDNAGet{dna: hash, hash: hash}
+		_, err = fn.Call(h)
+		So(err, ShouldEqual, ErrNoBridgeToDNA)
+	})
+
+	fakeFromApp, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHx")
+	Convey("it should fail if the capability doesn't permit gets", t, func() {
+		token, err := h.AddBridgeAsCallee(fakeFromApp, "app data")
+		So(err, ShouldBeNil)
+		_, err = h.BridgeGet(hash, token)
+		So(err, ShouldEqual, ErrBridgeGetNotPermitted)
+	})
+
+	Convey("it should fail with an invalid token", t, func() {
+		_, err := h.BridgeGet(hash, "bogus token")
+		So(err.Error(), ShouldEqual, "bridging error: "+CapabilityInvalidErr.Error())
+	})
+
+	Convey("it should get the entry if the capability permits gets", t, func() {
+		c, err := NewCapability(h.bridgeDB, `{"jsSampleZome":{"`+BridgeGetFunc+`":true}}`, nil)
+		So(err, ShouldBeNil)
+		result, err := h.BridgeGet(hash, c.Token)
+		So(err, ShouldBeNil)
+		So(result.(string), ShouldEqual, "7")
+	})
+
+	Convey("cross-DNA get should fail for a DNA that isn't bridged", t, func() {
+		fn := &APIFnCrossDNAGet{dna: fakeFromApp, hash: hash}
+		_, err := fn.Call(h)
+		So(err, ShouldEqual, ErrNoBridgeToDNA)
+	})
 }
 
 func TestBridgeSpecMake(t *testing.T) {
@@ -140,3 +188,111 @@ func TestBridgeGetBridges(t *testing.T) {
 		So(bridges[1].Token, ShouldNotEqual, 0)
 	})
 }
+
+func TestBridgeBridges(t *testing.T) {
+	d, _, h := SetupTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should return an empty list", t, func() {
+		bridges, err := h.Bridges()
+		So(err, ShouldBeNil)
+		So(len(bridges), ShouldEqual, 0)
+	})
+
+	fakeToApp, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHw")
+	err := h.AddBridgeAsCaller("jsSampleZome", fakeToApp, "fakeAppName", "some token", "http://localhost:31415", "")
+	if err != nil {
+		panic(err)
+	}
+
+	fakeFromApp, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHx")
+	h.Config.BridgeTokenTTL = time.Hour
+	token, err := h.AddBridgeAsCallee(fakeFromApp, "app data")
+	if err != nil {
+		panic(err)
+	}
+
+	Convey("it should return the bridges in both directions", t, func() {
+		bridges, err := h.Bridges()
+		So(err, ShouldBeNil)
+		So(len(bridges), ShouldEqual, 2)
+
+		So(bridges[0].Side, ShouldEqual, BridgeCaller)
+		So(bridges[0].DNA.String(), ShouldEqual, fakeToApp.String())
+		So(bridges[0].Token, ShouldEqual, "some token")
+		So(bridges[0].ExpiresIn, ShouldEqual, 0)
+
+		So(bridges[1].Side, ShouldEqual, BridgeCallee)
+		So(bridges[1].DNA.String(), ShouldEqual, fakeFromApp.String())
+		So(bridges[1].Token, ShouldEqual, token)
+		So(bridges[1].Scope, ShouldResemble, h.makeBridgeSpec())
+		So(bridges[1].ExpiresIn, ShouldBeGreaterThan, 59*time.Minute)
+		So(bridges[1].ExpiresIn, ShouldBeLessThanOrEqualTo, time.Hour)
+	})
+}
+
+func TestBridgeManifest(t *testing.T) {
+	d, _, h := SetupTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("our manifest should list the bridge operations and entry types we support", t, func() {
+		m := h.BridgeManifest()
+		So(m.Actions, ShouldResemble, []string{BridgeActionCall})
+		So(m.EntryTypes, ShouldContain, MigrateEntryType)
+		So(m.EntryTypes, ShouldContain, "evenNumbers")
+		So(m.BridgeFuncs, ShouldResemble, []string{"jsSampleZome.getProperty", "zySampleZome.testStrFn1"})
+	})
+
+	Convey("our manifest should list the gets if we permit cross-DNA gets", t, func() {
+		funcs := h.nucleus.dna.Zomes[0].BridgeFuncs
+		defer func() { h.nucleus.dna.Zomes[0].BridgeFuncs = funcs }()
+		h.nucleus.dna.Zomes[0].BridgeFuncs = append(funcs[:len(funcs):len(funcs)], BridgeGetFunc)
+		m := h.BridgeManifest()
+		So(m.Actions, ShouldResemble, []string{BridgeActionCall, BridgeActionGet, BridgeActionGetWithHeader, BridgeActionMigration})
+	})
+
+	calleeDNA, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHw")
+	oldDNA, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHx")
+	if err := h.AddBridgeAsCaller("jsSampleZome", calleeDNA, "fakeAppName", "some token", "http://localhost:31415", ""); err != nil {
+		panic(err)
+	}
+	if err := h.AddBridgeAsCaller("jsSampleZome", oldDNA, "oldAppName", "old token", "http://localhost:31416", ""); err != nil {
+		panic(err)
+	}
+
+	Convey("it should record the manifest the other side sent", t, func() {
+		manifest, _ := json.Marshal(BridgeManifest{Actions: []string{BridgeActionGet}, EntryTypes: []string{"profile"}})
+		So(h.AddBridgeManifest(calleeDNA, string(manifest)), ShouldBeNil)
+		So(h.AddBridgeManifest(oldDNA, ""), ShouldBeNil)
+		So(h.AddBridgeManifest(oldDNA, "{bogus"), ShouldNotBeNil)
+
+		bridges, err := h.Bridges()
+		So(err, ShouldBeNil)
+		So(len(bridges), ShouldEqual, 2)
+		So(bridges[0].Manifest, ShouldResemble, &BridgeManifest{Actions: []string{BridgeActionGet}, EntryTypes: []string{"profile"}})
+		So(bridges[1].Manifest, ShouldBeNil)
+	})
+
+	Convey("it should report what the other side supports", t, func() {
+		So(h.BridgeSupports(calleeDNA, BridgeActionGet), ShouldBeTrue)
+		So(h.BridgeSupports(calleeDNA, BridgeActionMigration), ShouldBeFalse)
+		So(h.BridgeSupports(oldDNA, BridgeActionGet), ShouldBeFalse)
+		So(h.BridgeSupports(NullHash(), BridgeActionGet), ShouldBeFalse)
+	})
+
+	Convey("an empty manifest from a rebridged DNA should remove the one it sent before", t, func() {
+		manifest, _ := json.Marshal(BridgeManifest{Actions: []string{BridgeActionGet}})
+		So(h.AddBridgeManifest(oldDNA, string(manifest)), ShouldBeNil)
+		So(h.BridgeSupports(oldDNA, BridgeActionGet), ShouldBeTrue)
+		So(h.AddBridgeManifest(oldDNA, ""), ShouldBeNil)
+		So(h.BridgeSupports(oldDNA, BridgeActionGet), ShouldBeFalse)
+	})
+
+	Convey("removing the bridge should remove the manifest", t, func() {
+		So(h.RemoveBridgeToCallee(calleeDNA), ShouldBeNil)
+		So(h.BridgeSupports(calleeDNA, BridgeActionGet), ShouldBeFalse)
+		_, _, err := h.GetBridgeToken(calleeDNA)
+		So(err, ShouldEqual, BridgeAppNotFoundErr)
+		So(h.RemoveBridgeToCallee(calleeDNA), ShouldEqual, BridgeAppNotFoundErr)
+	})
+}
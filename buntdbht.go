@@ -244,6 +244,54 @@ func (ht *BuntHT) Get(key Hash, statusMask int, getMask int) (data []byte, entry
 	return
 }
 
+// PutHeader stores the marshaled header of a held entry
+func (ht *BuntHT) PutHeader(key Hash, header []byte) (err error) {
+	err = ht.db.Update(func(tx *buntdb.Tx) error {
+		_, _, err := tx.Set("header:"+key.String(), string(header), nil)
+		return err
+	})
+	return
+}
+
+// GetHeader retrieves the marshaled header of a held entry
+func (ht *BuntHT) GetHeader(key Hash) (header []byte, err error) {
+	err = ht.db.View(func(tx *buntdb.Tx) error {
+		val, err := tx.Get("header:" + key.String())
+		if err == buntdb.ErrNotFound {
+			err = ErrHashNotFound
+		}
+		if err == nil {
+			header = []byte(val)
+		}
+		return err
+	})
+	return
+}
+
+// PutDelReason stores the reason a held entry was deleted
+func (ht *BuntHT) PutDelReason(key Hash, reason string) (err error) {
+	err = ht.db.Update(func(tx *buntdb.Tx) error {
+		_, _, err := tx.Set("delReason:"+key.String(), reason, nil)
+		return err
+	})
+	return
+}
+
+// GetDelReason retrieves the reason a held entry was deleted
+func (ht *BuntHT) GetDelReason(key Hash) (reason string, err error) {
+	err = ht.db.View(func(tx *buntdb.Tx) error {
+		val, err := tx.Get("delReason:" + key.String())
+		if err == buntdb.ErrNotFound {
+			err = ErrHashNotFound
+		}
+		if err == nil {
+			reason = val
+		}
+		return err
+	})
+	return
+}
+
 // _link is a low level routine to add a link, also used by delLink
 // this ensure monotonic recording of linking attempts
 func _link(tx *buntdb.Tx, base string, link string, tag string, src peer.ID, status int, linkingEntryHash Hash) (err error) {
@@ -677,3 +725,37 @@ func (ht *BuntHT) Iterate(fn HashTableIterateFn) {
 		return err
 	})
 }
+
+// CountByType returns the number of entries matching the status mask in the
+// table grouped by entry type, reading only the type and status records
+func (ht *BuntHT) CountByType(statusMask int) (counts map[string]int, err error) {
+	if statusMask == StatusDefault {
+		statusMask = StatusLive
+	}
+	counts = make(map[string]int)
+	err = ht.db.View(func(tx *buntdb.Tx) error {
+		var e error
+		err := tx.AscendKeys("type:*", func(key, entryType string) bool {
+			k := strings.TrimPrefix(key, "type:")
+			var val string
+			val, e = tx.Get("status:" + k)
+			if e != nil {
+				return false
+			}
+			var status int
+			status, e = strconv.Atoi(val)
+			if e != nil {
+				return false
+			}
+			if (status & statusMask) != 0 {
+				counts[entryType]++
+			}
+			return true
+		})
+		if err == nil {
+			err = e
+		}
+		return err
+	})
+	return
+}
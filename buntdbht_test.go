@@ -3,6 +3,7 @@ package holochain
 import (
 	"fmt"
 	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/tidwall/buntdb"
 	"path/filepath"
@@ -124,6 +125,46 @@ func TestBuntHTPutGetModDel(t *testing.T) {
 	})
 }
 
+func TestBuntHTCountByType(t *testing.T) {
+	d := SetupTestDir()
+	defer CleanupTestDir(d)
+	id, _ := peer.IDB58Decode("QmNiCwBNA8MWDADTFVq1BonUEJbS2SvjAoNkZZrhEwcuUi")
+
+	ht := &BuntHT{}
+	ht.Open(filepath.Join(d, DHTStoreFileName))
+
+	Convey("It should return an empty map when holding nothing", t, func() {
+		counts, err := ht.CountByType(StatusLive)
+		So(err, ShouldBeNil)
+		So(counts, ShouldNotBeNil)
+		So(len(counts), ShouldEqual, 0)
+	})
+
+	Convey("It should count entries by type and status", t, func() {
+		hashes := []string{
+			"QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2",
+			"QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh3",
+			"QmP1DfoUjiWH2ZBo1PBH6FupdBucbDepx3HpWmEY6JMUpY",
+			"QmS4bKx7zZt6qoX2om5M5ik3X2k4Fco2nFx82CDJ3iVKj2",
+		}
+		types := []string{MigrateEntryType, MigrateEntryType, "someType", "someType"}
+		statuses := []int{StatusLive, StatusLive, StatusLive, StatusRejected}
+		for i := range hashes {
+			hash, _ := NewHash(hashes[i])
+			err := ht.Put(nil, types[i], hash, id, []byte("some value"), statuses[i])
+			So(err, ShouldBeNil)
+		}
+
+		counts, err := ht.CountByType(StatusLive)
+		So(err, ShouldBeNil)
+		So(counts, ShouldResemble, map[string]int{MigrateEntryType: 2, "someType": 1})
+
+		counts, err = ht.CountByType(StatusAny)
+		So(err, ShouldBeNil)
+		So(counts, ShouldResemble, map[string]int{MigrateEntryType: 2, "someType": 2})
+	})
+}
+
 func TestBuntHTLinking(t *testing.T) {
 	d := SetupTestDir()
 	defer CleanupTestDir(d)
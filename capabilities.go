@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"github.com/tidwall/buntdb"
 	"math/rand"
+	"time"
 )
 
 type Capability struct {
@@ -28,11 +29,21 @@ func makeToken(capability string) (token string) {
 
 // NewCapability returns and registers a capability of a type, for a specific or anyone if who is nil
 func NewCapability(db *buntdb.DB, capability string, who interface{}) (c *Capability, err error) {
+	return NewCapabilityTTL(db, capability, who, 0)
+}
+
+// NewCapabilityTTL returns and registers a capability like NewCapability that
+// is only valid for the ttl, or forever if ttl is 0
+func NewCapabilityTTL(db *buntdb.DB, capability string, who interface{}, ttl time.Duration) (c *Capability, err error) {
 	c = &Capability{db: db}
 	c.Token = makeToken(capability)
+	var opts *buntdb.SetOptions
+	if ttl > 0 {
+		opts = &buntdb.SetOptions{Expires: true, TTL: ttl}
+	}
 	err = db.Update(func(tx *buntdb.Tx) error {
 		Debugf("NewCapability: save token:%s\n", c.Token)
-		_, _, err = tx.Set("tok:"+c.Token, capability, nil)
+		_, _, err = tx.Set("tok:"+c.Token, capability, opts)
 		if err != nil {
 			return err
 		}
@@ -31,6 +31,29 @@ var ErrHashNotFound = errors.New("hash not found")
 var ErrIncompleteChain = errors.New("operation not allowed on incomplete chain")
 var ErrChainLockedForBundle = errors.New("chain locked for bundle")
 var ErrBundleNotStarted = errors.New("bundle not started")
+var ErrChainLockedAfterClose = errors.New("chain locked after close migrate")
+var ErrStopIteration = errors.New("stop iteration")
+var ErrChainEmpty = errors.New("chain empty")
+var ErrChainHeaderHashMismatch = errors.New("header hash mismatch")
+var ErrChainHeaderLinkMismatch = errors.New("header link mismatch")
+var ErrChainEntryLinkMismatch = errors.New("entry link mismatch")
+var ErrChainSignatureInvalid = errors.New("signature invalid")
+var ErrChainAgentKeyMissing = errors.New("no agent entry to verify signatures with")
+
+// ChainIntegrityError identifies the first header at which a chain fails
+// to verify, and why
+type ChainIntegrityError struct {
+	Index      int
+	Underlying error
+}
+
+func (e *ChainIntegrityError) Error() string {
+	return fmt.Sprintf("chain integrity broken at header %d: %v", e.Index, e.Underlying)
+}
+
+func (e *ChainIntegrityError) Unwrap() error {
+	return e.Underlying
+}
 
 const (
 	ChainMarshalFlagsNone            = 0x00
@@ -49,6 +72,13 @@ type Bundle struct {
 }
 
 // Chain structure for providing in-memory access to chain data, entries headers and hashes
+//
+// A Chain is safe to use from multiple go routines.  Its methods take its lock
+// so that additions are serialized and each read, i.e. Length, Top or a Walk,
+// sees the chain as it was at one moment: a walk or iteration runs over a
+// snapshot taken when it starts and doesn't see entries added during it.  The
+// exported fields are only safe to read directly while nothing can add to the
+// chain, i.e. before it's shared or when it's been read from a file.
 type Chain struct {
 	Hashes   []Hash
 	Headers  []*Header
@@ -199,7 +229,7 @@ func (c *Chain) AddEntry(now time.Time, entryType string, e Entry, privKey ic.Pr
 // doesn't get inserted
 func (c *Chain) prepareHeader(now time.Time, entryType string, e Entry, privKey ic.PrivKey, change Hash) (entryIdx int, hash Hash, header *Header, err error) {
 
-	if c.BundleStarted() != nil {
+	if c.bundle != nil {
 		err = ErrChainLockedForBundle
 		return
 	}
@@ -248,7 +278,7 @@ func (c *Chain) prepareHeader(now time.Time, entryType string, e Entry, privKey
 
 // addEntry, low level entry add, not thread safe, must call c.lock in the calling funciton
 func (c *Chain) addEntry(entryIdx int, hash Hash, header *Header, e Entry) (err error) {
-	if c.BundleStarted() != nil {
+	if c.bundle != nil {
 		err = ErrChainLockedForBundle
 		return
 	}
@@ -263,18 +293,17 @@ func (c *Chain) addEntry(entryIdx int, hash Hash, header *Header, e Entry) (err
 		return
 	}
 
-	var g GobEntry
-	g = *e.(*GobEntry)
+	g := copyEntry(e)
 
 	c.Hashes = append(c.Hashes, hash)
 	c.Headers = append(c.Headers, header)
-	c.Entries = append(c.Entries, &g)
+	c.Entries = append(c.Entries, g)
 	c.TypeTops[header.Type] = entryIdx
 	c.Emap[header.EntryLink] = entryIdx
 	c.Hmap[hash] = entryIdx
 
 	if c.s != nil {
-		err = writePair(c.s, header, &g)
+		err = writePair(c.s, header, g)
 	}
 
 	return
@@ -500,11 +529,91 @@ func UnmarshalChain(hashSpec HashSpec, reader io.Reader) (flags int64, c *Chain,
 	return
 }
 
-// Walk traverses chain from most recent to first entry calling fn on each one
+// ClosedByMigrate returns true if the chain contains a close migrate entry
+// that hasn't been rolled back
+func (c *Chain) ClosedByMigrate() (closed bool) {
+	closed, _, _ = c.MigrationStatus()
+	return
+}
+
+// MigrationStatus scans the chain for a close migrate entry that hasn't been
+// rolled back, returning the DNA it closed to.  If there is none closed is
+// false and targetDNA is the null hash.
+func (c *Chain) MigrationStatus() (closed bool, targetDNA Hash, err error) {
+	c.lk.RLock()
+	defer c.lk.RUnlock()
+	closed, targetDNA, err = c.migrationStatus()
+	return
+}
+
+// migrationStatus is MigrationStatus for callers that hold the chain's lock
+func (c *Chain) migrationStatus() (closed bool, targetDNA Hash, err error) {
+	targetDNA = NullHash()
+	var idx int
+	var migrate MigrateEntry
+	idx, migrate, err = c.closeMigrate()
+	if err == nil && idx >= 0 {
+		closed = true
+		targetDNA = migrate.DNAHash
+	}
+	return
+}
+
+// CloseMigrate returns the chain's close migrate entry that hasn't been rolled
+// back and the hash of its header, or nil if the chain isn't closed
+func (c *Chain) CloseMigrate() (hash Hash, migrate *MigrateEntry, err error) {
+	c.lk.RLock()
+	defer c.lk.RUnlock()
+	var idx int
+	var m MigrateEntry
+	idx, m, err = c.closeMigrate()
+	if err == nil && idx >= 0 {
+		hash = c.Hashes[idx]
+		migrate = &m
+	}
+	return
+}
+
+// closeMigrate finds the close migrate entry that hasn't been rolled back,
+// returning the index of its header or -1 if there is none, for callers that
+// hold the chain's lock
+func (c *Chain) closeMigrate() (idx int, migrate MigrateEntry, err error) {
+	idx = -1
+	if _, ok := c.TypeTops[MigrateEntryType]; !ok {
+		return
+	}
+	rolledBack := make(map[Hash]bool)
+	for i := len(c.Headers) - 1; i >= 0; i-- {
+		switch c.Headers[i].Type {
+		case MigrateRollbackEntryType:
+			var rollback MigrateRollbackEntry
+			rollback, err = MigrateRollbackEntryFromJSON(c.Entries[i].Content().(string))
+			if err != nil {
+				return
+			}
+			rolledBack[rollback.MigrateHeaderHash] = true
+		case MigrateEntryType:
+			var m MigrateEntry
+			m, err = MigrateEntryFromJSON(c.Entries[i].Content().(string))
+			if err != nil {
+				return
+			}
+			if m.Type == MigrateEntryTypeClose && !rolledBack[c.Hashes[i]] {
+				idx = i
+				migrate = m
+				return
+			}
+		}
+	}
+	return
+}
+
+// Walk traverses chain from most recent to first entry calling fn on each one.
+// It walks the chain as it was when called, so fn may add to it.
 func (c *Chain) Walk(fn WalkerFn) (err error) {
-	l := len(c.Headers)
-	for i := l - 1; i >= 0; i-- {
-		err = fn(&c.Hashes[i], c.Headers[i], c.Entries[i])
+	hashes, headers, entries := c.snapshot()
+	for i := len(headers) - 1; i >= 0; i-- {
+		err = fn(&hashes[i], headers[i], entries[i])
 		if err != nil {
 			return
 		}
@@ -512,6 +621,40 @@ func (c *Chain) Walk(fn WalkerFn) (err error) {
 	return
 }
 
+// snapshot returns copies of the chain's hashes, headers and entries, so they
+// can be read without holding the lock while the chain is added to
+func (c *Chain) snapshot() (hashes []Hash, headers []*Header, entries []Entry) {
+	c.lk.RLock()
+	defer c.lk.RUnlock()
+	hashes = make([]Hash, len(c.Hashes))
+	copy(hashes, c.Hashes)
+	headers = make([]*Header, len(c.Headers))
+	copy(headers, c.Headers)
+	entries = make([]Entry, len(c.Entries))
+	copy(entries, c.Entries)
+	return
+}
+
+// IterEntriesByType traverses the chain from the first entry to most recent calling
+// fn on each entry of the given type.  Returning ErrStopIteration from fn ends the
+// traversal without an error.
+func (c *Chain) IterEntriesByType(entryType string, fn func(header *Header, entry Entry) error) (err error) {
+	_, headers, entries := c.snapshot()
+	for i, header := range headers {
+		if header.Type != entryType {
+			continue
+		}
+		err = fn(header, entries[i])
+		if err != nil {
+			if err == ErrStopIteration {
+				err = nil
+			}
+			return
+		}
+	}
+	return
+}
+
 // Validate traverses chain confirming the hashes
 // @TODO confirm that TypeLinks are also correct
 // @TODO confirm signatures
@@ -561,6 +704,104 @@ func (c *Chain) Validate(skipEntries bool) (err error) {
 	return
 }
 
+// VerifyIntegrity walks the chain from genesis to top checking that each
+// header is linked to the previous header, that each entry hashes to its
+// header's EntryLink, and if checkSignatures is set, that each header was
+// signed by the key of the agent entry in effect at that point in the chain,
+// the key of the chain's last agent entry, as a chain copied by Resign is, or
+// by the Signer of the header if it's one of the chain's group members.
+// It returns a *ChainIntegrityError for the first header that fails.
+func (c *Chain) VerifyIntegrity(checkSignatures bool) (err error) {
+	c.lk.RLock()
+	defer c.lk.RUnlock()
+
+	var pubKey, topKey ic.PubKey
+	if checkSignatures {
+		if i, ok := c.TypeTops[AgentEntryType]; ok {
+			topKey, err = agentEntryPubKey(c.Entries[i])
+			if err != nil {
+				return &ChainIntegrityError{Index: i, Underlying: err}
+			}
+		}
+		// entries before the first agent entry (i.e. the DNA) are signed
+		// by that agent
+		for i, hd := range c.Headers {
+			if hd.Type == AgentEntryType {
+				pubKey, err = agentEntryPubKey(c.Entries[i])
+				if err != nil {
+					return &ChainIntegrityError{Index: i, Underlying: err}
+				}
+				break
+			}
+		}
+		if pubKey == nil {
+			return &ChainIntegrityError{Index: 0, Underlying: ErrChainAgentKeyMissing}
+		}
+	}
+
+	var prev Hash
+	for i, hd := range c.Headers {
+		var hash Hash
+		hash, _, err = hd.Sum(c.hashSpec)
+		if err != nil {
+			return
+		}
+		if i < len(c.Hashes) && !hash.Equal(c.Hashes[i]) {
+			return &ChainIntegrityError{Index: i, Underlying: ErrChainHeaderHashMismatch}
+		}
+		if i > 0 && !hd.HeaderLink.Equal(prev) {
+			return &ChainIntegrityError{Index: i, Underlying: ErrChainHeaderLinkMismatch}
+		}
+
+		var entryHash Hash
+		entryHash, err = c.Entries[i].Sum(c.hashSpec)
+		if err != nil {
+			return
+		}
+		if !entryHash.Equal(hd.EntryLink) {
+			return &ChainIntegrityError{Index: i, Underlying: ErrChainEntryLinkMismatch}
+		}
+
+		if checkSignatures {
+			// a new agent entry signs itself with its new key
+			if hd.Type == AgentEntryType {
+				pubKey, err = agentEntryPubKey(c.Entries[i])
+				if err != nil {
+					return &ChainIntegrityError{Index: i, Underlying: err}
+				}
+			}
+			signerKey := pubKey
+			// a header of a group chain may be signed by one of its members
+			if hd.Signer != "" {
+				if signerKey, err = c.memberKeyAt(i, hd); err != nil {
+					return &ChainIntegrityError{Index: i, Underlying: err}
+				}
+			}
+			var matches bool
+			matches, err = signerKey.Verify([]byte(hd.EntryLink), hd.Sig.S)
+			if err == nil && !matches && hd.Signer == "" && topKey != nil {
+				matches, err = topKey.Verify([]byte(hd.EntryLink), hd.Sig.S)
+			}
+			if err != nil || !matches {
+				return &ChainIntegrityError{Index: i, Underlying: ErrChainSignatureInvalid}
+			}
+		}
+		prev = hash
+	}
+	return
+}
+
+// agentEntryPubKey decodes the public key held in an agent entry
+func agentEntryPubKey(entry Entry) (pubKey ic.PubKey, err error) {
+	var ae AgentEntry
+	ae, err = AgentEntryFromJSON(entry.Content().(string))
+	if err != nil {
+		return
+	}
+	pubKey, err = DecodePubKey(ae.PublicKey)
+	return
+}
+
 // String converts a chain to a textual dump of the headers and entries
 func (c *Chain) String() string {
 	return c.Dump(0)
@@ -588,7 +829,7 @@ func (c *Chain) Dump(start int) string {
 			r += fmt.Sprintf("       %s\n", e.(*GobEntry).C)
 		case AgentEntryType:
 			r += fmt.Sprintf("       %v\n", e.(*GobEntry).C)
-		case MigrateEntryType:
+		case MigrateEntryType, MigrateRollbackEntryType:
 			r += fmt.Sprintf("       %v\n", e.(*GobEntry).C)
 		default:
 			r += fmt.Sprintf("       %v\n", e)
@@ -630,7 +871,8 @@ func (c *Chain) JSON(start int) (string, error) {
 				firstEntry = true
 			}
 
-			appendEntryAsJSON(&buffer, hdr, &hash, e.(*GobEntry))
+			g := asGobEntry(e)
+			appendEntryAsJSON(&buffer, hdr, &hash, &g)
 
 			if lastEntry {
 				buffer.WriteString("]")
@@ -690,7 +932,7 @@ func (c *Chain) Dot(start int) (dump string, err error) {
 			contentBody = "See dna.json"
 		} else {
 			e := c.Entries[i]
-			contentBody = fmt.Sprintf("%s", e.(*GobEntry).C)
+			contentBody = fmt.Sprintf("%s", e.Content())
 			contentBody = strings.Replace(contentBody, `{"`, `\{"`, -1)
 			contentBody = strings.Replace(contentBody, `"}`, `"\}`, -1)
 			contentBody = strings.Replace(contentBody, `:[`, `:[<br/>`, -1)
@@ -713,11 +955,15 @@ func (c *Chain) Dot(start int) (dump string, err error) {
 
 // Length returns the number of entries in the chain
 func (c *Chain) Length() int {
+	c.lk.RLock()
+	defer c.lk.RUnlock()
 	return len(c.Headers)
 }
 
 // BundleStarted returns the index of the chain item before the bundle or 0 if no bundle is active
 func (c *Chain) BundleStarted() *Bundle {
+	c.lk.RLock()
+	defer c.lk.RUnlock()
 	return c.bundle
 }
 
@@ -727,14 +973,14 @@ func (c *Chain) StartBundle(userParam interface{}) (err error) {
 	if err != nil {
 		return
 	}
-	c.lk.RLock()
-	defer c.lk.RUnlock()
-	if c.BundleStarted() != nil {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	if c.bundle != nil {
 		err = errors.New("Bundle already started")
 		return
 	}
 	bundle := Bundle{
-		idx:       c.Length() - 1,
+		idx:       len(c.Headers) - 1,
 		chain:     NewChain(c.hashSpec),
 		userParam: string(j),
 	}
@@ -747,8 +993,8 @@ func (c *Chain) StartBundle(userParam interface{}) (err error) {
 // CloseBundle closes a started bundle and if commit
 // copies entries from the bundle onto the chain
 func (c *Chain) CloseBundle(commit bool) (err error) {
-	c.lk.RLock()
-	defer c.lk.RUnlock()
+	c.lk.Lock()
+	defer c.lk.Unlock()
 	if c.bundle == nil {
 		err = ErrBundleNotStarted
 		return
@@ -756,7 +1002,7 @@ func (c *Chain) CloseBundle(commit bool) (err error) {
 	bundle := c.bundle
 	c.bundle = nil
 	if commit {
-		l := c.Length()
+		l := len(c.Headers)
 		for i, header := range bundle.chain.Headers {
 			err = c.addEntry(i+l, bundle.chain.Hashes[i], header, bundle.chain.Entries[i])
 			if err != nil {
@@ -769,6 +1015,8 @@ func (c *Chain) CloseBundle(commit bool) (err error) {
 
 // Close the chain's file
 func (c *Chain) Close() {
+	c.lk.Lock()
+	defer c.lk.Unlock()
 	c.s.Close()
 	c.s = nil
 }
@@ -0,0 +1,185 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+)
+
+var ErrChainDiffNilChain = errors.New("can't diff a nil chain")
+
+// ChainDiffEntry is an entry found in only one of two diffed chains, or in a
+// different place in each.  IndexA and IndexB are its positions in the two
+// chains, -1 for the one it's missing from.
+type ChainDiffEntry struct {
+	Hash   Hash // the entry's hash
+	Type   string
+	IndexA int
+	IndexB int
+}
+
+// ChainDiffChange is an entry of the first chain that was replaced by one of
+// the same type with different content in the second
+type ChainDiffChange struct {
+	Type   string
+	IndexA int
+	IndexB int
+	A      Hash // the entry's hash in the first chain
+	B      Hash // the entry's hash in the second chain
+}
+
+// ChainDiff holds the differences between two chains, in chain order
+type ChainDiff struct {
+	Added     []ChainDiffEntry  // entries only in the second chain
+	Removed   []ChainDiffEntry  // entries only in the first chain
+	Reordered []ChainDiffEntry  // entries in both chains but out of order
+	Changed   []ChainDiffChange // entries whose content differs at the same position
+}
+
+// Same returns true if the diffed chains had the same entries in the same order
+func (d *ChainDiff) Same() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Reordered) == 0 && len(d.Changed) == 0
+}
+
+// DiffChains compares the entries of two chains by their hashes, i.e. to check
+// that an open migrate reconstructed the expected state on the destination.
+// The entries the chains have in common in the same order line them up.  Of
+// the rest, an entry in both chains is reordered, and one in just one of them
+// is added or removed, unless it sits in the same place as an entry of the
+// same type in the other chain, in which case its content was changed.
+func DiffChains(a, b *Chain) (diff *ChainDiff, err error) {
+	if a == nil || b == nil {
+		err = ErrChainDiffNilChain
+		return
+	}
+	a.lk.RLock()
+	defer a.lk.RUnlock()
+	if b != a {
+		b.lk.RLock()
+		defer b.lk.RUnlock()
+	}
+
+	hashesA := chainEntryHashes(a)
+	hashesB := chainEntryHashes(b)
+	matchA, matchB := lcsMatch(hashesA, hashesB)
+
+	// what's unmatched but in both chains was reordered
+	unmatchedB := make(map[Hash][]int)
+	for j, hash := range hashesB {
+		if matchB[j] < 0 {
+			unmatchedB[hash] = append(unmatchedB[hash], j)
+		}
+	}
+	reorderedB := make(map[int]bool)
+	diff = &ChainDiff{}
+	var removed []int
+	for i, hash := range hashesA {
+		if matchA[i] >= 0 {
+			continue
+		}
+		if js := unmatchedB[hash]; len(js) > 0 {
+			unmatchedB[hash] = js[1:]
+			reorderedB[js[0]] = true
+			diff.Reordered = append(diff.Reordered, ChainDiffEntry{Hash: hash, Type: a.Headers[i].Type, IndexA: i, IndexB: js[0]})
+			continue
+		}
+		removed = append(removed, i)
+	}
+
+	// pair up what was removed and added between the same matched entries
+	var added []int
+	for j := range hashesB {
+		if matchB[j] < 0 && !reorderedB[j] {
+			added = append(added, j)
+		}
+	}
+	gapsA, gapsB := lcsGaps(matchA), lcsGaps(matchB)
+	changedB := make(map[int]bool)
+	for _, i := range removed {
+		paired := false
+		for _, j := range added {
+			if gapsB[j] != gapsA[i] || changedB[j] || a.Headers[i].Type != b.Headers[j].Type {
+				continue
+			}
+			changedB[j] = true
+			paired = true
+			diff.Changed = append(diff.Changed, ChainDiffChange{Type: a.Headers[i].Type, IndexA: i, IndexB: j, A: hashesA[i], B: hashesB[j]})
+			break
+		}
+		if !paired {
+			diff.Removed = append(diff.Removed, ChainDiffEntry{Hash: hashesA[i], Type: a.Headers[i].Type, IndexA: i, IndexB: -1})
+		}
+	}
+	for _, j := range added {
+		if !changedB[j] {
+			diff.Added = append(diff.Added, ChainDiffEntry{Hash: hashesB[j], Type: b.Headers[j].Type, IndexA: -1, IndexB: j})
+		}
+	}
+	return
+}
+
+// chainEntryHashes returns the hashes of the chain's entries in chain order
+func chainEntryHashes(c *Chain) (hashes []Hash) {
+	hashes = make([]Hash, len(c.Headers))
+	for i, header := range c.Headers {
+		hashes[i] = header.EntryLink
+	}
+	return
+}
+
+// lcsMatch finds the longest common subsequence of a and b, returning for
+// each element of a the index of the element of b it's matched to, and vice
+// versa, -1 for those that aren't part of it
+func lcsMatch(a, b []Hash) (matchA, matchB []int) {
+	n, m := len(a), len(b)
+	lengths := make([][]int, n+1)
+	for i := range lengths {
+		lengths[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+	matchA = make([]int, n)
+	matchB = make([]int, m)
+	for i := range matchA {
+		matchA[i] = -1
+	}
+	for j := range matchB {
+		matchB[j] = -1
+	}
+	for i, j := 0, 0; i < n && j < m; {
+		if a[i] == b[j] {
+			matchA[i] = j
+			matchB[j] = i
+			i++
+			j++
+		} else if lengths[i+1][j] >= lengths[i][j+1] {
+			i++
+		} else {
+			j++
+		}
+	}
+	return
+}
+
+// lcsGaps returns for each element which gap between the matched elements it
+// falls in, counted as the number of matched elements before it, so that the
+// unmatched elements of a and b in the same gap sit in the same place
+func lcsGaps(match []int) (gaps []int) {
+	gaps = make([]int, len(match))
+	gap := 0
+	for i, m := range match {
+		gaps[i] = gap
+		if m >= 0 {
+			gap++
+		}
+	}
+	return
+}
@@ -0,0 +1,76 @@
+package holochain
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestDiffChains(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	commit(h, "evenNumbers", "2")
+	commit(h, "evenNumbers", "4")
+	commit(h, "oddNumbers", "7")
+	a := h.chain
+	n := a.Length()
+
+	// copyWithout returns a chain of a's headers and entries, less the skipped ones
+	copyWithout := func(skip ...int) *Chain {
+		c := NewChain(h.hashSpec)
+	next:
+		for i := range a.Headers {
+			for _, s := range skip {
+				if i == s {
+					continue next
+				}
+			}
+			c.Headers = append(c.Headers, a.Headers[i])
+			c.Entries = append(c.Entries, a.Entries[i])
+		}
+		return c
+	}
+
+	Convey("a chain should be the same as itself", t, func() {
+		diff, err := DiffChains(a, a)
+		So(err, ShouldBeNil)
+		So(diff.Same(), ShouldBeTrue)
+	})
+
+	Convey("an entry missing from the copy should be removed", t, func() {
+		diff, err := DiffChains(a, copyWithout(n-2))
+		So(err, ShouldBeNil)
+		So(diff.Same(), ShouldBeFalse)
+		So(diff.Removed, ShouldResemble, []ChainDiffEntry{{Hash: a.Headers[n-2].EntryLink, Type: "evenNumbers", IndexA: n - 2, IndexB: -1}})
+		So(len(diff.Added), ShouldEqual, 0)
+		So(len(diff.Reordered), ShouldEqual, 0)
+		So(len(diff.Changed), ShouldEqual, 0)
+
+		diff, err = DiffChains(copyWithout(n-2), a)
+		So(err, ShouldBeNil)
+		So(diff.Added, ShouldResemble, []ChainDiffEntry{{Hash: a.Headers[n-2].EntryLink, Type: "evenNumbers", IndexA: -1, IndexB: n - 2}})
+		So(len(diff.Removed), ShouldEqual, 0)
+	})
+
+	Convey("an entry moved in the copy should be reordered", t, func() {
+		b := copyWithout(n - 3)
+		b.Headers = append(b.Headers, a.Headers[n-3])
+		b.Entries = append(b.Entries, a.Entries[n-3])
+		diff, err := DiffChains(a, b)
+		So(err, ShouldBeNil)
+		So(diff.Reordered, ShouldResemble, []ChainDiffEntry{{Hash: a.Headers[n-3].EntryLink, Type: "evenNumbers", IndexA: n - 3, IndexB: n - 1}})
+		So(len(diff.Added)+len(diff.Removed)+len(diff.Changed), ShouldEqual, 0)
+	})
+
+	Convey("an entry replaced by one of the same type should be changed", t, func() {
+		diff, err := DiffChains(copyWithout(n-2), copyWithout(n-3))
+		So(err, ShouldBeNil)
+		So(diff.Changed, ShouldResemble, []ChainDiffChange{{Type: "evenNumbers", IndexA: n - 3, IndexB: n - 3, A: a.Headers[n-3].EntryLink, B: a.Headers[n-2].EntryLink}})
+		So(len(diff.Added)+len(diff.Removed)+len(diff.Reordered), ShouldEqual, 0)
+	})
+
+	Convey("it should refuse a nil chain", t, func() {
+		_, err := DiffChains(a, nil)
+		So(err, ShouldEqual, ErrChainDiffNilChain)
+	})
+}
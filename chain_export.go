@@ -0,0 +1,94 @@
+package holochain
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	"io"
+)
+
+// ChainExportMagic starts every chain export file
+const ChainExportMagic = "HCCHAIN"
+
+// ChainExportVersion is the version of the chain export format written
+const ChainExportVersion = 1
+
+var ErrChainExportBadFormat = errors.New("not a chain export")
+var ErrChainExportEmpty = errors.New("can't export an empty chain")
+
+// Export writes the whole chain to w in a portable format for backups or for
+// moving an agent to another machine.  The file records the format version and
+// the chain's hash spec followed by every header and entry exactly as
+// committed, so the chain read back with ImportChain validates and verifies
+// just as this one does.
+func (c *Chain) Export(w io.Writer) (err error) {
+	if c.Length() == 0 {
+		err = ErrChainExportEmpty
+		return
+	}
+	if _, err = io.WriteString(w, ChainExportMagic); err != nil {
+		return
+	}
+	if err = binary.Write(w, binary.LittleEndian, uint8(ChainExportVersion)); err != nil {
+		return
+	}
+	if err = binary.Write(w, binary.LittleEndian, c.hashSpec.Code); err != nil {
+		return
+	}
+	if err = binary.Write(w, binary.LittleEndian, int64(c.hashSpec.Length)); err != nil {
+		return
+	}
+	err = c.MarshalChain(w, ChainMarshalFlagsNone, nil, nil)
+	return
+}
+
+// ImportChain reads a chain written by Export.  The chain isn't backed by
+// a file.  It fails if the chain doesn't validate or if any of its headers'
+// signatures don't verify, returning a *ChainIntegrityError for the first
+// header that fails.
+func ImportChain(r io.Reader) (c *Chain, err error) {
+	reader := bufio.NewReader(r)
+	magic := make([]byte, len(ChainExportMagic))
+	if _, err = io.ReadFull(reader, magic); err != nil || string(magic) != ChainExportMagic {
+		err = ErrChainExportBadFormat
+		return
+	}
+	var version uint8
+	if err = binary.Read(reader, binary.LittleEndian, &version); err != nil {
+		return
+	}
+	if version != ChainExportVersion {
+		err = fmt.Errorf("unsupported chain export version %d", version)
+		return
+	}
+	var spec HashSpec
+	if err = binary.Read(reader, binary.LittleEndian, &spec.Code); err != nil {
+		return
+	}
+	var length int64
+	if err = binary.Read(reader, binary.LittleEndian, &length); err != nil {
+		return
+	}
+	spec.Length = int(length)
+
+	var flags int64
+	var chain *Chain
+	flags, chain, err = UnmarshalChain(spec, reader)
+	if err != nil {
+		return
+	}
+	if flags != ChainMarshalFlagsNone || len(chain.Headers) == 0 {
+		err = ErrChainExportBadFormat
+		return
+	}
+	if err = chain.Validate(false); err != nil {
+		return
+	}
+	if err = chain.VerifyIntegrity(true); err != nil {
+		return
+	}
+	c = chain
+	return
+}
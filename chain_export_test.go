@@ -0,0 +1,73 @@
+package holochain
+
+import (
+	"bytes"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestChainExportImport(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+	commit(h, "evenNumbers", "2")
+	commit(h, "oddNumbers", "3")
+
+	Convey("an exported chain should import exactly", t, func() {
+		var buf bytes.Buffer
+		So(h.chain.Export(&buf), ShouldBeNil)
+		So(bytes.HasPrefix(buf.Bytes(), []byte(ChainExportMagic)), ShouldBeTrue)
+
+		c, err := ImportChain(&buf)
+		So(err, ShouldBeNil)
+		So(c.Validate(false), ShouldBeNil)
+		So(c.VerifyIntegrity(true), ShouldBeNil)
+		So(c.hashSpec, ShouldResemble, h.chain.hashSpec)
+		So(c.Length(), ShouldEqual, h.chain.Length())
+		So(c.Hashes, ShouldResemble, h.chain.Hashes)
+		for i := range h.chain.Headers {
+			So(c.Headers[i].Sig, ShouldResemble, h.chain.Headers[i].Sig)
+			So(c.Entries[i].Content(), ShouldEqual, h.chain.Entries[i].Content())
+		}
+		So(c.TypeTops, ShouldResemble, h.chain.TypeTops)
+		So(c.String(), ShouldEqual, h.chain.String())
+	})
+
+	Convey("it should reject a chain whose signatures don't verify", t, func() {
+		var buf bytes.Buffer
+		So(h.chain.Export(&buf), ShouldBeNil)
+		c, err := ImportChain(&buf)
+		So(err, ShouldBeNil)
+
+		// tamper with the top's signature keeping the chain's hashes consistent
+		top := len(c.Headers) - 1
+		sig := append([]byte{}, c.Headers[top].Sig.S...)
+		sig[0] ^= 0xff
+		c.Headers[top].Sig.S = sig
+		c.Hashes[top], _, err = c.Headers[top].Sum(c.hashSpec)
+		So(err, ShouldBeNil)
+		buf.Reset()
+		So(c.Export(&buf), ShouldBeNil)
+
+		_, err = ImportChain(&buf)
+		So(errors.Is(err, ErrChainSignatureInvalid), ShouldBeTrue)
+		So(err.(*ChainIntegrityError).Index, ShouldEqual, top)
+	})
+
+	Convey("it should reject files that aren't chain exports", t, func() {
+		_, err := ImportChain(bytes.NewReader([]byte("not a chain")))
+		So(err, ShouldEqual, ErrChainExportBadFormat)
+
+		var buf bytes.Buffer
+		So(h.chain.Export(&buf), ShouldBeNil)
+		b := buf.Bytes()
+		b[len(ChainExportMagic)] = ChainExportVersion + 1
+		_, err = ImportChain(bytes.NewReader(b))
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "unsupported chain export version")
+	})
+
+	Convey("it should refuse to export an empty chain", t, func() {
+		So(NewChain(h.hashSpec).Export(&bytes.Buffer{}), ShouldEqual, ErrChainExportEmpty)
+	})
+}
@@ -0,0 +1,55 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	ic "github.com/libp2p/go-libp2p-crypto"
+	"time"
+)
+
+var ErrChainParentNotFound = errors.New("parent header not in chain")
+
+// CommitWithParent adds the action's entry to the chain under a header whose
+// HeaderLink is the given parent rather than the chain's top, i.e. for
+// migration tooling that rebuilds a chain or branches one off an earlier
+// header.  The parent must be a header in the chain, or the null hash while
+// the chain is empty, unless allowDetached is set, i.e. when continuing a
+// chain from a header that's held elsewhere.  The TypeLink is the latest header
+// of the entry's type at or before the parent, and the null hash for a
+// detached parent.  Like AddEntry it's low level, the action isn't validated,
+// just given its header, and a chain built with it need not Validate.
+func (c *Chain) CommitWithParent(now time.Time, a CommittingAction, privKey ic.PrivKey, parentHash Hash, allowDetached bool) (hash Hash, err error) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	if c.bundle != nil {
+		err = ErrChainLockedForBundle
+		return
+	}
+
+	entryType := a.EntryType()
+	entry := a.Entry()
+	typeLink := NullHash()
+	parent, ok := c.Hmap[parentHash]
+	if ok {
+		for i := parent; i >= 0; i-- {
+			if c.Headers[i].Type == entryType {
+				typeLink = c.Hashes[i]
+				break
+			}
+		}
+	} else if !allowDetached && !(parentHash.IsNullHash() && len(c.Hashes) == 0) {
+		err = ErrChainParentNotFound
+		return
+	}
+
+	var header *Header
+	hash, header, err = newHeader(c.hashSpec, now.Round(0), entryType, entry, privKey, parentHash, typeLink, NullHash())
+	if err != nil {
+		return
+	}
+	if err = c.addEntry(len(c.Hashes), hash, header, entry); err != nil {
+		return
+	}
+	a.SetHeader(header)
+	return
+}
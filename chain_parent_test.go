@@ -0,0 +1,73 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestChainCommitWithParent(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+	even := commit(h, "evenNumbers", "2")
+	commit(h, "oddNumbers", "3")
+	privKey := h.agent.PrivKey()
+
+	Convey("normal commits should still link to the top", t, func() {
+		c := h.chain
+		top := c.Hashes[len(c.Hashes)-1]
+		hash := commit(h, "evenNumbers", "12")
+		header, err := c.GetEntryHeader(hash)
+		So(err, ShouldBeNil)
+		So(header.HeaderLink.String(), ShouldEqual, top.String())
+	})
+
+	Convey("it should link the header to the given parent rather than the top", t, func() {
+		c := h.chain
+		// branch off the first even number, before the odd one
+		parent := c.Hashes[c.Emap[even]]
+		a := NewCommitAction("evenNumbers", &GobEntry{C: "4"})
+		hash, err := c.CommitWithParent(h.Now(), a, privKey, parent, false)
+		So(err, ShouldBeNil)
+		top, header := c.TopType("evenNumbers")
+		So(top.String(), ShouldEqual, hash.String())
+		So(header.HeaderLink.String(), ShouldEqual, parent.String())
+		So(header.TypeLink.String(), ShouldEqual, parent.String())
+		So(a.GetHeader(), ShouldEqual, header)
+
+		// a branch's type link only looks back from the parent
+		a = NewCommitAction("oddNumbers", &GobEntry{C: "5"})
+		_, err = c.CommitWithParent(h.Now(), a, privKey, parent, false)
+		So(err, ShouldBeNil)
+		So(a.GetHeader().TypeLink.IsNullHash(), ShouldBeTrue)
+	})
+
+	Convey("it should refuse a parent that isn't in the chain unless detached", t, func() {
+		missing, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		c := h.chain
+		l := c.Length()
+		_, err = c.CommitWithParent(h.Now(), NewCommitAction("evenNumbers", &GobEntry{C: "6"}), privKey, missing, false)
+		So(err, ShouldEqual, ErrChainParentNotFound)
+		So(c.Length(), ShouldEqual, l)
+		_, err = c.CommitWithParent(h.Now(), NewCommitAction("evenNumbers", &GobEntry{C: "6"}), privKey, NullHash(), false)
+		So(err, ShouldEqual, ErrChainParentNotFound)
+
+		a := NewCommitAction("evenNumbers", &GobEntry{C: "6"})
+		_, err = c.CommitWithParent(h.Now(), a, privKey, missing, true)
+		So(err, ShouldBeNil)
+		So(a.GetHeader().HeaderLink.String(), ShouldEqual, missing.String())
+		So(a.GetHeader().TypeLink.IsNullHash(), ShouldBeTrue)
+	})
+
+	Convey("an empty chain should take the null hash as its first parent", t, func() {
+		c := NewChain(h.hashSpec)
+		hash, err := c.CommitWithParent(h.Now(), NewCommitAction("evenNumbers", &GobEntry{C: "8"}), privKey, NullHash(), false)
+		So(err, ShouldBeNil)
+		a := NewCommitAction("evenNumbers", &GobEntry{C: "10"})
+		_, err = c.CommitWithParent(h.Now(), a, privKey, hash, false)
+		So(err, ShouldBeNil)
+		So(a.GetHeader().TypeLink.String(), ShouldEqual, hash.String())
+		So(c.Validate(true), ShouldBeNil)
+	})
+}
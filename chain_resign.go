@@ -0,0 +1,60 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	"time"
+)
+
+var ErrChainResignNoAgent = errors.New("can't re-sign a chain without an agent")
+
+// Resign returns a copy of the chain with every header signed by the new
+// agent's key, i.e. to carry a chain over to a rotated key during a
+// migration, followed by an agent entry for the new agent recording that its
+// key re-signed the chain.  The entries, agent entries included, their hashes
+// and the headers' times and types are kept, while the header links are
+// recomputed as the re-signed headers hash differently, so entry content that
+// refers to old header hashes, i.e. a migrate rollback, still refers to them.
+// The copy verifies with VerifyIntegrity(true), which accepts headers signed
+// by the key of the chain's last agent entry, but as its earlier agent
+// entries are signed by a key other than their own it can't be imported
+// header by header with AppendTrusted.  Headers that were signed by a group
+// member are signed by the new agent instead.  The copy isn't backed by a
+// file.
+func (c *Chain) Resign(newAgent Agent) (resigned *Chain, err error) {
+	if newAgent == nil || newAgent.PrivKey() == nil {
+		err = ErrChainResignNoAgent
+		return
+	}
+	var agentEntry AgentEntry
+	if agentEntry, err = newAgent.AgentEntry(nil); err != nil {
+		return
+	}
+	var j string
+	if j, err = agentEntry.ToJSON(); err != nil {
+		return
+	}
+	_, headers, entries := c.snapshot()
+	privKey := newAgent.PrivKey()
+	chain := NewChain(c.hashSpec)
+	add := func(now time.Time, entryType string, entry Entry, change Hash) (err error) {
+		var l int
+		var hash Hash
+		var hd *Header
+		l, hash, hd, err = chain.prepareHeader(now, entryType, entry, privKey, change)
+		if err == nil {
+			err = chain.addEntry(l, hash, hd, entry)
+		}
+		return
+	}
+	for i, header := range headers {
+		if err = add(header.Time, header.Type, entries[i], header.Change); err != nil {
+			return
+		}
+	}
+	if err = add(time.Now(), AgentEntryType, &GobEntry{C: j}, NullHash()); err != nil {
+		return
+	}
+	resigned = chain
+	return
+}
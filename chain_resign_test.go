@@ -0,0 +1,79 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestChainResign(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+	commit(h, "evenNumbers", "2")
+	commit(h, "oddNumbers", "3")
+
+	newAgent, err := NewAgent(LibP2P, "new agent", MakeTestSeed("new agent"))
+	if err != nil {
+		panic(err)
+	}
+
+	Convey("it should refuse to re-sign without an agent", t, func() {
+		_, err := h.chain.Resign(nil)
+		So(err, ShouldEqual, ErrChainResignNoAgent)
+	})
+
+	Convey("a re-signed chain should keep its entries but be signed by the new key", t, func() {
+		c, err := h.chain.Resign(newAgent)
+		So(err, ShouldBeNil)
+		So(c.Validate(true), ShouldBeNil)
+		So(c.Validate(false), ShouldBeNil)
+		l := h.chain.Length()
+		So(c.Length(), ShouldEqual, l+1)
+
+		pubKey := newAgent.PubKey()
+		for i, hd := range c.Headers[:l] {
+			old := h.chain.Headers[i]
+			So(hd.Type, ShouldEqual, old.Type)
+			So(hd.Time.Equal(old.Time), ShouldBeTrue)
+			So(hd.EntryLink.String(), ShouldEqual, old.EntryLink.String())
+			So(c.Entries[i].Content(), ShouldEqual, h.chain.Entries[i].Content())
+			So(c.Hashes[i].String(), ShouldNotEqual, h.chain.Hashes[i].String())
+			if i > 0 {
+				So(hd.HeaderLink.String(), ShouldEqual, c.Hashes[i-1].String())
+			}
+			matches, err := pubKey.Verify([]byte(hd.EntryLink), hd.Sig.S)
+			So(err, ShouldBeNil)
+			So(matches, ShouldBeTrue)
+		}
+
+		top := c.Headers[l]
+		So(top.Type, ShouldEqual, AgentEntryType)
+		So(top.HeaderLink.String(), ShouldEqual, c.Hashes[l-1].String())
+		key, err := agentEntryPubKey(c.Entries[l])
+		So(err, ShouldBeNil)
+		So(key.Equals(pubKey), ShouldBeTrue)
+	})
+
+	Convey("a re-signed chain should verify against its new key", t, func() {
+		c, err := h.chain.Resign(newAgent)
+		So(err, ShouldBeNil)
+		So(c.VerifyIntegrity(true), ShouldBeNil)
+	})
+
+	Convey("a chain signed by a key other than its agents' shouldn't verify", t, func() {
+		c, err := h.chain.Resign(newAgent)
+		So(err, ShouldBeNil)
+		l := c.Length()
+		c.Headers, c.Entries, c.Hashes = c.Headers[:l-1], c.Entries[:l-1], c.Hashes[:l-1]
+		c.TypeTops[AgentEntryType] = 1
+		err = c.VerifyIntegrity(true)
+		var e *ChainIntegrityError
+		So(errors.As(err, &e), ShouldBeTrue)
+		So(e.Underlying, ShouldEqual, ErrChainSignatureInvalid)
+	})
+
+	Convey("the original chain should be left as it was", t, func() {
+		So(h.chain.Validate(false), ShouldBeNil)
+		So(h.chain.VerifyIntegrity(true), ShouldBeNil)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -351,6 +352,62 @@ func TestWalkChain(t *testing.T) {
 	})
 }
 
+func TestChainIterEntriesByType(t *testing.T) {
+	hashSpec, key, now := chainTestSetup()
+	c := NewChain(hashSpec)
+	for _, x := range []struct{ entryType, data string }{
+		{"entryTypeFoo1", "first foo1"},
+		{MigrateEntryType, "first migrate"},
+		{"entryTypeFoo1", "second foo1"},
+		{"entryTypeFoo2", "only foo2"},
+		{MigrateEntryType, "second migrate"},
+		{"entryTypeFoo1", "third foo1"},
+	} {
+		e := GobEntry{C: x.data}
+		c.AddEntry(now, x.entryType, &e, key)
+	}
+
+	iter := func(entryType string, stopAfter int) (x string, err error) {
+		var i int
+		err = c.IterEntriesByType(entryType, func(h *Header, entry Entry) error {
+			i++
+			x += fmt.Sprintf("%d:%v ", i, entry.(*GobEntry).C)
+			if i == stopAfter {
+				return ErrStopIteration
+			}
+			return nil
+		})
+		return
+	}
+
+	Convey("it should visit only entries of the given type from first to last", t, func() {
+		x, err := iter("entryTypeFoo1", 0)
+		So(err, ShouldBeNil)
+		So(x, ShouldEqual, "1:first foo1 2:second foo1 3:third foo1 ")
+
+		x, err = iter(MigrateEntryType, 0)
+		So(err, ShouldBeNil)
+		So(x, ShouldEqual, "1:first migrate 2:second migrate ")
+
+		x, err = iter("entryTypeBar", 0)
+		So(err, ShouldBeNil)
+		So(x, ShouldEqual, "")
+	})
+
+	Convey("it should stop without error when the callback returns ErrStopIteration", t, func() {
+		x, err := iter("entryTypeFoo1", 2)
+		So(err, ShouldBeNil)
+		So(x, ShouldEqual, "1:first foo1 2:second foo1 ")
+	})
+
+	Convey("it should return other errors from the callback", t, func() {
+		err := c.IterEntriesByType("entryTypeFoo2", func(h *Header, entry Entry) error {
+			return ErrHashNotFound
+		})
+		So(err, ShouldEqual, ErrHashNotFound)
+	})
+}
+
 func TestChainValidateChain(t *testing.T) {
 	hashSpec, key, now := chainTestSetup()
 	c := NewChain(hashSpec)
@@ -415,6 +472,82 @@ func TestChainValidateChain(t *testing.T) {
 	})
 }
 
+func TestChainVerifyIntegrity(t *testing.T) {
+	hashSpec, key, now := chainTestSetup()
+	c := NewChain(hashSpec)
+	for i := 0; i < 5; i++ {
+		e := GobEntry{C: fmt.Sprintf("data %d", i)}
+		c.AddEntry(now, "entryTypeFoo", &e, key)
+	}
+
+	Convey("it should verify an intact chain", t, func() {
+		So(c.VerifyIntegrity(false), ShouldBeNil)
+	})
+
+	Convey("it should report the index of a corrupted middle header", t, func() {
+		hd := c.Headers[2]
+		orig := hd.Time
+		hd.Time = time.Now() // tweak
+		err := c.VerifyIntegrity(false)
+		hd.Time = orig // restore
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "chain integrity broken at header 2: header hash mismatch")
+		ierr, ok := err.(*ChainIntegrityError)
+		So(ok, ShouldBeTrue)
+		So(ierr.Index, ShouldEqual, 2)
+		So(ierr.Underlying, ShouldEqual, ErrChainHeaderHashMismatch)
+		So(c.VerifyIntegrity(false), ShouldBeNil)
+	})
+
+	Convey("it should report a broken header link", t, func() {
+		hashes := c.Hashes[3]
+		c.Headers[3].HeaderLink = c.Headers[3].EntryLink // tweak
+		c.Hashes[3], _, _ = c.Headers[3].Sum(hashSpec)   // hide the tweak from the hash check
+		err := c.VerifyIntegrity(false)
+		c.Headers[3].HeaderLink = c.Hashes[2] // restore
+		c.Hashes[3] = hashes
+		So(err.(*ChainIntegrityError).Index, ShouldEqual, 3)
+		So(err.(*ChainIntegrityError).Underlying, ShouldEqual, ErrChainHeaderLinkMismatch)
+		So(c.VerifyIntegrity(false), ShouldBeNil)
+	})
+
+	Convey("it should report an entry that doesn't match its header", t, func() {
+		c.Entries[1].(*GobEntry).C = "fish" // tweak
+		err := c.VerifyIntegrity(false)
+		c.Entries[1].(*GobEntry).C = "data 1" // restore
+		So(err.(*ChainIntegrityError).Index, ShouldEqual, 1)
+		So(err.(*ChainIntegrityError).Underlying, ShouldEqual, ErrChainEntryLinkMismatch)
+	})
+
+	Convey("it should require an agent entry to check signatures", t, func() {
+		err := c.VerifyIntegrity(true)
+		So(err.(*ChainIntegrityError).Underlying, ShouldEqual, ErrChainAgentKeyMissing)
+	})
+
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+	commit(h, "oddNumbers", "7")
+	commit(h, "oddNumbers", "9")
+
+	Convey("it should verify the signatures of a genesis chain", t, func() {
+		So(h.chain.VerifyIntegrity(true), ShouldBeNil)
+	})
+
+	Convey("it should report a bad signature", t, func() {
+		top := len(h.chain.Headers) - 1
+		hd := h.chain.Headers[top]
+		sig, hash := hd.Sig, h.chain.Hashes[top]
+		hd.Sig = h.chain.Headers[top-1].Sig // tweak
+		h.chain.Hashes[top], _, _ = hd.Sum(h.hashSpec)
+		So(h.chain.VerifyIntegrity(false), ShouldBeNil)
+		err := h.chain.VerifyIntegrity(true)
+		hd.Sig, h.chain.Hashes[top] = sig, hash // restore
+		So(err.(*ChainIntegrityError).Index, ShouldEqual, top)
+		So(err.(*ChainIntegrityError).Underlying, ShouldEqual, ErrChainSignatureInvalid)
+		So(h.chain.VerifyIntegrity(true), ShouldBeNil)
+	})
+}
+
 func TestChain2String(t *testing.T) {
 	hashSpec, key, now := chainTestSetup()
 	c := NewChain(hashSpec)
@@ -753,6 +886,88 @@ func TestChainBundle(t *testing.T) {
 	})
 }
 
+func TestChainConcurrency(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	// run with -race to check the chain's locking
+	Convey("concurrent commits and reads should leave a valid chain", t, func() {
+		start := h.ChainLength()
+		committers, commits := 4, 5
+		done := make(chan struct{})
+		var readers sync.WaitGroup
+		for r := 0; r < 2; r++ {
+			readers.Add(1)
+			go func() {
+				defer readers.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					l := h.ChainLength()
+					header, hash, err := h.TopHeader()
+					if err != nil || header == nil || l < start {
+						panic("inconsistent chain read")
+					}
+					h.chain.Get(hash)
+					h.chain.Walk(func(key *Hash, header *Header, entry Entry) error { return nil })
+					h.chain.IterEntriesByType("evenNumbers", func(header *Header, entry Entry) error { return nil })
+					h.chain.String()
+				}
+			}()
+		}
+		var wg sync.WaitGroup
+		for c := 0; c < committers; c++ {
+			wg.Add(1)
+			go func(c int) {
+				defer wg.Done()
+				for i := 0; i < commits; i++ {
+					commit(h, "evenNumbers", fmt.Sprintf("%d", 2*(c*commits+i)))
+				}
+			}(c)
+		}
+		wg.Wait()
+		close(done)
+		readers.Wait()
+
+		So(h.ChainLength(), ShouldEqual, start+committers*commits)
+		So(h.chain.Validate(false), ShouldBeNil)
+		So(h.chain.VerifyIntegrity(true), ShouldBeNil)
+	})
+
+	Convey("a commit racing a close migrate shouldn't land after the close", t, func() {
+		var wg sync.WaitGroup
+		for c := 0; c < 4; c++ {
+			wg.Add(1)
+			go func(c int) {
+				defer wg.Done()
+				entry := GobEntry{C: fmt.Sprintf("%d", 1000+2*c)}
+				h.doCommit(NewCommitAction("evenNumbers", &entry), NullHash())
+			}(c)
+		}
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Type = MigrateEntryTypeClose
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+		_, err = fn.Call(h)
+		So(err, ShouldBeNil)
+		wg.Wait()
+
+		closed := false
+		err = h.chain.Walk(func(key *Hash, header *Header, entry Entry) error {
+			if header.Type == MigrateEntryType {
+				closed = true
+			} else if !closed {
+				So(header.Type, ShouldNotEqual, "evenNumbers")
+			}
+			return nil
+		})
+		So(err, ShouldBeNil)
+	})
+}
+
 /*
 func TestPersistingChain(t *testing.T) {
 	hashSpec, key, now := chainTestSetup()
@@ -0,0 +1,114 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"strconv"
+	"testing"
+)
+
+func TestChainAppendTrusted(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+	commit(h, "evenNumbers", "2")
+	commit(h, "oddNumbers", "3")
+	src := h.chain
+
+	integrityErr := func(err error, index int, underlying error) {
+		var e *ChainIntegrityError
+		So(errors.As(err, &e), ShouldBeTrue)
+		So(e.Index, ShouldEqual, index)
+		So(e.Underlying, ShouldEqual, underlying)
+	}
+
+	Convey("it should import a chain that then validates", t, func() {
+		c := NewChain(h.hashSpec)
+		for i, hd := range src.Headers {
+			So(c.AppendTrusted(hd, src.Entries[i]), ShouldBeNil)
+		}
+		So(c.Length(), ShouldEqual, src.Length())
+		So(c.Validate(false), ShouldBeNil)
+		So(c.VerifyIntegrity(true), ShouldBeNil)
+		So(c.TypeTops, ShouldResemble, src.TypeTops)
+	})
+
+	Convey("it should refuse a header that doesn't link to the top", t, func() {
+		c := NewChain(h.hashSpec)
+		integrityErr(c.AppendTrusted(src.Headers[1], src.Entries[1]), 0, ErrChainHeaderLinkMismatch)
+		So(c.AppendTrusted(src.Headers[0], src.Entries[0]), ShouldBeNil)
+		integrityErr(c.AppendTrusted(src.Headers[2], src.Entries[2]), 1, ErrChainHeaderLinkMismatch)
+		So(c.Length(), ShouldEqual, 1)
+	})
+
+	Convey("it should refuse an entry that isn't the header's", t, func() {
+		c := NewChain(h.hashSpec)
+		integrityErr(c.AppendTrusted(src.Headers[0], src.Entries[1]), 0, ErrChainEntryLinkMismatch)
+	})
+
+	Convey("it should refuse headers not signed by the chain's agent", t, func() {
+		forger, err := NewAgent(LibP2P, "forger", MakeTestSeed("forger"))
+		So(err, ShouldBeNil)
+
+		forged, err := src.Resign(forger)
+		So(err, ShouldBeNil)
+
+		// a DNA header is checked once the agent entry is in
+		c := NewChain(h.hashSpec)
+		So(c.AppendTrusted(forged.Headers[0], forged.Entries[0]), ShouldBeNil)
+		integrityErr(c.AppendTrusted(forged.Headers[1], forged.Entries[1]), 1, ErrChainSignatureInvalid)
+
+		c = NewChain(h.hashSpec)
+		_, hd, err := newHeader(h.hashSpec, src.Headers[0].Time, DNAEntryType, src.Entries[0], forger.PrivKey(), NullHash(), NullHash(), NullHash())
+		So(err, ShouldBeNil)
+		So(c.AppendTrusted(hd, src.Entries[0]), ShouldBeNil)
+		_, hd, err = newHeader(h.hashSpec, src.Headers[1].Time, AgentEntryType, src.Entries[1], h.agent.PrivKey(), c.Hashes[0], NullHash(), NullHash())
+		So(err, ShouldBeNil)
+		integrityErr(c.AppendTrusted(hd, src.Entries[1]), 0, ErrChainSignatureInvalid)
+		So(c.Length(), ShouldEqual, 1)
+
+		c = NewChain(h.hashSpec)
+		So(c.AppendTrusted(src.Headers[0], src.Entries[0]), ShouldBeNil)
+		So(c.AppendTrusted(src.Headers[1], src.Entries[1]), ShouldBeNil)
+		_, hd, err = newHeader(h.hashSpec, h.Now(), "evenNumbers", src.Entries[2], forger.PrivKey(), c.Hashes[1], NullHash(), NullHash())
+		So(err, ShouldBeNil)
+		integrityErr(c.AppendTrusted(hd, src.Entries[2]), 2, ErrChainSignatureInvalid)
+	})
+}
+
+// BenchmarkChainAppend compares appending pre-signed entries to a chain with
+// AppendTrusted against committing them through validation
+func BenchmarkChainAppend(b *testing.B) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	b.Run("trusted", func(b *testing.B) {
+		src := NewChain(h.hashSpec)
+		for i := 0; i < 2; i++ {
+			if err := src.AppendTrusted(h.chain.Headers[i], h.chain.Entries[i]); err != nil {
+				b.Fatal(err)
+			}
+		}
+		for i := 0; i < b.N; i++ {
+			if _, err := src.AddEntry(h.Now(), "evenNumbers", &GobEntry{C: strconv.Itoa(2 * i)}, h.agent.PrivKey()); err != nil {
+				b.Fatal(err)
+			}
+		}
+		c := NewChain(h.hashSpec)
+		b.ResetTimer()
+		for i, hd := range src.Headers {
+			if err := c.AppendTrusted(hd, src.Entries[i]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("validated", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			a := NewCommitAction("evenNumbers", &GobEntry{C: strconv.Itoa(2 * i)})
+			if _, err := h.doCommit(a, NullHash()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
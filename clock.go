@@ -0,0 +1,54 @@
+package holochain
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the time used to stamp headers
+type Clock interface {
+	Now() time.Time
+}
+
+// RealClock is the default Clock, it just reports the system time
+type RealClock struct{}
+
+func (c RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// MonotonicClock is a fake Clock for testing that starts at a fixed time and
+// advances by a fixed step each time it's read, so that successive headers
+// have predictable, strictly increasing timestamps
+type MonotonicClock struct {
+	now  time.Time
+	step time.Duration
+	lk   sync.Mutex
+}
+
+// NewMonotonicClock creates a MonotonicClock whose first reading is start
+func NewMonotonicClock(start time.Time, step time.Duration) *MonotonicClock {
+	return &MonotonicClock{now: start, step: step}
+}
+
+func (c *MonotonicClock) Now() (now time.Time) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	now = c.now
+	c.now = c.now.Add(c.step)
+	return
+}
+
+// SetClock sets the clock used to stamp the headers of new entries
+func (h *Holochain) SetClock(clock Clock) {
+	h.clock = clock
+}
+
+// Now returns the current time according to the holochain's clock, which
+// is the real time unless it has been replaced with SetClock
+func (h *Holochain) Now() time.Time {
+	if h.clock == nil {
+		return time.Now()
+	}
+	return h.clock.Now()
+}
@@ -0,0 +1,39 @@
+package holochain
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestMonotonicClock(t *testing.T) {
+	Convey("it should advance by the step each time it's read", t, func() {
+		start := time.Unix(1000, 0)
+		c := NewMonotonicClock(start, time.Second)
+		So(c.Now(), ShouldEqual, start)
+		So(c.Now(), ShouldEqual, start.Add(time.Second))
+		So(c.Now(), ShouldEqual, start.Add(2*time.Second))
+	})
+}
+
+func TestHolochainClock(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should default to real time", t, func() {
+		before := time.Now()
+		So(h.Now().Before(before), ShouldBeFalse)
+	})
+
+	Convey("committed entries should be stamped by the holochain's clock", t, func() {
+		start := time.Unix(1000, 0)
+		h.SetClock(NewMonotonicClock(start, time.Minute))
+		defer h.SetClock(nil)
+
+		commit(h, "oddNumbers", "3")
+		commit(h, "oddNumbers", "5")
+		l := len(h.chain.Headers)
+		So(h.chain.Headers[l-2].Time.Equal(start), ShouldBeTrue)
+		So(h.chain.Headers[l-1].Time.Equal(start.Add(time.Minute)), ShouldBeTrue)
+	})
+}
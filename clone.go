@@ -0,0 +1,162 @@
+package holochain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var ErrCloneAgentMismatch = errors.New("agent doesn't match the identity of the holochain being cloned")
+var ErrCloneSameDNA = errors.New("can't clone a holochain to its own DNA")
+var ErrCloneDNANotFound = errors.New("no installed DNA has that hash")
+
+// CloneAgentError is returned by Clone when the agent can't be reused for the
+// new instance
+type CloneAgentError struct {
+	Err error
+}
+
+func (e *CloneAgentError) Error() string {
+	return fmt.Sprintf("clone: unable to reuse agent: %v", e.Err)
+}
+
+func (e *CloneAgentError) Unwrap() error {
+	return e.Err
+}
+
+// CloneDNAError is returned by Clone when the destination DNA can't be loaded
+type CloneDNAError struct {
+	DNAHash Hash
+	Err     error
+}
+
+func (e *CloneDNAError) Error() string {
+	return fmt.Sprintf("clone: unable to load DNA %v: %v", e.DNAHash, e.Err)
+}
+
+func (e *CloneDNAError) Unwrap() error {
+	return e.Err
+}
+
+// Clone creates a new holochain instance for the DNA with hash newDNAHash,
+// using this instance as the template, i.e. for an open migrate to a new DNA.
+// The DNA is looked for among the chains installed alongside this one and the
+// new instance is created in a sibling directory named by the DNA hash.  It
+// shares this instance's agent identity, and a copy of its config, but has an
+// empty chain; none of the source chain's entries are copied.  Call GenChain
+// on it before committing the open migrate, after changing its DHTPort if this
+// instance is running.
+// If agent is nil this instance's agent is used, otherwise it must have the
+// same identity and keys.  Failures to reuse the agent are returned as a
+// *CloneAgentError and failures to find or load the DNA as a *CloneDNAError.
+func (h *Holochain) Clone(newDNAHash Hash, agent Agent) (clone *Holochain, err error) {
+	if agent == nil {
+		agent = h.agent
+	} else if err = sameAgent(h.agent, agent); err != nil {
+		err = &CloneAgentError{Err: err}
+		return
+	}
+
+	if newDNAHash.Equal(h.dnaHash) {
+		err = &CloneDNAError{DNAHash: newDNAHash, Err: ErrCloneSameDNA}
+		return
+	}
+
+	s := &Service{Path: filepath.Dir(h.rootPath)}
+	dna, format, err := s.findDNAByHash(newDNAHash, agent)
+	if err != nil {
+		err = &CloneDNAError{DNAHash: newDNAHash, Err: err}
+		return
+	}
+
+	config := h.Config
+	clone, err = gen(filepath.Join(s.Path, newDNAHash.String()), true, func(root string) (hP *Holochain, err error) {
+		c := Holochain{Config: config, encodingFormat: format, rootPath: root, agent: agent}
+		c.nucleus = NewNucleus(&c, dna)
+		c.nodeID, c.nodeIDStr, err = agent.NodeID()
+		if err != nil {
+			err = &CloneAgentError{Err: err}
+			return
+		}
+		if err = c.PrepareHashType(); err != nil {
+			err = &CloneDNAError{DNAHash: newDNAHash, Err: err}
+			return
+		}
+		if err = c.saveConfig(); err != nil {
+			return
+		}
+		if err = os.MkdirAll(c.DNAPath(), os.ModePerm); err != nil {
+			return
+		}
+		if err = s.saveDNAFile(root, dna, format, true); err != nil {
+			return
+		}
+		if err = SaveAgent(root, agent); err != nil {
+			err = &CloneAgentError{Err: err}
+			return
+		}
+		hP = &c
+		return
+	})
+	return
+}
+
+// sameAgent checks that two agents have the same identity and keys
+func sameAgent(a Agent, b Agent) (err error) {
+	if a.Identity() != b.Identity() || !a.PubKey().Equals(b.PubKey()) {
+		err = ErrCloneAgentMismatch
+	}
+	return
+}
+
+// saveConfig writes out the holochain's config file
+func (h *Holochain) saveConfig() (err error) {
+	var buf bytes.Buffer
+	if err = Encode(&buf, h.encodingFormat, &h.Config); err != nil {
+		return
+	}
+	if err = WriteFile(buf.Bytes(), h.rootPath, ConfigFileName+"."+h.encodingFormat); err != nil {
+		return
+	}
+	err = h.Config.Setup()
+	return
+}
+
+// findDNAByHash searches the service's chains for one whose DNA has the given
+// hash, returning the DNA and the format it's encoded in
+func (s *Service) findDNAByHash(dnaHash Hash, agent Agent) (dna *DNA, format string, err error) {
+	files, err := ioutil.ReadDir(s.Path)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+		path := filepath.Join(s.Path, f.Name(), ChainDNADir)
+		var encoding string
+		if encoding, err = findDNA(path); err != nil {
+			continue
+		}
+		var d *DNA
+		if d, err = s.loadDNA(path, DNAFileName, encoding); err != nil {
+			continue
+		}
+		h := Holochain{encodingFormat: encoding, agent: agent}
+		h.nucleus = NewNucleus(&h, d)
+		var hash Hash
+		if hash, err = DNAHashofUngenedChain(&h); err != nil {
+			continue
+		}
+		if hash.Equal(dnaHash) {
+			dna, format = d, encoding
+			return
+		}
+	}
+	err = ErrCloneDNANotFound
+	return
+}
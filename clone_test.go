@@ -0,0 +1,61 @@
+package holochain
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"path/filepath"
+	"testing"
+)
+
+func TestHolochainClone(t *testing.T) {
+	d, s, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	_, err := s.Clone(h.rootPath, filepath.Join(s.Path, "dest"), h.agent, true, false)
+	if err != nil {
+		panic(err)
+	}
+	dest, err := s.Load("dest")
+	if err != nil {
+		panic(err)
+	}
+	destHash, err := DNAHashofUngenedChain(dest)
+	if err != nil {
+		panic(err)
+	}
+
+	Convey("it should refuse an agent with a different identity", t, func() {
+		agent, err := NewAgent(LibP2P, "someone else", MakeTestSeed("someone else"))
+		So(err, ShouldBeNil)
+		_, err = h.Clone(destHash, agent)
+		So(err, ShouldResemble, &CloneAgentError{Err: ErrCloneAgentMismatch})
+	})
+
+	Convey("it should fail on unknown DNAs", t, func() {
+		hash, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		_, err = h.Clone(hash, nil)
+		So(err, ShouldResemble, &CloneDNAError{DNAHash: hash, Err: ErrCloneDNANotFound})
+
+		_, err = h.Clone(h.DNAHash(), nil)
+		So(err, ShouldResemble, &CloneDNAError{DNAHash: h.DNAHash(), Err: ErrCloneSameDNA})
+	})
+
+	Convey("it should create an empty instance of the new DNA for the same agent", t, func() {
+		clone, err := h.Clone(destHash, h.Agent())
+		So(err, ShouldBeNil)
+		So(clone.rootPath, ShouldEqual, filepath.Join(s.Path, destHash.String()))
+		So(clone.Started(), ShouldBeFalse)
+		So(clone.ChainLength(), ShouldEqual, 0)
+		So(clone.nodeID, ShouldEqual, h.nodeID)
+		So(clone.Nucleus().DNA().UUID, ShouldEqual, dest.Nucleus().DNA().UUID)
+
+		port, err := getFreePort()
+		So(err, ShouldBeNil)
+		clone.Config.DHTPort = port
+		_, err = clone.GenChain()
+		So(err, ShouldBeNil)
+		defer clone.Close()
+		So(clone.DNAHash().Equal(destHash), ShouldBeTrue)
+		So(clone.ChainLength(), ShouldEqual, 2)
+	})
+}
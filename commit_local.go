@@ -0,0 +1,85 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+)
+
+var ErrNotCommittingAction = errors.New("action doesn't commit an entry")
+var ErrCommitLocalInBundle = errors.New("can't commit locally while a bundle is open")
+var ErrNotPendingShare = errors.New("hash is not of an entry waiting to be shared")
+
+type pendingShare struct {
+	action CommittingAction
+	def    *EntryDef
+}
+
+// CommitLocal validates and commits the action's entry to the chain, as
+// commitAndShare does, but doesn't share it to the DHT until ShareHash is
+// called with the hash returned.  Until then the entry can only be gotten
+// locally, i.e. with GetOptions.Local.  This allows staging several entries,
+// such as a migrate and its link, and then publishing them together.
+// N.B. the entries waiting to be shared are only remembered in memory.
+func (h *Holochain) CommitLocal(action Action) (hash Hash, err error) {
+	a, ok := action.(CommittingAction)
+	if !ok {
+		err = ErrNotCommittingAction
+		return
+	}
+	if h.Chain().BundleStarted() != nil {
+		err = ErrCommitLocalInBundle
+		return
+	}
+	var def *EntryDef
+	def, err = h.doCommit(a, NullHash())
+	if err != nil {
+		return
+	}
+	hash = h.addPendingShare(a, def)
+	return
+}
+
+// addPendingShare leaves a committed action waiting for ShareHash
+func (h *Holochain) addPendingShare(a CommittingAction, def *EntryDef) (hash Hash) {
+	hash = a.GetHeader().EntryLink
+	h.pendingSharesLk.Lock()
+	defer h.pendingSharesLk.Unlock()
+	if h.pendingShares == nil {
+		h.pendingShares = make(map[Hash]pendingShare)
+	}
+	h.pendingShares[hash] = pendingShare{action: a, def: def}
+	return
+}
+
+// ShareHash shares an entry committed with CommitLocal to the DHT.  If the
+// share fails the entry stays waiting to be shared so it can be tried again.
+func (h *Holochain) ShareHash(hash Hash) (err error) {
+	h.pendingSharesLk.Lock()
+	p, ok := h.pendingShares[hash]
+	if ok {
+		delete(h.pendingShares, hash)
+	}
+	h.pendingSharesLk.Unlock()
+	if !ok {
+		err = ErrNotPendingShare
+		return
+	}
+	err = h.share(p.action, p.def)
+	if err != nil {
+		h.pendingSharesLk.Lock()
+		h.pendingShares[hash] = p
+		h.pendingSharesLk.Unlock()
+	}
+	return
+}
+
+// PendingShares returns the hashes of the entries committed with CommitLocal
+// that haven't been shared yet
+func (h *Holochain) PendingShares() (hashes []Hash) {
+	h.pendingSharesLk.Lock()
+	defer h.pendingSharesLk.Unlock()
+	for hash := range h.pendingShares {
+		hashes = append(hashes, hash)
+	}
+	return
+}
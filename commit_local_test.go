@@ -0,0 +1,61 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestCommitLocal(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should only commit actions that commit entries", t, func() {
+		_, err := h.CommitLocal(&ActionGet{})
+		So(err, ShouldEqual, ErrNotCommittingAction)
+	})
+
+	Convey("a locally committed entry should be on the chain but not in the DHT", t, func() {
+		hash, err := h.CommitLocal(NewCommitAction("evenNumbers", &GobEntry{C: "2"}))
+		So(err, ShouldBeNil)
+		So(h.PendingShares(), ShouldResemble, []Hash{hash})
+
+		r, err := callGet(h, GetReq{H: hash, GetMask: GetMaskEntry}, &GetOptions{GetMask: GetMaskEntry, Local: true})
+		So(err, ShouldBeNil)
+		So(r.(GetResp).Entry.C, ShouldEqual, "2")
+		So(h.dht.Exists(hash, StatusLive), ShouldEqual, ErrHashNotFound)
+
+		So(h.ShareHash(hash), ShouldBeNil)
+		So(h.dht.Exists(hash, StatusLive), ShouldBeNil)
+		So(len(h.PendingShares()), ShouldEqual, 0)
+		So(h.ShareHash(hash), ShouldEqual, ErrNotPendingShare)
+	})
+
+	Convey("a migrate and its link should be publishable together", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		migrateHash, err := h.CommitLocal(&ActionMigrate{entry: entry})
+		So(err, ShouldBeNil)
+		linkEntry, err := h.migrateLinkEntry(migrateHash)
+		So(err, ShouldBeNil)
+		linkHash, err := h.CommitLocal(NewCommitAction(MigrateLinkEntryType, linkEntry))
+		So(err, ShouldBeNil)
+		So(len(h.PendingShares()), ShouldEqual, 2)
+		So(h.dht.Exists(migrateHash, StatusLive), ShouldEqual, ErrHashNotFound)
+
+		So(h.ShareHash(migrateHash), ShouldBeNil)
+		So(h.ShareHash(linkHash), ShouldBeNil)
+		So(h.dht.Exists(migrateHash, StatusLive), ShouldBeNil)
+		links, err := h.dht.GetLinks(h.AgentHash(), MigrateLinkTag, StatusLive)
+		So(err, ShouldBeNil)
+		So(len(links), ShouldEqual, 1)
+		So(links[0].H, ShouldEqual, migrateHash.String())
+	})
+
+	Convey("it should refuse to commit locally in a bundle", t, func() {
+		So(h.Chain().StartBundle("staging"), ShouldBeNil)
+		defer h.Chain().CloseBundle(false)
+		_, err := h.CommitLocal(NewCommitAction("evenNumbers", &GobEntry{C: "4"}))
+		So(err, ShouldEqual, ErrCommitLocalInBundle)
+	})
+}
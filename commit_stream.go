@@ -0,0 +1,107 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	"sync"
+)
+
+var ErrCommitStreamClosed = errors.New("commit stream closed")
+
+type streamedCommit struct {
+	action CommittingAction
+	def    *EntryDef
+}
+
+// CommitStream commits entries to the chain one after the other as they are
+// written and defers sharing them to the DHT until the stream is flushed, so
+// that a failed commit part way through, i.e. while replaying state into a new
+// chain after an open migrate, leaves nothing on the DHT from the stream since
+// its last flush.
+// N.B. that's all it saves: each entry is still validated, signed and shared
+// exactly as Commit would.  Signing can't be amortised as each header carries
+// the signature of its own entry, which is what holders check, and PUTs can't
+// be batched as each entry hashes to its own part of the DHT, whose holders
+// then fetch it back from us to validate it.
+type CommitStream struct {
+	h       *Holochain
+	pending []streamedCommit
+	hashes  []Hash
+	closed  bool
+	lk      sync.Mutex
+}
+
+// CommitStream returns a new stream for committing to the chain with the
+// shares deferred until it's flushed or closed
+func (h *Holochain) CommitStream() *CommitStream {
+	return &CommitStream{h: h}
+}
+
+// Write commits an entry to the chain returning its hash.  The entry isn't
+// shared until the stream is flushed or closed.
+func (s *CommitStream) Write(entryType string, entry Entry) (hash Hash, err error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	if s.closed {
+		err = ErrCommitStreamClosed
+		return
+	}
+	a := NewCommitAction(entryType, entry)
+	var def *EntryDef
+	def, err = s.h.doCommit(a, NullHash())
+	if err != nil {
+		return
+	}
+	hash = a.GetHeader().EntryLink
+	s.pending = append(s.pending, streamedCommit{action: a, def: def})
+	s.hashes = append(s.hashes, hash)
+	return
+}
+
+// Flush shares all the entries committed since the last flush, in the order
+// they were committed, each as a separate PUT.  If a share fails the entries
+// from it on stay pending so flushing again retries them.  If a bundle is open
+// the shares are left to the bundle's close, just as for a regular commit.
+func (s *CommitStream) Flush() (err error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	err = s.flush()
+	return
+}
+
+// flush assumes the lock is held
+func (s *CommitStream) flush() (err error) {
+	h := s.h
+	bundle := h.Chain().BundleStarted()
+	for len(s.pending) > 0 {
+		p := s.pending[0]
+		if bundle == nil {
+			err = h.share(p.action, p.def)
+			if err != nil {
+				return
+			}
+		} else {
+			bundle.sharing = append(bundle.sharing, p.action)
+		}
+		s.pending = s.pending[1:]
+	}
+	return
+}
+
+// Close flushes the stream and returns the hashes of all the entries written
+// to it in commit order.  Writing to a closed stream is an error.
+func (s *CommitStream) Close() (hashes []Hash, err error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	if s.closed {
+		err = ErrCommitStreamClosed
+		return
+	}
+	err = s.flush()
+	if err != nil {
+		return
+	}
+	s.closed = true
+	hashes = s.hashes
+	return
+}
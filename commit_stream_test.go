@@ -0,0 +1,59 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestCommitStream(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	s := h.CommitStream()
+	var written []Hash
+
+	Convey("written entries should be committed in order but not shared", t, func() {
+		l := h.ChainLength()
+		for _, n := range []string{"2", "4", "6"} {
+			hash, err := s.Write("evenNumbers", &GobEntry{C: n})
+			So(err, ShouldBeNil)
+			written = append(written, hash)
+		}
+		So(h.ChainLength(), ShouldEqual, l+3)
+		for i, hash := range written {
+			So(h.chain.Headers[l+i].EntryLink.Equal(hash), ShouldBeTrue)
+			So(h.dht.Exists(hash, StatusLive), ShouldEqual, ErrHashNotFound)
+		}
+	})
+
+	Convey("entries should still be validated", t, func() {
+		_, err := s.Write("evenNumbers", &GobEntry{C: "5"})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("flushing should share the entries", t, func() {
+		So(s.Flush(), ShouldBeNil)
+		for _, hash := range written {
+			So(h.dht.Exists(hash, StatusLive), ShouldBeNil)
+		}
+	})
+
+	Convey("close should flush and return the hashes in commit order", t, func() {
+		hash, err := s.Write("evenNumbers", &GobEntry{C: "8"})
+		So(err, ShouldBeNil)
+		written = append(written, hash)
+
+		hashes, err := s.Close()
+		So(err, ShouldBeNil)
+		So(hashes, ShouldResemble, written)
+		So(h.dht.Exists(hash, StatusLive), ShouldBeNil)
+	})
+
+	Convey("a closed stream should refuse writes", t, func() {
+		_, err := s.Write("evenNumbers", &GobEntry{C: "10"})
+		So(err, ShouldEqual, ErrCommitStreamClosed)
+		_, err = s.Close()
+		So(err, ShouldEqual, ErrCommitStreamClosed)
+	})
+}
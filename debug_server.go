@@ -0,0 +1,187 @@
+package holochain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+)
+
+var ErrDebugServerRunning = errors.New("debug server already running")
+
+// debugServerShutdownTimeout bounds how long closing the holochain waits for
+// in flight debug requests to finish
+const debugServerShutdownTimeout = time.Second * 5
+
+// DebugStatus is the summary of a node's state served at /status
+type DebugStatus struct {
+	Name        string
+	DNAHash     Hash
+	NodeID      string
+	ChainLength int
+	Migration   DebugMigrationStatus
+}
+
+// DebugMigrationStatus is the migration status of a node's chain served at
+// /migration
+type DebugMigrationStatus struct {
+	Closed    bool
+	TargetDNA Hash
+}
+
+// DebugPeerRecord is a world model record with the peer id in its string form,
+// as served at /world
+type DebugPeerRecord struct {
+	ID          string
+	LastSeen    time.Time
+	Holding     []Hash
+	Responsible []Hash
+}
+
+type debugError struct {
+	Error string
+}
+
+// ServeDebug starts an HTTP server on addr answering GET requests for the
+// node's state as JSON at:
+//
+//	/status     name, DNA hash, node id, chain length and migration status
+//	/entrydefs  all the entry definitions, as from EntryDefs
+//	/holding    the number of live entries held by type, as from CountHolding
+//	/world      the world model records, as from WorldModel
+//	/bridges    the bridges, as from GetBridges
+//	/migration  the migration status, as from MigrationStatus
+//
+// The server is read only and not started unless this is called.  It returns
+// once addr is bound, and keeps serving until StopDebug is called or the
+// holochain is closed.
+func (h *Holochain) ServeDebug(addr string) (err error) {
+	h.debugLk.Lock()
+	defer h.debugLk.Unlock()
+	if h.debugServer != nil {
+		err = ErrDebugServerRunning
+		return
+	}
+	var l net.Listener
+	l, err = net.Listen("tcp", addr)
+	if err != nil {
+		return
+	}
+
+	mux := http.NewServeMux()
+	h.handleDebug(mux, "/status", func() (v interface{}, err error) {
+		closed, targetDNA, err := h.MigrationStatus()
+		if err != nil {
+			return
+		}
+		v = DebugStatus{
+			Name:        h.Name(),
+			DNAHash:     h.dnaHash,
+			NodeID:      h.nodeIDStr,
+			ChainLength: h.chain.Length(),
+			Migration:   DebugMigrationStatus{Closed: closed, TargetDNA: targetDNA},
+		}
+		return
+	})
+	h.handleDebug(mux, "/entrydefs", func() (v interface{}, err error) {
+		v = h.EntryDefs()
+		return
+	})
+	h.handleDebug(mux, "/holding", func() (v interface{}, err error) {
+		v, err = h.CountHolding()
+		return
+	})
+	h.handleDebug(mux, "/world", func() (v interface{}, err error) {
+		records, err := h.WorldModel()
+		if err != nil {
+			return
+		}
+		peers := make([]DebugPeerRecord, len(records))
+		for i, r := range records {
+			peers[i] = DebugPeerRecord{ID: r.ID.Pretty(), LastSeen: r.LastSeen, Holding: r.Holding, Responsible: r.Responsible}
+		}
+		sort.Slice(peers, func(i, j int) bool { return peers[i].ID < peers[j].ID })
+		v = peers
+		return
+	})
+	h.handleDebug(mux, "/bridges", func() (v interface{}, err error) {
+		bridges, err := h.GetBridges()
+		if err != nil {
+			return
+		}
+		if bridges == nil {
+			bridges = []Bridge{}
+		}
+		v = bridges
+		return
+	})
+	h.handleDebug(mux, "/migration", func() (v interface{}, err error) {
+		closed, targetDNA, err := h.MigrationStatus()
+		v = DebugMigrationStatus{Closed: closed, TargetDNA: targetDNA}
+		return
+	})
+
+	h.debugServer = &http.Server{Handler: mux}
+	h.debugAddr = l.Addr()
+	go func(s *http.Server) {
+		// when the server is stopped by Shutdown() Serve returns with ErrServerClosed
+		if err := s.Serve(l); err != nil && err != http.ErrServerClosed {
+			h.Debugf("debug server stopped: %v", err)
+		}
+	}(h.debugServer)
+	h.Debugf("debug server listening on %v", h.debugAddr)
+	return
+}
+
+// DebugAddr returns the address the debug server is bound to, or nil if it
+// isn't running
+func (h *Holochain) DebugAddr() (addr net.Addr) {
+	h.debugLk.Lock()
+	defer h.debugLk.Unlock()
+	addr = h.debugAddr
+	return
+}
+
+// StopDebug shuts down the debug server, waiting for in flight requests to be
+// answered.  It does nothing if the server isn't running.
+func (h *Holochain) StopDebug() (err error) {
+	h.debugLk.Lock()
+	defer h.debugLk.Unlock()
+	if h.debugServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), debugServerShutdownTimeout)
+	defer cancel()
+	err = h.debugServer.Shutdown(ctx)
+	h.debugServer = nil
+	h.debugAddr = nil
+	return
+}
+
+// handleDebug registers a read only JSON endpoint on the debug server
+func (h *Holochain) handleDebug(mux *http.ServeMux, path string, fn func() (interface{}, error)) {
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(debugError{Error: "method not allowed"})
+			return
+		}
+		v, err := fn()
+		if err != nil {
+			code := http.StatusInternalServerError
+			if err == ErrWorldModelNotEnabled {
+				code = http.StatusNotFound
+			}
+			w.WriteHeader(code)
+			json.NewEncoder(w).Encode(debugError{Error: err.Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(v)
+	})
+}
@@ -0,0 +1,94 @@
+package holochain
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func getDebug(h *Holochain, path string, v interface{}) (code int, err error) {
+	resp, err := http.Get(fmt.Sprintf("http://%s%s", h.DebugAddr(), path))
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	code = resp.StatusCode
+	err = json.NewDecoder(resp.Body).Decode(v)
+	return
+}
+
+func TestServeDebug(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should be off by default", t, func() {
+		So(h.DebugAddr(), ShouldBeNil)
+		So(h.StopDebug(), ShouldBeNil)
+	})
+
+	Convey("it should bind when started and only once", t, func() {
+		So(h.ServeDebug("127.0.0.1:0"), ShouldBeNil)
+		So(h.DebugAddr(), ShouldNotBeNil)
+		So(h.ServeDebug("127.0.0.1:0"), ShouldEqual, ErrDebugServerRunning)
+	})
+
+	Convey("it should serve the node status", t, func() {
+		var status DebugStatus
+		code, err := getDebug(h, "/status", &status)
+		So(err, ShouldBeNil)
+		So(code, ShouldEqual, http.StatusOK)
+		So(status.Name, ShouldEqual, h.Name())
+		So(status.DNAHash.Equal(h.dnaHash), ShouldBeTrue)
+		So(status.NodeID, ShouldEqual, h.nodeIDStr)
+		So(status.ChainLength, ShouldEqual, h.chain.Length())
+		So(status.Migration.Closed, ShouldBeFalse)
+	})
+
+	Convey("it should serve the entry defs and holding counts", t, func() {
+		var defs map[string]EntryDef
+		code, err := getDebug(h, "/entrydefs", &defs)
+		So(err, ShouldBeNil)
+		So(code, ShouldEqual, http.StatusOK)
+		So(len(defs), ShouldEqual, len(h.EntryDefs()))
+		So(defs["evenNumbers"].Name, ShouldEqual, "evenNumbers")
+
+		var counts map[string]int
+		code, err = getDebug(h, "/holding", &counts)
+		So(err, ShouldBeNil)
+		So(code, ShouldEqual, http.StatusOK)
+		expected, _ := h.CountHolding()
+		So(counts, ShouldResemble, expected)
+	})
+
+	Convey("it should serve the bridges and migration status", t, func() {
+		var bridges []Bridge
+		code, err := getDebug(h, "/bridges", &bridges)
+		So(err, ShouldBeNil)
+		So(code, ShouldEqual, http.StatusOK)
+		So(len(bridges), ShouldEqual, 0)
+
+		var migration DebugMigrationStatus
+		code, err = getDebug(h, "/migration", &migration)
+		So(err, ShouldBeNil)
+		So(code, ShouldEqual, http.StatusOK)
+		So(migration.Closed, ShouldBeFalse)
+	})
+
+	Convey("it should be read only", t, func() {
+		resp, err := http.Post(fmt.Sprintf("http://%s/status", h.DebugAddr()), "application/json", strings.NewReader("{}"))
+		So(err, ShouldBeNil)
+		resp.Body.Close()
+		So(resp.StatusCode, ShouldEqual, http.StatusMethodNotAllowed)
+	})
+
+	Convey("it should stop when the holochain is closed", t, func() {
+		addr := h.DebugAddr().String()
+		h.Close()
+		So(h.DebugAddr(), ShouldBeNil)
+		_, err := http.Get(fmt.Sprintf("http://%s/status", addr))
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -13,9 +13,11 @@ import (
 	"gopkg.in/mgo.v2/bson"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 
 	. "github.com/holochain/holochain-proto/hash"
 	peer "github.com/libp2p/go-libp2p-peer"
+	pset "github.com/libp2p/go-libp2p-peer/peerset"
 )
 
 type HashType string
@@ -28,12 +30,13 @@ type DHTConfig struct {
 	//RedundancyFactor(integer) Establishes minimum online redundancy targets for data, and size of peer sets for sync gossip. A redundancy factor ZERO means no sharding (every node syncs all data with every other node). ONE means you are running this as a centralized application and gossip is turned OFF. For most applications we recommend neighborhoods no smaller than 8 for nearness or 32 for hashmask sharding.
 	RedundancyFactor int
 
+	// ValidationTimeout : (integer) Time period in milliseconds an app's validation callback may run before the commit or hold it's validating fails with ErrValidationTimeout. It's in the DNA so that every node gives validators the same time, ZERO means DefaultValidationTimeout.
+	ValidationTimeout int
+
 	// ShardingMethod : Identifier for sharding method (none, XOR, hashmask, other nearness algorithms?, etc.)
 
 	// MaxLinkSets : (integer) Maximum number of results to return on a GetLinks query to keep computation and traffic to a reasonable size. You need to break these result sets into multiple "pages" of results retrieve more.
 
-	// ValidationTimeout : (integer) Time period in seconds, until data that needs to be validated against a source remains "alive" to keep trying to get validation from that source. If someone commits something and then goes offline, how long do they have to come back online before DHT sync requests consider that data invalid?
-
 	//PeerTimeout : (integer) Time period in seconds, until a node drops a peer from its neighborhood list for failing to respond to gossip requests.
 
 	// WireEncryption : settings for point-to-point encryption of messages on the network (none, AES, what are the options?)
@@ -55,12 +58,24 @@ type DHT struct {
 	ht          HashTable
 	retryQueue  chan *retry
 	changeQueue Channel
-	gossipPuts  Channel
-	glog        *Logger // the gossip logger
-	dlog        *Logger // the dht logger
-	gchan       Channel
-	config      *DHTConfig
-	glk         sync.RWMutex
+	// the number of changes taken off the queue but not yet sent
+	changesInFlight int32
+	ops             *opTracker   // outgoing changes and queries, see PendingOps
+	transport       DHTTransport // carries messages to peers if set, otherwise the node does
+	gossipPuts      Channel
+	glog            *Logger // the gossip logger
+	dlog            *Logger // the dht logger
+	gchan           Channel
+	config          *DHTConfig
+	glk             sync.RWMutex
+
+	subscriptions []*EntryTypeSubscription
+	holdHandlers  []*HoldHandler
+	slk           sync.RWMutex
+	putIfLk       sync.Mutex // serializes conditional puts
+
+	gossipCompleteHandlers []*gossipCompleteHandler
+	changeFeed             *changeFeed
 	//	sources      map[peer.ID]bool
 	//	fingerprints map[string]bool
 }
@@ -68,6 +83,9 @@ type DHT struct {
 type changeReq struct {
 	key Hash
 	msg Message
+	op  *pendingOp
+	// how many peers to send the change to, 0 for the DHT's default
+	redundancy int
 }
 
 type retry struct {
@@ -96,6 +114,7 @@ type GetReq struct {
 	H          Hash
 	StatusMask int
 	GetMask    int
+	Fields     []string // if set only these top-level fields of a JSON entry are returned, see GetFields
 }
 
 // GetResp holds the data of a get response
@@ -103,7 +122,72 @@ type GetResp struct {
 	Entry      GobEntry
 	EntryType  string
 	Sources    []string
-	FollowHash string // hash of new entry if the entry was modified and needs following
+	FollowHash string    // hash of new entry if the entry was modified and needs following
+	DelReason  string    // why the entry was deleted, only set if it's been deleted
+	Header     *Header   // only set if requested with GetMaskHeader
+	Holders    []peer.ID // only set if requested with GetMaskHolders
+	Status     int       // the entry's current status, only set if requested with GetMaskStatus
+}
+
+// GetBatchReq holds the data of a get request for multiple hashes
+type GetBatchReq struct {
+	Hashes     []Hash
+	StatusMask int
+	GetMask    int
+}
+
+// GetBatchResp holds the responses of a batched get request keyed by hash
+type GetBatchResp struct {
+	Responses map[string]GetResp
+	Errors    map[string]ErrorResponse
+}
+
+// MigrationStatusReq asks a node whether its chain of the given DNA has been
+// closed by a migrate
+type MigrationStatusReq struct {
+	DNAHash Hash
+}
+
+// MigrationStatusResp holds a node's answer to a MigrationStatusReq
+type MigrationStatusResp struct {
+	Closed    bool
+	TargetDNA Hash
+}
+
+// MigrationTargetsReq asks a node for the migrates it holds out of a DNA
+type MigrationTargetsReq struct {
+	SourceDNAHash Hash
+}
+
+// MigrationTargetsResp holds a node's answer to a MigrationTargetsReq: the
+// DNA each migrate it holds out of the source migrated to, keyed by the
+// migrate's hash so migrates held by more than one node are only counted once
+type MigrationTargetsResp struct {
+	Targets map[string]string
+}
+
+// Pagination selects a page of the results of a query
+type Pagination struct {
+	Offset int
+	Limit  int // zero for no limit
+}
+
+// page returns the bounds of the page within n results, and the offset of the
+// page after it which is zero if this is the last page
+func (p Pagination) page(n int) (start, end, next int) {
+	start = p.Offset
+	if start < 0 {
+		start = 0
+	}
+	if start > n {
+		start = n
+	}
+	end = n
+	if p.Limit > 0 && start+p.Limit < n {
+		end = start + p.Limit
+		next = end
+	}
+	return
 }
 
 // LinkQuery holds a getLinks query
@@ -111,7 +195,8 @@ type LinkQuery struct {
 	Base       Hash
 	T          string
 	StatusMask int
-	// order
+	// if paginated, links are ordered by hash and then tag so pages are stable
+	Pagination Pagination
 	// filter, etc
 }
 
@@ -127,11 +212,14 @@ type GetOptions struct {
 type GetLinksOptions struct {
 	Load       bool // indicates whether GetLinks should retrieve the entries of all links
 	StatusMask int  // mask of which status of links to return
+	Offset     int  // index of the first link to return
+	Limit      int  // maximum number of links to return, zero for all of them
 }
 
 // LinkQueryResp holds response to getLinks query
 type LinkQueryResp struct {
-	Links []TaggedHash
+	Links      []TaggedHash
+	NextOffset int // offset of the next page of links, zero if there are no more
 }
 
 type ListAddReq struct {
@@ -170,6 +258,8 @@ func (dht *DHT) Open(options interface{}) (err error) {
 	dht.ht.Open(filepath.Join(h.DBPath(), DHTStoreFileName))
 	dht.retryQueue = make(chan *retry, 100)
 	dht.changeQueue = make(Channel, 100)
+	dht.ops = newOpTracker()
+	dht.changeFeed = &changeFeed{}
 	//go dht.HandleChangeRequests()
 
 	//	dht.sources = make(map[peer.ID]bool)
@@ -246,7 +336,22 @@ func (dht *DHT) SetupDHT() (err error) {
 // N.B. This call assumes that the value has already been validated
 func (dht *DHT) Put(m *Message, entryType string, key Hash, src peer.ID, value []byte, status int) (err error) {
 	dht.dlog.Logf("put %v=>%s", key, string(value))
+	if dht.h.logEnabled(LogInfo) {
+		dht.h.log(LogInfo, "dht put", LogField{"hash", key}, LogField{"type", entryType}, LogField{"status", status})
+	}
+	newlyHeld := status == StatusLive && dht.hasHoldHandlers() && dht.ht.Exists(key, StatusLive) != nil
+	dht.changeFeed.lk.Lock()
 	err = dht.ht.Put(m, entryType, key, src, value, status)
+	if err == nil {
+		dht.changed(m, PUT_REQUEST, entryType, key, status)
+	}
+	dht.changeFeed.lk.Unlock()
+	if err == nil && status == StatusLive {
+		dht.notifySubscribers(entryType, key)
+		if newlyHeld {
+			dht.notifyHoldHandlers(entryType, key)
+		}
+	}
 	return
 }
 
@@ -254,7 +359,12 @@ func (dht *DHT) Put(m *Message, entryType string, key Hash, src peer.ID, value [
 // N.B. this functions assumes that the validity of this action has been confirmed
 func (dht *DHT) Del(m *Message, key Hash) (err error) {
 	dht.dlog.Logf("del %v", key)
+	dht.changeFeed.lk.Lock()
+	defer dht.changeFeed.lk.Unlock()
 	err = dht.ht.Del(m, key)
+	if err == nil {
+		dht.changed(m, DEL_REQUEST, "", key, StatusDeleted)
+	}
 	return
 }
 
@@ -262,12 +372,20 @@ func (dht *DHT) Del(m *Message, key Hash) (err error) {
 // N.B. this functions assumes that the validity of this action has been confirmed
 func (dht *DHT) Mod(m *Message, key Hash, newkey Hash) (err error) {
 	dht.dlog.Logf("mod %v", key)
+	dht.changeFeed.lk.Lock()
+	defer dht.changeFeed.lk.Unlock()
 	err = dht.ht.Mod(m, key, newkey)
+	if err == nil {
+		dht.changed(m, MOD_REQUEST, "", key, StatusModified)
+	}
 	return
 }
 
 // Exists checks for the existence of the hash in the store
 func (dht *DHT) Exists(key Hash, statusMask int) (err error) {
+	if _, err = dht.expireIfDue(key); err != nil {
+		return
+	}
 	err = dht.ht.Exists(key, statusMask)
 	return
 }
@@ -280,7 +398,78 @@ func (dht *DHT) Source(key Hash) (id peer.ID, err error) {
 
 // Get retrieves a value from the DHT store
 func (dht *DHT) Get(key Hash, statusMask int, getMask int) (data []byte, entryType string, sources []string, status int, err error) {
+	if _, err = dht.expireIfDue(key); err != nil {
+		return
+	}
 	data, entryType, sources, status, err = dht.ht.Get(key, statusMask, getMask)
+	if dht.h.logEnabled(LogDebug) {
+		dht.h.log(LogDebug, "dht get", LogField{"hash", key}, LogField{"status", status}, LogField{"err", err})
+	}
+	return
+}
+
+// CountByType returns the number of entries matching the status mask that
+// this node holds, grouped by entry type
+func (dht *DHT) CountByType(statusMask int) (counts map[string]int, err error) {
+	counts, err = dht.ht.CountByType(statusMask)
+	return
+}
+
+// PutHeader stores the marshaled header of a held entry
+func (dht *DHT) PutHeader(key Hash, header []byte) (err error) {
+	err = dht.ht.PutHeader(key, header)
+	return
+}
+
+// GetHeader retrieves the marshaled header of a held entry
+func (dht *DHT) GetHeader(key Hash) (header []byte, err error) {
+	header, err = dht.ht.GetHeader(key)
+	return
+}
+
+// PutDelReason stores the reason a held entry was deleted
+func (dht *DHT) PutDelReason(key Hash, reason string) (err error) {
+	err = dht.ht.PutDelReason(key, reason)
+	return
+}
+
+// GetDelReason retrieves the reason a held entry was deleted
+func (dht *DHT) GetDelReason(key Hash) (reason string, err error) {
+	reason, err = dht.ht.GetDelReason(key)
+	return
+}
+
+// putEntryHeader marshals and stores the header of a held entry put to us by
+// its author, indexing it for DetectFork and recording when the entry expires
+// if its type has a TTL
+func (dht *DHT) putEntryHeader(author peer.ID, key Hash, header *Header) (err error) {
+	var b []byte
+	b, err = header.Marshal()
+	if err != nil {
+		return
+	}
+	err = dht.PutHeader(key, b)
+	if err == nil {
+		err = dht.indexHeader(author, header)
+	}
+	if err == nil {
+		err = dht.setExpiry(header.Type, key, header.Time)
+	}
+	return
+}
+
+// getEntryHeader retrieves and unmarshals the header of a held entry
+func (dht *DHT) getEntryHeader(key Hash) (header *Header, err error) {
+	var b []byte
+	b, err = dht.GetHeader(key)
+	if err != nil {
+		return
+	}
+	var hd Header
+	err = hd.Unmarshal(b, 34)
+	if err == nil {
+		header = &hd
+	}
 	return
 }
 
@@ -289,6 +478,9 @@ func (dht *DHT) Get(key Hash, statusMask int, getMask int) (data []byte, entryTy
 // and validated from the cource chain
 func (dht *DHT) PutLink(m *Message, base string, link string, tag string) (err error) {
 	dht.dlog.Logf("putLink on %v link %v as %s", base, link, tag)
+	// links are logged too so hold the change feed's lock for its indexes
+	dht.changeFeed.lk.Lock()
+	defer dht.changeFeed.lk.Unlock()
 	err = dht.ht.PutLink(m, base, link, tag)
 	return
 }
@@ -297,6 +489,8 @@ func (dht *DHT) PutLink(m *Message, base string, link string, tag string) (err e
 // N.B. this function assumes that the action has been properly validated
 func (dht *DHT) DelLink(m *Message, base string, link string, tag string) (err error) {
 	dht.dlog.Logf("delLink on %v link %v as %s", base, link, tag)
+	dht.changeFeed.lk.Lock()
+	defer dht.changeFeed.lk.Unlock()
 	err = dht.ht.DelLink(m, base, link, tag)
 	return
 }
@@ -316,15 +510,20 @@ func (dht *DHT) HandleChangeRequests() (err error) {
 
 func handleChangeRequests(dht *DHT, x interface{}) (err error) {
 	req := x.(changeReq)
-	err = dht.change(req)
+	atomic.AddInt32(&dht.changesInFlight, 1)
+	defer atomic.AddInt32(&dht.changesInFlight, -1)
+	err = dht.change(nil, req)
 	return
 }
 
-func (dht *DHT) sendChange(p peer.ID, msg *Message) (held bool, err error) {
+func (dht *DHT) sendChange(ctx context.Context, p peer.ID, msg *Message) (held bool, err error) {
 	if dht == nil || dht.h.node == nil {
 		return
 	}
-	ctx, cancel := context.WithCancel(dht.h.node.ctx)
+	if ctx == nil {
+		ctx = dht.h.node.ctx
+	}
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	resp, err := dht.send(ctx, p, msg)
@@ -340,6 +539,10 @@ func (dht *DHT) sendChange(p peer.ID, msg *Message) (held bool, err error) {
 			held = true
 			// TODO check the signature on the receipt
 		case CloserPeersResp:
+			if dht.transport != nil {
+				// the transport already knows all the peers
+				break
+			}
 			closerPeers := peerInfos2Pis(t.CloserPeers)
 			//	s := fmt.Sprintf("%v says closer to %v are: ", p.Pretty()[2:4], key)
 
@@ -356,24 +559,61 @@ func (dht *DHT) sendChange(p peer.ID, msg *Message) (held bool, err error) {
 	return
 }
 
-func (dht *DHT) change(req changeReq) (err error) {
+func (dht *DHT) change(ctx context.Context, req changeReq) (err error) {
 	key := req.key
 	msg := &req.msg
 	node := dht.h.node
-	pchan, err := node.GetClosestPeers(node.ctx, key)
-	if err != nil {
-		return err
+	if ctx == nil {
+		ctx = node.ctx
+	}
+	if req.op != nil {
+		ctx, _, err = dht.ops.start(ctx, req.op)
+		defer func() {
+			if e := dht.ops.done(req.op, err); e != nil {
+				err = e
+			}
+		}()
+		if err != nil {
+			return
+		}
+	}
+	var pchan <-chan peer.ID
+	if dht.transport != nil {
+		var peers []peer.ID
+		if req.redundancy > 0 {
+			peers = dht.transport.ClosestPeers(dht.h, key, req.redundancy)
+		} else {
+			peers = dht.transport.ResponsiblePeers(dht.h, key)
+		}
+		c := make(chan peer.ID, len(peers))
+		for _, p := range peers {
+			c <- p
+		}
+		close(c)
+		pchan = c
+	} else {
+		pchan, err = node.GetClosestPeers(ctx, key)
+		if err != nil {
+			return err
+		}
 	}
 	var held []peer.ID
+	sent := 0
 	wg := sync.WaitGroup{}
 	for p := range pchan {
 		if p == node.HashAddr {
 			continue
 		}
+		// the peers come closest first, the rest are skipped
+		if req.redundancy > 0 && sent >= req.redundancy {
+			continue
+		}
+		sent++
 		wg.Add(1)
 		go func(p peer.ID) {
 			defer wg.Done()
-			wasHeld, err := dht.sendChange(p, msg)
+			defer dht.ops.sending(req.op, p)()
+			wasHeld, err := dht.sendChange(ctx, p, msg)
 			if err != nil {
 				dht.dlog.Logf("DHT sendChange of %v failed to peer %v with error: %s", msg.Type, p, err)
 			} else if wasHeld {
@@ -393,32 +633,145 @@ func (dht *DHT) change(req changeReq) (err error) {
 	return
 }
 
+// withNodeContext returns a context that is done when either ctx or the
+// node's own context is done, so operations still stop when the node closes
+func (dht *DHT) withNodeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	merged, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-dht.h.node.ctx.Done():
+			cancel()
+		case <-merged.Done():
+		}
+	}()
+	return merged, cancel
+}
+
 // Change sends DHT change messages to the closest peers to the hash in question
 func (dht *DHT) Change(key Hash, msgType MsgType, body interface{}) (err error) {
+	return dht.ChangeCtx(context.Background(), key, msgType, body)
+}
+
+// ChangeCtx is Change aborting with ctx.Err() if ctx is done before the local
+// change completes.  The change to peers is queued and so isn't canceled.
+func (dht *DHT) ChangeCtx(ctx context.Context, key Hash, msgType MsgType, body interface{}) (err error) {
+	_, err = dht.changeCtx(ctx, key, msgType, body, 0)
+	return
+}
+
+// sharePut sends the put of an entry to the number of peers its def's
+// Redundancy asks for, returning the id of the operation sending it to them
+// for WaitOp and CancelOp
+func (dht *DHT) sharePut(key Hash, def *EntryDef) (opID string, err error) {
+	return dht.changeCtx(context.Background(), key, PUT_REQUEST, HoldReq{EntryHash: key}, def.Redundancy)
+}
+
+// changeCtx makes the change locally and then sends it to peers, returning
+// the id of the operation sending it, which is empty if the local change failed
+func (dht *DHT) changeCtx(ctx context.Context, key Hash, msgType MsgType, body interface{}, redundancy int) (opID string, err error) {
 	dht.h.Debugf("Starting %v Change for %v with body %v", msgType, key, body)
 
+	ctx, cancel := dht.withNodeContext(ctx)
+	defer cancel()
 	msg := dht.h.node.NewMessage(msgType, body)
 	// change in our local DHT
-	_, err = dht.send(nil, dht.h.nodeID, msg)
+	_, err = dht.send(ctx, dht.h.nodeID, msg)
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
 	if err != nil {
-		return err
+		return
 	}
 	/*	if err != nil {
 		dht.dlog.Logf("DHT send of %v to self failed with error: %s", msgType, err)
 		err = nil
 	}*/
-	dht.changeQueue <- changeReq{msg: *msg, key: key}
+	req := changeReq{msg: *msg, key: key, op: dht.ops.add(msgType, key), redundancy: redundancy}
+	opID = req.op.id
+	if dht.transport != nil {
+		// a transport sends the change to peers before we return
+		err = dht.change(ctx, req)
+		return
+	}
+	dht.changeQueue <- req
 
 	return
 }
 
 // Query sends DHT query messages recursively to peers until one is able to respond.
 func (dht *DHT) Query(key Hash, msgType MsgType, body interface{}) (response interface{}, err error) {
+	return dht.QueryCtx(context.Background(), key, msgType, body)
+}
+
+// GetCtx gets an entry from the DHT, aborting with ctx.Err() if ctx is done
+// before a peer responds.  If we hold the entry ourselves our copy is
+// returned.  Otherwise a get whose response can differ between peers, i.e.
+// one asking for the status, sources or header, or for entries of other than
+// StatusLive, waits for all the peers that have it and chooses between their
+// responses deterministically, see preferGetResult, and a StatusLive get of
+// just the entry takes the first response.  A chosen peer's hash to follow is
+// returned with ErrHashModified.  If none of the closest peers have it the get
+// falls back to more peers, see Config.GetFallbackFactor.
+func (dht *DHT) GetCtx(ctx context.Context, key Hash, statusMask int, getMask int) (response GetResp, err error) {
+	var r interface{}
+	r, err = dht.QueryCtx(ctx, key, GET_REQUEST, GetReq{H: key, StatusMask: statusMask, GetMask: getMask})
+	if t, ok := r.(GetResp); ok {
+		response = t
+	} else if err == nil {
+		err = fmt.Errorf("expected GetResp response from GET_REQUEST, got: %T", r)
+	}
+	return
+}
+
+// getStatusPrecedence ranks the statuses of an entry returned by different
+// peers, higher is preferred: Live > Modified > Deleted > Rejected
+func getStatusPrecedence(status int) int {
+	switch status {
+	case StatusLive:
+		return 4
+	case StatusModified:
+		return 3
+	case StatusDeleted:
+		return 2
+	case StatusRejected:
+		return 1
+	}
+	return 0
+}
+
+// preferGetResult returns the GET_REQUEST result to use of those returned by
+// several peers so that the same responses always give the same result: the
+// one with the status of highest precedence and, of those with equal status,
+// the one from the peer with the lexicographically smallest b58 encoded ID
+func preferGetResult(results []*dhtQueryResult) (best *dhtQueryResult) {
+	var bestRank int
+	var bestPeer string
+	for _, res := range results {
+		rank := getStatusPrecedence(res.response.(GetResp).Status)
+		from := peer.IDB58Encode(res.from)
+		if best == nil || rank > bestRank || rank == bestRank && from < bestPeer {
+			best, bestRank, bestPeer = res, rank, from
+		}
+	}
+	return
+}
+
+// QueryCtx is Query aborting with ctx.Err() if ctx is done before a peer responds
+func (dht *DHT) QueryCtx(ctx context.Context, key Hash, msgType MsgType, body interface{}) (response interface{}, err error) {
 	dht.h.Debugf("Starting %v Query for %v with body %v", msgType, key, body)
 
+	ctx, cancel := dht.withNodeContext(ctx)
+	defer cancel()
+	defer func() {
+		if err != nil && ctx.Err() != nil {
+			response = nil
+			err = ctx.Err()
+		}
+	}()
+
 	msg := dht.h.node.NewMessage(msgType, body)
 	// try locally first
-	response, err = dht.send(nil, dht.h.nodeID, msg)
+	response, err = dht.send(ctx, dht.h.nodeID, msg)
 	if err == nil {
 		// if we actually got a response (not a closer peers list) then return it
 		_, notok := response.(CloserPeersResp)
@@ -435,15 +788,49 @@ func (dht *DHT) Query(key Hash, msgType MsgType, body interface{}) (response int
 	// get closest peers in the routing table
 	rtp := dht.h.node.routingTable.NearestPeers(key, AlphaValue)
 	dht.h.Debugf("peers in rt: %d %s", len(rtp), rtp)
-	if len(rtp) == 0 {
+	if len(rtp) == 0 && dht.transport == nil {
 		Info("DHT Query with no peers in routing table!")
 		return nil, ErrHashNotFound
 	}
 
+	// a get whose response can differ between the peers that have the entry,
+	// i.e. asking for its status, sources or header, or for entries of any
+	// status or that may have been modified, waits for all of them so that it
+	// can choose between them, which needs their statuses.  Only a get of a
+	// live entry stops at the first peer that has it, as being content
+	// addressed the entry is the same whoever sends it.
+	var collect, statusRequested bool
+	if req, ok := body.(GetReq); ok && msgType == GET_REQUEST && (req.GetMask&(GetMaskStatus|GetMaskSources|GetMaskHeader) != 0 || req.StatusMask != StatusLive) {
+		collect = true
+		statusRequested = req.GetMask&GetMaskStatus != 0
+		req.GetMask |= GetMaskStatus
+		msg = dht.h.node.NewMessage(msgType, req)
+	}
+
+	// track the query so it can be canceled while it waits on peers
+	op := dht.ops.add(msgType, key)
+	opCtx, _, err := dht.ops.start(ctx, op)
+	defer func() {
+		if e := dht.ops.done(op, err); e != nil {
+			response = nil
+			err = e
+		}
+	}()
+	if err != nil {
+		return
+	}
+
 	// setup the Query
 	query := dht.h.node.newQuery(key, func(ctx context.Context, to peer.ID) (*dhtQueryResult, error) {
+		defer dht.ops.sending(op, to)()
 
 		response, err := dht.send(ctx, to, msg)
+		if t, ok := response.(GetResp); ok && err == ErrHashModified && t.FollowHash != "" {
+			// a peer that knows the entry was modified answers with the
+			// hash to follow, which is chosen between like the others
+			t.Status = StatusModified
+			return &dhtQueryResult{success: true, response: t}, nil
+		}
 		if err != nil {
 			dht.h.Debugf("Query failed: %v", err)
 			return nil, err
@@ -468,15 +855,164 @@ func (dht *DHT) Query(key Hash, msgType MsgType, body interface{}) (response int
 		}
 		return res, nil
 	})
+	query.collect = collect
 
 	// run it!
 	var result *dhtQueryResult
-	result, err = query.Run(dht.h.node.ctx, rtp)
+	if dht.transport != nil {
+		result, err = dht.queryTransport(opCtx, key, query.qfunc, query.collect)
+	} else {
+		result, err = query.Run(opCtx, rtp)
+	}
+	if err != nil && msgType == GET_REQUEST && result != nil && opCtx.Err() == nil {
+		// no peer had it, so before giving up widen the get to the next
+		// closest peers we know of
+		if peers := dht.fallbackPeers(key, result.finalSet); len(peers) > 0 {
+			dht.h.Debugf("widening %v query to %d more peers", key, len(peers))
+			fallback := dht.h.node.newQuery(key, query.qfunc)
+			fallback.collect = collect
+			fallback.exclude = result.finalSet
+			result, err = fallback.Run(opCtx, peers)
+			if err == nil && result == nil {
+				err = ErrHashNotFound
+			}
+		}
+	}
 	if err != nil {
 
 		return nil, err
 	}
 	response = result.response
+	if query.collect {
+		response = preferGetResult(result.results).response
+	}
+	if resp, ok := response.(GetResp); ok && msgType == GET_REQUEST {
+		if !statusRequested {
+			resp.Status = StatusDefault
+		}
+		if resp.FollowHash != "" {
+			err = ErrHashModified
+		}
+		response = resp
+	}
+	return
+}
+
+// queryTransport runs a query on the transport's peers responsible for the
+// key, closest first, stopping at the first that succeeds unless collecting
+func (dht *DHT) queryTransport(ctx context.Context, key Hash, qfunc queryFunc, collect bool) (result *dhtQueryResult, err error) {
+	var results []*dhtQueryResult
+	for _, p := range dht.transport.ResponsiblePeers(dht.h, key) {
+		res, e := qfunc(ctx, p)
+		if e != nil {
+			if err = ctx.Err(); err != nil {
+				return
+			}
+			continue
+		}
+		if !res.success {
+			continue
+		}
+		res.from = p
+		if !collect {
+			result = res
+			return
+		}
+		results = append(results, res)
+	}
+	if len(results) == 0 {
+		err = ErrHashNotFound
+		return
+	}
+	result = &dhtQueryResult{success: true, results: results}
+	return
+}
+
+// fallbackPeers returns the GetFallbackFactor*AlphaValue peers in the routing
+// table next closest to key after the ones a query already tried
+func (dht *DHT) fallbackPeers(key Hash, tried *pset.PeerSet) (peers []peer.ID) {
+	factor := dht.h.Config.GetFallbackFactor
+	if factor <= 0 || tried == nil {
+		return
+	}
+	for _, p := range dht.h.node.routingTable.NearestPeers(key, tried.Size()+factor*AlphaValue) {
+		if !tried.Contains(p) {
+			peers = append(peers, p)
+		}
+	}
+	return
+}
+
+// GetBatch retrieves multiple hashes sending a single GETBATCH_REQUEST to each of the
+// peers responsible for them.  Hashes that a peer couldn't provide are retried with
+// a regular Query, and the ones that still fail are returned in the errs map.
+func (dht *DHT) GetBatch(hashes []Hash, statusMask int, getMask int) (responses map[Hash]GetResp, errs map[Hash]error) {
+	responses = make(map[Hash]GetResp)
+	errs = make(map[Hash]error)
+
+	// group the hashes by the closest peer we know of, including ourselves
+	groups := make(map[peer.ID][]Hash)
+	for _, hash := range hashes {
+		to := dht.h.nodeID
+		rtp := dht.h.node.routingTable.NearestPeers(hash, 1)
+		if len(rtp) > 0 && distance(rtp[0], hash).Cmp(distance(dht.h.nodeID, hash)) < 0 {
+			to = rtp[0]
+		}
+		groups[to] = append(groups[to], hash)
+	}
+
+	var lk sync.Mutex
+	var wg sync.WaitGroup
+	for to, group := range groups {
+		wg.Add(1)
+		go func(to peer.ID, group []Hash) {
+			defer wg.Done()
+			msg := dht.h.node.NewMessage(GETBATCH_REQUEST, GetBatchReq{Hashes: group, StatusMask: statusMask, GetMask: getMask})
+			response, err := dht.send(nil, to, msg)
+			lk.Lock()
+			defer lk.Unlock()
+			if err != nil {
+				for _, hash := range group {
+					errs[hash] = err
+				}
+				return
+			}
+			batch, ok := response.(GetBatchResp)
+			if !ok {
+				err = fmt.Errorf("expected GetBatchResp response from GETBATCH_REQUEST, got: %T", response)
+				for _, hash := range group {
+					errs[hash] = err
+				}
+				return
+			}
+			for _, hash := range group {
+				if errResp, ok := batch.Errors[hash.String()]; ok {
+					errs[hash] = errResp.DecodeResponseError()
+				} else {
+					responses[hash] = batch.Responses[hash.String()]
+				}
+			}
+		}(to, group)
+	}
+	wg.Wait()
+
+	// fall back to the regular recursive query for anything the peer didn't have
+	for hash, err := range errs {
+		if err != ErrHashNotFound {
+			continue
+		}
+		response, err := dht.Query(hash, GET_REQUEST, GetReq{H: hash, StatusMask: statusMask, GetMask: getMask})
+		if err != nil {
+			errs[hash] = err
+			continue
+		}
+		resp, ok := response.(GetResp)
+		if !ok {
+			continue
+		}
+		responses[hash] = resp
+		delete(errs, hash)
+	}
 	return
 }
 
@@ -529,6 +1065,9 @@ func (dht *DHT) JSON() (result string, err error) {
 
 // Close cleans up the DHT
 func (dht *DHT) Close() {
+	if dht.transport != nil {
+		dht.transport.Leave(dht.h)
+	}
 	close(dht.changeQueue)
 	dht.changeQueue = nil
 	close(dht.retryQueue)
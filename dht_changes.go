@@ -0,0 +1,190 @@
+package holochain
+
+import (
+	"context"
+	. "github.com/holochain/holochain-proto/hash"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultChangeStreamBuffer is how many changes can be waiting for a reader
+// of a change stream before further ones are dropped
+const DefaultChangeStreamBuffer = 100
+
+// ChangeEvent is a put, mod or del applied to this node's DHT store
+type ChangeEvent struct {
+	Seq       int     // the change's index in the DHT's change log, 0 if it isn't logged
+	Type      MsgType // PUT_REQUEST, MOD_REQUEST or DEL_REQUEST
+	EntryType string
+	Hash      Hash // the entry put, modified or deleted
+	Status    int  // the entry's status after the change
+}
+
+type changeStream struct {
+	queue chan ChangeEvent
+}
+
+// changeFeed hands the changes to the DHT store to the change streams
+type changeFeed struct {
+	dropped int64 // first so it's aligned for atomic access on 32 bit platforms
+	streams []*changeStream
+	// held over a change and the reading back of its index so that the
+	// index is the change's, and over subscribing so no change is missed
+	lk sync.Mutex
+}
+
+// Changes returns a stream of the puts, mods and dels applied to this node's
+// DHT store from now on, i.e. to build an external index of what it holds.
+// The stream is closed when ctx is done.  Changes are buffered for a slow
+// reader up to Config.ChangeStreamBuffer, past which they're dropped and
+// counted in DroppedChanges.
+func (dht *DHT) Changes(ctx context.Context) (<-chan ChangeEvent, error) {
+	return dht.changes(ctx, -1)
+}
+
+// ChangesSince returns a stream of the changes applied to this node's DHT
+// store after the one with sequence number seq, so a reader can catch up with
+// what it missed while down, 0 replays every change logged.  Changes that
+// aren't logged, i.e. the put of the DNA and expiries, have a Seq of 0 and are
+// only streamed live.
+func (dht *DHT) ChangesSince(ctx context.Context, seq int) (<-chan ChangeEvent, error) {
+	if seq < 0 {
+		seq = 0
+	}
+	return dht.changes(ctx, seq)
+}
+
+// DroppedChanges returns the number of changes that weren't streamed because
+// a reader's buffer was full
+func (dht *DHT) DroppedChanges() int64 {
+	return atomic.LoadInt64(&dht.changeFeed.dropped)
+}
+
+func (dht *DHT) changes(ctx context.Context, since int) (changes <-chan ChangeEvent, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	size := dht.h.Config.ChangeStreamBuffer
+	if size <= 0 {
+		size = DefaultChangeStreamBuffer
+	}
+	stream := &changeStream{queue: make(chan ChangeEvent, size)}
+
+	feed := dht.changeFeed
+	feed.lk.Lock()
+	var replay []ChangeEvent
+	if since >= 0 {
+		var puts []Put
+		puts, err = dht.GetPuts(since + 1)
+		if err != nil {
+			feed.lk.Unlock()
+			return
+		}
+		for _, p := range puts {
+			if event, ok := dht.loggedChangeEvent(p); ok {
+				replay = append(replay, event)
+			}
+		}
+	}
+	feed.streams = append(feed.streams, stream)
+	feed.lk.Unlock()
+
+	out := make(chan ChangeEvent)
+	go func() {
+		defer close(out)
+		defer dht.removeChangeStream(stream)
+		for _, event := range replay {
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		for {
+			select {
+			case event := <-stream.queue:
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	changes = out
+	return
+}
+
+func (dht *DHT) removeChangeStream(stream *changeStream) {
+	feed := dht.changeFeed
+	feed.lk.Lock()
+	defer feed.lk.Unlock()
+	for i, s := range feed.streams {
+		if s == stream {
+			feed.streams = append(feed.streams[:i], feed.streams[i+1:]...)
+			break
+		}
+	}
+}
+
+// loggedChangeEvent rebuilds the change event of an entry of the change log,
+// a put's status is the one its entry has now
+func (dht *DHT) loggedChangeEvent(p Put) (event ChangeEvent, ok bool) {
+	req, isHold := p.M.Body.(HoldReq)
+	if !isHold {
+		return
+	}
+	event = ChangeEvent{Seq: p.Idx, Type: p.M.Type}
+	switch p.M.Type {
+	case PUT_REQUEST:
+		event.Hash = req.EntryHash
+	case MOD_REQUEST:
+		event.Hash = req.RelatedHash
+		event.Status = StatusModified
+	case DEL_REQUEST:
+		event.Hash = req.RelatedHash
+		event.Status = StatusDeleted
+	default:
+		return
+	}
+	_, entryType, _, status, err := dht.ht.Get(event.Hash, StatusAny, GetMaskEntryType)
+	if err != nil {
+		return
+	}
+	event.EntryType = entryType
+	if p.M.Type == PUT_REQUEST {
+		event.Status = status
+	}
+	ok = true
+	return
+}
+
+// changed streams a change just applied to the DHT store, with the feed's
+// lock held.  The entry type is looked up if it's not given.
+func (dht *DHT) changed(m *Message, msgType MsgType, entryType string, key Hash, status int) {
+	feed := dht.changeFeed
+	if len(feed.streams) == 0 {
+		return
+	}
+	event := ChangeEvent{Type: msgType, EntryType: entryType, Hash: key, Status: status}
+	// a change is only logged, and so given an index, with its message
+	if m != nil {
+		idx, err := dht.ht.GetIdx()
+		if err == nil {
+			event.Seq = idx
+		}
+	}
+	if event.EntryType == "" {
+		_, event.EntryType, _, _, _ = dht.ht.Get(key, StatusAny, GetMaskEntryType)
+	}
+	for _, s := range feed.streams {
+		select {
+		case s.queue <- event:
+		default:
+			atomic.AddInt64(&feed.dropped, 1)
+			dht.dlog.Logf("change stream full, dropping %v of %v", msgType, key)
+		}
+	}
+}
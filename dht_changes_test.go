@@ -0,0 +1,113 @@
+package holochain
+
+import (
+	"context"
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestDHTChanges(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	put := func(content string) Hash {
+		e := GobEntry{C: content}
+		hash, err := e.Sum(h.hashSpec)
+		if err != nil {
+			panic(err)
+		}
+		b, _ := e.Marshal()
+		m := h.node.NewMessage(PUT_REQUEST, HoldReq{EntryHash: hash})
+		if err = h.dht.Put(m, "evenNumbers", hash, h.nodeID, b, StatusLive); err != nil {
+			panic(err)
+		}
+		return hash
+	}
+	next := func(changes <-chan ChangeEvent) (event ChangeEvent) {
+		select {
+		case event = <-changes:
+		case <-time.After(time.Second):
+			panic("no change streamed")
+		}
+		return
+	}
+
+	var putSeq int
+	var hash1, hash2, hash3 Hash
+	Convey("puts, mods and dels should be streamed as they're applied", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		changes, err := h.dht.Changes(ctx)
+		So(err, ShouldBeNil)
+
+		hash1 = put("2")
+		event := next(changes)
+		So(event.Type, ShouldEqual, PUT_REQUEST)
+		So(event.EntryType, ShouldEqual, "evenNumbers")
+		So(event.Hash.String(), ShouldEqual, hash1.String())
+		So(event.Status, ShouldEqual, StatusLive)
+		So(event.Seq, ShouldBeGreaterThan, 0)
+		putSeq = event.Seq
+
+		hash2 = put("4")
+		So(next(changes).Seq, ShouldEqual, putSeq+1)
+
+		So(h.dht.Mod(h.node.NewMessage(MOD_REQUEST, HoldReq{RelatedHash: hash1, EntryHash: hash2}), hash1, hash2), ShouldBeNil)
+		event = next(changes)
+		So(event, ShouldResemble, ChangeEvent{Seq: putSeq + 2, Type: MOD_REQUEST, EntryType: "evenNumbers", Hash: hash1, Status: StatusModified})
+
+		hash3 = put("6")
+		next(changes)
+		So(h.dht.Del(h.node.NewMessage(DEL_REQUEST, HoldReq{RelatedHash: hash3}), hash3), ShouldBeNil)
+		event = next(changes)
+		So(event, ShouldResemble, ChangeEvent{Seq: putSeq + 4, Type: DEL_REQUEST, EntryType: "evenNumbers", Hash: hash3, Status: StatusDeleted})
+	})
+
+	Convey("a stream since a sequence number should replay the changes after it then go live", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		changes, err := h.dht.ChangesSince(ctx, putSeq)
+		So(err, ShouldBeNil)
+
+		So(next(changes), ShouldResemble, ChangeEvent{Seq: putSeq + 1, Type: PUT_REQUEST, EntryType: "evenNumbers", Hash: hash2, Status: StatusLive})
+		So(next(changes).Type, ShouldEqual, MOD_REQUEST)
+		So(next(changes).Hash.String(), ShouldEqual, hash3.String())
+		So(next(changes).Type, ShouldEqual, DEL_REQUEST)
+
+		hash := put("8")
+		event := next(changes)
+		So(event.Seq, ShouldEqual, putSeq+5)
+		So(event.Hash.String(), ShouldEqual, hash.String())
+	})
+
+	Convey("a stream should be closed when its context is done", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		changes, err := h.dht.Changes(ctx)
+		So(err, ShouldBeNil)
+		cancel()
+		select {
+		case _, ok := <-changes:
+			So(ok, ShouldBeFalse)
+		case <-time.After(time.Second):
+			panic("stream not closed")
+		}
+
+		_, err = h.dht.Changes(ctx)
+		So(err, ShouldEqual, context.Canceled)
+	})
+
+	Convey("changes should be dropped and counted when a reader falls behind", t, func() {
+		h.Config.ChangeStreamBuffer = 1
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		_, err := h.dht.Changes(ctx)
+		So(err, ShouldBeNil)
+		So(h.dht.DroppedChanges(), ShouldEqual, 0)
+		put("10")
+		put("12")
+		put("14")
+		So(h.dht.DroppedChanges(), ShouldBeGreaterThanOrEqualTo, 1)
+	})
+}
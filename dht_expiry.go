@@ -0,0 +1,160 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	"github.com/tidwall/buntdb"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExpiredDelReason is the reason recorded for an entry deleted because its
+// type's TTL ran out
+const ExpiredDelReason = "expired"
+
+const (
+	DefaultExpiryInterval = time.Minute
+)
+
+// ErrEntryExpired is returned when asked to hold an entry whose TTL has
+// already run out
+var ErrEntryExpired = errors.New("entry's TTL has already run out")
+
+// setExpiry records when an entry expires if its type has a TTL.  The TTL
+// runs from when the entry was committed, the time of its header, so every
+// holder expires it at the same time however late the put reached them, i.e.
+// by gossip.  An expiry already recorded isn't changed.
+func (dht *DHT) setExpiry(entryType string, key Hash, committed time.Time) (err error) {
+	_, def, e := dht.h.GetEntryDef(entryType)
+	if e != nil || def.TTL <= 0 {
+		return
+	}
+	expires := committed.Add(def.TTL).UnixNano()
+	db := dht.ht.(*BuntHT).db
+	err = db.Update(func(tx *buntdb.Tx) (e error) {
+		k := "expires:" + key.String()
+		if _, e = tx.Get(k); e != buntdb.ErrNotFound {
+			return
+		}
+		_, _, e = tx.Set(k, strconv.FormatInt(expires, 10), nil)
+		return
+	})
+	return
+}
+
+// checkNotExpired returns ErrEntryExpired if the TTL of an entry committed
+// with the header has already run out, so it isn't held only to be expired
+func (dht *DHT) checkNotExpired(entryType string, header *Header) (err error) {
+	_, def, e := dht.h.GetEntryDef(entryType)
+	if e != nil || def.TTL <= 0 {
+		return
+	}
+	if !dht.h.Now().Before(header.Time.Add(def.TTL)) {
+		err = ErrEntryExpired
+	}
+	return
+}
+
+// expireIfDue deletes the entry if it has expired, returning true if it did
+func (dht *DHT) expireIfDue(key Hash) (expired bool, err error) {
+	if dht.ht == nil {
+		return
+	}
+	db := dht.ht.(*BuntHT).db
+	var value string
+	err = db.View(func(tx *buntdb.Tx) (e error) {
+		value, e = tx.Get("expires:" + key.String())
+		return
+	})
+	if err == buntdb.ErrNotFound {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	var expires int64
+	if expires, err = strconv.ParseInt(value, 10, 64); err != nil {
+		return
+	}
+	if dht.h.Now().UnixNano() < expires {
+		return
+	}
+	expired, err = dht.expire(key)
+	return
+}
+
+// expire deletes the entry, if it's still live, and forgets its expiry
+func (dht *DHT) expire(key Hash) (expired bool, err error) {
+	if dht.ht.Exists(key, StatusLive) == nil {
+		dht.dlog.Logf("expiring %v", key)
+		dht.changeFeed.lk.Lock()
+		err = dht.ht.Del(nil, key)
+		if err == nil {
+			dht.changed(nil, DEL_REQUEST, "", key, StatusDeleted)
+		}
+		dht.changeFeed.lk.Unlock()
+		if err != nil {
+			return
+		}
+		if err = dht.ht.PutDelReason(key, ExpiredDelReason); err != nil {
+			return
+		}
+		expired = true
+	}
+	db := dht.ht.(*BuntHT).db
+	err = db.Update(func(tx *buntdb.Tx) (e error) {
+		_, e = tx.Delete("expires:" + key.String())
+		if e == buntdb.ErrNotFound {
+			e = nil
+		}
+		return
+	})
+	return
+}
+
+// ExpireEntries deletes all the entries we hold whose TTL has run out,
+// returning how many it did
+func (dht *DHT) ExpireEntries() (count int, err error) {
+	now := dht.h.Now().UnixNano()
+	var due []Hash
+	db := dht.ht.(*BuntHT).db
+	err = db.View(func(tx *buntdb.Tx) error {
+		return tx.AscendKeys("expires:*", func(k, value string) bool {
+			expires, e := strconv.ParseInt(value, 10, 64)
+			if e == nil && now >= expires {
+				if hash, e := NewHash(strings.TrimPrefix(k, "expires:")); e == nil {
+					due = append(due, hash)
+				}
+			}
+			return true
+		})
+	})
+	if err != nil {
+		return
+	}
+	for _, key := range due {
+		var expired bool
+		if expired, err = dht.expire(key); err != nil {
+			return
+		}
+		if expired {
+			count++
+		}
+	}
+	return
+}
+
+// ExpiryTask deletes the entries whose TTL has run out
+func ExpiryTask(h *Holochain) {
+	if h.dht == nil {
+		return
+	}
+	count, err := h.dht.ExpireEntries()
+	if err != nil {
+		h.dht.dlog.Logf("ExpiryTask: %v", err)
+	} else if count > 0 {
+		h.dht.dlog.Logf("ExpiryTask: expired %d entries", count)
+	}
+}
@@ -0,0 +1,97 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestDHTEntryTTL(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	for i, z := range h.nucleus.dna.Zomes {
+		for j, def := range z.Entries {
+			if def.Name == "evenNumbers" {
+				h.nucleus.dna.Zomes[i].Entries[j].TTL = time.Minute
+			}
+		}
+	}
+	clock := &stoppedClock{now: time.Unix(1000, 0)}
+	h.SetClock(clock)
+
+	// put holds an entry as if its author committed it at the given time
+	put := func(entryType string, content string, committed time.Time) Hash {
+		e := GobEntry{C: content}
+		hash, header, err := newHeader(h.hashSpec, committed, entryType, &e, h.agent.PrivKey(), NullHash(), NullHash(), NullHash())
+		if err != nil {
+			panic(err)
+		}
+		b, _ := e.Marshal()
+		if err = h.dht.Put(nil, entryType, hash, h.nodeID, b, StatusLive); err != nil {
+			panic(err)
+		}
+		if err = h.dht.putEntryHeader(h.nodeID, hash, header); err != nil {
+			panic(err)
+		}
+		return hash
+	}
+
+	Convey("an entry should stay live until its TTL runs out", t, func() {
+		committed := clock.now
+		hash := put("evenNumbers", "2", committed)
+		clock.now = clock.now.Add(59 * time.Second)
+		So(h.dht.Exists(hash, StatusLive), ShouldBeNil)
+
+		// putting it again shouldn't extend its TTL
+		put("evenNumbers", "2", committed)
+		clock.now = clock.now.Add(time.Second)
+		So(h.dht.Exists(hash, StatusLive), ShouldEqual, ErrHashNotFound)
+		_, _, _, status, err := h.dht.Get(hash, StatusDeleted, GetMaskEntry)
+		So(err, ShouldBeNil)
+		So(status, ShouldEqual, StatusDeleted)
+		reason, err := h.dht.GetDelReason(hash)
+		So(err, ShouldBeNil)
+		So(reason, ShouldEqual, ExpiredDelReason)
+	})
+
+	Convey("the TTL should run from when the entry was committed, not when it was held", t, func() {
+		hash := put("evenNumbers", "6", clock.now.Add(-50*time.Second))
+		So(h.dht.Exists(hash, StatusLive), ShouldBeNil)
+		clock.now = clock.now.Add(10 * time.Second)
+		So(h.dht.Exists(hash, StatusLive), ShouldEqual, ErrHashNotFound)
+	})
+
+	Convey("a put of an entry whose TTL has run out should be refused", t, func() {
+		hash, err := h.CommitLocal(NewCommitAction("evenNumbers", &GobEntry{C: "8"}))
+		So(err, ShouldBeNil)
+		clock.now = clock.now.Add(time.Minute)
+
+		ActionReceiver(h, h.node.NewMessage(PUT_REQUEST, HoldReq{EntryHash: hash}))
+		_, _, _, _, err = h.dht.Get(hash, StatusAny, GetMaskEntryType)
+		So(err, ShouldEqual, ErrHashNotFound)
+	})
+
+	Convey("the sweep should expire entries that aren't accessed", t, func() {
+		hash := put("evenNumbers", "4", clock.now)
+		count, err := h.dht.ExpireEntries()
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 0)
+
+		clock.now = clock.now.Add(time.Minute)
+		count, err = h.dht.ExpireEntries()
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 1)
+		So(h.dht.ht.Exists(hash, StatusLive), ShouldEqual, ErrHashNotFound)
+		So(h.dht.ht.Exists(hash, StatusDeleted), ShouldBeNil)
+	})
+
+	Convey("an entry of a type without a TTL should stay live", t, func() {
+		hash := put("oddNumbers", "7", clock.now)
+		clock.now = clock.now.Add(time.Hour)
+		_, err := h.dht.ExpireEntries()
+		So(err, ShouldBeNil)
+		So(h.dht.Exists(hash, StatusLive), ShouldBeNil)
+	})
+}
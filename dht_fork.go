@@ -0,0 +1,73 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	"github.com/tidwall/buntdb"
+	"sort"
+	"strings"
+)
+
+// forkIndexKey is the key of a header in the index of the headers each author
+// has put to us by the header they follow
+func forkIndexKey(author peer.ID, prev Hash, headerHash Hash) string {
+	return "follows:" + HashFromPeerID(author).String() + ":" + prev.String() + ":" + headerHash.String()
+}
+
+// indexHeader adds a header put to us by its author to the index DetectFork
+// uses.  The first header of a chain follows nothing so it can't fork.
+func (dht *DHT) indexHeader(author peer.ID, header *Header) (err error) {
+	if header.HeaderLink.IsNullHash() || author == "" {
+		return
+	}
+	var headerHash Hash
+	headerHash, _, err = header.Sum(dht.h.hashSpec)
+	if err != nil {
+		return
+	}
+	db := dht.ht.(*BuntHT).db
+	err = db.Update(func(tx *buntdb.Tx) (e error) {
+		_, _, e = tx.Set(forkIndexKey(author, header.HeaderLink, headerHash), "", nil)
+		return
+	})
+	return
+}
+
+// DetectFork reports a fork if two different headers the agent has put to
+// this node follow the same previous header, i.e. the agent committed two
+// different entries at the same chain position.  The conflicting header
+// hashes are returned sorted.  The headers are indexed as they're put, so
+// only forks in the headers this node holds can be detected.
+func (dht *DHT) DetectFork(agentKey Hash) (forked bool, conflicting []Hash, err error) {
+	prefix := "follows:" + agentKey.String() + ":"
+	// the agent's held headers by the header they follow
+	following := make(map[string][]Hash)
+	var parseErr error
+	db := dht.ht.(*BuntHT).db
+	err = db.View(func(tx *buntdb.Tx) error {
+		return tx.AscendKeys(prefix+"*", func(k, value string) bool {
+			x := strings.Split(strings.TrimPrefix(k, prefix), ":")
+			var headerHash Hash
+			if headerHash, parseErr = NewHash(x[1]); parseErr != nil {
+				return false
+			}
+			following[x[0]] = append(following[x[0]], headerHash)
+			return true
+		})
+	})
+	if err == nil {
+		err = parseErr
+	}
+	if err != nil {
+		return
+	}
+
+	for _, headers := range following {
+		if len(headers) > 1 {
+			conflicting = append(conflicting, headers...)
+		}
+	}
+	forked = len(conflicting) > 0
+	sort.Slice(conflicting, func(i, j int) bool { return conflicting[i].String() < conflicting[j].String() })
+	return
+}
@@ -0,0 +1,91 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestDetectFork(t *testing.T) {
+	n := 3
+	mt := setupMultiNodeTesting(n)
+	ringConnect(t, mt.ctx, mt.nodes, n)
+	defer mt.cleanupMultiNodeTesting()
+	author := mt.nodes[0]
+	holder := mt.nodes[1]
+	agentKey := HashFromPeerID(author.nodeID)
+
+	// commit an entry on the author's chain and forge a second entry at the
+	// same position
+	hash := commit(author, "evenNumbers", "2")
+	header, err := author.chain.GetEntryHeader(hash)
+	if err != nil {
+		panic(err)
+	}
+	forgedEntry := GobEntry{C: "4"}
+	forgedHash, forgedHeader, err := newHeader(author.hashSpec, time.Now(), "evenNumbers", &forgedEntry, author.agent.PrivKey(), header.HeaderLink, header.TypeLink, NullHash())
+	if err != nil {
+		panic(err)
+	}
+	headerHash, _, err := header.Sum(author.hashSpec)
+	if err != nil {
+		panic(err)
+	}
+
+	Convey("an unforked chain should not be reported", t, func() {
+		forked, conflicting, err := mt.nodes[2].dht.DetectFork(agentKey)
+		So(err, ShouldBeNil)
+		So(forked, ShouldBeFalse)
+		So(len(conflicting), ShouldEqual, 0)
+	})
+
+	Convey("the same header put again should not be reported as a fork", t, func() {
+		for i := 0; i < 2; i++ {
+			So(holder.dht.putEntryHeader(author.nodeID, header.EntryLink, header), ShouldBeNil)
+		}
+		forked, _, err := holder.dht.DetectFork(agentKey)
+		So(err, ShouldBeNil)
+		So(forked, ShouldBeFalse)
+	})
+
+	Convey("a fork injected into a node's DHT should be detected", t, func() {
+		for _, e := range []struct {
+			entry  GobEntry
+			header *Header
+		}{{GobEntry{C: "2"}, header}, {forgedEntry, forgedHeader}} {
+			b, _ := e.entry.Marshal()
+			So(holder.dht.Put(nil, "evenNumbers", e.header.EntryLink, author.nodeID, b, StatusLive), ShouldBeNil)
+			So(holder.dht.putEntryHeader(author.nodeID, e.header.EntryLink, e.header), ShouldBeNil)
+		}
+
+		forked, conflicting, err := holder.dht.DetectFork(agentKey)
+		So(err, ShouldBeNil)
+		So(forked, ShouldBeTrue)
+		expected := []Hash{headerHash, forgedHash}
+		if expected[1].String() < expected[0].String() {
+			expected[0], expected[1] = expected[1], expected[0]
+		}
+		So(conflicting, ShouldResemble, expected)
+	})
+
+	Convey("other agents' chains should not be reported as forked", t, func() {
+		forked, _, err := holder.dht.DetectFork(HashFromPeerID(mt.nodes[2].nodeID))
+		So(err, ShouldBeNil)
+		So(forked, ShouldBeFalse)
+	})
+
+	Convey("migrating from a forked chain should be refused", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		migrateHeader, err := GenTestHeader()
+		So(err, ShouldBeNil)
+		a := &ActionMigrate{entry: entry, header: migrateHeader}
+		err = a.SysValidation(holder, MigrateEntryDef, &Package{}, []peer.ID{author.nodeID})
+		So(err, ShouldEqual, ErrMigrateForkedChain)
+
+		err = a.SysValidation(mt.nodes[2], MigrateEntryDef, &Package{}, []peer.ID{author.nodeID})
+		So(err, ShouldBeNil)
+	})
+}
@@ -0,0 +1,73 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	"sort"
+	"time"
+)
+
+// HistoryEvent is one change in the life of a held entry
+type HistoryEvent struct {
+	Type       MsgType // PUT_REQUEST, MOD_REQUEST or DEL_REQUEST
+	EntryHash  Hash    // the entry put, or the entry that modified or deleted it
+	HeaderHash Hash    // hash of EntryHash's header, if the header is held
+	Time       time.Time
+}
+
+// GetHistory returns the changes this node has held for an entry in the order
+// they were made: the put of the entry followed by any mods and dels of it.
+// An entry that has only ever been put has a history of one event.  The
+// history is rebuilt from the DHT's change log and headers so it's only
+// complete on a node responsible for the entry.
+func (dht *DHT) GetHistory(hash Hash) (history []HistoryEvent, err error) {
+	if err = dht.Exists(hash, StatusAny); err != nil {
+		return
+	}
+	var puts []Put
+	if puts, err = dht.GetPuts(0); err != nil {
+		return
+	}
+
+	seen := make(map[MsgType]map[string]bool)
+	for _, p := range puts {
+		req, ok := p.M.Body.(HoldReq)
+		if !ok {
+			continue
+		}
+		switch p.M.Type {
+		case PUT_REQUEST:
+			ok = req.EntryHash.Equal(hash)
+		case MOD_REQUEST, DEL_REQUEST:
+			ok = req.RelatedHash.Equal(hash)
+		default:
+			ok = false
+		}
+		if !ok {
+			continue
+		}
+		// the same change can be received from more than one source
+		k := req.EntryHash.String()
+		if seen[p.M.Type] == nil {
+			seen[p.M.Type] = make(map[string]bool)
+		}
+		if seen[p.M.Type][k] {
+			continue
+		}
+		seen[p.M.Type][k] = true
+
+		event := HistoryEvent{Type: p.M.Type, EntryHash: req.EntryHash, Time: p.M.Time}
+		header, e := dht.getEntryHeader(req.EntryHash)
+		if e == nil {
+			event.Time = header.Time
+			event.HeaderHash, _, err = header.Sum(dht.h.hashSpec)
+			if err != nil {
+				return
+			}
+		}
+		history = append(history, event)
+	}
+	// the log is in the order we received the changes which needn't be the
+	// order they were made in
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Time.Before(history[j].Time) })
+	return
+}
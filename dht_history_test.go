@@ -0,0 +1,64 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestDHTGetHistory(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	hash := commit(h, "evenNumbers", "2")
+
+	Convey("it should fail for entries that aren't held", t, func() {
+		missing, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		_, err = h.dht.GetHistory(missing)
+		So(err, ShouldEqual, ErrHashNotFound)
+	})
+
+	Convey("an entry that's only been put should have a single event", t, func() {
+		history, err := h.dht.GetHistory(hash)
+		So(err, ShouldBeNil)
+		So(len(history), ShouldEqual, 1)
+		So(history[0].Type, ShouldEqual, PUT_REQUEST)
+		So(history[0].EntryHash.Equal(hash), ShouldBeTrue)
+
+		header, err := h.chain.GetEntryHeader(hash)
+		So(err, ShouldBeNil)
+		headerHash, _, err := header.Sum(h.hashSpec)
+		So(err, ShouldBeNil)
+		So(history[0].HeaderHash.Equal(headerHash), ShouldBeTrue)
+		So(history[0].Time.Equal(header.Time), ShouldBeTrue)
+	})
+
+	Convey("mods and dels should follow the put in the order they were made", t, func() {
+		var mods []Hash
+		for _, n := range []string{"4", "6"} {
+			r, err := (&APIFnMod{action: *NewModAction("evenNumbers", &GobEntry{C: n}, hash)}).Call(h)
+			So(err, ShouldBeNil)
+			mods = append(mods, r.(Hash))
+		}
+		delHash, err := h.commitAndShare(&ActionDel{entry: DelEntry{Hash: hash, Message: "retracted"}}, NullHash())
+		So(err, ShouldBeNil)
+
+		history, err := h.dht.GetHistory(hash)
+		So(err, ShouldBeNil)
+		So(len(history), ShouldEqual, 4)
+		So(history[0].Type, ShouldEqual, PUT_REQUEST)
+		So(history[1].Type, ShouldEqual, MOD_REQUEST)
+		So(history[1].EntryHash.Equal(mods[0]), ShouldBeTrue)
+		So(history[2].Type, ShouldEqual, MOD_REQUEST)
+		So(history[2].EntryHash.Equal(mods[1]), ShouldBeTrue)
+		So(history[3].Type, ShouldEqual, DEL_REQUEST)
+		So(history[3].EntryHash.Equal(delHash), ShouldBeTrue)
+		for i, event := range history {
+			So(event.HeaderHash, ShouldNotEqual, NullHash())
+			if i > 0 {
+				So(event.Time.Before(history[i-1].Time), ShouldBeFalse)
+			}
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package holochain
+
+import (
+	"context"
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+)
+
+var ErrModificationCycle = errors.New("modification cycle detected")
+
+// GetLatest follows the modifications of an entry forward to its newest
+// version, returning that version's hash and entry.  An entry that hasn't
+// been modified is its own newest version.  A chain of modifications that
+// comes back on itself returns ErrModificationCycle.
+func (dht *DHT) GetLatest(hash Hash) (latest Hash, entry Entry, err error) {
+	seen := make(map[string]bool)
+	key := hash
+	for {
+		if seen[key.String()] {
+			err = ErrModificationCycle
+			return
+		}
+		seen[key.String()] = true
+
+		var resp GetResp
+		resp, err = dht.GetCtx(context.Background(), key, StatusDefault, GetMaskEntry)
+		if err == ErrHashModified {
+			if resp.FollowHash == "" {
+				return
+			}
+			if key, err = NewHash(resp.FollowHash); err != nil {
+				return
+			}
+			continue
+		}
+		if err != nil {
+			return
+		}
+		latest = key
+		entry = &resp.Entry
+		return
+	}
+}
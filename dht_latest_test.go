@@ -0,0 +1,61 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestDHTGetLatest(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+	response, err := fn.Call(h)
+	if err != nil {
+		panic(err)
+	}
+	hashes := []Hash{response.(Hash)}
+
+	mod := func(data string, replaces Hash) Hash {
+		corrected := entry
+		corrected.Data = data
+		j, _ := corrected.ToJSON()
+		modFn := &APIFnMod{action: *NewModAction(MigrateEntryType, &GobEntry{C: j}, replaces)}
+		response, err := modFn.Call(h)
+		if err != nil {
+			panic(err)
+		}
+		return response.(Hash)
+	}
+	hashes = append(hashes, mod("second", hashes[0]))
+	hashes = append(hashes, mod("third", hashes[1]))
+
+	Convey("it should follow the mods from any version to the third", t, func() {
+		for _, hash := range hashes {
+			latest, e, err := h.dht.GetLatest(hash)
+			So(err, ShouldBeNil)
+			So(latest.String(), ShouldEqual, hashes[2].String())
+			m, err := MigrateEntryFromJSON(e.Content().(string))
+			So(err, ShouldBeNil)
+			So(m.Data, ShouldEqual, "third")
+		}
+	})
+
+	Convey("it should detect a cycle of mods", t, func() {
+		// point the third version back at the first
+		So(h.dht.Mod(h.node.NewMessage(MOD_REQUEST, HoldReq{RelatedHash: hashes[2], EntryHash: hashes[0]}), hashes[2], hashes[0]), ShouldBeNil)
+		_, _, err := h.dht.GetLatest(hashes[1])
+		So(err, ShouldEqual, ErrModificationCycle)
+	})
+
+	Convey("it should return the error for a hash that isn't held", t, func() {
+		missing, _ := NewHash("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
+		_, _, err := h.dht.GetLatest(missing)
+		So(err, ShouldEqual, ErrHashNotFound)
+	})
+}
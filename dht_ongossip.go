@@ -0,0 +1,67 @@
+package holochain
+
+import (
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+// GossipCompleteQueueSize is how many finished gossip rounds can be waiting
+// for a gossip complete handler before further ones are dropped
+const GossipCompleteQueueSize = 100
+
+// GossipCompleteFn is called with the peer of each finished gossip round and
+// the number of new puts exchanged in it
+type GossipCompleteFn func(peer peer.ID, received int)
+
+type gossipComplete struct {
+	peer     peer.ID
+	received int
+}
+
+// gossipCompleteHandler runs a GossipCompleteFn in its own go routine
+type gossipCompleteHandler struct {
+	fn    GossipCompleteFn
+	queue chan gossipComplete
+}
+
+// gossipDone marks the end of a round's puts in the gossip put queue, so the
+// round is reported once they've all been handled
+type gossipDone gossipComplete
+
+// OnGossipComplete registers fn to be called when this node finishes a
+// gossip round with a peer.  For a round we started, received is the number
+// of the peer's puts we didn't already have, and fn is called once they've
+// been handled.  For a round the peer started, it's the number of puts we
+// sent it.  Like hold handlers, fn is called from a queue so it can't stall
+// gossiping, if the queue is full the round isn't reported.
+func (h *Holochain) OnGossipComplete(fn GossipCompleteFn) {
+	handler := &gossipCompleteHandler{fn: fn, queue: make(chan gossipComplete, GossipCompleteQueueSize)}
+	go func() {
+		for g := range handler.queue {
+			handler.fn(g.peer, g.received)
+		}
+	}()
+	dht := h.dht
+	dht.slk.Lock()
+	dht.gossipCompleteHandlers = append(dht.gossipCompleteHandlers, handler)
+	dht.slk.Unlock()
+}
+
+func (dht *DHT) hasGossipCompleteHandlers() bool {
+	dht.slk.RLock()
+	defer dht.slk.RUnlock()
+	return len(dht.gossipCompleteHandlers) > 0
+}
+
+// notifyGossipComplete queues a finished gossip round for all the gossip
+// complete handlers
+func (dht *DHT) notifyGossipComplete(id peer.ID, received int) {
+	dht.slk.RLock()
+	defer dht.slk.RUnlock()
+	for _, handler := range dht.gossipCompleteHandlers {
+		select {
+		case handler.queue <- gossipComplete{peer: id, received: received}:
+		default:
+			dht.glog.Logf("gossip complete queue full, dropped round with %v", id)
+		}
+	}
+}
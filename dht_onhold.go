@@ -0,0 +1,91 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	"sync/atomic"
+)
+
+// HoldHandlerQueueSize is how many held entries can be waiting for a hold
+// handler before further ones are dropped
+const HoldHandlerQueueSize = 100
+
+// HoldHandlerFn is called with the type and hash of each entry a node starts holding
+type HoldHandlerFn func(entryType string, hash Hash)
+
+type holdEvent struct {
+	entryType string
+	hash      Hash
+}
+
+// HoldHandler runs a HoldHandlerFn for newly held entries in its own go routine
+type HoldHandler struct {
+	fn      HoldHandlerFn
+	queue   chan holdEvent
+	done    chan struct{}
+	dropped int64
+}
+
+// Dropped returns the number of held entries that weren't handled because the
+// handler's queue was full
+func (handler *HoldHandler) Dropped() int64 {
+	return atomic.LoadInt64(&handler.dropped)
+}
+
+// OnHold registers fn to be called with every entry this node starts holding
+// as live, whether it was gossiped to us or put to us directly.  Handlers are
+// called from a queue so a slow handler can't stall the DHT, if the queue is
+// full the entry is dropped and counted instead.
+func (h *Holochain) OnHold(fn HoldHandlerFn) (handler *HoldHandler) {
+	handler = &HoldHandler{fn: fn, queue: make(chan holdEvent, HoldHandlerQueueSize), done: make(chan struct{})}
+	go func() {
+		defer close(handler.done)
+		for e := range handler.queue {
+			handler.fn(e.entryType, e.hash)
+		}
+	}()
+	dht := h.dht
+	dht.slk.Lock()
+	dht.holdHandlers = append(dht.holdHandlers, handler)
+	dht.slk.Unlock()
+	return
+}
+
+// RemoveOnHold stops calling a hold handler, waiting for any queued calls to
+// finish
+func (h *Holochain) RemoveOnHold(handler *HoldHandler) {
+	dht := h.dht
+	dht.slk.Lock()
+	removed := false
+	for i, hh := range dht.holdHandlers {
+		if hh == handler {
+			dht.holdHandlers = append(dht.holdHandlers[:i], dht.holdHandlers[i+1:]...)
+			removed = true
+			break
+		}
+	}
+	dht.slk.Unlock()
+	if removed {
+		close(handler.queue)
+		<-handler.done
+	}
+}
+
+func (dht *DHT) hasHoldHandlers() bool {
+	dht.slk.RLock()
+	defer dht.slk.RUnlock()
+	return len(dht.holdHandlers) > 0
+}
+
+// notifyHoldHandlers queues a newly held entry for all the hold handlers
+func (dht *DHT) notifyHoldHandlers(entryType string, key Hash) {
+	dht.slk.RLock()
+	defer dht.slk.RUnlock()
+	for _, handler := range dht.holdHandlers {
+		select {
+		case handler.queue <- holdEvent{entryType: entryType, hash: key}:
+		default:
+			atomic.AddInt64(&handler.dropped, 1)
+			dht.dlog.Logf("hold handler queue full, dropped %v", key)
+		}
+	}
+}
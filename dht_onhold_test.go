@@ -0,0 +1,74 @@
+package holochain
+
+import (
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestOnHold(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	now := time.Unix(1, 1) // pick a constant time so the test will always work
+	e := GobEntry{C: "124"}
+	_, hd, _ := h.NewEntry(now, "evenNumbers", &e)
+	hash := hd.EntryLink
+
+	type held struct {
+		entryType string
+		hash      Hash
+	}
+	ch := make(chan held, 10)
+	handler := h.OnHold(func(entryType string, hash Hash) {
+		ch <- held{entryType, hash}
+	})
+
+	Convey("entries put to us should be handed to the handler", t, func() {
+		m := h.node.NewMessage(PUT_REQUEST, HoldReq{EntryHash: hash})
+		r, err := ActionReceiver(h, m)
+		So(err, ShouldBeNil)
+		So(r.(HoldResp).Code, ShouldEqual, ReceiptOK)
+
+		var got held
+		select {
+		case got = <-ch:
+		case <-time.After(time.Second):
+		}
+		So(got.entryType, ShouldEqual, "evenNumbers")
+		So(got.hash.String(), ShouldEqual, hash.String())
+	})
+
+	Convey("entries already held should not be handed over again", t, func() {
+		So(h.dht.Put(nil, "evenNumbers", hash, h.nodeID, []byte("124"), StatusLive), ShouldBeNil)
+		h.RemoveOnHold(handler) // waits for the queue to drain
+		So(len(ch), ShouldEqual, 0)
+	})
+
+	Convey("a slow handler should not stall the DHT", t, func() {
+		block := make(chan bool)
+		slow := h.OnHold(func(entryType string, hash Hash) {
+			<-block
+		})
+		for i := 0; i < HoldHandlerQueueSize+10; i++ {
+			e := GobEntry{C: fmt.Sprintf("%d", i*2)}
+			k, _ := e.Sum(h.hashSpec)
+			b, _ := e.Marshal()
+			So(h.dht.Put(nil, "evenNumbers", k, h.nodeID, b, StatusLive), ShouldBeNil)
+		}
+		So(slow.Dropped(), ShouldBeGreaterThan, 0)
+		close(block)
+		h.RemoveOnHold(slow)
+		So(len(h.dht.holdHandlers), ShouldEqual, 0)
+	})
+
+	Convey("removed handlers should not be called", t, func() {
+		e := GobEntry{C: "1000"}
+		k, _ := e.Sum(h.hashSpec)
+		b, _ := e.Marshal()
+		So(h.dht.Put(nil, "evenNumbers", k, h.nodeID, b, StatusLive), ShouldBeNil)
+		So(len(ch), ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,75 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	"strings"
+	"sync/atomic"
+)
+
+// EntryTypeWildcard matches any entry type when used alone, or any entry type
+// with the given prefix when appended to one, i.e. "%*" matches all sys types
+const EntryTypeWildcard = "*"
+
+// EntryTypeSubscription delivers the hashes of newly held entries of a type
+type EntryTypeSubscription struct {
+	entryType string
+	ch        chan<- Hash
+	dropped   int64
+}
+
+// Matches returns true if the subscription is for the given entry type
+func (sub *EntryTypeSubscription) Matches(entryType string) bool {
+	if strings.HasSuffix(sub.entryType, EntryTypeWildcard) {
+		return strings.HasPrefix(entryType, strings.TrimSuffix(sub.entryType, EntryTypeWildcard))
+	}
+	return sub.entryType == entryType
+}
+
+// Dropped returns the number of hashes that weren't delivered because the
+// subscriber's channel was full
+func (sub *EntryTypeSubscription) Dropped() int64 {
+	return atomic.LoadInt64(&sub.dropped)
+}
+
+// SubscribeEntryType registers ch to receive the hash of every entry of
+// entryType that this node newly holds as live, whether it was gossiped to us
+// or put to us directly.  Delivery never blocks the DHT, so if ch is full the
+// hash is dropped and counted instead; use a buffered channel.
+// N.B. the channel is the subscriber's and is never closed by the DHT
+func (dht *DHT) SubscribeEntryType(entryType string, ch chan<- Hash) (sub *EntryTypeSubscription) {
+	sub = &EntryTypeSubscription{entryType: entryType, ch: ch}
+	dht.slk.Lock()
+	dht.subscriptions = append(dht.subscriptions, sub)
+	dht.slk.Unlock()
+	return
+}
+
+// UnsubscribeEntryType stops delivery to a subscription
+func (dht *DHT) UnsubscribeEntryType(sub *EntryTypeSubscription) {
+	dht.slk.Lock()
+	defer dht.slk.Unlock()
+	for i, s := range dht.subscriptions {
+		if s == sub {
+			dht.subscriptions = append(dht.subscriptions[:i], dht.subscriptions[i+1:]...)
+			return
+		}
+	}
+}
+
+// notifySubscribers sends the hash of a newly held entry to all subscriptions
+// matching its type
+func (dht *DHT) notifySubscribers(entryType string, key Hash) {
+	dht.slk.RLock()
+	defer dht.slk.RUnlock()
+	for _, sub := range dht.subscriptions {
+		if !sub.Matches(entryType) {
+			continue
+		}
+		select {
+		case sub.ch <- key:
+		default:
+			atomic.AddInt64(&sub.dropped, 1)
+			dht.dlog.Logf("subscription to %s full, dropped %v", sub.entryType, key)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestEntryTypeSubscriptionMatches(t *testing.T) {
+	Convey("subscriptions should match exact types, prefixes and wildcards", t, func() {
+		sub := EntryTypeSubscription{entryType: MigrateEntryType}
+		So(sub.Matches(MigrateEntryType), ShouldBeTrue)
+		So(sub.Matches(MigrateRollbackEntryType), ShouldBeFalse)
+
+		sub = EntryTypeSubscription{entryType: SysEntryTypePrefix + EntryTypeWildcard}
+		So(sub.Matches(MigrateEntryType), ShouldBeTrue)
+		So(sub.Matches("evenNumbers"), ShouldBeFalse)
+
+		sub = EntryTypeSubscription{entryType: EntryTypeWildcard}
+		So(sub.Matches(MigrateEntryType), ShouldBeTrue)
+		So(sub.Matches("evenNumbers"), ShouldBeTrue)
+	})
+}
+
+func TestDHTSubscribeEntryType(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	dht := h.dht
+	now := time.Unix(1, 1) // pick a constant time so the test will always work
+	e := GobEntry{C: "124"}
+	_, hd, _ := h.NewEntry(now, "evenNumbers", &e)
+	hash := hd.EntryLink
+
+	ch := make(chan Hash, 1)
+	sub := dht.SubscribeEntryType("even*", ch)
+	other := make(chan Hash, 1)
+	otherSub := dht.SubscribeEntryType("oddNumbers", other)
+
+	Convey("newly held entries should be delivered to matching subscriptions", t, func() {
+		m := h.node.NewMessage(PUT_REQUEST, HoldReq{EntryHash: hash})
+		r, err := ActionReceiver(h, m)
+		So(err, ShouldBeNil)
+		So(r.(HoldResp).Code, ShouldEqual, ReceiptOK)
+
+		var got Hash
+		select {
+		case got = <-ch:
+		default:
+		}
+		So(got.String(), ShouldEqual, hash.String())
+		So(len(other), ShouldEqual, 0)
+	})
+
+	Convey("entries that aren't live should not be delivered", t, func() {
+		err := dht.Put(nil, "evenNumbers", hash, h.nodeID, []byte("124"), StatusRejected)
+		So(err, ShouldBeNil)
+		So(len(ch), ShouldEqual, 0)
+	})
+
+	Convey("a slow subscriber should have hashes dropped rather than block", t, func() {
+		So(dht.Put(nil, "evenNumbers", hash, h.nodeID, []byte("124"), StatusLive), ShouldBeNil)
+		So(dht.Put(nil, "evenNumbers", hash, h.nodeID, []byte("124"), StatusLive), ShouldBeNil)
+		So(len(ch), ShouldEqual, 1)
+		So(sub.Dropped(), ShouldEqual, 1)
+		<-ch
+	})
+
+	Convey("unsubscribing should stop delivery", t, func() {
+		dht.UnsubscribeEntryType(sub)
+		dht.UnsubscribeEntryType(otherSub)
+		So(len(dht.subscriptions), ShouldEqual, 0)
+		So(dht.Put(nil, "evenNumbers", hash, h.nodeID, []byte("124"), StatusLive), ShouldBeNil)
+		So(len(ch), ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,90 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	"github.com/tidwall/buntdb"
+	"strings"
+)
+
+var ErrLocalTagInvalid = errors.New("local tag must not be empty or contain ':', '*' or '?'")
+
+// TagLocal tags an entry we hold, i.e. to mark a migrate as reviewed.  Tags are
+// only kept in this node's store so they're never gossiped, and they persist
+// across restarts until removed with UntagLocal.  Tagging an entry again with a
+// tag it already has does nothing.
+func (dht *DHT) TagLocal(hash Hash, tags ...string) (err error) {
+	if err = checkLocalTags(tags); err != nil {
+		return
+	}
+	if err = dht.ht.Exists(hash, StatusAny); err != nil {
+		return
+	}
+	db := dht.ht.(*BuntHT).db
+	err = db.Update(func(tx *buntdb.Tx) (e error) {
+		for _, tag := range tags {
+			if _, _, e = tx.Set(localTagKey(tag, hash), "", nil); e != nil {
+				return
+			}
+		}
+		return
+	})
+	return
+}
+
+// UntagLocal removes tags from an entry, ignoring the ones it doesn't have
+func (dht *DHT) UntagLocal(hash Hash, tags ...string) (err error) {
+	if err = checkLocalTags(tags); err != nil {
+		return
+	}
+	db := dht.ht.(*BuntHT).db
+	err = db.Update(func(tx *buntdb.Tx) (e error) {
+		for _, tag := range tags {
+			if _, e = tx.Delete(localTagKey(tag, hash)); e != nil && e != buntdb.ErrNotFound {
+				return
+			}
+		}
+		e = nil
+		return
+	})
+	return
+}
+
+// QueryByTag returns the hashes of the entries tagged with the tag
+func (dht *DHT) QueryByTag(tag string) (hashes []Hash, err error) {
+	if err = checkLocalTags([]string{tag}); err != nil {
+		return
+	}
+	prefix := "tag:" + tag + ":"
+	db := dht.ht.(*BuntHT).db
+	var parseErr error
+	err = db.View(func(tx *buntdb.Tx) error {
+		return tx.AscendKeys(prefix+"*", func(k, value string) bool {
+			var hash Hash
+			if hash, parseErr = NewHash(strings.TrimPrefix(k, prefix)); parseErr != nil {
+				return false
+			}
+			hashes = append(hashes, hash)
+			return true
+		})
+	})
+	if err == nil {
+		err = parseErr
+	}
+	return
+}
+
+func localTagKey(tag string, hash Hash) string {
+	return "tag:" + tag + ":" + hash.String()
+}
+
+// checkLocalTags refuses tags that would be ambiguous in a key pattern
+func checkLocalTags(tags []string) (err error) {
+	for _, tag := range tags {
+		if tag == "" || strings.ContainsAny(tag, ":*?") {
+			err = ErrLocalTagInvalid
+			return
+		}
+	}
+	return
+}
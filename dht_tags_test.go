@@ -0,0 +1,70 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"path/filepath"
+	"testing"
+)
+
+func TestDHTLocalTags(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	hash2 := commit(h, "evenNumbers", "2")
+	hash4 := commit(h, "evenNumbers", "4")
+	dht := h.dht
+
+	Convey("it should only tag entries we hold with valid tags", t, func() {
+		missing, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		So(dht.TagLocal(missing, "reviewed"), ShouldEqual, ErrHashNotFound)
+		So(dht.TagLocal(hash2, ""), ShouldEqual, ErrLocalTagInvalid)
+		So(dht.TagLocal(hash2, "re:viewed"), ShouldEqual, ErrLocalTagInvalid)
+		_, err = dht.QueryByTag("review*")
+		So(err, ShouldEqual, ErrLocalTagInvalid)
+	})
+
+	Convey("it should find the entries with a tag", t, func() {
+		idx, err := dht.GetIdx()
+		So(err, ShouldBeNil)
+		So(dht.TagLocal(hash2, "reviewed", "flagged"), ShouldBeNil)
+		So(dht.TagLocal(hash4, "reviewed"), ShouldBeNil)
+		So(dht.TagLocal(hash4, "reviewed"), ShouldBeNil)
+
+		hashes, err := dht.QueryByTag("reviewed")
+		So(err, ShouldBeNil)
+		So(len(hashes), ShouldEqual, 2)
+		So(hashes, ShouldContain, hash2)
+		So(hashes, ShouldContain, hash4)
+		hashes, err = dht.QueryByTag("flagged")
+		So(err, ShouldBeNil)
+		So(hashes, ShouldResemble, []Hash{hash2})
+		hashes, err = dht.QueryByTag("other")
+		So(err, ShouldBeNil)
+		So(len(hashes), ShouldEqual, 0)
+
+		// tags aren't logged so they're never gossiped
+		after, err := dht.GetIdx()
+		So(err, ShouldBeNil)
+		So(after, ShouldEqual, idx)
+	})
+
+	Convey("tags should persist when the store is reopened", t, func() {
+		dht.ht.Close()
+		dht.ht.Open(filepath.Join(h.DBPath(), DHTStoreFileName))
+		hashes, err := dht.QueryByTag("reviewed")
+		So(err, ShouldBeNil)
+		So(len(hashes), ShouldEqual, 2)
+	})
+
+	Convey("it should remove tags", t, func() {
+		So(dht.UntagLocal(hash2, "reviewed", "unknown"), ShouldBeNil)
+		hashes, err := dht.QueryByTag("reviewed")
+		So(err, ShouldBeNil)
+		So(hashes, ShouldResemble, []Hash{hash4})
+		hashes, err = dht.QueryByTag("flagged")
+		So(err, ShouldBeNil)
+		So(hashes, ShouldResemble, []Hash{hash2})
+	})
+}
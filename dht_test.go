@@ -1,6 +1,7 @@
 package holochain
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -177,6 +178,177 @@ func TestDHTQueryGet(t *testing.T) {
 	})
 }
 
+func TestDHTGetCtx(t *testing.T) {
+	nodesCount := 2
+	mt := setupMultiNodeTesting(nodesCount)
+	defer mt.cleanupMultiNodeTesting()
+
+	h := mt.nodes[0]
+	unresponsive := mt.nodes[1]
+	connect(t, mt.ctx, h, unresponsive)
+
+	// the second node never answers gets, so a get of a hash we don't have
+	// waits on it
+	block := make(chan struct{})
+	registerActionReceiver("get", func(dht *DHT, msg *Message) (response interface{}, err error) {
+		if dht == unresponsive.dht {
+			<-block
+		}
+		return (&ActionGet{}).Receive(dht, msg)
+	})
+	defer UnregisterActionReceiver("get")
+	defer close(block)
+
+	hash, _ := NewHash("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
+
+	Convey("a get should abort when its context's deadline expires", t, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := h.dht.GetCtx(ctx, hash, StatusLive, GetMaskEntry)
+		So(err, ShouldEqual, context.DeadlineExceeded)
+		So(time.Since(start), ShouldBeLessThan, DefaultSendTimeout)
+	})
+
+	Convey("a get should abort when its context is canceled", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+		}()
+		start := time.Now()
+		_, err := h.dht.GetCtx(ctx, hash, StatusLive, GetMaskEntry)
+		So(err, ShouldEqual, context.Canceled)
+		So(time.Since(start), ShouldBeLessThan, DefaultSendTimeout)
+	})
+}
+
+func TestDHTGetDeterministic(t *testing.T) {
+	nodesCount := 3
+	mt := setupMultiNodeTesting(nodesCount)
+	defer mt.cleanupMultiNodeTesting()
+	nodes := mt.nodes
+	fullConnect(t, mt.ctx, nodes, nodesCount)
+	h := nodes[0]
+
+	hold := func(content string, statuses ...int) (hash Hash) {
+		e := GobEntry{C: content}
+		hash, err := e.Sum(h.hashSpec)
+		if err != nil {
+			panic(err)
+		}
+		b, _ := e.Marshal()
+		for i, status := range statuses {
+			holder := nodes[i+1]
+			if err = holder.dht.Put(nil, "evenNumbers", hash, holder.nodeID, b, status); err != nil {
+				panic(err)
+			}
+		}
+		return
+	}
+
+	Convey("a live response should be preferred over a modified one", t, func() {
+		hash := hold("contested", StatusModified, StatusLive)
+		for i := 0; i < 5; i++ {
+			resp, err := h.dht.GetCtx(context.Background(), hash, StatusAny, GetMaskEntry|GetMaskStatus)
+			So(err, ShouldBeNil)
+			So(resp.Status, ShouldEqual, StatusLive)
+		}
+		// without asking for the status or sources the first response is taken
+		resp, err := h.dht.GetCtx(context.Background(), hash, StatusAny, GetMaskEntry)
+		So(err, ShouldBeNil)
+		So(resp.Status, ShouldEqual, StatusDefault)
+		So(resp.Entry.C, ShouldEqual, "contested")
+	})
+
+	Convey("a modified response should be preferred over a deleted one", t, func() {
+		hash := hold("superseded", StatusDeleted, StatusModified)
+		resp, err := h.dht.GetCtx(context.Background(), hash, StatusAny, GetMaskStatus)
+		So(err, ShouldBeNil)
+		So(resp.Status, ShouldEqual, StatusModified)
+	})
+
+	Convey("of equal statuses the smallest peer ID should be preferred", t, func() {
+		hash := hold("agreed", StatusLive, StatusLive)
+		first := nodes[1].nodeID
+		if peer.IDB58Encode(nodes[2].nodeID) < peer.IDB58Encode(first) {
+			first = nodes[2].nodeID
+		}
+		for i := 0; i < 5; i++ {
+			resp, err := h.dht.GetCtx(context.Background(), hash, StatusAny, GetMaskSources)
+			So(err, ShouldBeNil)
+			So(resp.Sources, ShouldResemble, []string{peer.IDB58Encode(first)})
+		}
+	})
+
+	Convey("an entry-only get of any status should be chosen between too", t, func() {
+		hash := hold("undecided", StatusDeleted, StatusLive)
+		for i := 0; i < 5; i++ {
+			resp, err := h.dht.GetCtx(context.Background(), hash, StatusAny, GetMaskEntry)
+			So(err, ShouldBeNil)
+			So(resp.Status, ShouldEqual, StatusDefault)
+			So(resp.Entry.Content(), ShouldEqual, "undecided")
+		}
+	})
+
+	Convey("a peer's hash to follow should be returned from a default get", t, func() {
+		hash := hold("replaced", StatusLive)
+		newHash := hold("replacement", StatusLive)
+		So(nodes[1].dht.Mod(nil, hash, newHash), ShouldBeNil)
+		resp, err := h.dht.GetCtx(context.Background(), hash, StatusDefault, GetMaskEntry)
+		So(err, ShouldEqual, ErrHashModified)
+		So(resp.FollowHash, ShouldEqual, newHash.String())
+		So(resp.Status, ShouldEqual, StatusDefault)
+	})
+}
+
+func TestDHTGetFallback(t *testing.T) {
+	nodesCount := 10
+	mt := setupMultiNodeTesting(nodesCount)
+	defer mt.cleanupMultiNodeTesting()
+	nodes := mt.nodes
+	fullConnect(t, mt.ctx, nodes, nodesCount)
+	h := nodes[0]
+
+	e := GobEntry{C: "far away"}
+	hash, err := e.Sum(h.hashSpec)
+	if err != nil {
+		panic(err)
+	}
+	b, _ := e.Marshal()
+
+	// the entry is held by the peer closest to it, which we stop, and by the
+	// one furthest from it, which a normal query never reaches
+	byID := make(map[peer.ID]*Holochain)
+	var ids []peer.ID
+	for _, n := range nodes[1:] {
+		byID[n.nodeID] = n
+		ids = append(ids, n.nodeID)
+	}
+	ids = SortClosestPeers(ids, hash)
+	primary, far := byID[ids[0]], byID[ids[len(ids)-1]]
+	for _, holder := range []*Holochain{primary, far} {
+		if err = holder.dht.Put(nil, "evenNumbers", hash, holder.nodeID, b, StatusLive); err != nil {
+			panic(err)
+		}
+	}
+	primary.node.Close()
+
+	Convey("without a fallback the get should not find the entry", t, func() {
+		h.Config.GetFallbackFactor = 0
+		_, err := h.dht.GetCtx(context.Background(), hash, StatusAny, GetMaskEntry)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("with a fallback the get should widen to the peer still holding it", t, func() {
+		h.Config.GetFallbackFactor = 3
+		resp, err := h.dht.GetCtx(context.Background(), hash, StatusAny, GetMaskEntry|GetMaskSources)
+		So(err, ShouldBeNil)
+		So(resp.Entry.C, ShouldEqual, "far away")
+		So(resp.Sources, ShouldResemble, []string{peer.IDB58Encode(far.nodeID)})
+	})
+}
+
 func TestDHTKadPut(t *testing.T) {
 	nodesCount := 6
 	mt := setupMultiNodeTesting(nodesCount)
@@ -385,6 +557,31 @@ func TestActionReceiver(t *testing.T) {
 		So(l4star.T, ShouldEqual, "4stars")
 	})
 
+	Convey("GETLINK_REQUEST with pagination should retrieve links a page at a time", t, func() {
+		mq := LinkQuery{Base: hash, T: "", Pagination: Pagination{Offset: 0, Limit: 1}}
+		m := h.node.NewMessage(GETLINK_REQUEST, mq)
+		r, err := ActionReceiver(h, m)
+		So(err, ShouldBeNil)
+		first := r.(*LinkQueryResp)
+		So(len(first.Links), ShouldEqual, 1)
+		So(first.NextOffset, ShouldEqual, 1)
+
+		mq.Pagination.Offset = first.NextOffset
+		m = h.node.NewMessage(GETLINK_REQUEST, mq)
+		r, err = ActionReceiver(h, m)
+		So(err, ShouldBeNil)
+		second := r.(*LinkQueryResp)
+		So(len(second.Links), ShouldEqual, 1)
+		So(second.NextOffset, ShouldEqual, 0)
+		So(first.Links[0].H < second.Links[0].H, ShouldBeTrue)
+
+		mq.Pagination.Offset = 5
+		m = h.node.NewMessage(GETLINK_REQUEST, mq)
+		r, err = ActionReceiver(h, m)
+		So(err, ShouldBeNil)
+		So(len(r.(*LinkQueryResp).Links), ShouldEqual, 0)
+	})
+
 	Convey("GOSSIP_REQUEST should request and advertise data by idx", t, func() {
 		g := GossipReq{MyIdx: 1, YourIdx: 2}
 		m := h.node.NewMessage(GOSSIP_REQUEST, g)
@@ -732,6 +929,39 @@ func TestDHTMultiNode(t *testing.T) {
 	})
 }
 
+func TestDHTGetBatch(t *testing.T) {
+	nodesCount := 3
+	mt := setupMultiNodeTesting(nodesCount)
+	defer mt.cleanupMultiNodeTesting()
+	nodes := mt.nodes
+	ringConnect(t, mt.ctx, mt.nodes, nodesCount)
+
+	hashes := []Hash{}
+	for i := 0; i < 50; i++ {
+		h := nodes[i%nodesCount]
+		hashes = append(hashes, commit(h, "review", fmt.Sprintf("batch statement %d", i)))
+	}
+	missing, _ := NewHash("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzfrom")
+
+	Convey("GetBatch should retrieve all the entries in one call", t, func() {
+		responses, errs := nodes[0].dht.GetBatch(hashes, StatusLive, GetMaskEntry)
+		So(len(errs), ShouldEqual, 0)
+		So(len(responses), ShouldEqual, 50)
+		for i, hash := range hashes {
+			resp := responses[hash]
+			So(resp.Entry.Content(), ShouldEqual, fmt.Sprintf("batch statement %d", i))
+		}
+	})
+
+	Convey("GetBatch should report per hash errors without failing the batch", t, func() {
+		responses, errs := nodes[1].dht.GetBatch([]Hash{hashes[0], missing}, StatusLive, GetMaskEntry)
+		So(len(responses), ShouldEqual, 1)
+		resp := responses[hashes[0]]
+		So(resp.Entry.Content(), ShouldEqual, "batch statement 0")
+		So(errs[missing], ShouldEqual, ErrHashNotFound)
+	})
+}
+
 func TestDHTMakeReciept(t *testing.T) {
 	d, _, h := PrepareTestChain("test")
 	defer CleanupTestChain(h, d)
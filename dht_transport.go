@@ -0,0 +1,118 @@
+package holochain
+
+import (
+	"context"
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	pstore "github.com/libp2p/go-libp2p-peerstore"
+	"sync"
+)
+
+var ErrPeerNotOnTransport = errors.New("peer not on the transport")
+
+// DHTTransport carries the messages between peers in place of the libp2p
+// node, which does when Config.DHTTransport isn't set.  Changes and
+// queries sent through a transport go straight to the peers it says are
+// responsible for the hash, and changes are sent before they return.
+type DHTTransport interface {
+	// Join adds the holochain to the peers the transport reaches
+	Join(h *Holochain)
+	// Leave removes the holochain, i.e. when it's closed
+	Leave(h *Holochain)
+	// Send delivers the message from h to the peer on the protocol,
+	// returning its response
+	Send(ctx context.Context, h *Holochain, proto int, to peer.ID, msg *Message) (response interface{}, err error)
+	// ResponsiblePeers returns the peers other than h responsible for the
+	// hash, closest first
+	ResponsiblePeers(h *Holochain, key Hash) []peer.ID
+	// ClosestPeers returns the n peers other than h closest to the hash,
+	// closest first, for changes to entries whose def sets their redundancy
+	ClosestPeers(h *Holochain, key Hash, n int) []peer.ID
+}
+
+// MemTransport is an in-memory, synchronous DHT transport between the
+// holochains in one process, for tests.  A PUT has reached all its
+// responsible peers by the time it returns, so a GET from any node finds it.
+type MemTransport struct {
+	redundancy int
+	nodes      map[peer.ID]*Holochain
+	lk         sync.RWMutex
+}
+
+// NewMemTransport returns an in-memory transport where the redundancy peers
+// closest to a hash are responsible for it, 0 means every peer is
+func NewMemTransport(redundancy int) *MemTransport {
+	return &MemTransport{redundancy: redundancy, nodes: make(map[peer.ID]*Holochain)}
+}
+
+// Join adds the holochain and makes it and the others already joined peers
+// of each other, in their routing tables and world models
+func (t *MemTransport) Join(h *Holochain) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	for _, o := range t.nodes {
+		memTransportMeet(h, o)
+		memTransportMeet(o, h)
+	}
+	t.nodes[h.nodeID] = h
+}
+
+// memTransportMeet adds b as a peer of a
+func memTransportMeet(a, b *Holochain) {
+	a.node.routingTable.Update(b.nodeID)
+	if a.Config.EnableWorldModel {
+		a.world.AddNode(pstore.PeerInfo{ID: b.nodeID}, b.agent.PubKey())
+	}
+}
+
+// Leave removes the holochain, other peers' sends to it then fail
+func (t *MemTransport) Leave(h *Holochain) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	delete(t.nodes, h.nodeID)
+}
+
+// Send hands the message straight to the peer's receiver for the protocol
+func (t *MemTransport) Send(ctx context.Context, h *Holochain, proto int, to peer.ID, msg *Message) (response interface{}, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	if h.node.IsBlocked(to) {
+		err = ErrBlockedListed
+		return
+	}
+	t.lk.RLock()
+	o, ok := t.nodes[to]
+	t.lk.RUnlock()
+	if !ok {
+		err = ErrPeerNotOnTransport
+		return
+	}
+	// the receiver gets its own copy as it would off the wire
+	m := *msg
+	response, err = o.node.protocols[proto].Receiver(o, &m)
+	return
+}
+
+// ResponsiblePeers returns the peers closest to the hash, all of them if the
+// transport's redundancy is 0
+func (t *MemTransport) ResponsiblePeers(h *Holochain, key Hash) (peers []peer.ID) {
+	return t.ClosestPeers(h, key, t.redundancy)
+}
+
+// ClosestPeers returns the n peers closest to the hash, all of them if n is 0
+func (t *MemTransport) ClosestPeers(h *Holochain, key Hash, n int) (peers []peer.ID) {
+	t.lk.RLock()
+	for id := range t.nodes {
+		if id != h.nodeID {
+			peers = append(peers, id)
+		}
+	}
+	t.lk.RUnlock()
+	peers = SortClosestPeers(peers, key)
+	if n > 0 && len(peers) > n {
+		peers = peers[:n]
+	}
+	return
+}
@@ -0,0 +1,153 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestMemTransportMigrateShare(t *testing.T) {
+	n := 3
+	mt := setupMemMultiNodeTesting(n, 0)
+	defer mt.cleanupMultiNodeTesting()
+
+	Convey("ActionMigrate should share as a PUT on the DHT and roundtrip as JSON", t, func() {
+		header, err := GenTestHeader()
+		So(err, ShouldBeNil)
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		action := ActionMigrate{header: header, entry: entry}
+
+		fn := &APIFnMigrate{action: action}
+		callResponse, err := fn.Call(mt.nodes[0])
+		So(err, ShouldBeNil)
+		dhtHash, ok := callResponse.(Hash)
+		So(ok, ShouldBeTrue)
+		So(fn.action.VerifyEntryLink(), ShouldBeNil)
+
+		// no waiting, every node already holds it
+		for i := 0; i < n; i++ {
+			So(mt.nodes[i].dht.Exists(dhtHash, StatusLive), ShouldBeNil)
+
+			request := GetReq{H: dhtHash, StatusMask: StatusLive, GetMask: GetMaskEntry | GetMaskHeader}
+			response, err := callGet(mt.nodes[i], request, &GetOptions{GetMask: request.GetMask})
+			So(err, ShouldBeNil)
+			r, ok := response.(GetResp)
+			So(ok, ShouldBeTrue)
+			So(&r.Entry, ShouldResemble, action.Entry())
+			So(NewPutAction(MigrateEntryType, &r.Entry, r.Header).VerifyEntryLink(), ShouldBeNil)
+		}
+	})
+}
+
+func TestMemTransportRouting(t *testing.T) {
+	n := 4
+	mt := setupMemMultiNodeTesting(n, 1)
+	defer mt.cleanupMultiNodeTesting()
+	h := mt.nodes[0]
+
+	hash := commit(h, "evenNumbers", "2")
+	transport := h.Config.DHTTransport.(*MemTransport)
+	responsible := transport.ResponsiblePeers(h, hash)
+
+	Convey("only the peer closest to the hash should be responsible for it", t, func() {
+		So(len(responsible), ShouldEqual, 1)
+		var others []peer.ID
+		for _, o := range mt.nodes[1:] {
+			others = append(others, o.nodeID)
+		}
+		So(responsible[0], ShouldEqual, SortClosestPeers(others, hash)[0])
+	})
+
+	Convey("a share should be held by just the responsible peer", t, func() {
+		for _, o := range mt.nodes[1:] {
+			if o.nodeID == responsible[0] {
+				So(o.dht.Exists(hash, StatusLive), ShouldBeNil)
+			} else {
+				So(o.dht.Exists(hash, StatusLive), ShouldEqual, ErrHashNotFound)
+			}
+		}
+	})
+
+	Convey("any peer should be able to get it through the responsible one", t, func() {
+		for _, o := range mt.nodes[1:] {
+			resp, err := o.dht.GetCtx(mt.ctx, hash, StatusLive, GetMaskEntry)
+			So(err, ShouldBeNil)
+			So(resp.Entry.C, ShouldEqual, "2")
+		}
+	})
+
+	Convey("a closed peer should leave the transport", t, func() {
+		closed := mt.nodes[n-1]
+		closed.Close()
+		for _, p := range transport.ResponsiblePeers(h, hash) {
+			So(p, ShouldNotEqual, closed.nodeID)
+		}
+		_, err := transport.Send(mt.ctx, h, ActionProtocol, closed.nodeID, h.node.NewMessage(GET_REQUEST, GetReq{H: hash}))
+		So(err, ShouldEqual, ErrPeerNotOnTransport)
+	})
+}
+
+func TestMemTransportEntryRedundancy(t *testing.T) {
+	n := 5
+	mt := setupMemMultiNodeTesting(n, 1)
+	defer mt.cleanupMultiNodeTesting()
+	h := mt.nodes[0]
+
+	holders := func(hash Hash) (count int) {
+		for _, o := range mt.nodes[1:] {
+			if o.dht.Exists(hash, StatusLive) == nil {
+				count++
+			}
+		}
+		return
+	}
+
+	Convey("an ordinary entry should reach the transport's default number of peers", t, func() {
+		So(holders(commit(h, "evenNumbers", "2")), ShouldEqual, 1)
+	})
+
+	Convey("a migrate should reach as many peers as its def's redundancy", t, func() {
+		zomes := h.nucleus.dna.Zomes
+		defer func() { h.nucleus.dna.Zomes = zomes }()
+		h.nucleus.dna.Zomes = append(zomes[:len(zomes):len(zomes)], Zome{
+			Name:         "migrationRules",
+			RibosomeType: JSRibosomeType,
+			Entries:      []EntryDef{{Name: MigrateEntryType, DataFormat: DataFormatJSON, Redundancy: 3}},
+			Code: `function validateCommit(entryType,entry,header,pkg,sources) { return ""; }
+function validatePut(entryType,entry,header,pkg,sources) { return ""; }`,
+		})
+
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+		response, err := fn.Call(h)
+		So(err, ShouldBeNil)
+		hash := response.(Hash)
+		So(holders(hash), ShouldEqual, 3)
+
+		transport := h.Config.DHTTransport.(*MemTransport)
+		for _, p := range transport.ClosestPeers(h, hash, 3) {
+			for _, o := range mt.nodes[1:] {
+				if o.nodeID == p {
+					So(o.dht.Exists(hash, StatusLive), ShouldBeNil)
+				}
+			}
+		}
+	})
+
+	Convey("a DNA with an entry redundancy below 1 should be rejected", t, func() {
+		dna := DNA{Zomes: []Zome{{Entries: []EntryDef{{Name: "evenNumbers", Redundancy: -1}}}}}
+		err := dna.check()
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, ErrEntryRedundancyInvalid.Error()+": evenNumbers")
+	})
+
+	Convey("a DNA with an entry redundancy above the peers a put reaches should be rejected", t, func() {
+		dna := DNA{Zomes: []Zome{{Entries: []EntryDef{{Name: MigrateEntryType, Redundancy: KValue + 1}}}}}
+		err := dna.check()
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, ErrEntryRedundancyTooHigh.Error()+": "+MigrateEntryType)
+	})
+}
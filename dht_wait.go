@@ -0,0 +1,62 @@
+package holochain
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	"time"
+)
+
+const (
+	WaitForStatusPollInterval = time.Millisecond * 10
+)
+
+var ErrWaitForStatusTimeout = errors.New("timed out waiting for status")
+
+// WaitForStatusError reports the last status seen for a hash that didn't
+// reach the status waited for in time
+type WaitForStatusError struct {
+	Hash Hash
+	// Status is the status mask that was waited for
+	Status int
+	// LastStatus is the last status of the hash seen, only valid if Held
+	LastStatus int
+	Held       bool
+}
+
+func (e *WaitForStatusError) Error() string {
+	if !e.Held {
+		return fmt.Sprintf("%v %#x: %v not held", ErrWaitForStatusTimeout, e.Status, e.Hash)
+	}
+	return fmt.Sprintf("%v %#x: %v has status %#x", ErrWaitForStatusTimeout, e.Status, e.Hash, e.LastStatus)
+}
+
+func (e *WaitForStatusError) Unwrap() error {
+	return ErrWaitForStatusTimeout
+}
+
+// WaitForStatus waits until this node holds hash with one of the statuses in
+// the status mask, i.e. StatusLive, or the timeout elapses in which case it
+// returns a *WaitForStatusError with the last status seen.
+func (dht *DHT) WaitForStatus(hash Hash, status int, timeout time.Duration) (err error) {
+	deadline := time.Now().Add(timeout)
+	last := &WaitForStatusError{Hash: hash, Status: status}
+	for {
+		_, _, _, s, e := dht.Get(hash, StatusAny, GetMaskEntryType)
+		if e == nil {
+			if s&status != 0 {
+				return
+			}
+			last.LastStatus = s
+			last.Held = true
+		} else if e != ErrHashNotFound {
+			err = e
+			return
+		}
+		if !time.Now().Before(deadline) {
+			err = last
+			return
+		}
+		time.Sleep(WaitForStatusPollInterval)
+	}
+}
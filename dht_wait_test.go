@@ -0,0 +1,48 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestDHTWaitForStatus(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	dht := h.dht
+	hash, err := GenTestStringHash()
+	if err != nil {
+		panic(err)
+	}
+
+	Convey("it should time out on a hash that isn't held", t, func() {
+		err := dht.WaitForStatus(hash, StatusLive, time.Millisecond*30)
+		So(errors.Is(err, ErrWaitForStatusTimeout), ShouldBeTrue)
+		var werr *WaitForStatusError
+		So(errors.As(err, &werr), ShouldBeTrue)
+		So(werr.Held, ShouldBeFalse)
+		So(err.Error(), ShouldContainSubstring, "not held")
+	})
+
+	Convey("it should report the last status seen on timeout", t, func() {
+		So(dht.Put(nil, "evenNumbers", hash, h.nodeID, []byte("124"), StatusRejected), ShouldBeNil)
+		err := dht.WaitForStatus(hash, StatusLive, time.Millisecond*30)
+		var werr *WaitForStatusError
+		So(errors.As(err, &werr), ShouldBeTrue)
+		So(werr.Held, ShouldBeTrue)
+		So(werr.LastStatus, ShouldEqual, StatusRejected)
+	})
+
+	Convey("it should return once the hash reaches the status", t, func() {
+		go func() {
+			time.Sleep(time.Millisecond * 50)
+			dht.Put(nil, "evenNumbers", hash, h.nodeID, []byte("124"), StatusLive)
+		}()
+		start := time.Now()
+		So(dht.WaitForStatus(hash, StatusLive, time.Second*5), ShouldBeNil)
+		So(time.Since(start), ShouldBeLessThan, time.Second*5)
+		So(dht.WaitForStatus(hash, StatusLive|StatusRejected, 0), ShouldBeNil)
+	})
+}
@@ -0,0 +1,130 @@
+package holochain
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	ic "github.com/libp2p/go-libp2p-crypto"
+	"golang.org/x/crypto/nacl/box"
+)
+
+var ErrEncryptionKeyNotEd25519 = errors.New("encryption needs an ed25519 key")
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+var ErrDecryptionFailed = errors.New("decryption failed")
+var ErrEntryNotEncrypted = errors.New("entry must be encrypted")
+
+const (
+	curve25519KeySize = 32
+	boxNonceSize      = 24
+	// an ephemeral public key and a nonce precede the sealed box
+	ciphertextOverhead = curve25519KeySize + boxNonceSize + box.Overhead
+)
+
+// EncryptFor seals plaintext so that only the holder of the private key
+// matching pubKey can open it.  Each call uses a new ephemeral key, so the
+// sender can't open the result either.
+func EncryptFor(pubKey ic.PubKey, plaintext []byte) (ciphertext []byte, err error) {
+	var recipient *[curve25519KeySize]byte
+	recipient, err = curve25519PublicKey(pubKey)
+	if err != nil {
+		return
+	}
+	ephemeralPub, ephemeralPriv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return
+	}
+	var nonce [boxNonceSize]byte
+	if _, err = rand.Read(nonce[:]); err != nil {
+		return
+	}
+	ciphertext = append(ephemeralPub[:], nonce[:]...)
+	ciphertext = box.Seal(ciphertext, plaintext, &nonce, recipient, ephemeralPriv)
+	return
+}
+
+// DecryptWith opens ciphertext sealed by EncryptFor to privKey's public key
+func DecryptWith(privKey ic.PrivKey, ciphertext []byte) (plaintext []byte, err error) {
+	if len(ciphertext) < ciphertextOverhead {
+		err = ErrCiphertextTooShort
+		return
+	}
+	var priv *[curve25519KeySize]byte
+	priv, err = curve25519PrivateKey(privKey)
+	if err != nil {
+		return
+	}
+	var ephemeralPub [curve25519KeySize]byte
+	var nonce [boxNonceSize]byte
+	copy(ephemeralPub[:], ciphertext[:curve25519KeySize])
+	copy(nonce[:], ciphertext[curve25519KeySize:curve25519KeySize+boxNonceSize])
+	var ok bool
+	plaintext, ok = box.Open(nil, ciphertext[curve25519KeySize+boxNonceSize:], &nonce, &ephemeralPub, priv)
+	if !ok {
+		err = ErrDecryptionFailed
+	}
+	return
+}
+
+// curve25519PublicKey converts an agent's ed25519 public key to the
+// equivalent curve25519 key
+func curve25519PublicKey(pubKey ic.PubKey) (key *[curve25519KeySize]byte, err error) {
+	pk, ok := pubKey.(*ic.Ed25519PublicKey)
+	if !ok {
+		err = ErrEncryptionKeyNotEd25519
+		return
+	}
+	key, err = pk.ToCurve25519()
+	if err != nil {
+		err = ErrEncryptionKeyNotEd25519
+	}
+	return
+}
+
+// curve25519PrivateKey converts an agent's ed25519 private key to the
+// equivalent curve25519 key
+func curve25519PrivateKey(privKey ic.PrivKey) (key *[curve25519KeySize]byte, err error) {
+	sk, ok := privKey.(*ic.Ed25519PrivateKey)
+	if !ok {
+		err = ErrEncryptionKeyNotEd25519
+		return
+	}
+	key = sk.ToCurve25519()
+	return
+}
+
+// checkEncrypted checks that an entry of a def with Encrypted sharing is
+// encrypted, which can only be done structurally.  A migrate's Data must be
+// encrypted to its Recipient, the content of any other entry must be sealed
+// with EncryptFor and base64 encoded.
+func checkEncrypted(def *EntryDef, entry Entry) (err error) {
+	content, ok := entry.Content().(string)
+	if !ok {
+		err = ErrEntryNotEncrypted
+		return
+	}
+	if def.Name == MigrateEntryType {
+		var migrate MigrateEntry
+		migrate, err = MigrateEntryFromJSON(content)
+		if err != nil {
+			return
+		}
+		if !migrate.IsEncrypted() {
+			err = ErrEntryNotEncrypted
+			return
+		}
+		err = migrate.validateEncryption()
+		return
+	}
+	if def.DataFormat == DataFormatJSON {
+		if json.Unmarshal([]byte(content), &content) != nil {
+			err = ErrEntryNotEncrypted
+			return
+		}
+	}
+	ciphertext, e := base64.StdEncoding.DecodeString(content)
+	if e != nil || len(ciphertext) < ciphertextOverhead {
+		err = ErrEntryNotEncrypted
+	}
+	return
+}
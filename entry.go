@@ -9,12 +9,13 @@ package holochain
 import (
 	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"fmt"
 	. "github.com/holochain/holochain-proto/hash"
 	"github.com/lestrrat/go-jsval"
 	"io"
 	"strings"
-	"fmt"
-	"errors"
+	"time"
 )
 
 const (
@@ -30,9 +31,10 @@ const (
 
 	// Entry sharing types
 
-	Public  = "public"
-	Partial = "partial"
-	Private = "private"
+	Public    = "public"
+	Partial   = "partial"
+	Private   = "private"
+	Encrypted = "encrypted" // shared like public, but validated to be ciphertext
 )
 
 // EntryDef struct holds an entry definition
@@ -42,10 +44,77 @@ type EntryDef struct {
 	Sharing    string
 	Schema     string
 	validator  SchemaValidator
+
+	// DataSchema optionally constrains the Data field of a migrate entry.
+	// It and RequireMigrationChain are only allowed on the migrate entry
+	// type, which a zome declares to set them for the DNA's migrates
+	DataSchema    string
+	dataValidator SchemaValidator
+
+	// RequireMigrationChain requires the Data of an open migrate to be the
+	// hash of the close migrate it continues, held in the DHT with the same Key
+	RequireMigrationChain bool
+
+	// MaxSize is the largest size in bytes an entry of this type may be,
+	// zero means unlimited
+	MaxSize int
+
+	// Format is the encoding entries of this type are serialized with when
+	// they're stored and sent, one of gob, json or raw, empty means gob.
+	// It's independent of DataFormat, which is what the content itself is
+	// and how it's validated: a json DataFormat entry's content is a JSON
+	// string whatever its encoding, which only wraps that string in gob,
+	// JSON or nothing, so raw suits string content
+	Format string
+
+	// TTL is how long the nodes holding an entry of this type keep it live
+	// before deleting it, i.e. for transient handoff tokens, zero means forever
+	TTL time.Duration
+
+	// Redundancy is how many peers a put of an entry of this type is sent
+	// to, i.e. more for migrates than for ordinary data.  Zero means the
+	// DHT's default, otherwise it must be at least 1 and, as a put only
+	// reaches the KValue closest peers the node finds, at most KValue.
+	Redundancy int
+}
+
+var ErrEntryTooLarge = errors.New("entry too large")
+var ErrEntryRedundancyInvalid = errors.New("entry redundancy must be at least 1")
+var ErrEntryRedundancyTooHigh = errors.New("entry redundancy can't be more than the peers a put reaches")
+var ErrEntryDefNotMigrate = errors.New("only the migrate entry type can have a data schema or require a migration chain")
+var ErrEntryDefEncryptedChain = errors.New("an encrypted migrate entry type can't require a migration chain")
+
+// EntryTooLargeError reports an entry that is bigger than its def allows
+type EntryTooLargeError struct {
+	Size    int
+	MaxSize int
+}
+
+func (e *EntryTooLargeError) Error() string {
+	return fmt.Sprintf("%v: %d bytes exceeds maximum of %d", ErrEntryTooLarge, e.Size, e.MaxSize)
+}
+
+func (e *EntryTooLargeError) Unwrap() error {
+	return ErrEntryTooLarge
+}
+
+// entrySize returns the size of an entry's content in bytes
+func entrySize(entry Entry) (size int, err error) {
+	switch c := entry.Content().(type) {
+	case string:
+		size = len(c)
+	case []byte:
+		size = len(c)
+	default:
+		var b []byte
+		b, err = entry.Marshal()
+		size = len(b)
+	}
+	return
 }
 
 func (def EntryDef) isSharingPublic() bool {
-	return def.Sharing == Public || def.DataFormat == DataFormatLinks
+	return def.Sharing == Public || def.Sharing == Encrypted || def.DataFormat == DataFormatLinks
 }
 
 // Entry describes serialization and deserialziation of entry data
@@ -85,7 +154,7 @@ func (def *EntryDef) IsVirtualEntry() bool {
 func MarshalEntry(writer io.Writer, e Entry) (err error) {
 	var b []byte
 	b, err = e.Marshal()
-	l := uint64(len(b))
+	l := uint64(len(b)) | uint64(entryEncodingTag(e))<<entryEncodingTagShift
 	err = binary.Write(writer, binary.LittleEndian, l)
 	if err != nil {
 		return
@@ -101,16 +170,19 @@ func UnmarshalEntry(reader io.Reader) (e Entry, err error) {
 	if err != nil {
 		return
 	}
+	e, err = newEntryOfEncodingTag(byte(l >> entryEncodingTagShift))
+	if err != nil {
+		return
+	}
+	l &= 1<<entryEncodingTagShift - 1
+
 	var b = make([]byte, l)
 	err = binary.Read(reader, binary.LittleEndian, b)
 	if err != nil {
 		return
 	}
 
-	var g GobEntry
-	err = g.Unmarshal(b)
-
-	e = &g
+	err = e.Unmarshal(b)
 	return
 }
 
@@ -159,6 +231,16 @@ func (e *JSONEntry) Unmarshal(b []byte) (err error) {
 }
 func (e *JSONEntry) Content() interface{} { return e.C }
 
+func (e *JSONEntry) Sum(s HashSpec) (h Hash, err error) {
+	var b []byte
+	b, err = e.Marshal()
+	if err != nil {
+		return
+	}
+	h, err = Sum(s, b)
+	return
+}
+
 type JSONSchemaValidator struct {
 	v *jsval.JSVal
 }
@@ -191,6 +273,19 @@ func (d *EntryDef) BuildJSONSchemaValidatorFromString(schema string) (err error)
 	return
 }
 
+// BuildDataJSONSchemaValidatorFromString sets the schema used to validate the Data
+// field of migrate entries
+func (d *EntryDef) BuildDataJSONSchemaValidatorFromString(schema string) (err error) {
+	validator, err := BuildJSONSchemaValidatorFromString(schema)
+	if err != nil {
+		return
+	}
+	validator.v.SetName(d.Name + ".Data")
+	d.DataSchema = schema
+	d.dataValidator = validator
+	return
+}
+
 // sysValidateEntry does system level validation for adding an entry (put or commit)
 // It checks that entry is not nil, and that it conforms to the entry schema in the definition
 // if it's a Links entry that the contents are correctly structured
@@ -232,6 +327,23 @@ func sysValidateEntry(h *Holochain, def *EntryDef, entry Entry, pkg *Package) (e
 		}
 
 		// TODO check anything in the package
+	case GroupEntryType:
+		j, ok := entry.Content().(string)
+		if !ok {
+			err = ValidationFailedErr
+			return
+		}
+		g, e := GroupEntryFromJSON(j)
+		if e != nil {
+			err = ValidationFailedErr
+			return
+		}
+		for _, m := range g.Members {
+			if !isValidPubKey(m) {
+				err = ValidationFailed(ValidationFailureBadPublicKeyFormat)
+				return
+			}
+		}
 	case HeadersEntryType:
 		// TODO check signatures!
 	case DelEntryType:
@@ -243,6 +355,24 @@ func sysValidateEntry(h *Holochain, def *EntryDef, entry Entry, pkg *Package) (e
 		return
 	}
 
+	if def.MaxSize > 0 {
+		var size int
+		size, err = entrySize(entry)
+		if err != nil {
+			return
+		}
+		if size > def.MaxSize {
+			err = &EntryTooLargeError{Size: size, MaxSize: def.MaxSize}
+			return
+		}
+	}
+
+	if def.Sharing == Encrypted {
+		if err = checkEncrypted(def, entry); err != nil {
+			return
+		}
+	}
+
 	// see if there is a schema validator for the entry type and validate it if so
 	if def.validator != nil {
 		var input interface{}
@@ -263,47 +393,69 @@ func sysValidateEntry(h *Holochain, def *EntryDef, entry Entry, pkg *Package) (e
 			// @see https://github.com/holochain/holochain-proto/issues/733
 			hashValue, ok := input.(map[string]interface{})["Hash"].(string)
 			if !ok {
-				err = ValidationFailed("expected string!")
+				err = validationFieldFailed("Hash", "expected string!", nil)
 				return
 			}
 			_, err = NewHash(hashValue)
 			if err != nil {
-				err = ValidationFailed(fmt.Sprintf("Error (%s) when decoding Hash value '%s'", err.Error(), hashValue))
+				err = validationFieldFailed("Hash", fmt.Sprintf("Error (%s) when decoding Hash value '%s'", err.Error(), hashValue), err)
 				return
 			}
 		}
-		if def == MigrateEntryDef {
+		if def.Name == MigrateEntryType {
 			// @TODO refactor with above
 			// @see https://github.com/holochain/holochain-proto/issues/733
 			dnaHashValue, ok := input.(map[string]interface{})["DNAHash"].(string)
 			if !ok {
-				err = ValidationFailed("expected string!")
+				err = validationFieldFailed("DNAHash", "expected string!", nil)
 				return
 			}
 			_, err = NewHash(dnaHashValue)
 			if err != nil {
-				err = ValidationFailed(fmt.Sprintf("Error (%s) when decoding DNAHash value '%s'", err.Error(), dnaHashValue))
+				err = validationFieldFailed("DNAHash", fmt.Sprintf("Error (%s) when decoding DNAHash value '%s'", err.Error(), dnaHashValue), err)
 				return
 			}
 
 			keyValue, ok := input.(map[string]interface{})["Key"].(string)
 			if !ok {
-				err = ValidationFailed("expected string!")
+				err = validationFieldFailed("Key", "expected string!", nil)
 				return
 			}
 			_, err = NewHash(keyValue)
 			if err != nil {
-				err = ValidationFailed(fmt.Sprintf("Error (%s) when decoding Key value '%s'", err.Error(), keyValue))
+				err = validationFieldFailed("Key", fmt.Sprintf("Error (%s) when decoding Key value '%s'", err.Error(), keyValue), err)
 				return
 			}
 
 			typeValue, ok := input.(map[string]interface{})["Type"].(string)
 			if !ok {
-				err = ValidationFailed("expected string!")
+				err = validationFieldFailed("Type", "expected string!", nil)
+				return
+			}
+			// open and close are reserved and checked positionally by the action,
+			// any other non-empty type is allowed
+			if typeValue == "" {
+				err = validationFieldFailed("Type", "Type value must not be empty", nil)
+				return
+			}
+			var migrate MigrateEntry
+			if migrate, err = MigrateEntryFromJSON(entry.Content().(string)); err != nil {
+				err = ValidationFailed(err.Error())
+				return
+			}
+			if err = sysValidateMigrateData(h, def, &migrate); err != nil {
+				return
+			}
+		}
+		if def == MigrateRollbackEntryDef {
+			headerHashValue, ok := input.(map[string]interface{})["MigrateHeaderHash"].(string)
+			if !ok {
+				err = validationFieldFailed("MigrateHeaderHash", "expected string!", nil)
 				return
 			}
-			if !(typeValue == MigrateEntryTypeClose || typeValue == MigrateEntryTypeOpen) {
-				err = ValidationFailed(fmt.Sprintf("Type value '%s' must be either '%s' or '%s'", typeValue, MigrateEntryTypeOpen, MigrateEntryTypeClose))
+			_, err = NewHash(headerHashValue)
+			if err != nil {
+				err = validationFieldFailed("MigrateHeaderHash", fmt.Sprintf("Error (%s) when decoding MigrateHeaderHash value '%s'", err.Error(), headerHashValue), err)
 				return
 			}
 		}
@@ -347,5 +499,13 @@ func sysValidateEntry(h *Holochain, def *EntryDef, entry Entry, pkg *Package) (e
 		}
 
 	}
+
+	// registered sys entry types get their own validation
+	if r, ok := getRegisteredSysEntryType(def.Name); ok && r.validator != nil {
+		err = r.validator(h, entry)
+		if err != nil && !IsValidationFailedErr(err) {
+			err = ValidationFailed(err.Error())
+		}
+	}
 	return
 }
@@ -0,0 +1,217 @@
+package holochain
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+)
+
+const (
+	// Entry encodings, an EntryDef with no Encoding uses gob
+
+	EntryEncodingGob  = "gob"
+	EntryEncodingJSON = "json"
+	EntryEncodingRaw  = "raw"
+)
+
+// entry encoding tags carried in the top byte of an entry's length when it's
+// marshaled to a stream, gob is zero so existing chains read as they always have
+const (
+	entryEncodingTagGob byte = iota
+	entryEncodingTagJSON
+	entryEncodingTagRaw
+)
+
+const entryEncodingTagShift = 56
+
+var ErrEntryEncodingUnknown = errors.New("unknown entry encoding")
+var ErrRawEntryContent = errors.New("raw entry content must be a string or bytes")
+
+// RawEntry is a structure for implementing Entry content that is stored as its
+// bytes as is
+type RawEntry struct {
+	C interface{}
+}
+
+func (e *RawEntry) Marshal() (b []byte, err error) {
+	switch c := e.C.(type) {
+	case string:
+		b = []byte(c)
+	case []byte:
+		b = c
+	default:
+		err = ErrRawEntryContent
+	}
+	return
+}
+
+func (e *RawEntry) Unmarshal(b []byte) (err error) {
+	e.C = string(b)
+	return
+}
+
+func (e *RawEntry) Content() interface{} { return e.C }
+
+func (e *RawEntry) Sum(s HashSpec) (h Hash, err error) {
+	var b []byte
+	b, err = e.Marshal()
+	if err != nil {
+		return
+	}
+	h, err = Sum(s, b)
+	return
+}
+
+// checkEntryEncoding returns an error if the encoding isn't one we know how to serialize
+func checkEntryEncoding(encoding string) (err error) {
+	switch encoding {
+	case "", EntryEncodingGob, EntryEncodingJSON, EntryEncodingRaw:
+	default:
+		err = fmt.Errorf("%v: %s", ErrEntryEncodingUnknown, encoding)
+	}
+	return
+}
+
+// newEntryOfEncoding returns an empty entry that serializes in the given encoding
+func newEntryOfEncoding(encoding string) (e Entry, err error) {
+	switch encoding {
+	case "", EntryEncodingGob:
+		e = &GobEntry{}
+	case EntryEncodingJSON:
+		e = &JSONEntry{}
+	case EntryEncodingRaw:
+		e = &RawEntry{}
+	default:
+		err = fmt.Errorf("%v: %s", ErrEntryEncodingUnknown, encoding)
+	}
+	return
+}
+
+// EncodeEntry returns the entry with its content serialized in the def's
+// encoding.  Entries already in that encoding, and so all entries of defs that
+// don't declare one, are returned as is.
+func (def *EntryDef) EncodeEntry(entry Entry) (encoded Entry, err error) {
+	encoded = entry
+	if entry == nil {
+		return
+	}
+	switch def.Format {
+	case "", EntryEncodingGob:
+		if _, ok := entry.(*GobEntry); !ok {
+			encoded = &GobEntry{C: entry.Content()}
+		}
+	case EntryEncodingJSON:
+		if _, ok := entry.(*JSONEntry); !ok {
+			encoded = &JSONEntry{C: entry.Content()}
+		}
+	case EntryEncodingRaw:
+		if _, ok := entry.(*RawEntry); !ok {
+			encoded = &RawEntry{C: entry.Content()}
+		}
+	default:
+		err = fmt.Errorf("%v: %s", ErrEntryEncodingUnknown, def.Format)
+	}
+	return
+}
+
+// UnmarshalEntry unserializes an entry in the def's encoding
+func (def *EntryDef) UnmarshalEntry(b []byte) (entry Entry, err error) {
+	entry, err = newEntryOfEncoding(def.Format)
+	if err != nil {
+		return
+	}
+	err = entry.Unmarshal(b)
+	return
+}
+
+// entryEncodingTag returns the stream tag of the entry's encoding
+func entryEncodingTag(e Entry) byte {
+	switch e.(type) {
+	case *JSONEntry:
+		return entryEncodingTagJSON
+	case *RawEntry:
+		return entryEncodingTagRaw
+	}
+	return entryEncodingTagGob
+}
+
+// newEntryOfEncodingTag returns an empty entry for a stream tag
+func newEntryOfEncodingTag(tag byte) (e Entry, err error) {
+	switch tag {
+	case entryEncodingTagGob:
+		e = &GobEntry{}
+	case entryEncodingTagJSON:
+		e = &JSONEntry{}
+	case entryEncodingTagRaw:
+		e = &RawEntry{}
+	default:
+		err = fmt.Errorf("%v: tag %d", ErrEntryEncodingUnknown, tag)
+	}
+	return
+}
+
+// copyEntry returns a shallow copy of an entry of one of the known encodings
+func copyEntry(e Entry) Entry {
+	switch t := e.(type) {
+	case *JSONEntry:
+		c := *t
+		return &c
+	case *RawEntry:
+		c := *t
+		return &c
+	}
+	g := asGobEntry(e)
+	return &g
+}
+
+// asGobEntry returns the entry as the GobEntry that carries entry content in
+// responses
+func asGobEntry(e Entry) GobEntry {
+	if g, ok := e.(*GobEntry); ok {
+		return *g
+	}
+	return GobEntry{C: e.Content()}
+}
+
+// entrySetter is implemented by actions whose entry can be replaced by one in
+// its def's encoding
+type entrySetter interface {
+	setEntry(entry Entry)
+}
+
+// encodeEntry returns the entry in the encoding of its entry type's def,
+// entries of unknown types are returned as is
+func (h *Holochain) encodeEntry(entryType string, entry Entry) (encoded Entry, err error) {
+	encoded = entry
+	if _, def, e := h.GetEntryDef(entryType); e == nil && def != nil {
+		encoded, err = def.EncodeEntry(entry)
+	}
+	return
+}
+
+// marshalEntry serializes an entry in the encoding of its entry type's def
+func (h *Holochain) marshalEntry(entryType string, entry Entry) (b []byte, err error) {
+	entry, err = h.encodeEntry(entryType, entry)
+	if err != nil {
+		return
+	}
+	b, err = entry.Marshal()
+	return
+}
+
+// unmarshalEntry unserializes an entry in the encoding of its entry type's def
+// returning it as a GobEntry
+func (h *Holochain) unmarshalEntry(entryType string, b []byte) (entry GobEntry, err error) {
+	var e Entry
+	if _, def, de := h.GetEntryDef(entryType); de == nil && def != nil {
+		e, err = def.UnmarshalEntry(b)
+	} else {
+		e = &GobEntry{}
+		err = e.Unmarshal(b)
+	}
+	if err != nil {
+		return
+	}
+	entry = asGobEntry(e)
+	return
+}
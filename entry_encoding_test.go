@@ -0,0 +1,111 @@
+package holochain
+
+import (
+	"bytes"
+	"encoding/binary"
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestEntryEncodings(t *testing.T) {
+	Convey("an unknown encoding should be rejected", t, func() {
+		So(checkEntryEncoding(""), ShouldBeNil)
+		So(checkEntryEncoding("xml"), ShouldNotBeNil)
+		_, err := (&EntryDef{Format: "xml"}).EncodeEntry(&GobEntry{C: "1"})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("defs without an encoding should leave entries as they are", t, func() {
+		e := &GobEntry{C: "124"}
+		f, err := (&EntryDef{}).EncodeEntry(e)
+		So(err, ShouldBeNil)
+		So(f, ShouldEqual, e)
+
+		var b bytes.Buffer
+		So(MarshalEntry(&b, e), ShouldBeNil)
+		m, _ := e.Marshal()
+		var l uint64
+		So(binary.Read(&b, binary.LittleEndian, &l), ShouldBeNil)
+		So(l, ShouldEqual, len(m))
+	})
+
+	for _, encoding := range []string{EntryEncodingGob, EntryEncodingJSON, EntryEncodingRaw} {
+		def := &EntryDef{Name: "evenNumbers", DataFormat: DataFormatString, Format: encoding}
+		Convey(encoding+" entries should roundtrip through marshaling and streams", t, func() {
+			e, err := def.EncodeEntry(&GobEntry{C: "124"})
+			So(err, ShouldBeNil)
+			b, err := e.Marshal()
+			So(err, ShouldBeNil)
+			u, err := def.UnmarshalEntry(b)
+			So(err, ShouldBeNil)
+			So(u.Content(), ShouldEqual, "124")
+
+			var buf bytes.Buffer
+			So(MarshalEntry(&buf, e), ShouldBeNil)
+			s, err := UnmarshalEntry(&buf)
+			So(err, ShouldBeNil)
+			So(s, ShouldResemble, e)
+		})
+	}
+
+	Convey("a raw entry should be its bytes", t, func() {
+		b, err := (&RawEntry{C: "124"}).Marshal()
+		So(err, ShouldBeNil)
+		So(string(b), ShouldEqual, "124")
+		_, err = (&RawEntry{C: 124}).Marshal()
+		So(err, ShouldEqual, ErrRawEntryContent)
+	})
+}
+
+func TestEntryEncodingsCommitAndGet(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	setEncoding := func(entryType string, encoding string) {
+		for i := range h.nucleus.dna.Zomes {
+			for j := range h.nucleus.dna.Zomes[i].Entries {
+				if h.nucleus.dna.Zomes[i].Entries[j].Name == entryType {
+					h.nucleus.dna.Zomes[i].Entries[j].Format = encoding
+				}
+			}
+		}
+	}
+	defer setEncoding("evenNumbers", "")
+
+	for i, encoding := range []string{EntryEncodingGob, EntryEncodingJSON, EntryEncodingRaw} {
+		encoding := encoding
+		content := []string{"2", "4", "6"}[i]
+		Convey(encoding+" entries should be committed and got back the same", t, func() {
+			setEncoding("evenNumbers", encoding)
+			_, def, err := h.GetEntryDef("evenNumbers")
+			So(err, ShouldBeNil)
+			So(def.Format, ShouldEqual, encoding)
+
+			a := NewCommitAction("evenNumbers", &GobEntry{C: content})
+			hash, err := h.commitAndShare(a, NullHash())
+			So(err, ShouldBeNil)
+			So(a.VerifyEntryLink(), ShouldBeNil)
+
+			expected, err := def.EncodeEntry(a.Entry())
+			So(err, ShouldBeNil)
+			expectedHash, err := expected.Sum(h.hashSpec)
+			So(err, ShouldBeNil)
+			So(hash.String(), ShouldEqual, expectedHash.String())
+
+			stored, _, _, _, err := h.dht.Get(hash, StatusLive, GetMaskEntry)
+			So(err, ShouldBeNil)
+			b, _ := expected.Marshal()
+			So(stored, ShouldResemble, b)
+
+			rsp, err := callGet(h, GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry}, &GetOptions{StatusMask: StatusLive, GetMask: GetMaskEntry})
+			So(err, ShouldBeNil)
+			So(rsp.(GetResp).Entry.C, ShouldEqual, a.Entry().Content())
+
+			m := h.node.NewMessage(GET_REQUEST, GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry})
+			r, err := ActionReceiver(h, m)
+			So(err, ShouldBeNil)
+			So(r.(GetResp).Entry.C, ShouldEqual, a.Entry().Content())
+		})
+	}
+}
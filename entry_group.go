@@ -0,0 +1,254 @@
+package holochain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	b58 "github.com/jbenet/go-base58"
+	ic "github.com/libp2p/go-libp2p-crypto"
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+const (
+	GroupEntryType   = SysEntryTypePrefix + "group"
+	GroupEntrySchema = `
+{
+  "$id": "http://example.com/example.json",
+  "type": "object",
+  "definitions": {},
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "properties": {
+    "Members": {
+      "$id": "/properties/Members",
+      "type": "array",
+      "title": "The Members Schema ",
+      "items": {
+        "type": "string"
+      }
+    }
+  },
+  "required": ["Members"]
+}
+`
+)
+
+var ErrNotGroupMember = errors.New("signer is not a member of the group chain")
+var ErrChainSignerNotMember = errors.New("header signed by an agent that isn't a member of the group")
+var ErrGroupEntryByMember = errors.New("only the chain's own agent can change its group")
+var ErrGroupHeaderInvalid = errors.New("group entry wasn't committed by the chain's agent before the entry")
+
+// GroupEntry makes a chain a group chain, one that agents other than its
+// own can append to.  Members are the b58 encoded public keys, as in an
+// agent's entry, of the agents authorized to sign its headers.  A later group
+// entry replaces the members of an earlier one.
+type GroupEntry struct {
+	Members []string
+}
+
+var GroupEntryDef = &EntryDef{Name: GroupEntryType, DataFormat: DataFormatJSON, Sharing: Public, Schema: GroupEntrySchema}
+
+func (e *GroupEntry) Def() *EntryDef {
+	return GroupEntryDef
+}
+
+func (e *GroupEntry) ToJSON() (encodedEntry string, err error) {
+	var j []byte
+	j, err = json.Marshal(e)
+	encodedEntry = string(j)
+	return
+}
+
+func GroupEntryFromJSON(j string) (entry GroupEntry, err error) {
+	err = json.Unmarshal([]byte(j), &entry)
+	return
+}
+
+// IsMember returns true if the encoded public key is one of the group's members
+func (e *GroupEntry) IsMember(b58pk string) bool {
+	for _, m := range e.Members {
+		if m == b58pk {
+			return true
+		}
+	}
+	return false
+}
+
+// encodePubKey b58 encodes a public key as it's held in agent and group entries
+func encodePubKey(pubKey ic.PubKey) (b58pk string, err error) {
+	var pk []byte
+	if pk, err = ic.MarshalPublicKey(pubKey); err != nil {
+		return
+	}
+	b58pk = b58.Encode(pk)
+	return
+}
+
+// Group returns the group entry in effect at the top of the chain, nil if
+// the chain isn't a group chain
+func (c *Chain) Group() (group *GroupEntry, err error) {
+	c.lk.RLock()
+	defer c.lk.RUnlock()
+	group, err = c.groupAt(len(c.Headers))
+	return
+}
+
+// groupAt returns the last group entry before index i
+func (c *Chain) groupAt(i int) (group *GroupEntry, err error) {
+	if j := c.groupIndexAt(i); j >= 0 {
+		var g GroupEntry
+		if g, err = GroupEntryFromJSON(c.Entries[j].Content().(string)); err != nil {
+			return
+		}
+		group = &g
+	}
+	return
+}
+
+// groupIndexAt returns the index of the last group entry before index i, -1
+// if there isn't one
+func (c *Chain) groupIndexAt(i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if c.Headers[j].Type == GroupEntryType {
+			return j
+		}
+	}
+	return -1
+}
+
+// memberKeyAt returns the key of the Signer of the header at index i, which
+// must be a member of the group in effect there
+func (c *Chain) memberKeyAt(i int, hd *Header) (pubKey ic.PubKey, err error) {
+	if hd.Type == GroupEntryType {
+		err = ErrGroupEntryByMember
+		return
+	}
+	var group *GroupEntry
+	if group, err = c.groupAt(i); err != nil {
+		return
+	}
+	if group == nil || !group.IsMember(hd.Signer) {
+		err = ErrChainSignerNotMember
+		return
+	}
+	pubKey, err = DecodePubKey(hd.Signer)
+	return
+}
+
+// groupOfEntry returns the JSON of the group entry in effect for the entry
+// with the given hash along with the header it was committed with, empty if
+// the chain wasn't a group chain there
+func (c *Chain) groupOfEntry(hash Hash) (j string, header *Header, err error) {
+	c.lk.RLock()
+	defer c.lk.RUnlock()
+	i, ok := c.Emap[hash]
+	if !ok {
+		err = ErrHashNotFound
+		return
+	}
+	g := c.groupIndexAt(i)
+	if g < 0 {
+		return
+	}
+	j, _ = c.Entries[g].Content().(string)
+	header = c.Headers[g]
+	return
+}
+
+// checkMember returns the encoded key of a member about to sign an entry of
+// entryType on the chain, failing if it isn't a member or the entry would
+// change the group
+func (c *Chain) checkMember(entryType string, member ic.PrivKey) (signer string, err error) {
+	if entryType == GroupEntryType {
+		err = ErrGroupEntryByMember
+		return
+	}
+	if signer, err = encodePubKey(member.GetPublic()); err != nil {
+		return
+	}
+	var group *GroupEntry
+	if group, err = c.Group(); err != nil {
+		return
+	}
+	if group == nil || !group.IsMember(signer) {
+		err = ErrNotGroupMember
+	}
+	return
+}
+
+// checkHeaderSigner checks that a header signed by a group member was signed
+// with that member's key, and that the member was in the author's group.  For
+// our own commits the group is read from our chain, otherwise from the one
+// the author sent in the validation package, whose header must show the
+// author committed it no later than the entry.
+func checkHeaderSigner(h *Holochain, hd *Header, pkg *Package, source peer.ID) (err error) {
+	if hd.Type == GroupEntryType {
+		err = ErrGroupEntryByMember
+		return
+	}
+	var group *GroupEntry
+	if source == h.nodeID {
+		if group, err = h.chain.Group(); err != nil {
+			return
+		}
+	} else if pkg != nil && pkg.Group != "" {
+		if err = checkGroupHeader(h, hd, pkg, source); err != nil {
+			return
+		}
+		var g GroupEntry
+		if g, err = GroupEntryFromJSON(pkg.Group); err != nil {
+			err = ValidationFailedErr
+			return
+		}
+		group = &g
+	}
+	if group == nil || !group.IsMember(hd.Signer) {
+		err = ErrChainSignerNotMember
+		return
+	}
+	pubKey, e := DecodePubKey(hd.Signer)
+	if e != nil {
+		err = ErrChainSignatureInvalid
+		return
+	}
+	matches, e := pubKey.Verify([]byte(hd.EntryLink), hd.Sig.S)
+	if e != nil || !matches {
+		err = ErrChainSignatureInvalid
+	}
+	return
+}
+
+// checkGroupHeader checks that the package's group header is for its group
+// entry, was signed by the author itself and is no later than the header hd
+func checkGroupHeader(h *Holochain, hd *Header, pkg *Package, author peer.ID) (err error) {
+	gh := pkg.GroupHeader
+	if gh == nil || gh.Type != GroupEntryType || gh.Signer != "" || gh.Time.After(hd.Time) {
+		err = ErrGroupHeaderInvalid
+		return
+	}
+	entry := GobEntry{C: pkg.Group}
+	hash, e := entry.Sum(h.hashSpec)
+	if e != nil || !hash.Equal(gh.EntryLink) {
+		err = ErrGroupHeaderInvalid
+		return
+	}
+	pubKey, e := h.getNodePubKey(author)
+	if e != nil {
+		err = ErrGroupHeaderInvalid
+		return
+	}
+	matches, e := pubKey.Verify([]byte(gh.EntryLink), gh.Sig.S)
+	if e != nil || !matches {
+		err = ErrGroupHeaderInvalid
+	}
+	return
+}
+
+// CommitAsMember commits an entry to a group chain signed by one of its
+// members, whose key is the header's Signer, and shares it.  It's validated
+// as any commit is, and members can't change the group, only the chain's own
+// agent can.
+func (h *Holochain) CommitAsMember(a CommittingAction, member ic.PrivKey) (response Hash, err error) {
+	response, _, err = h.commitAndShareAs(context.Background(), a, NullHash(), DefaultSharePolicy, member)
+	return
+}
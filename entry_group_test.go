@@ -0,0 +1,177 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestGroupChain(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	member, err := NewAgent(LibP2P, "member", nil)
+	if err != nil {
+		panic(err)
+	}
+	outsider, err := NewAgent(LibP2P, "outsider", nil)
+	if err != nil {
+		panic(err)
+	}
+	memberKey, _ := encodePubKey(member.PubKey())
+	outsiderKey, _ := encodePubKey(outsider.PubKey())
+
+	Convey("a chain without a group entry shouldn't be a group chain", t, func() {
+		group, err := h.chain.Group()
+		So(err, ShouldBeNil)
+		So(group, ShouldBeNil)
+		_, err = h.CommitAsMember(NewCommitAction("evenNumbers", &GobEntry{C: "2"}), member.PrivKey())
+		So(err, ShouldEqual, ErrNotGroupMember)
+	})
+
+	Convey("a group entry should be validated and make the chain a group chain", t, func() {
+		bad := GroupEntry{Members: []string{"not a key"}}
+		j, _ := bad.ToJSON()
+		_, err := h.commitAndShare(NewCommitAction(GroupEntryType, &GobEntry{C: j}), NullHash())
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+
+		g := GroupEntry{Members: []string{memberKey}}
+		j, _ = g.ToJSON()
+		commit(h, GroupEntryType, j)
+		group, err := h.chain.Group()
+		So(err, ShouldBeNil)
+		So(group.IsMember(memberKey), ShouldBeTrue)
+		So(group.IsMember(outsiderKey), ShouldBeFalse)
+	})
+
+	Convey("members but no one else should be able to append", t, func() {
+		hash, err := h.CommitAsMember(NewCommitAction("evenNumbers", &GobEntry{C: "4"}), member.PrivKey())
+		So(err, ShouldBeNil)
+		So(h.chain.Top().Signer, ShouldEqual, memberKey)
+		So(h.chain.Top().EntryLink.String(), ShouldEqual, hash.String())
+		_, err = h.CommitAsMember(NewCommitAction("evenNumbers", &GobEntry{C: "6"}), outsider.PrivKey())
+		So(err, ShouldEqual, ErrNotGroupMember)
+		_, err = h.CommitAsMember(NewCommitAction(GroupEntryType, &GobEntry{C: `{"Members":[]}`}), member.PrivKey())
+		So(err, ShouldEqual, ErrGroupEntryByMember)
+		// member commits are still validated
+		_, err = h.CommitAsMember(NewCommitAction("evenNumbers", &GobEntry{C: "5"}), member.PrivKey())
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+		So(h.chain.VerifyIntegrity(true), ShouldBeNil)
+	})
+
+	Convey("a header's signer should survive marshaling", t, func() {
+		hd := h.chain.Top()
+		b, err := hd.Marshal()
+		So(err, ShouldBeNil)
+		var hd2 Header
+		So(hd2.Unmarshal(b, 34), ShouldBeNil)
+		So(hd2.Signer, ShouldEqual, memberKey)
+		So(hd2.Sig.Equal(hd.Sig), ShouldBeTrue)
+	})
+
+	// a node that isn't in the group validating h's entries as the DHT does
+	d2, _, h2 := PrepareTestChain("test2")
+	defer CleanupTestChain(h2, d2)
+	// which knows h's key as the DHT would hold it
+	hKey, _ := h.agent.EncodePubKey()
+	if err := h2.dht.Put(nil, KeyEntryType, HashFromPeerID(h.nodeID), h.nodeID, []byte(hKey), StatusLive); err != nil {
+		panic(err)
+	}
+	validatePut := func(hash Hash, header *Header, pkg *Package) error {
+		resp, err := h.GetValidationResponse(&ActionPut{}, hash)
+		if err != nil {
+			panic(err)
+		}
+		if header == nil {
+			header = &resp.Header
+		}
+		if pkg == nil {
+			pkg = &resp.Package
+		}
+		_, err = h2.ValidateAction(NewPutAction(resp.Type, &resp.Entry, header), resp.Type, pkg, []peer.ID{h.nodeID})
+		return err
+	}
+
+	Convey("a member's entry should be validated against the author's group from the package", t, func() {
+		hash, err := h.CommitAsMember(NewCommitAction("evenNumbers", &GobEntry{C: "8"}), member.PrivKey())
+		So(err, ShouldBeNil)
+		So(validatePut(hash, nil, nil), ShouldBeNil)
+		So(validatePut(hash, nil, &Package{}), ShouldEqual, ErrChainSignerNotMember)
+	})
+
+	// the package h sends for its members' entries
+	memberHash, err := h.CommitAsMember(NewCommitAction("evenNumbers", &GobEntry{C: "12"}), member.PrivKey())
+	if err != nil {
+		panic(err)
+	}
+	memberResp, err := h.GetValidationResponse(&ActionPut{}, memberHash)
+	if err != nil {
+		panic(err)
+	}
+	groupPkg := memberResp.Package
+
+	// a group listing the outsider along with a header for it the outsider signed
+	forgedGroup := GroupEntry{Members: []string{memberKey, outsiderKey}}
+	forgedJ, _ := forgedGroup.ToJSON()
+	_, forgedGroupHeader, err := newHeader(h.hashSpec, h.Now(), GroupEntryType, &GobEntry{C: forgedJ}, outsider.PrivKey(), NullHash(), NullHash(), NullHash())
+	if err != nil {
+		panic(err)
+	}
+	forgedGroupHeader.Time = groupPkg.GroupHeader.Time
+
+	Convey("the package should carry the group entry's header", t, func() {
+		So(groupPkg.Group, ShouldNotEqual, "")
+		So(groupPkg.GroupHeader, ShouldNotBeNil)
+		So(groupPkg.GroupHeader.Type, ShouldEqual, GroupEntryType)
+	})
+
+	Convey("a put claiming a signer that isn't in the group, or didn't sign it, should be rejected", t, func() {
+		hash := commit(h, "evenNumbers", "10")
+		hd, err := h.chain.GetEntryHeader(hash)
+		So(err, ShouldBeNil)
+
+		forged := *hd
+		forged.Signer = outsiderKey
+		So(validatePut(hash, &forged, &groupPkg), ShouldEqual, ErrChainSignerNotMember)
+		forged.Signer = memberKey
+		So(validatePut(hash, &forged, &groupPkg), ShouldEqual, ErrChainSignatureInvalid)
+	})
+
+	Convey("a group in the package that the author didn't commit before the entry should be rejected", t, func() {
+		So(validatePut(memberHash, nil, &Package{Group: groupPkg.Group}), ShouldEqual, ErrGroupHeaderInvalid)
+		So(validatePut(memberHash, nil, &Package{Group: forgedJ, GroupHeader: groupPkg.GroupHeader}), ShouldEqual, ErrGroupHeaderInvalid)
+		So(validatePut(memberHash, nil, &Package{Group: forgedJ, GroupHeader: forgedGroupHeader}), ShouldEqual, ErrGroupHeaderInvalid)
+
+		later := *groupPkg.GroupHeader
+		later.Time = memberResp.Header.Time.Add(time.Second)
+		So(validatePut(memberHash, nil, &Package{Group: groupPkg.Group, GroupHeader: &later}), ShouldEqual, ErrGroupHeaderInvalid)
+	})
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	entry.Type = MigrateEntryTypeClose
+
+	Convey("an unauthorized agent's close migrate on a group chain should be rejected where it's validated", t, func() {
+		a := &ActionMigrate{entry: entry}
+		_, forged, err := newHeader(h.hashSpec, h.Now(), MigrateEntryType, a.Entry(), outsider.PrivKey(), NullHash(), NullHash(), NullHash())
+		So(err, ShouldBeNil)
+		forged.Signer = outsiderKey
+		a.header = forged
+
+		_, err = h2.ValidateAction(a, MigrateEntryType, &groupPkg, []peer.ID{h.nodeID})
+		So(err, ShouldEqual, ErrChainSignerNotMember)
+		_, err = h2.ValidateAction(a, MigrateEntryType, &Package{Group: forgedJ, GroupHeader: forgedGroupHeader}, []peer.ID{h.nodeID})
+		So(err, ShouldEqual, ErrGroupHeaderInvalid)
+	})
+
+	Convey("a member's close migrate should be accepted and validate where it's held", t, func() {
+		hash, err := h.CommitAsMember(&ActionMigrate{entry: entry}, member.PrivKey())
+		So(err, ShouldBeNil)
+		So(h.chain.Top().Signer, ShouldEqual, memberKey)
+		So(validatePut(hash, nil, nil), ShouldBeNil)
+	})
+}
@@ -1,8 +1,13 @@
 package holochain
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	. "github.com/holochain/holochain-proto/hash"
+	b58 "github.com/jbenet/go-base58"
+	ic "github.com/libp2p/go-libp2p-crypto"
+	"strings"
 )
 
 const (
@@ -38,23 +43,41 @@ const (
       "type": "string",
       "title": "The Data Schema ",
       "default": ""
+    },
+    "Recipient": {
+      "$id": "/properties/Recipient",
+      "type": "string",
+      "title": "The Recipient Schema ",
+      "default": ""
     }
   },
   "required": ["Type", "DNAHash", "Key"]
 }
 `
 
-	// Type can only be one of two things... open or close
+	// Type open and close are reserved, a close must be the last entry on the
+	// source chain and an open the first non-genesis entry on the destination.
+	// Any other type is free-form and not positionally checked.
 	MigrateEntryTypeClose = "close"
 	MigrateEntryTypeOpen  = "open"
 )
 
+var ErrMigrateEntryTypeEmpty = errors.New("migrate entry: Type must not be empty")
+var ErrMigrateEntryTypeReserved = errors.New("migrate entry: Type prefix " + SysEntryTypePrefix + " is reserved")
+var ErrMigrateEntryDNAHashMissing = errors.New("migrate entry: DNAHash must not be the null hash")
+var ErrMigrateEntryKeyMissing = errors.New("migrate entry: Key must not be the null hash")
+var ErrMigrateEntryRecipientInvalid = errors.New("migrate entry: Recipient must be a valid public key")
+var ErrMigrateEntryDataNotEncrypted = errors.New("migrate entry: Data must be encrypted when there is a Recipient")
+
 // MigrateEntry struct is the record of a chain opening or closing
+// If Recipient is set, it is the public key of the destination agent and Data
+// is encrypted to it, base64 encoded, so it isn't readable on the DHT
 type MigrateEntry struct {
 	Type  string
 	DNAHash Hash
 	Key  Hash
 	Data  string
+	Recipient string
 }
 
 var MigrateEntryDef = &EntryDef{Name: MigrateEntryType, DataFormat: DataFormatJSON, Sharing: Public, Schema: MigrateEntrySchema}
@@ -64,17 +87,54 @@ func (e *MigrateEntry) Def() *EntryDef {
 	return MigrateEntryDef
 }
 
+// migrateEntryDef returns the def migrates are validated and shared with: the
+// built-in one, with the DataSchema, RequireMigrationChain, Redundancy and
+// MaxSize of the migrate entry type if a zome of the DNA declares it, and its
+// Sharing if that is Encrypted
+func (h *Holochain) migrateEntryDef() (def *EntryDef, err error) {
+	_, appDef := h.migrateValidationZome()
+	if appDef == nil {
+		def = MigrateEntryDef
+		return
+	}
+	d := *MigrateEntryDef
+	d.RequireMigrationChain = appDef.RequireMigrationChain
+	d.Redundancy = appDef.Redundancy
+	d.MaxSize = appDef.MaxSize
+	if appDef.Sharing == Encrypted {
+		d.Sharing = Encrypted
+	}
+	if appDef.DataSchema != "" {
+		h.migrateDefLk.Lock()
+		defer h.migrateDefLk.Unlock()
+		// the validator is built once per schema rather than per entry
+		if h.migrateDataDef == nil || h.migrateDataDef.DataSchema != appDef.DataSchema {
+			dataDef := &EntryDef{Name: MigrateEntryType}
+			if err = dataDef.BuildDataJSONSchemaValidatorFromString(appDef.DataSchema); err != nil {
+				return
+			}
+			h.migrateDataDef = dataDef
+		}
+		d.DataSchema = h.migrateDataDef.DataSchema
+		d.dataValidator = h.migrateDataDef.dataValidator
+	}
+	def = &d
+	return
+}
+
 func (e *MigrateEntry) ToJSON() (encodedEntry string, err error) {
 	var x struct {
 		Type string
 		DNAHash string
 		Key  string
 		Data  string
+		Recipient string `json:",omitempty"`
 	}
 	x.Type = e.Type
 	x.DNAHash = e.DNAHash.String()
 	x.Key = e.Key.String()
 	x.Data = e.Data
+	x.Recipient = e.Recipient
 	var j []byte
 	j, err = json.Marshal(x)
 	encodedEntry = string(j)
@@ -87,6 +147,7 @@ func MigrateEntryFromJSON(j string) (entry MigrateEntry, err error) {
 		DNAHash string
 		Key  string
 		Data  string
+		Recipient string
 	}
 	err = json.Unmarshal([]byte(j), &x)
 	if err != nil {
@@ -97,5 +158,92 @@ func MigrateEntryFromJSON(j string) (entry MigrateEntry, err error) {
 	entry.DNAHash, err = NewHash(x.DNAHash)
 	entry.Key, err = NewHash(x.Key)
 	entry.Data = x.Data
+	entry.Recipient = x.Recipient
+	return
+}
+
+// NewMigrateEntry builds a MigrateEntry, checking its fields up front rather
+// than leaving it to SysValidation at commit time.  Any non-empty Type is
+// allowed except those starting with SysEntryTypePrefix which are reserved.
+func NewMigrateEntry(migrationType string, dnaHash Hash, key Hash, data string) (entry MigrateEntry, err error) {
+	if migrationType == "" {
+		err = ErrMigrateEntryTypeEmpty
+		return
+	}
+	if strings.HasPrefix(migrationType, SysEntryTypePrefix) {
+		err = ErrMigrateEntryTypeReserved
+		return
+	}
+	if dnaHash.IsNullHash() {
+		err = ErrMigrateEntryDNAHashMissing
+		return
+	}
+	if key.IsNullHash() {
+		err = ErrMigrateEntryKeyMissing
+		return
+	}
+	entry = MigrateEntry{Type: migrationType, DNAHash: dnaHash, Key: key, Data: data}
+	return
+}
+
+// NewEncryptedMigrateEntry builds a MigrateEntry like NewMigrateEntry but with
+// data encrypted to the destination agent's public key
+func NewEncryptedMigrateEntry(migrationType string, dnaHash Hash, key Hash, data string, recipient ic.PubKey) (entry MigrateEntry, err error) {
+	entry, err = NewMigrateEntry(migrationType, dnaHash, key, "")
+	if err != nil {
+		return
+	}
+	var pk []byte
+	pk, err = ic.MarshalPublicKey(recipient)
+	if err != nil {
+		return
+	}
+	var ciphertext []byte
+	ciphertext, err = EncryptFor(recipient, []byte(data))
+	if err != nil {
+		return
+	}
+	entry.Data = base64.StdEncoding.EncodeToString(ciphertext)
+	entry.Recipient = b58.Encode(pk)
+	return
+}
+
+// IsEncrypted returns true if the entry's Data is encrypted to a Recipient
+func (e *MigrateEntry) IsEncrypted() bool {
+	return e.Recipient != ""
+}
+
+// validateEncryption checks the structure of an encrypted entry, which can be
+// done without being able to decrypt it
+func (e *MigrateEntry) validateEncryption() (err error) {
+	if !isValidPubKey(e.Recipient) {
+		err = ErrMigrateEntryRecipientInvalid
+		return
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(e.Data)
+	if err != nil || len(ciphertext) < ciphertextOverhead {
+		err = ErrMigrateEntryDataNotEncrypted
+	}
+	return
+}
+
+// DecryptData returns the entry's Data, decrypting it with privKey if the
+// entry is encrypted
+func (e *MigrateEntry) DecryptData(privKey ic.PrivKey) (data string, err error) {
+	if !e.IsEncrypted() {
+		data = e.Data
+		return
+	}
+	var ciphertext, plaintext []byte
+	ciphertext, err = base64.StdEncoding.DecodeString(e.Data)
+	if err != nil {
+		err = ErrMigrateEntryDataNotEncrypted
+		return
+	}
+	plaintext, err = DecryptWith(privKey, ciphertext)
+	if err != nil {
+		return
+	}
+	data = string(plaintext)
 	return
 }
@@ -0,0 +1,189 @@
+package holochain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	ic "github.com/libp2p/go-libp2p-crypto"
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+const (
+	// MigrateLinkEntryType is the type of the links entry that links an
+	// agent's entry to its migrate entries
+	MigrateLinkEntryType = SysEntryTypePrefix + "migrateLink"
+
+	// MigrateLinkTag is the tag of the links from an agent's entry to its
+	// migrate entries
+	MigrateLinkTag = "migratedTo"
+)
+
+var ErrMigrateLinkNotAuthors = errors.New("migrate link is not between the author's entries")
+
+var MigrateLinkEntryDef = &EntryDef{Name: MigrateLinkEntryType, DataFormat: DataFormatLinks, Sharing: Public}
+
+func init() {
+	err := RegisterSysEntryType(MigrateLinkEntryType, MigrateLinkEntryDef, func(h *Holochain, e Entry) error {
+		return validateMigrateLinkEntry(e)
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// migrateLinkEntry builds the links entry linking the agent to a migrate entry
+func (h *Holochain) migrateLinkEntry(migrateHash Hash) (entry Entry, err error) {
+	le := LinksEntry{Links: []Link{{Base: h.AgentHash().String(), Link: migrateHash.String(), Tag: MigrateLinkTag}}}
+	var j string
+	j, err = le.ToJSON()
+	if err != nil {
+		return
+	}
+	entry = &GobEntry{C: j}
+	return
+}
+
+// validateMigrateLinkEntry checks that a migrate links entry only has links
+// tagged as migrations
+func validateMigrateLinkEntry(entry Entry) (err error) {
+	var le LinksEntry
+	le, err = LinksEntryFromJSON(entry.Content().(string))
+	if err != nil {
+		return
+	}
+	for _, l := range le.Links {
+		if l.Tag != MigrateLinkTag {
+			err = validationFieldFailed("Tag", fmt.Sprintf("migrate links must be tagged %s, not '%s'", MigrateLinkTag, l.Tag), nil)
+			return
+		}
+	}
+	return
+}
+
+// checkMigrateLinks checks that the links of a migrate links entry are from
+// the author's agent entry to the author's migrates.  Our own links are
+// checked against our chain, or the bundle committing them, and others'
+// against the DHT, where the migrate is shared before its link.
+func checkMigrateLinks(h *Holochain, entry Entry, author peer.ID) (err error) {
+	var le LinksEntry
+	le, err = LinksEntryFromJSON(entry.Content().(string))
+	if err != nil {
+		return
+	}
+	for _, l := range le.Links {
+		if author == h.nodeID {
+			err = checkOwnMigrateLink(h, l)
+		} else {
+			err = checkHeldMigrateLink(h, l, author)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func checkOwnMigrateLink(h *Holochain, l Link) (err error) {
+	if l.Base != h.AgentHash().String() {
+		err = validationFieldFailed("Base", "migrate links must be from the author's agent entry", nil)
+		return
+	}
+	hash, e := NewHash(l.Link)
+	if e != nil {
+		err = validationFieldFailed("Link", "migrate links must be to the author's migrate", e)
+		return
+	}
+	var header *Header
+	if bundle := h.Chain().BundleStarted(); bundle != nil {
+		header, e = bundle.chain.GetEntryHeader(hash)
+	}
+	if header == nil {
+		header, e = h.chain.GetEntryHeader(hash)
+	}
+	if e != nil || header.Type != MigrateEntryType {
+		err = validationFieldFailed("Link", "migrate links must be to the author's migrate", e)
+	}
+	return
+}
+
+func checkHeldMigrateLink(h *Holochain, l Link, author peer.ID) (err error) {
+	pubKey, e := heldAgentKey(h, l.Base, author)
+	if e != nil {
+		err = validationFieldFailed("Base", "migrate links must be from the author's agent entry", e)
+		return
+	}
+	if e := heldMigrateSignedBy(h, l.Link, pubKey); e != nil {
+		err = validationFieldFailed("Link", "migrate links must be to the author's migrate", e)
+	}
+	return
+}
+
+// heldAgentKey returns the key of the author's agent entry held at hash
+func heldAgentKey(h *Holochain, hash string, author peer.ID) (pubKey ic.PubKey, err error) {
+	var resp GetResp
+	if resp, err = getHeldOfType(h, hash, AgentEntryType, GetMaskEntry); err != nil {
+		return
+	}
+	if pubKey, err = agentEntryPubKey(&resp.Entry); err != nil {
+		return
+	}
+	id, err := peer.IDFromPublicKey(pubKey)
+	if err == nil && id != author {
+		err = ErrMigrateLinkNotAuthors
+	}
+	return
+}
+
+// heldMigrateSignedBy checks that the DHT holds a migrate at hash committed
+// with the key, or by a member of the key's group chain who signed it
+func heldMigrateSignedBy(h *Holochain, hash string, pubKey ic.PubKey) (err error) {
+	var resp GetResp
+	if resp, err = getHeldOfType(h, hash, MigrateEntryType, GetMaskHeader); err != nil {
+		return
+	}
+	hd := resp.Header
+	if hd == nil || hd.EntryLink.String() != hash {
+		err = ErrMigrateLinkNotAuthors
+		return
+	}
+	if hd.Signer != "" {
+		if pubKey, err = DecodePubKey(hd.Signer); err != nil {
+			return
+		}
+	}
+	matches, err := pubKey.Verify([]byte(hd.EntryLink), hd.Sig.S)
+	if err == nil && !matches {
+		err = ErrMigrateLinkNotAuthors
+	}
+	return
+}
+
+// getHeldOfType gets a live or modified entry from the DHT, failing if it
+// isn't of the entry type
+func getHeldOfType(h *Holochain, hash string, entryType string, getMask int) (resp GetResp, err error) {
+	var key Hash
+	if key, err = NewHash(hash); err != nil {
+		return
+	}
+	resp, err = h.dht.GetCtx(context.Background(), key, StatusLive|StatusModified, getMask|GetMaskEntryType)
+	if err == nil && resp.EntryType != entryType {
+		err = ErrEntryDefInvalid
+	}
+	return
+}
+
+// migrateLinksOnlyTo returns true if all the links of a migrate links entry
+// are to the given migrate
+func migrateLinksOnlyTo(entry Entry, migrateHash Hash) bool {
+	le, err := LinksEntryFromJSON(entry.Content().(string))
+	if err != nil || len(le.Links) == 0 {
+		return false
+	}
+	for _, l := range le.Links {
+		if l.Link != migrateHash.String() {
+			return false
+		}
+	}
+	return true
+}
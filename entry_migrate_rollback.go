@@ -0,0 +1,61 @@
+package holochain
+
+import (
+	"encoding/json"
+	. "github.com/holochain/holochain-proto/hash"
+)
+
+const (
+	MigrateRollbackEntryType   = SysEntryTypePrefix + "migrateRollback"
+	MigrateRollbackEntrySchema = `
+{
+  "$id": "http://example.com/example.json",
+  "type": "object",
+  "definitions": {},
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "properties": {
+    "MigrateHeaderHash": {
+      "$id": "/properties/MigrateHeaderHash",
+      "type": "string",
+      "title": "The MigrateHeaderHash Schema ",
+      "default": ""
+    }
+  },
+  "required": ["MigrateHeaderHash"]
+}
+`
+)
+
+// MigrateRollbackEntry struct is the record of a migrate entry being rolled back
+type MigrateRollbackEntry struct {
+	MigrateHeaderHash Hash
+}
+
+var MigrateRollbackEntryDef = &EntryDef{Name: MigrateRollbackEntryType, DataFormat: DataFormatJSON, Sharing: Public, Schema: MigrateRollbackEntrySchema}
+
+func (e *MigrateRollbackEntry) Def() *EntryDef {
+	return MigrateRollbackEntryDef
+}
+
+func (e *MigrateRollbackEntry) ToJSON() (encodedEntry string, err error) {
+	var x struct {
+		MigrateHeaderHash string
+	}
+	x.MigrateHeaderHash = e.MigrateHeaderHash.String()
+	var j []byte
+	j, err = json.Marshal(x)
+	encodedEntry = string(j)
+	return
+}
+
+func MigrateRollbackEntryFromJSON(j string) (entry MigrateRollbackEntry, err error) {
+	var x struct {
+		MigrateHeaderHash string
+	}
+	err = json.Unmarshal([]byte(j), &x)
+	if err != nil {
+		return
+	}
+	entry.MigrateHeaderHash, err = NewHash(x.MigrateHeaderHash)
+	return
+}
@@ -0,0 +1,43 @@
+package holochain
+
+import (
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestMigrateRollbackEntrySysValidation(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("validate migrate rollback entry should fail if it doesn't match the schema", t, func() {
+		err := sysValidateEntry(h, MigrateRollbackEntryDef, &GobEntry{C: `{"Fish":2}`}, nil)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "Validation Failed: validator %migrateRollback failed: object property 'MigrateHeaderHash' is required")
+
+		err = sysValidateEntry(h, MigrateRollbackEntryDef, &GobEntry{C: `{"MigrateHeaderHash":"not-a-hash"}`}, nil)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "Validation Failed: Error (input isn't valid multihash) when decoding MigrateHeaderHash value 'not-a-hash'")
+	})
+
+	Convey("validate migrate rollback entry should succeed on valid entry", t, func() {
+		err := sysValidateEntry(h, MigrateRollbackEntryDef, &GobEntry{C: `{"MigrateHeaderHash":"QmUfY4WeqD3UUfczjdkoFQGEgCAVNf7rgFfjdeTbr7JF1C"}`}, nil)
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestMigrateRollbackEntryToJSON(t *testing.T) {
+	Convey("MigrateRollbackEntry should convert to JSON and roundtrip safely", t, func() {
+		hash, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		entry := MigrateRollbackEntry{MigrateHeaderHash: hash}
+
+		j, err := entry.ToJSON()
+		So(err, ShouldBeNil)
+		So(j, ShouldEqual, fmt.Sprintf(`{"MigrateHeaderHash":"%s"}`, hash))
+
+		roundtrip, err := MigrateRollbackEntryFromJSON(j)
+		So(err, ShouldBeNil)
+		So(roundtrip, ShouldResemble, entry)
+	})
+}
@@ -1,9 +1,12 @@
 package holochain
 
 import (
+  "errors"
   "testing"
   . "github.com/smartystreets/goconvey/convey"
   "fmt"
+  . "github.com/holochain/holochain-proto/hash"
+  peer "github.com/libp2p/go-libp2p-peer"
 )
 
 func TestMigrateConstants(t *testing.T) {
@@ -39,12 +42,12 @@ func TestMigrateEntrySysValidation(t *testing.T) {
 		So(err, ShouldNotBeNil)
     So(err.Error(), ShouldEqual, "Validation Failed: Error (input isn't valid multihash) when decoding DNAHash value ''")
 
-    dnaHash, err := genTestStringHash()
-    key, err := genTestStringHash()
+    dnaHash, err := GenTestStringHash()
+    key, err := GenTestStringHash()
     So(err, ShouldBeNil)
 
     migrateType := randomSliceItem([]string{MigrateEntryTypeOpen, MigrateEntryTypeClose})
-    data, err := genTestString()
+    data, err := GenTestString()
     So(err, ShouldBeNil)
 
     entry.DNAHash = dnaHash
@@ -56,7 +59,10 @@ func TestMigrateEntrySysValidation(t *testing.T) {
     entry.Key = key
     err = sysValidateEntry(h, entry.Def(), toEntry(entry), nil)
     So(err, ShouldNotBeNil)
-    So(err.Error(), ShouldEqual, "Validation Failed: Type value '' must be either 'open' or 'close'")
+    So(err.Error(), ShouldEqual, "Validation Failed: Type value must not be empty")
+
+    entry.Type = "split"
+    So(sysValidateEntry(h, entry.Def(), toEntry(entry), nil), ShouldBeNil)
 
     entry.Type = migrateType
     So(sysValidateEntry(h, entry.Def(), toEntry(entry), nil), ShouldBeNil)
@@ -98,13 +104,13 @@ func TestMigrateEntrySysValidation(t *testing.T) {
 
 func TestMigrateEntryFromJSON(t *testing.T) {
   Convey("MigrateEntry should be unserializable from JSON", t, func() {
-    dnaHash, err := genTestStringHash()
+    dnaHash, err := GenTestStringHash()
     So(err, ShouldBeNil)
 
-    key, err := genTestStringHash()
+    key, err := GenTestStringHash()
     So(err, ShouldBeNil)
 
-    data, err := genTestString()
+    data, err := GenTestString()
     So(err, ShouldBeNil)
 
     migrateType := randomSliceItem([]string{MigrateEntryTypeOpen, MigrateEntryTypeClose})
@@ -125,7 +131,7 @@ func TestMigrateEntryToJSON(t *testing.T) {
   Convey("MigrateEntry should convert to JSON and roundtrip safely", t, func() {
     var j string
     var err error
-    entry, err := genTestMigrateEntry()
+    entry, err := GenTestMigrateEntry()
     So(err, ShouldBeNil)
 
 		j, err = entry.ToJSON()
@@ -139,3 +145,164 @@ func TestMigrateEntryToJSON(t *testing.T) {
     So(roundtrip, ShouldResemble, entry)
 	})
 }
+
+func TestNewMigrateEntry(t *testing.T) {
+  dnaHash, err := GenTestStringHash()
+  if err != nil {
+    panic(err)
+  }
+  key, err := GenTestStringHash()
+  if err != nil {
+    panic(err)
+  }
+
+  Convey("NewMigrateEntry should build an entry from valid fields", t, func() {
+    entry, err := NewMigrateEntry(MigrateEntryTypeClose, dnaHash, key, "some data")
+    So(err, ShouldBeNil)
+    So(entry, ShouldResemble, MigrateEntry{Type: MigrateEntryTypeClose, DNAHash: dnaHash, Key: key, Data: "some data"})
+
+    entry, err = NewMigrateEntry("split", dnaHash, key, "")
+    So(err, ShouldBeNil)
+    So(entry.Type, ShouldEqual, "split")
+  })
+
+  Convey("NewMigrateEntry should reject empty and reserved types", t, func() {
+    _, err := NewMigrateEntry("", dnaHash, key, "")
+    So(err, ShouldEqual, ErrMigrateEntryTypeEmpty)
+    _, err = NewMigrateEntry(SysEntryTypePrefix+"open", dnaHash, key, "")
+    So(err, ShouldEqual, ErrMigrateEntryTypeReserved)
+  })
+
+  Convey("NewMigrateEntry should reject null hashes", t, func() {
+    _, err := NewMigrateEntry(MigrateEntryTypeOpen, NullHash(), key, "")
+    So(err, ShouldEqual, ErrMigrateEntryDNAHashMissing)
+    _, err = NewMigrateEntry(MigrateEntryTypeOpen, dnaHash, NullHash(), "")
+    So(err, ShouldEqual, ErrMigrateEntryKeyMissing)
+  })
+}
+
+func TestEncryptedMigrateEntry(t *testing.T) {
+  mt := setupMultiNodeTesting(3)
+  defer mt.cleanupMultiNodeTesting()
+  source, dest, third := mt.nodes[0], mt.nodes[1], mt.nodes[2]
+
+  dnaHash, err := GenTestStringHash()
+  if err != nil {
+    panic(err)
+  }
+  key, err := GenTestStringHash()
+  if err != nil {
+    panic(err)
+  }
+  secret := "some private key material"
+  entry, err := NewEncryptedMigrateEntry("handoff", dnaHash, key, secret, dest.agent.PubKey())
+  if err != nil {
+    panic(err)
+  }
+
+  Convey("the data should only be readable by the recipient", t, func() {
+    So(entry.IsEncrypted(), ShouldBeTrue)
+    So(entry.Data, ShouldNotContainSubstring, secret)
+    data, err := entry.DecryptData(dest.agent.PrivKey())
+    So(err, ShouldBeNil)
+    So(data, ShouldEqual, secret)
+    _, err = entry.DecryptData(source.agent.PrivKey())
+    So(err, ShouldEqual, ErrDecryptionFailed)
+  })
+
+  Convey("sys validation should check the structure without decrypting", t, func() {
+    header, err := GenTestHeader()
+    So(err, ShouldBeNil)
+    sources := []peer.ID{third.nodeID}
+    a := &ActionMigrate{entry: entry, header: header}
+    So(a.SysValidation(third, MigrateEntryDef, nil, sources), ShouldBeNil)
+
+    bad := entry
+    bad.Data = "not encrypted"
+    a = &ActionMigrate{entry: bad, header: header}
+    So(a.SysValidation(third, MigrateEntryDef, nil, sources), ShouldEqual, ErrMigrateEntryDataNotEncrypted)
+
+    bad = entry
+    bad.Recipient = "not a key"
+    a = &ActionMigrate{entry: bad, header: header}
+    So(a.SysValidation(third, MigrateEntryDef, nil, sources), ShouldEqual, ErrMigrateEntryRecipientInvalid)
+  })
+
+  Convey("a DNA's migrate def with Encrypted sharing should refuse unencrypted migrates", t, func() {
+    zomes := third.nucleus.dna.Zomes
+    defer func() { third.nucleus.dna.Zomes = zomes }()
+    third.nucleus.dna.Zomes = append(zomes[:len(zomes):len(zomes)], Zome{
+      Name:         "migrationRules",
+      RibosomeType: JSRibosomeType,
+      Entries:      []EntryDef{{Name: MigrateEntryType, DataFormat: DataFormatJSON, Sharing: Encrypted}},
+      Code: `function validateCommit(entryType,entry,header,pkg,sources) { return ""; }
+function validatePut(entryType,entry,header,pkg,sources) { return ""; }`,
+    })
+    def, err := third.migrateEntryDef()
+    So(err, ShouldBeNil)
+    So(def.Sharing, ShouldEqual, Encrypted)
+    So(MigrateEntryDef.Sharing, ShouldEqual, Public)
+    j, err := entry.ToJSON()
+    So(err, ShouldBeNil)
+    So(sysValidateEntry(third, def, &GobEntry{C: j}, nil), ShouldBeNil)
+
+    plain := entry
+    plain.Recipient = ""
+    plain.Data = secret
+    j, err = plain.ToJSON()
+    So(err, ShouldBeNil)
+    So(sysValidateEntry(third, def, &GobEntry{C: j}, nil), ShouldEqual, ErrEntryNotEncrypted)
+  })
+
+  Convey("an encrypted migrate def should not be allowed to require a migration chain", t, func() {
+    dna := DNA{Zomes: []Zome{{Entries: []EntryDef{{Name: MigrateEntryType, Sharing: Encrypted, RequireMigrationChain: true}}}}}
+    err := dna.check()
+    So(err, ShouldNotBeNil)
+    So(err.Error(), ShouldEqual, ErrEntryDefEncryptedChain.Error()+": "+MigrateEntryType)
+
+    def := *MigrateEntryDef
+    def.RequireMigrationChain = true
+    open := entry
+    open.Type = MigrateEntryTypeOpen
+    err = sysValidateMigrateData(third, &def, &open)
+    So(errors.Is(err, ErrOrphanMigration), ShouldBeTrue)
+  })
+
+  j, err := entry.ToJSON()
+  if err != nil {
+    panic(err)
+  }
+  e := GobEntry{C: j}
+  hash, err := e.Sum(source.hashSpec)
+  if err != nil {
+    panic(err)
+  }
+  b, err := e.Marshal()
+  if err != nil {
+    panic(err)
+  }
+  get := func(h *Holochain) (migrate MigrateEntry) {
+    err := h.dht.Put(nil, MigrateEntryType, hash, source.nodeID, b, StatusLive)
+    So(err, ShouldBeNil)
+    req := GetReq{H: hash, GetMask: GetMaskEntry}
+    rsp, err := callGet(h, req, &GetOptions{GetMask: req.GetMask})
+    So(err, ShouldBeNil)
+    migrate, err = MigrateEntryFromJSON(rsp.(GetResp).Entry.C.(string))
+    So(err, ShouldBeNil)
+    return
+  }
+
+  Convey("a third party should be able to hold but not read the data", t, func() {
+    held := get(third)
+    So(held.IsEncrypted(), ShouldBeTrue)
+    So(held.Data, ShouldEqual, entry.Data)
+    _, err := held.DecryptData(third.agent.PrivKey())
+    So(err, ShouldEqual, ErrDecryptionFailed)
+  })
+
+  Convey("a get by the recipient should return the data decrypted", t, func() {
+    held := get(dest)
+    So(held.IsEncrypted(), ShouldBeFalse)
+    So(held.Data, ShouldEqual, secret)
+  })
+}
@@ -0,0 +1,147 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	ic "github.com/libp2p/go-libp2p-crypto"
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+const (
+	RevocationEntryType   = SysEntryTypePrefix + "revocation"
+	RevocationEntrySchema = `
+{
+  "$id": "http://example.com/example.json",
+  "type": "object",
+  "definitions": {},
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "properties": {
+    "Data": {
+      "$id": "/properties/Data",
+      "type": "string",
+      "title": "The Data Schema ",
+      "default": ""
+    },
+    "OldSig": {
+      "$id": "/properties/OldSig",
+      "type": "string",
+      "title": "The OldSig Schema ",
+      "default": ""
+    },
+    "NewSig": {
+      "$id": "/properties/NewSig",
+      "type": "string",
+      "title": "The NewSig Schema ",
+      "default": ""
+    }
+  },
+  "required": ["Data", "OldSig", "NewSig"]
+}
+`
+)
+
+var ErrRevocationNotByCurrentKey = errors.New("revocation: old key is not the author's current key")
+var ErrRevocationNewKeySame = errors.New("revocation: new key must differ from the old key")
+var ErrKeyRevoked = errors.New("key has been revoked")
+
+// RevocationEntry struct is the record of an agent revoking its key in favor of
+// a new one.  It's the agent's SelfRevocation, signed by both keys, with the
+// proof of why the key was revoked as its payload.
+type RevocationEntry struct {
+	SelfRevocation
+}
+
+var RevocationEntryDef = &EntryDef{Name: RevocationEntryType, DataFormat: DataFormatJSON, Sharing: Public, Schema: RevocationEntrySchema}
+
+func (e *RevocationEntry) Def() *EntryDef {
+	return RevocationEntryDef
+}
+
+func (e *RevocationEntry) ToJSON() (encodedEntry string, err error) {
+	encodedEntry, err = e.Marshal()
+	return
+}
+
+func RevocationEntryFromJSON(j string) (entry RevocationEntry, err error) {
+	err = entry.Unmarshal(j)
+	return
+}
+
+// checkFormat makes sure the revocation's data holds the two keys its
+// signatures are checked with
+func (e *RevocationEntry) checkFormat() (err error) {
+	if len(e.Data) == 0 || len(e.Data) < int(e.Data[0])*2+1 {
+		err = ValidationFailed(ValidationFailureBadRevocationFormat)
+	}
+	return
+}
+
+func keyHash(pubKey ic.PubKey) (hash Hash, err error) {
+	var id peer.ID
+	id, err = peer.IDFromPublicKey(pubKey)
+	if err != nil {
+		return
+	}
+	hash = HashFromPeerID(id)
+	return
+}
+
+// OldKeyHash returns the hash of the revoked key, i.e. the revoked agent's node ID
+func (e *RevocationEntry) OldKeyHash() (hash Hash, err error) {
+	if err = e.checkFormat(); err != nil {
+		return
+	}
+	var pubKey ic.PubKey
+	if pubKey, err = e.getOldKey(); err != nil {
+		return
+	}
+	hash, err = keyHash(pubKey)
+	return
+}
+
+// NewKeyHash returns the hash of the key replacing the revoked one
+func (e *RevocationEntry) NewKeyHash() (hash Hash, err error) {
+	if err = e.checkFormat(); err != nil {
+		return
+	}
+	var pubKey ic.PubKey
+	if pubKey, err = e.getNewKey(); err != nil {
+		return
+	}
+	hash, err = keyHash(pubKey)
+	return
+}
+
+// Proof returns the revocation's payload
+func (e *RevocationEntry) Proof() (proof string, err error) {
+	if err = e.checkFormat(); err != nil {
+		return
+	}
+	l := int(e.Data[0])
+	proof = string(e.Data[l*2+1:])
+	return
+}
+
+// Verify checks the revocation's signatures by both keys and that it was
+// authored by the holder of the key it revokes, i.e. it was the author's
+// current key
+func (e *RevocationEntry) Verify(author peer.ID) (err error) {
+	var oldHash, newHash Hash
+	if oldHash, err = e.OldKeyHash(); err != nil {
+		return
+	}
+	if newHash, err = e.NewKeyHash(); err != nil {
+		return
+	}
+	if oldHash.Equal(newHash) {
+		err = ErrRevocationNewKeySame
+		return
+	}
+	if err = e.SelfRevocation.Verify(); err != nil {
+		return
+	}
+	if !oldHash.Equal(HashFromPeerID(author)) {
+		err = ErrRevocationNotByCurrentKey
+	}
+	return
+}
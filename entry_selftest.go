@@ -0,0 +1,20 @@
+package holochain
+
+import (
+	"time"
+)
+
+// SelfTestEntryType is the type of the throwaway entries shared by the self
+// test, they are never committed to the chain
+const SelfTestEntryType = SysEntryTypePrefix + "selfTest"
+
+// SelfTestEntryTTL is how long the nodes holding a self test entry keep it
+const SelfTestEntryTTL = 10 * time.Minute
+
+var SelfTestEntryDef = &EntryDef{Name: SelfTestEntryType, DataFormat: DataFormatString, Sharing: Public, TTL: SelfTestEntryTTL}
+
+func init() {
+	if err := RegisterSysEntryType(SelfTestEntryType, SelfTestEntryDef, nil); err != nil {
+		panic(err)
+	}
+}
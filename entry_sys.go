@@ -0,0 +1,95 @@
+package holochain
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// SysEntryValidatorFn is the signature of the validation function of a
+// registered system entry type
+type SysEntryValidatorFn func(h *Holochain, e Entry) error
+
+type registeredSysEntryType struct {
+	def       *EntryDef
+	validator SysEntryValidatorFn
+}
+
+var sysEntryTypes = make(map[string]registeredSysEntryType)
+var sysEntryTypesLk sync.RWMutex
+
+var ErrSysEntryTypeBuiltIn = errors.New("sys entry type collides with built-in type")
+var ErrSysEntryTypeExists = errors.New("sys entry type already registered")
+var ErrSysEntryTypeNotRegistered = errors.New("sys entry type not registered")
+var ErrSysEntryTypeInvalidName = errors.New("sys entry type name must start with " + SysEntryTypePrefix)
+
+func isBuiltInSysEntryType(name string) bool {
+	switch name {
+	case DNAEntryType, AgentEntryType, KeyEntryType, HeadersEntryType, DelEntryType, MigrateEntryType, MigrateRollbackEntryType, RevocationEntryType, GroupEntryType:
+		return true
+	}
+	return false
+}
+
+// RegisterSysEntryType adds a system level entry type whose entries are checked by
+// the given validator (which may be nil) after the def's schema, if any, is checked.
+// The name must carry the SysEntryTypePrefix so that app level validation is skipped.
+func RegisterSysEntryType(name string, def *EntryDef, validator SysEntryValidatorFn) (err error) {
+	if !strings.HasPrefix(name, SysEntryTypePrefix) {
+		err = ErrSysEntryTypeInvalidName
+		return
+	}
+	if isBuiltInSysEntryType(name) {
+		err = ErrSysEntryTypeBuiltIn
+		return
+	}
+	sysEntryTypesLk.Lock()
+	defer sysEntryTypesLk.Unlock()
+	if _, exists := sysEntryTypes[name]; exists {
+		err = ErrSysEntryTypeExists
+		return
+	}
+	def.Name = name
+	if def.Schema != "" && def.validator == nil {
+		err = def.BuildJSONSchemaValidatorFromString(def.Schema)
+		if err != nil {
+			return
+		}
+	}
+	sysEntryTypes[name] = registeredSysEntryType{def: def, validator: validator}
+	return
+}
+
+// UnregisterSysEntryType removes a previously registered system entry type
+func UnregisterSysEntryType(name string) (err error) {
+	sysEntryTypesLk.Lock()
+	defer sysEntryTypesLk.Unlock()
+	if _, exists := sysEntryTypes[name]; !exists {
+		err = ErrSysEntryTypeNotRegistered
+		return
+	}
+	delete(sysEntryTypes, name)
+	return
+}
+
+func getRegisteredSysEntryType(name string) (r registeredSysEntryType, ok bool) {
+	sysEntryTypesLk.RLock()
+	defer sysEntryTypesLk.RUnlock()
+	r, ok = sysEntryTypes[name]
+	return
+}
+
+// builtInSysEntryDefs returns the defs of the built-in system entry types
+func builtInSysEntryDefs() []*EntryDef {
+	return []*EntryDef{DNAEntryDef, AgentEntryDef, KeyEntryDef, HeadersEntryDef, DelEntryDef, MigrateEntryDef, MigrateRollbackEntryDef, RevocationEntryDef, GroupEntryDef}
+}
+
+// registeredSysEntryDefs returns the defs of the registered system entry types
+func registeredSysEntryDefs() (defs []*EntryDef) {
+	sysEntryTypesLk.RLock()
+	defer sysEntryTypesLk.RUnlock()
+	for _, r := range sysEntryTypes {
+		defs = append(defs, r.def)
+	}
+	return
+}
@@ -0,0 +1,61 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestRegisterSysEntryType(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should refuse to register over built-in types", t, func() {
+		err := RegisterSysEntryType(MigrateEntryType, &EntryDef{DataFormat: DataFormatString, Sharing: Public}, nil)
+		So(err, ShouldEqual, ErrSysEntryTypeBuiltIn)
+		err = RegisterSysEntryType(DNAEntryType, &EntryDef{DataFormat: DataFormatString, Sharing: Public}, nil)
+		So(err, ShouldEqual, ErrSysEntryTypeBuiltIn)
+	})
+
+	Convey("it should refuse names without the sys prefix", t, func() {
+		err := RegisterSysEntryType("handoff", &EntryDef{DataFormat: DataFormatString, Sharing: Public}, nil)
+		So(err, ShouldEqual, ErrSysEntryTypeInvalidName)
+	})
+
+	Convey("registered types should be resolved and validated", t, func() {
+		entryType := SysEntryTypePrefix + "handoff"
+		def := &EntryDef{DataFormat: DataFormatString, Sharing: Public}
+		err := RegisterSysEntryType(entryType, def, func(h *Holochain, e Entry) error {
+			if e.Content().(string) != "token" {
+				return errors.New("bad handoff")
+			}
+			return nil
+		})
+		So(err, ShouldBeNil)
+		defer UnregisterSysEntryType(entryType)
+
+		So(RegisterSysEntryType(entryType, def, nil), ShouldEqual, ErrSysEntryTypeExists)
+
+		_, d, err := h.GetEntryDef(entryType)
+		So(err, ShouldBeNil)
+		So(d, ShouldEqual, def)
+		So(d.Name, ShouldEqual, entryType)
+
+		err = sysValidateEntry(h, def, &GobEntry{C: "nope"}, nil)
+		So(err.Error(), ShouldEqual, "Validation Failed: bad handoff")
+
+		fn := &APIFnCommit{}
+		fn.SetAction(NewCommitAction(entryType, &GobEntry{C: "token"}))
+		_, err = fn.Call(h)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("unregistering should remove the type", t, func() {
+		entryType := SysEntryTypePrefix + "temp"
+		So(RegisterSysEntryType(entryType, &EntryDef{DataFormat: DataFormatString, Sharing: Public}, nil), ShouldBeNil)
+		So(UnregisterSysEntryType(entryType), ShouldBeNil)
+		So(UnregisterSysEntryType(entryType), ShouldEqual, ErrSysEntryTypeNotRegistered)
+		_, ok := getRegisteredSysEntryType(entryType)
+		So(ok, ShouldBeFalse)
+	})
+}
@@ -2,11 +2,16 @@ package holochain
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
 	. "github.com/smartystreets/goconvey/convey"
 
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -21,6 +26,7 @@ func TestEntryConstants(t *testing.T) {
 		So(Public, ShouldEqual, "public")
 		So(Partial, ShouldEqual, "partial")
 		So(Private, ShouldEqual, "private")
+		So(Encrypted, ShouldEqual, "encrypted")
 	})
 }
 
@@ -128,3 +134,88 @@ func TestMarshalEntry(t *testing.T) {
 		So(fmt.Sprintf("%v", ne), ShouldEqual, fmt.Sprintf("%v", &e))
 	})
 }
+
+func TestSysValidateEntryEncrypted(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	ciphertext, err := EncryptFor(h.agent.PubKey(), []byte("a secret"))
+	if err != nil {
+		panic(err)
+	}
+	sealed := base64.StdEncoding.EncodeToString(ciphertext)
+
+	Convey("an Encrypted def should be shared", t, func() {
+		So(EntryDef{Sharing: Encrypted}.isSharingPublic(), ShouldBeTrue)
+	})
+
+	Convey("an Encrypted def should only accept sealed content", t, func() {
+		def := &EntryDef{Name: "secrets", DataFormat: DataFormatString, Sharing: Encrypted}
+		So(sysValidateEntry(h, def, &GobEntry{C: sealed}, nil), ShouldBeNil)
+		So(sysValidateEntry(h, def, &GobEntry{C: "a secret"}, nil), ShouldEqual, ErrEntryNotEncrypted)
+		So(sysValidateEntry(h, def, &GobEntry{C: sealed[:8]}, nil), ShouldEqual, ErrEntryNotEncrypted)
+
+		def.DataFormat = DataFormatJSON
+		So(sysValidateEntry(h, def, &GobEntry{C: `"` + sealed + `"`}, nil), ShouldBeNil)
+		So(sysValidateEntry(h, def, &GobEntry{C: `{"secret":"a secret"}`}, nil), ShouldEqual, ErrEntryNotEncrypted)
+	})
+}
+
+func TestEntryMaxSize(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	_, def, err := h.GetEntryDef("evenNumbers")
+	if err != nil {
+		panic(err)
+	}
+
+	Convey("zero should mean unlimited", t, func() {
+		So(def.MaxSize, ShouldEqual, 0)
+		_, err := h.commitAndShare(NewCommitAction("evenNumbers", &GobEntry{C: "123456789012"}), NullHash())
+		So(err, ShouldBeNil)
+	})
+
+	Convey("committing an oversized app entry should fail with the sizes", t, func() {
+		def.MaxSize = 4
+		defer func() { def.MaxSize = 0 }()
+		l := h.ChainLength()
+		_, err := h.commitAndShare(NewCommitAction("evenNumbers", &GobEntry{C: "123456"}), NullHash())
+		So(errors.Is(err, ErrEntryTooLarge), ShouldBeTrue)
+		var sizeErr *EntryTooLargeError
+		So(errors.As(err, &sizeErr), ShouldBeTrue)
+		So(sizeErr.Size, ShouldEqual, 6)
+		So(sizeErr.MaxSize, ShouldEqual, 4)
+		So(err.Error(), ShouldEqual, "entry too large: 6 bytes exceeds maximum of 4")
+		So(h.ChainLength(), ShouldEqual, l)
+
+		_, err = h.commitAndShare(NewCommitAction("evenNumbers", &GobEntry{C: "1234"}), NullHash())
+		So(err, ShouldBeNil)
+	})
+
+	Convey("a received put of an oversized entry should fail validation", t, func() {
+		def.MaxSize = 4
+		defer func() { def.MaxSize = 0 }()
+		a := NewPutAction("evenNumbers", &GobEntry{C: "123456"}, &Header{})
+		err := a.SysValidation(h, def, &Package{}, []peer.ID{h.nodeID})
+		So(errors.Is(err, ErrEntryTooLarge), ShouldBeTrue)
+	})
+
+	Convey("the limit should apply to migrate entries as the DNA declares it", t, func() {
+		zomes := h.nucleus.dna.Zomes
+		defer func() { h.nucleus.dna.Zomes = zomes }()
+		h.nucleus.dna.Zomes = append(zomes[:len(zomes):len(zomes)], Zome{
+			Name:         "migrationRules",
+			RibosomeType: JSRibosomeType,
+			Entries:      []EntryDef{{Name: MigrateEntryType, DataFormat: DataFormatJSON, MaxSize: 100}},
+			Code: `function validateCommit(entryType,entry,header,pkg,sources) { return ""; }
+function validatePut(entryType,entry,header,pkg,sources) { return ""; }`,
+		})
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Data = strings.Repeat("x", 100)
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+		_, err = fn.Call(h)
+		So(errors.Is(err, ErrEntryTooLarge), ShouldBeTrue)
+	})
+}
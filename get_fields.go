@@ -0,0 +1,83 @@
+package holochain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+)
+
+var ErrFieldNotFound = errors.New("entry has no such field")
+var ErrEntryNotJSONObject = errors.New("entry content isn't a JSON object")
+
+// projectContent returns just the top-level fields of entry content that is
+// a JSON object, either as a string or already decoded to a map.  A field the
+// content doesn't have is an ErrFieldNotFound unless omitMissing is set.
+func projectContent(content interface{}, fields []string, omitMissing bool) (projected interface{}, err error) {
+	switch c := content.(type) {
+	case string:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal([]byte(c), &obj) != nil || obj == nil {
+			err = ErrEntryNotJSONObject
+			return
+		}
+		result := make(map[string]json.RawMessage)
+		for _, f := range fields {
+			v, ok := obj[f]
+			if !ok {
+				if omitMissing {
+					continue
+				}
+				err = ErrFieldNotFound
+				return
+			}
+			result[f] = v
+		}
+		var b []byte
+		b, err = json.Marshal(result)
+		if err != nil {
+			return
+		}
+		projected = string(b)
+	case map[string]interface{}:
+		result := make(map[string]interface{})
+		for _, f := range fields {
+			v, ok := c[f]
+			if !ok {
+				if omitMissing {
+					continue
+				}
+				err = ErrFieldNotFound
+				return
+			}
+			result[f] = v
+		}
+		projected = result
+	default:
+		err = ErrEntryNotJSONObject
+	}
+	return
+}
+
+// GetFields gets just the given top-level fields of an entry whose content is
+// a JSON object, i.e. the DNAHash and Key of a migrate entry without its Data.
+// The serving peer projects the entry so the rest isn't sent, and if it
+// can't, i.e. it predates field selectors, we do.  A field the entry doesn't
+// have is an error unless omitMissing is set.
+func (dht *DHT) GetFields(key Hash, fields []string, omitMissing bool) (response GetResp, err error) {
+	req := GetReq{H: key, StatusMask: StatusDefault, GetMask: GetMaskEntry | GetMaskEntryType, Fields: fields}
+	var r interface{}
+	r, err = dht.QueryCtx(context.Background(), key, GET_REQUEST, req)
+	if err != nil {
+		return
+	}
+	t, ok := r.(GetResp)
+	if !ok {
+		err = fmt.Errorf("expected GetResp response from GET_REQUEST, got: %T", r)
+		return
+	}
+	response = t
+	response.Entry.C, err = projectContent(t.Entry.C, fields, omitMissing)
+	return
+}
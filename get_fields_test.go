@@ -0,0 +1,101 @@
+package holochain
+
+import (
+	"bytes"
+	"encoding/json"
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestProjectContent(t *testing.T) {
+	Convey("it should keep just the requested fields of a JSON string", t, func() {
+		c, err := projectContent(`{"a":1,"b":{"c":"x"},"d":"big"}`, []string{"a", "b"}, false)
+		So(err, ShouldBeNil)
+		So(c, ShouldEqual, `{"a":1,"b":{"c":"x"}}`)
+	})
+
+	Convey("it should project decoded content too", t, func() {
+		c, err := projectContent(map[string]interface{}{"a": 1, "d": "big"}, []string{"a"}, false)
+		So(err, ShouldBeNil)
+		So(c, ShouldResemble, map[string]interface{}{"a": 1})
+	})
+
+	Convey("a missing field should be an error unless omitted", t, func() {
+		_, err := projectContent(`{"a":1}`, []string{"a", "z"}, false)
+		So(err, ShouldEqual, ErrFieldNotFound)
+		c, err := projectContent(`{"a":1}`, []string{"a", "z"}, true)
+		So(err, ShouldBeNil)
+		So(c, ShouldEqual, `{"a":1}`)
+	})
+
+	Convey("content that isn't a JSON object should be an error", t, func() {
+		_, err := projectContent("2", []string{"a"}, true)
+		So(err, ShouldEqual, ErrEntryNotJSONObject)
+		_, err = projectContent(`["a"]`, []string{"a"}, true)
+		So(err, ShouldEqual, ErrEntryNotJSONObject)
+		_, err = projectContent(42, []string{"a"}, true)
+		So(err, ShouldEqual, ErrEntryNotJSONObject)
+	})
+}
+
+func TestGetFields(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	r, err := (&APIFnMigrate{action: ActionMigrate{entry: entry}}).Call(h)
+	if err != nil {
+		panic(err)
+	}
+	hash := r.(Hash)
+
+	fields := func(c interface{}) (m map[string]string) {
+		if err := json.Unmarshal([]byte(c.(string)), &m); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	Convey("the serving node should project the entry", t, func() {
+		a := ActionGet{}
+		msg := h.node.NewMessage(GET_REQUEST, GetReq{H: hash, StatusMask: StatusDefault, GetMask: GetMaskEntry, Fields: []string{"DNAHash", "Key"}})
+		resp, err := a.Receive(h.dht, msg)
+		So(err, ShouldBeNil)
+		m := fields(resp.(GetResp).Entry.C)
+		So(len(m), ShouldEqual, 2)
+		So(m["DNAHash"], ShouldEqual, entry.DNAHash.String())
+		So(m["Key"], ShouldEqual, entry.Key.String())
+	})
+
+	Convey("it should get just the requested fields", t, func() {
+		resp, err := h.dht.GetFields(hash, []string{"DNAHash", "Key"}, false)
+		So(err, ShouldBeNil)
+		So(resp.EntryType, ShouldEqual, MigrateEntryType)
+		m := fields(resp.Entry.C)
+		So(len(m), ShouldEqual, 2)
+		So(m["DNAHash"], ShouldEqual, entry.DNAHash.String())
+		_, ok := m["Data"]
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("a missing field should be an error unless omitted", t, func() {
+		_, err := h.dht.GetFields(hash, []string{"Key", "Bogus"}, false)
+		So(err, ShouldEqual, ErrFieldNotFound)
+		resp, err := h.dht.GetFields(hash, []string{"Key", "Bogus"}, true)
+		So(err, ShouldBeNil)
+		So(len(fields(resp.Entry.C)), ShouldEqual, 1)
+	})
+
+	Convey("field selectors should survive the compact wire format", t, func() {
+		msg := h.node.NewMessage(GET_REQUEST, GetReq{H: hash, GetMask: GetMaskEntry, Fields: []string{"Key"}})
+		data, err := msg.EncodeCompact()
+		So(err, ShouldBeNil)
+		var decoded Message
+		So(decoded.DecodeCompact(bytes.NewReader(data)), ShouldBeNil)
+		So(decoded.Body.(GetReq).Fields, ShouldResemble, []string{"Key"})
+	})
+}
@@ -285,6 +285,9 @@ func GossipReceiver(h *Holochain, m *Message) (response interface{}, err error)
 			puts, err = h.dht.GetPuts(t.YourIdx)
 			g := Gossip{Puts: puts}
 			response = g
+			if err == nil && dht.hasGossipCompleteHandlers() {
+				dht.notifyGossipComplete(m.From, len(puts))
+			}
 
 			// check to see what we know they said, and if our record is less
 			// that where they are currently at, gossip back
@@ -355,17 +358,32 @@ func (dht *DHT) gossipWith(id peer.ID) (err error) {
 	// gossiper has more stuff that we new about before so update the gossipers status
 	// and also run their puts
 	count := len(puts)
+	notify := dht.hasGossipCompleteHandlers()
+	received := 0
 	if count > 0 {
 		dht.glog.Logf("queuing %d puts:\n%v", count, puts)
 		var idx int
 		for i, p := range puts {
 			idx = i + yourIdx + 1
+			if notify {
+				if f, e := p.M.Fingerprint(); e == nil {
+					if have, _ := dht.HaveFingerprint(f); !have {
+						received++
+					}
+				}
+			}
 			// put the message into the gossip put handling queue so we can return quickly
 			dht.gossipPuts <- p
 		}
 		err = dht.UpdateGossiper(id, idx)
+		if err == nil && notify {
+			dht.gossipPuts <- gossipDone{peer: id, received: received}
+		}
 	} else {
 		dht.glog.Log("no new puts received")
+		if notify {
+			dht.notifyGossipComplete(id, 0)
+		}
 	}
 	return
 }
@@ -380,7 +398,13 @@ func (dht *DHT) gossipPut(p Put) (err error) {
 		exists, e := dht.HaveFingerprint(f)
 		if !exists && e == nil {
 			dht.glog.Logf("PUT--%d calling ActionReceiver", p.Idx)
-			r, e := ActionReceiver(dht.h, &p.M)
+			// the put is replayed from the gossiper's log with its original
+			// author as the sender, so it isn't rate limited, or counted
+			// against the author if it fails validation, as if the author
+			// had sent it to us
+			m := p.M
+			m.replayed = true
+			r, e := actionReceiver(dht.h, &m, MaxRetries)
 			dht.glog.Logf("PUT--%d ActionReceiver returned %v with err %v", p.Idx, r, e)
 			if e != nil {
 				// put receiver error so do what? probably nothing because
@@ -401,6 +425,10 @@ func (dht *DHT) gossipPut(p Put) (err error) {
 }
 
 func handleGossipPut(dht *DHT, x interface{}) (err error) {
+	if g, ok := x.(gossipDone); ok {
+		dht.notifyGossipComplete(g.peer, g.received)
+		return
+	}
 	p := x.(Put)
 	err = dht.gossipPut(p)
 	return
@@ -300,6 +300,79 @@ func TestGossip(t *testing.T) {
 	})
 }
 
+func TestGossipIsNotPutRateLimited(t *testing.T) {
+	nodesCount := 2
+	mt := setupMultiNodeTesting(nodesCount)
+	defer mt.cleanupMultiNodeTesting()
+	nodes := mt.nodes
+
+	h1 := nodes[0]
+	h2 := nodes[1]
+
+	// h2 allows a single put from each sender
+	h2.Config.PutRateLimit = 0.001
+	h2.Config.PutRateBurst = 1
+	if err := h2.makePutRateLimiter(); err != nil {
+		panic(err)
+	}
+	h2.PutRateLimiter().clock = &stoppedClock{now: time.Unix(1, 0)}
+
+	commit(h1, "oddNumbers", "3")
+	commit(h1, "oddNumbers", "5")
+	commit(h1, "oddNumbers", "7")
+
+	ringConnect(t, mt.ctx, mt.nodes, nodesCount)
+	Convey("puts gossiped from their author should all be held", t, func() {
+		err := h2.dht.gossipWith(h1.nodeID)
+		So(err, ShouldBeNil)
+		go h2.dht.HandleGossipPuts()
+		time.Sleep(time.Millisecond * 100)
+		puts2, _ := h2.dht.GetPuts(0)
+		So(len(puts2), ShouldEqual, 7)
+		So(h2.PutRateLimiter().Allow(h1.nodeID), ShouldBeTrue)
+	})
+}
+
+func TestOnGossipComplete(t *testing.T) {
+	nodesCount := 2
+	mt := setupMultiNodeTesting(nodesCount)
+	defer mt.cleanupMultiNodeTesting()
+	h1 := mt.nodes[0]
+	h2 := mt.nodes[1]
+
+	commit(h1, "oddNumbers", "3")
+	commit(h1, "oddNumbers", "5")
+	commit(h1, "oddNumbers", "7")
+	ringConnect(t, mt.ctx, mt.nodes, nodesCount)
+
+	rounds1 := make(chan gossipComplete, 10)
+	rounds2 := make(chan gossipComplete, 10)
+	h1.OnGossipComplete(func(p peer.ID, received int) { rounds1 <- gossipComplete{p, received} })
+	h2.OnGossipComplete(func(p peer.ID, received int) { rounds2 <- gossipComplete{p, received} })
+
+	Convey("both sides of a round should be told of it with the number of new puts", t, func() {
+		So(h2.dht.gossipWith(h1.nodeID), ShouldBeNil)
+		So(<-rounds1, ShouldResemble, gossipComplete{h2.nodeID, 5})
+
+		// the initiator hears once the puts have been handled
+		select {
+		case <-rounds2:
+			t.Fatal("round reported before its puts were handled")
+		case <-time.After(50 * time.Millisecond):
+		}
+		go h2.dht.HandleGossipPuts()
+		So(<-rounds2, ShouldResemble, gossipComplete{h1.nodeID, 5})
+		puts, _ := h2.dht.GetPuts(0)
+		So(len(puts), ShouldEqual, 7)
+	})
+
+	Convey("a round with nothing new should report none", t, func() {
+		So(h2.dht.gossipWith(h1.nodeID), ShouldBeNil)
+		So(<-rounds2, ShouldResemble, gossipComplete{h1.nodeID, 0})
+		So(<-rounds1, ShouldResemble, gossipComplete{h2.nodeID, 0})
+	})
+}
+
 func TestPeerLists(t *testing.T) {
 	d, _, h := PrepareTestChain("test")
 	defer CleanupTestChain(h, d)
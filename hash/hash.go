@@ -8,6 +8,7 @@ package hash
 
 import (
 	"encoding/binary"
+	"fmt"
 	peer "github.com/libp2p/go-libp2p-peer"
 	mh "github.com/multiformats/go-multihash"
 	"io"
@@ -32,6 +33,51 @@ func NewHash(s string) (h Hash, err error) {
 	return
 }
 
+// HashParseError reports which of the strings given to ParseHashes couldn't be
+// parsed and why
+type HashParseError struct {
+	Index int
+	Input string
+	Err   error
+}
+
+func (e *HashParseError) Error() string {
+	return fmt.Sprintf("hash %d ('%s'): %v", e.Index, e.Input, e.Err)
+}
+
+func (e *HashParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseHashes builds Hashes from b58 string encoded hashes.  It stops at the
+// first string that isn't a valid hash returning a *HashParseError for it.
+// N.B. empty strings aren't valid, as for NewHash they fail with
+// ErrInvalidMultihash rather than giving the NullHash.
+func ParseHashes(strs []string) (hashes []Hash, err error) {
+	hashes = make([]Hash, len(strs))
+	for i, s := range strs {
+		if s == "" {
+			err = &HashParseError{Index: i, Input: s, Err: mh.ErrInvalidMultihash}
+		} else if hashes[i], err = NewHash(s); err != nil {
+			err = &HashParseError{Index: i, Input: s, Err: err}
+		}
+		if err != nil {
+			hashes = nil
+			return
+		}
+	}
+	return
+}
+
+// FormatHashes encodes hashes to b58 strings
+func FormatHashes(hashes []Hash) (strs []string) {
+	strs = make([]string, len(hashes))
+	for i, h := range hashes {
+		strs[i] = h.String()
+	}
+	return
+}
+
 // HashFromBytes cast a byte slice to Hash type, and validate
 // the id to make sure it is a multihash.
 func HashFromBytes(b []byte) (h Hash, err error) {
@@ -75,6 +121,31 @@ func Sum(hc HashSpec, data []byte) (hash Hash, err error) {
 	return
 }
 
+// Codec returns the spec of the multihash codec, i.e. the code and digest
+// length, that was used to make the hash
+func (h Hash) Codec() (spec HashSpec, err error) {
+	var decoded *mh.DecodedMultihash
+	decoded, err = mh.Decode([]byte(h))
+	if err == nil {
+		spec.Code = decoded.Code
+		spec.Length = decoded.Length
+	}
+	return
+}
+
+// Compatible checks to see if two hashes were made with the same multihash codec
+func (h Hash) Compatible(h2 Hash) bool {
+	spec, err := h.Codec()
+	if err != nil {
+		return false
+	}
+	spec2, err := h2.Codec()
+	if err != nil {
+		return false
+	}
+	return spec == spec2
+}
+
 // IsNullHash checks to see if this hash's value is the null hash
 func (h Hash) IsNullHash() bool {
 	return h == ""
@@ -104,6 +175,17 @@ func (h Hash) Equal(h2 Hash) bool {
 	return h == h2
 }
 
+// Shard maps the hash into one of n buckets, in the range [0, n), by taking its
+// raw bytes as a big-endian integer modulo n.  It only depends on the bytes of
+// the hash, so it's stable across runs and platforms.  n must be positive.
+func (h Hash) Shard(n int) int {
+	if n <= 0 {
+		panic("hash: shard count must be positive")
+	}
+	i := big.NewInt(0).SetBytes([]byte(h))
+	return int(i.Mod(i, big.NewInt(int64(n))).Int64())
+}
+
 // MarshalHash writes a hash to a binary stream
 func (h Hash) MarshalHash(writer io.Writer) (err error) {
 	if h.IsNullHash() {
@@ -151,6 +233,13 @@ func HashXORDistance(h1, h2 Hash) *big.Int {
 	return dist
 }
 
+// Distance returns the XOR distance between the raw bytes of two hashes, the
+// metric the routing table uses to find the peers closest to a hash, so a
+// node holding an entry should be among those nearest to it by Distance
+func (h Hash) Distance(other Hash) *big.Int {
+	return HashXORDistance(h, other)
+}
+
 // Less returns whether the first key is smaller than the second.
 func HashLess(h1, h2 Hash) bool {
 	a := h1
@@ -2,6 +2,7 @@ package hash
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	peer "github.com/libp2p/go-libp2p-peer"
 	mh "github.com/multiformats/go-multihash"
@@ -27,6 +28,39 @@ func TestHash(t *testing.T) {
 	})
 }
 
+func TestParseHashes(t *testing.T) {
+	strs := []string{"QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2", "QmNiCwBNA8MWDADTFVq1BonUEJbS2SvjAoNkZZrhEwcuU2"}
+
+	Convey("it should parse and format hashes", t, func() {
+		hashes, err := ParseHashes(strs)
+		So(err, ShouldBeNil)
+		So(len(hashes), ShouldEqual, 2)
+		So(hashes[0].String(), ShouldEqual, strs[0])
+		So(FormatHashes(hashes), ShouldResemble, strs)
+
+		hashes, err = ParseHashes(nil)
+		So(err, ShouldBeNil)
+		So(len(hashes), ShouldEqual, 0)
+		So(FormatHashes(nil), ShouldResemble, []string{})
+	})
+
+	Convey("it should report which string couldn't be parsed", t, func() {
+		_, err := ParseHashes([]string{strs[0], "not-a-hash"})
+		var parseErr *HashParseError
+		So(errors.As(err, &parseErr), ShouldBeTrue)
+		So(parseErr.Index, ShouldEqual, 1)
+		So(parseErr.Input, ShouldEqual, "not-a-hash")
+		So(err.Error(), ShouldEqual, "hash 1 ('not-a-hash'): input isn't valid multihash")
+	})
+
+	Convey("it should reject empty strings", t, func() {
+		hashes, err := ParseHashes([]string{strs[0], strs[1], ""})
+		So(hashes, ShouldBeNil)
+		So(errors.Is(err, mh.ErrInvalidMultihash), ShouldBeTrue)
+		So(err.(*HashParseError).Index, ShouldEqual, 2)
+	})
+}
+
 func TestHashSum(t *testing.T) {
 	var hs = HashSpec{mh.SHA2_256, -1}
 	b := []byte("test data")
@@ -37,6 +71,32 @@ func TestHashSum(t *testing.T) {
 	})
 }
 
+func TestHashCodec(t *testing.T) {
+	b := []byte("test data")
+	h256, _ := Sum(HashSpec{mh.SHA2_256, -1}, b)
+	h512, _ := Sum(HashSpec{mh.SHA2_512, -1}, b)
+	Convey("it should return the codec of the hash", t, func() {
+		spec, err := h256.Codec()
+		So(err, ShouldBeNil)
+		So(spec.Code, ShouldEqual, mh.SHA2_256)
+		So(spec.Length, ShouldEqual, 32)
+		spec, err = h512.Codec()
+		So(err, ShouldBeNil)
+		So(spec.Code, ShouldEqual, mh.SHA2_512)
+		So(spec.Length, ShouldEqual, 64)
+	})
+	Convey("it should fail to return the codec of a bad hash", t, func() {
+		_, err := NullHash().Codec()
+		So(err, ShouldNotBeNil)
+	})
+	Convey("it should check compatibility of hashes", t, func() {
+		other, _ := Sum(HashSpec{mh.SHA2_256, -1}, []byte("other data"))
+		So(h256.Compatible(other), ShouldBeTrue)
+		So(h256.Compatible(h512), ShouldBeFalse)
+		So(h256.Compatible(NullHash()), ShouldBeFalse)
+	})
+}
+
 func TestNullHash(t *testing.T) {
 	Convey("There should be a null hash", t, func() {
 		h := NullHash()
@@ -97,6 +157,38 @@ func TestHashEqual(t *testing.T) {
 
 }
 
+func TestHashShard(t *testing.T) {
+	h, _ := NewHash("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
+
+	Convey("it should be deterministic", t, func() {
+		So(h.Shard(8), ShouldEqual, 1)
+		So(h.Shard(7), ShouldEqual, 3)
+		So(h.Shard(1000), ShouldEqual, 353)
+		So(h.Shard(1), ShouldEqual, 0)
+	})
+
+	Convey("it should panic on a non-positive shard count", t, func() {
+		So(func() { h.Shard(0) }, ShouldPanic)
+	})
+
+	Convey("it should distribute hashes roughly uniformly", t, func() {
+		n := 8
+		count := 4000
+		buckets := make([]int, n)
+		for i := 0; i < count; i++ {
+			m, err := mh.Sum([]byte(fmt.Sprintf("data %d", i)), mh.SHA2_256, -1)
+			So(err, ShouldBeNil)
+			s := Hash(m).Shard(n)
+			So(s, ShouldBeBetweenOrEqual, 0, n-1)
+			buckets[s]++
+		}
+		expected := count / n
+		for _, b := range buckets {
+			So(b, ShouldBeBetween, expected*8/10, expected*12/10)
+		}
+	})
+}
+
 func TestMarshalHash(t *testing.T) {
 	Convey("should be able to marshal and unmarshal a hash", t, func() {
 		hash, _ := NewHash("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
@@ -154,6 +246,31 @@ func TestHashXORDistance(t *testing.T) {
 
 }
 
+func TestHashDistance(t *testing.T) {
+	hash := func(data string) Hash {
+		multih, _ := mh.Sum([]byte(data), mh.SHA2_256, -1)
+		return Hash(multih)
+	}
+	h0, h1, h2 := hash("zero"), hash("one"), hash("two")
+
+	Convey("a hash should be at distance 0 from itself", t, func() {
+		So(h0.Distance(h0).Sign(), ShouldEqual, 0)
+	})
+
+	Convey("distance should be symmetric", t, func() {
+		So(h0.Distance(h1).Cmp(h1.Distance(h0)), ShouldEqual, 0)
+		So(h1.Distance(h2).Cmp(h2.Distance(h1)), ShouldEqual, 0)
+		So(h0.Distance(h1).Sign(), ShouldEqual, 1)
+	})
+
+	Convey("it should be the metric hashes are sorted by", t, func() {
+		So(h0.Distance(h1), ShouldResemble, HashXORDistance(h0, h1))
+		sorted := SortByDistance(h0, []Hash{h2, h1, h0})
+		So(sorted[0], ShouldEqual, h0)
+		So(sorted[1].Distance(h0).Cmp(sorted[2].Distance(h0)), ShouldBeLessThanOrEqualTo, 0)
+	})
+}
+
 func TestHashDistancesAndCenterSorting(t *testing.T) {
 
 	hashes := makeTestHashes()
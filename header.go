@@ -9,6 +9,7 @@ package holochain
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	. "github.com/holochain/holochain-proto/hash"
 	b58 "github.com/jbenet/go-base58"
@@ -30,8 +31,16 @@ type Header struct {
 	TypeLink   Hash // link to header of previous header of this type
 	Sig        Signature
 	Change     Hash
+
+	// Signer is the b58 encoded public key of the agent that signed the
+	// header if it isn't the chain's own agent, i.e. a member of a group chain
+	Signer string
 }
 
+// headerMetaSigner is set in a marshaled header's meta word when the header
+// has a Signer, which then follows it
+const headerMetaSigner = uint64(1)
+
 // newHeader makes Header object linked to a previous Header by hash
 func newHeader(hashSpec HashSpec, now time.Time, t string, entry Entry, privKey ic.PrivKey, prev Hash, prevType Hash, change Hash) (hash Hash, header *Header, err error) {
 	var hd Header
@@ -102,6 +111,77 @@ func (hd *Header) ToJSON() (result string, err error) {
 	return
 }
 
+// headerJSON is the JSON representation of a header, with hashes and signature
+// encoded as base58 strings
+type headerJSON struct {
+	Type       string
+	Time       string
+	HeaderLink string
+	EntryLink  string
+	TypeLink   string
+	Sig        string
+	Change     string
+	Signer     string `json:",omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler for headers
+func (hd Header) MarshalJSON() (b []byte, err error) {
+	j := headerJSON{
+		Type:       hd.Type,
+		Time:       hd.Time.Format(time.RFC3339Nano),
+		HeaderLink: hd.HeaderLink.String(),
+		EntryLink:  hd.EntryLink.String(),
+		TypeLink:   hd.TypeLink.String(),
+		Sig:        hd.Sig.B58String(),
+		Change:     hd.Change.String(),
+		Signer:     hd.Signer,
+	}
+	b, err = json.Marshal(j)
+	return
+}
+
+func hashFromJSONString(s string) (hash Hash, err error) {
+	if s == "" {
+		hash = NullHash()
+		return
+	}
+	hash, err = NewHash(s)
+	return
+}
+
+// UnmarshalJSON implements json.Unmarshaler for headers
+func (hd *Header) UnmarshalJSON(b []byte) (err error) {
+	var j headerJSON
+	err = json.Unmarshal(b, &j)
+	if err != nil {
+		return
+	}
+	var h Header
+	h.Type = j.Type
+	h.Time, err = time.Parse(time.RFC3339Nano, j.Time)
+	if err != nil {
+		return
+	}
+	if h.HeaderLink, err = hashFromJSONString(j.HeaderLink); err != nil {
+		return
+	}
+	if h.EntryLink, err = hashFromJSONString(j.EntryLink); err != nil {
+		return
+	}
+	if h.TypeLink, err = hashFromJSONString(j.TypeLink); err != nil {
+		return
+	}
+	if h.Change, err = hashFromJSONString(j.Change); err != nil {
+		return
+	}
+	if j.Sig != "" {
+		h.Sig = SignatureFromB58String(j.Sig)
+	}
+	h.Signer = j.Signer
+	*hd = h
+	return
+}
+
 // Marshal writes a header to bytes
 func (hd *Header) Marshal() (b []byte, err error) {
 	var s bytes.Buffer
@@ -162,12 +242,19 @@ func MarshalHeader(writer io.Writer, hd *Header) (err error) {
 		return
 	}
 
-	// write out 0 for future expansion (meta)
+	// write out the meta flags, 0 for headers without the optional fields so
+	// that they hash as they always have
 	z := uint64(0)
+	if hd.Signer != "" {
+		z |= headerMetaSigner
+	}
 	err = binary.Write(writer, binary.LittleEndian, &z)
 	if err != nil {
 		return
 	}
+	if hd.Signer != "" {
+		err = writeStr(writer, hd.Signer)
+	}
 	return
 }
 
@@ -239,6 +326,9 @@ func UnmarshalHeader(reader io.Reader, hd *Header, hashSize int) (err error) {
 	if err != nil {
 		return
 	}
+	if z&headerMetaSigner != 0 {
+		hd.Signer, err = readStr(reader)
+	}
 	return
 }
 
@@ -3,6 +3,8 @@ package holochain
 import (
 	"bytes"
 	"crypto/rand"
+	"encoding/gob"
+	"encoding/json"
 	"fmt"
 	. "github.com/holochain/holochain-proto/hash"
 	ic "github.com/libp2p/go-libp2p-crypto"
@@ -70,6 +72,58 @@ func TestHeaderMarshal(t *testing.T) {
 	})
 }
 
+func TestHeaderMarshalJSON(t *testing.T) {
+	h, key, now := chainTestSetup()
+
+	e := GobEntry{C: "some data"}
+	hd := testHeader(h, "evenNumbers", &e, key, now)
+	hd.Change, _ = NewHash("QmNiCwBNA8MWDADTFVq1BonUEJbS2SvjAoNkZZrhEwcuU2")
+	Convey("it should encode hashes as strings and time as RFC3339", t, func() {
+		b, err := json.Marshal(hd)
+		So(err, ShouldBeNil)
+		var m map[string]string
+		err = json.Unmarshal(b, &m)
+		So(err, ShouldBeNil)
+		So(m["EntryLink"], ShouldEqual, hd.EntryLink.String())
+		So(m["HeaderLink"], ShouldEqual, hd.HeaderLink.String())
+		So(m["TypeLink"], ShouldEqual, "")
+		So(m["Change"], ShouldEqual, hd.Change.String())
+		So(m["Sig"], ShouldEqual, hd.Sig.B58String())
+		So(m["Time"], ShouldEqual, hd.Time.Format(time.RFC3339Nano))
+	})
+
+	Convey("it should reconstruct the same header as gob", t, func() {
+		var buf bytes.Buffer
+		err := gob.NewEncoder(&buf).Encode(hd)
+		So(err, ShouldBeNil)
+		var gobHeader Header
+		err = gob.NewDecoder(&buf).Decode(&gobHeader)
+		So(err, ShouldBeNil)
+
+		b, err := json.Marshal(hd)
+		So(err, ShouldBeNil)
+		var jsonHeader Header
+		err = json.Unmarshal(b, &jsonHeader)
+		So(err, ShouldBeNil)
+
+		So(jsonHeader.EntryLink.Equal(hd.EntryLink), ShouldBeTrue)
+		So(jsonHeader.HeaderLink.Equal(hd.HeaderLink), ShouldBeTrue)
+		So(jsonHeader.TypeLink.Equal(hd.TypeLink), ShouldBeTrue)
+		So(jsonHeader.Sig.Equal(hd.Sig), ShouldBeTrue)
+		So(jsonHeader.Time.Equal(gobHeader.Time), ShouldBeTrue)
+
+		// locations may differ in representation but not in instant
+		jsonHeader.Time = gobHeader.Time
+		So(jsonHeader, ShouldResemble, gobHeader)
+	})
+
+	Convey("it should fail on bad hashes", t, func() {
+		var nh Header
+		err := json.Unmarshal([]byte(`{"Type":"evenNumbers","Time":"2018-01-01T00:00:00Z","EntryLink":"not-a-hash"}`), &nh)
+		So(err, ShouldNotBeNil)
+	})
+}
+
 func TestSignatureB58(t *testing.T) {
 	h, key, now := chainTestSetup()
 	e := GobEntry{C: "1234"}
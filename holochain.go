@@ -21,11 +21,14 @@ import (
 	"github.com/tidwall/buntdb"
 	"io"
 	"math/rand"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -63,11 +66,61 @@ type Config struct {
 	BootstrapServer  string
 	Loggers          Loggers
 
+	// ValidationCacheSize is the number of successful system validations of
+	// received entries to remember, 0 means DefaultValidationCacheSize
+	ValidationCacheSize int
+
+	// EnableCompactWire makes the node offer peers the compact binary wire
+	// format for DHT messages, falling back to gob for peers without it
+	EnableCompactWire bool
+
+	// PutRateLimit is how many PUT_REQUESTs and PUTIF_REQUESTs per second a
+	// peer may send us after a burst of PutRateBurst, 0 means no limit.
+	// Requests over the limit are refused with ErrRateLimited.
+	// PutRateExemptPeers lists the b58 ids of trusted peers, i.e. bootstrap
+	// peers, that aren't limited.
+	PutRateLimit       float64
+	PutRateBurst       int
+	PutRateExemptPeers []string
+
+	// BridgeTokenTTL is how long the tokens granted to bridged callers are
+	// valid for, 0 means they don't expire
+	BridgeTokenTTL time.Duration
+
+	// BridgeNonceWindow is how far from our clock the timestamp of a
+	// cross-DNA request may be, older requests and ones whose nonce doesn't
+	// increase are refused with ErrReplayedMessage, 0 means
+	// DefaultBridgeNonceWindow
+	BridgeNonceWindow time.Duration
+
+	// ReputationThreshold is how many validation failures of the entries a
+	// peer sends us it takes for us to block it, 0 means
+	// DefaultReputationThreshold and a negative value never blocks.  Failures
+	// stop counting against a peer after ReputationDecay, 0 means
+	// DefaultReputationDecay.
+	ReputationThreshold int
+	ReputationDecay     time.Duration
+
+	// GetFallbackFactor widens a get that none of the closest peers could
+	// answer to the next GetFallbackFactor*AlphaValue closest peers in the
+	// routing table before it fails with ErrHashNotFound, 0 means no fallback
+	GetFallbackFactor int
+
+	// ChangeStreamBuffer is how many changes can be waiting for a slow reader
+	// of a DHT change stream before further ones are dropped, 0 means
+	// DefaultChangeStreamBuffer
+	ChangeStreamBuffer int
+
+	// DHTTransport replaces the libp2p node as the carrier of DHT messages
+	// when set, i.e. with a MemTransport for tests
+	DHTTransport DHTTransport `json:"-" toml:"-" yaml:"-"`
+
 	holdingCheckInterval     time.Duration
 	gossipInterval           time.Duration
 	bootstrapRefreshInterval time.Duration
 	routingRefreshInterval   time.Duration
 	retryInterval            time.Duration
+	expiryInterval           time.Duration
 }
 
 // Progenitor holds data on the creator of the DNA
@@ -78,6 +131,8 @@ type Progenitor struct {
 
 // Holochain struct holds the full "DNA" of the holochain (all your app code for managing distributed data integrity)
 type Holochain struct {
+	bridgeNonce uint64 // first so it's aligned for atomic access on 32 bit platforms
+
 	//---- lowercase private values not serialized; initialized on Load
 	nodeID           peer.ID // this is hash of the public key of the id and acts as the node address
 	nodeIDStr        string  // this is just a cached version of the nodeID B58 string encoded
@@ -88,6 +143,7 @@ type Holochain struct {
 	agent            Agent
 	encodingFormat   string
 	hashSpec         HashSpec
+	hashCodecs       []uint64
 	Config           Config
 	dht              *DHT
 	nucleus          *Nucleus
@@ -99,6 +155,37 @@ type Holochain struct {
 	gossipProtocol   *Protocol
 	actionProtocol   *Protocol
 	asyncSends       chan error
+	clock            Clock
+	metrics          Metrics
+	validationCache  *ValidationCache
+	putLimiter       *RateLimiter
+	bridgeReplay     *ReplayGuard
+	reputation       *Reputation
+	shuttingDown     int32
+	signalHandlers   []SignalHandler
+	signalLk         sync.RWMutex
+	signalQueue      *signalQueue
+	signalQueueLk    sync.RWMutex
+	actionMiddleware []ActionMiddleware
+	commitGate       CommitGate
+	auditSink        AuditSink
+	middlewareLk     sync.RWMutex
+	pendingShares    map[Hash]pendingShare
+	pendingSharesLk  sync.Mutex
+	idempotencyLk    sync.Mutex
+	levelLogger      LevelLogger
+	levelLoggerLk    sync.RWMutex
+	debugServer      *http.Server
+	debugAddr        net.Addr
+	debugLk          sync.Mutex
+	selfTestChain    *Chain // the throwaway chain of a running self test
+	selfTestLk       sync.Mutex
+	keyTrust         map[Hash]keyTrust
+	keyTrustLk       sync.Mutex
+	migrateDataDef   *EntryDef // holds the validator for the DNA's migrate DataSchema
+	migrateDefLk     sync.Mutex
+	closing          chan struct{} // closed by Close, see closingCh
+	closingLk        sync.Mutex
 }
 
 func (h *Holochain) Nucleus() (n *Nucleus) {
@@ -117,6 +204,20 @@ func (h *Holochain) World() *World {
 	return h.world
 }
 
+// CountHolding returns the number of live entries this node is holding in
+// the DHT grouped by entry type
+func (h *Holochain) CountHolding() (counts map[string]int, err error) {
+	counts, err = h.dht.CountByType(StatusLive)
+	return
+}
+
+// MigrationStatus reports whether the chain has been closed by a migrate that
+// hasn't been rolled back, and if so the DNA it migrated to
+func (h *Holochain) MigrationStatus() (closed bool, targetDNA Hash, err error) {
+	closed, targetDNA, err = h.chain.MigrationStatus()
+	return
+}
+
 var debugLog Logger
 var infoLog Logger
 var SendTimeoutErr = errors.New("send timeout")
@@ -177,6 +278,14 @@ func InitializeHolochain() {
 		gob.Register(HoldResp{})
 		gob.Register(GetReq{})
 		gob.Register(GetResp{})
+		gob.Register(GetBatchReq{})
+		gob.Register(GetBatchResp{})
+		gob.Register(MigrationStatusReq{})
+		gob.Register(MigrationStatusResp{})
+		gob.Register(PutIfReq{})
+		gob.Register(MigrationTargetsReq{})
+		gob.Register(MigrationTargetsResp{})
+		gob.Register(ActionReq{})
 		gob.Register(LinkQuery{})
 		gob.Register(GossipReq{})
 		gob.Register(Gossip{})
@@ -266,15 +375,62 @@ func (h *Holochain) NodeIDStr() string {
 	return h.nodeIDStr
 }
 
-// PrepareHashType makes sure the given string is a correct multi-hash and stores
-// the code and length to the Holochain struct
+// PrepareHashType makes sure the given string is a correct multi-hash that can
+// be computed and stores the code and length to the Holochain struct
 func (h *Holochain) PrepareHashType() (err error) {
 	c, ok := mh.Names[string(h.nucleus.dna.DHTConfig.HashType)]
 	if !ok {
 		return fmt.Errorf("Unknown hash type: %s", h.nucleus.dna.DHTConfig.HashType)
 	}
+	if !hashCodeComputable(c) {
+		return fmt.Errorf("Unsupported hash type: %s", h.nucleus.dna.DHTConfig.HashType)
+	}
 	h.hashSpec.Code = c
 	h.hashSpec.Length = -1
+
+	// node IDs are always sha2-256 multihashes of the agent's public key
+	h.hashCodecs = []uint64{c}
+	if c != mh.SHA2_256 {
+		h.hashCodecs = append(h.hashCodecs, mh.SHA2_256)
+	}
+	return
+}
+
+// hashCodeComputable checks that multihash can compute hashes with the code,
+// as it names more hash functions than it implements
+func hashCodeComputable(code uint64) bool {
+	_, err := mh.Sum(nil, code, -1)
+	return err == nil
+}
+
+// hashComputable checks that a hash was made with a codec multihash can
+// compute, which needn't be this holochain's, i.e. another DNA's hash
+func hashComputable(hash Hash) bool {
+	spec, err := hash.Codec()
+	return err == nil && hashCodeComputable(spec.Code)
+}
+
+// checkMigrateHashCodecs checks the hashes of a migrate can be compared across
+// DNAs.  As DNAs can hash with different codecs, the DNAHash needn't use the
+// Key's codec, only one that can be computed, but the Key must be hashed like
+// all node IDs, with sha2-256, as it's the same agent on both sides.
+func checkMigrateHashCodecs(entry *MigrateEntry) (err error) {
+	if !hashComputable(entry.DNAHash) {
+		err = ErrMigrateHashCodecUnsupported
+		return
+	}
+	spec, e := entry.Key.Codec()
+	if e != nil || spec.Code != mh.SHA2_256 {
+		err = ErrMigrateHashCodecMismatch
+	}
+	return
+}
+
+// SupportedHashCodecs returns the multihash codes of the hashes this holochain
+// can produce and validate
+func (h *Holochain) SupportedHashCodecs() (codecs []uint64) {
+	codecs = make([]uint64, len(h.hashCodecs))
+	copy(codecs, h.hashCodecs)
 	return
 }
 
@@ -288,6 +444,9 @@ func (h *Holochain) createNode() (err error) {
 	}
 	listenaddr := fmt.Sprintf("/ip4/%s/tcp/%d", ip, h.Config.DHTPort)
 	h.node, err = NewNode(listenaddr, h.dnaHash.String(), h.Agent().(*LibP2PAgent), h.Config.EnableNATUPnP, &h.Config.Loggers.Debug)
+	if err == nil && h.Config.EnableCompactWire {
+		h.node.EnableCompactWire()
+	}
 	return
 }
 
@@ -306,6 +465,12 @@ func (h *Holochain) Prepare() (err error) {
 	}
 
 	h.asyncSends = make(chan error, 10)
+	h.validationCache = NewValidationCache(h.Config.ValidationCacheSize)
+	if err = h.makePutRateLimiter(); err != nil {
+		return
+	}
+	h.bridgeReplay = NewReplayGuard(h.Config.BridgeNonceWindow)
+	h.reputation = NewReputation(h.Config.ReputationThreshold, h.Config.ReputationDecay)
 
 	err = h.createNode()
 	if err != nil {
@@ -318,6 +483,10 @@ func (h *Holochain) Prepare() (err error) {
 	if h.Config.EnableWorldModel {
 		h.world = NewWorld(h.node.HashAddr, h.dht, &h.Config.Loggers.World)
 	}
+	if h.Config.DHTTransport != nil {
+		h.dht.transport = h.Config.DHTTransport
+		h.dht.transport.Join(h)
+	}
 
 	var peerList PeerList
 	peerList, err = h.dht.getList(BlockedList)
@@ -395,9 +564,36 @@ func (h *Holochain) AgentTopHash() (id Hash) {
 
 // Top returns a hash of top header or err if not yet defined
 func (h *Holochain) Top() (top Hash, err error) {
-	//TODO: LOCK!!!
-	tp := h.chain.Hashes[len(h.chain.Hashes)-1]
-	top = tp.Clone()
+	h.chain.lk.RLock()
+	defer h.chain.lk.RUnlock()
+	l := len(h.chain.Hashes)
+	if l == 0 {
+		err = ErrChainEmpty
+		return
+	}
+	top = h.chain.Hashes[l-1].Clone()
+	return
+}
+
+// ChainLength returns the number of entries in the source chain
+func (h *Holochain) ChainLength() int {
+	h.chain.lk.RLock()
+	defer h.chain.lk.RUnlock()
+	return len(h.chain.Headers)
+}
+
+// TopHeader returns the top header of the source chain and its hash, or
+// ErrChainEmpty if genesis hasn't been written yet
+func (h *Holochain) TopHeader() (header *Header, hash Hash, err error) {
+	h.chain.lk.RLock()
+	defer h.chain.lk.RUnlock()
+	l := len(h.chain.Headers)
+	if l == 0 {
+		err = ErrChainEmpty
+		return
+	}
+	header = h.chain.Headers[l-1]
+	hash = h.chain.Hashes[l-1].Clone()
 	return
 }
 
@@ -422,7 +618,7 @@ func (h *Holochain) AddAgentEntry(revocation Revocation) (headerHash, agentHash
 	e := GobEntry{C: j}
 
 	var agentHeader *Header
-	headerHash, agentHeader, err = h.NewEntry(time.Now(), AgentEntryType, &e)
+	headerHash, agentHeader, err = h.NewEntry(h.Now(), AgentEntryType, &e)
 	if err != nil {
 		return
 	}
@@ -452,7 +648,7 @@ func (h *Holochain) GenChain() (headerHash Hash, err error) {
 	e := GobEntry{C: buf.Bytes()}
 
 	var dnaHeader *Header
-	_, dnaHeader, err = h.NewEntry(time.Now(), DNAEntryType, &e)
+	_, dnaHeader, err = h.NewEntry(h.Now(), DNAEntryType, &e)
 	if err != nil {
 		return
 	}
@@ -540,6 +736,7 @@ func (config *Config) Setup() (err error) {
 	config.bootstrapRefreshInterval = BootstrapTTL
 	config.routingRefreshInterval = DefaultRoutingRefreshInterval
 	config.retryInterval = DefaultRetryInterval
+	config.expiryInterval = DefaultExpiryInterval
 	err = config.SetupLogging()
 	return
 }
@@ -643,8 +840,18 @@ func (h *Holochain) GetEntryDef(t string) (zome *Zome, d *EntryDef, err error) {
 	case DelEntryType:
 		d = DelEntryDef
 	case MigrateEntryType:
-		d = MigrateEntryDef
+		d, err = h.migrateEntryDef()
+	case MigrateRollbackEntryType:
+		d = MigrateRollbackEntryDef
+	case RevocationEntryType:
+		d = RevocationEntryDef
+	case GroupEntryType:
+		d = GroupEntryDef
 	default:
+		if r, ok := getRegisteredSysEntryType(t); ok {
+			d = r.def
+			return
+		}
 		for _, z := range h.nucleus.dna.Zomes {
 			d, err = z.GetEntryDef(t)
 			if err == nil {
@@ -656,6 +863,35 @@ func (h *Holochain) GetEntryDef(t string) (zome *Zome, d *EntryDef, err error) {
 	return
 }
 
+// EntryDefs returns the defs of all the entry types the DNA's zomes declare,
+// and of the system types, keyed by type.  They are copies, so changing them
+// doesn't change the DNA.  As with GetEntryDef the system types take
+// precedence, and if more than one zome declares a type the first one wins.
+func (h *Holochain) EntryDefs() (defs map[string]*EntryDef) {
+	defs = make(map[string]*EntryDef)
+	add := func(d *EntryDef) {
+		c := *d
+		defs[d.Name] = &c
+	}
+	for _, z := range h.nucleus.dna.Zomes {
+		for i := range z.Entries {
+			if _, exists := defs[z.Entries[i].Name]; !exists {
+				add(&z.Entries[i])
+			}
+		}
+	}
+	for _, d := range registeredSysEntryDefs() {
+		add(d)
+	}
+	for _, d := range builtInSysEntryDefs() {
+		add(d)
+	}
+	if d, err := h.migrateEntryDef(); err == nil {
+		add(d)
+	}
+	return
+}
+
 func (h *Holochain) GetPrivateEntryDefs() (privateDefs []EntryDef) {
 	privateDefs = make([]EntryDef, 0)
 	for _, z := range h.nucleus.dna.Zomes {
@@ -719,6 +955,14 @@ func (h *Holochain) GetZome(zName string) (z *Zome, err error) {
 
 // Close releases the resources associated with a holochain
 func (h *Holochain) Close() {
+	h.signalClosing()
+	if err := h.StopDebug(); err != nil {
+		h.Debugf("error stopping debug server: %v", err)
+	}
+	if err := h.closeAuditSink(); err != nil {
+		h.Debugf("error closing audit sink: %v", err)
+	}
+	h.stopSignalQueue()
 	if h.chain != nil {
 		h.chain.Close()
 		h.chain = nil
@@ -853,6 +1097,7 @@ func (h *Holochain) StartBackgroundTasks() {
 	}
 
 	h.node.stoppers[RetryingStopper] = h.TaskTicker(h.Config.retryInterval, RetryTask)
+	h.node.stoppers[ExpiringStopper] = h.TaskTicker(h.Config.expiryInterval, ExpiryTask)
 	if h.Config.BootstrapServer != "" {
 		go BootstrapRefreshTask(h)
 		h.node.stoppers[BootstrappingStopper] = h.TaskTicker(h.Config.bootstrapRefreshInterval, BootstrapRefreshTask)
@@ -892,6 +1137,10 @@ func (h *Holochain) Send(basectx context.Context, proto int, to peer.ID, message
 			h.Debugf("Sending message (local):%v (fingerprint:%s)", message, f)
 			response, err = h.node.protocols[proto].Receiver(h, message)
 			h.Debugf("send result (local): %v (fp:%s)error:%v", response, f, err)
+		} else if h.dht != nil && h.dht.transport != nil {
+			h.Debugf("Sending message to %v (transport):%v (fingerprint:%s)", to, message, f)
+			response, err = h.dht.transport.Send(ctx, h, proto, to, message)
+			h.Debugf("send result to %v (transport): %v (fp:%s) error:%v", to, response, f, err)
 		} else {
 			h.Debugf("Sending message to %v (net):%v (fingerprint:%s)", to, message, f)
 			var r Message
@@ -999,7 +1248,8 @@ func (h *Holochain) Query(options *QueryOptions) (results []QueryResult, err err
 	var equalsMap, containsMap map[string]interface{}
 	var reMap map[string]*regexp.Regexp
 	defs := make(map[string]*EntryDef)
-	for i, header := range chain.Headers {
+	_, headers, entries := chain.snapshot()
+	for i, header := range headers {
 
 		var def *EntryDef
 		var ok bool
@@ -1027,12 +1277,12 @@ func (h *Holochain) Query(options *QueryOptions) (results []QueryResult, err err
 			var contentMap map[string]interface{}
 			if def.DataFormat == DataFormatJSON {
 				contentMap = make(map[string]interface{})
-				err = json.Unmarshal([]byte(chain.Entries[i].Content().(string)), &contentMap)
+				err = json.Unmarshal([]byte(entries[i].Content().(string)), &contentMap)
 				if err != nil {
 					return
 				}
 			} else {
-				content = chain.Entries[i].Content().(string)
+				content = entries[i].Content().(string)
 			}
 
 			if !skip && options.Constrain.Equals != "" {
@@ -1124,7 +1374,7 @@ func (h *Holochain) Query(options *QueryOptions) (results []QueryResult, err err
 			// Return values gets limited down to the actual info in the Ribosomes
 			qr := QueryResult{Header: header}
 			if options.Return.Entries {
-				qr.Entry = chain.Entries[i]
+				qr.Entry = entries[i]
 			}
 			if options.Order.Ascending {
 				results = append([]QueryResult{qr}, results...)
@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	. "github.com/holochain/holochain-proto/hash"
 	peer "github.com/libp2p/go-libp2p-peer"
+	mh "github.com/multiformats/go-multihash"
 	. "github.com/smartystreets/goconvey/convey"
 	"os"
 	"path/filepath"
@@ -78,6 +79,7 @@ func TestSetupConfig(t *testing.T) {
 		So(config.bootstrapRefreshInterval, ShouldEqual, BootstrapTTL)
 		So(config.routingRefreshInterval, ShouldEqual, DefaultRoutingRefreshInterval)
 		So(config.retryInterval, ShouldEqual, DefaultRetryInterval)
+		So(config.expiryInterval, ShouldEqual, DefaultExpiryInterval)
 
 		config.EnableWorldModel = true
 		config.Setup()
@@ -242,6 +244,95 @@ func TestPrepareHashType(t *testing.T) {
 		So(err, ShouldBeNil)
 		So(hash.String(), ShouldEqual, "2DrjgbL49zKmX4P7UgdopSCC7MhfVUySNbRHBQzdDuXgaJSNEg")
 	})
+	Convey("A hash type multihash can't compute should return an error", t, func() {
+		for name, code := range mh.Names {
+			dna := DNA{DHTConfig: DHTConfig{HashType: HashType(name)}}
+			h := Holochain{}
+			h.nucleus = NewNucleus(&h, &dna)
+			err := h.PrepareHashType()
+			if _, e := mh.Sum([]byte("test data"), code, -1); e != nil {
+				So(err.Error(), ShouldEqual, "Unsupported hash type: "+name)
+			} else {
+				So(err, ShouldBeNil)
+			}
+		}
+	})
+	Convey("It should expose the supported hash codecs", t, func() {
+		dna := DNA{DHTConfig: DHTConfig{HashType: "sha2-256"}}
+		h := Holochain{}
+		h.nucleus = NewNucleus(&h, &dna)
+		err := h.PrepareHashType()
+		So(err, ShouldBeNil)
+		So(h.SupportedHashCodecs(), ShouldResemble, []uint64{mh.SHA2_256})
+
+		h.nucleus.dna.DHTConfig.HashType = "sha1"
+		err = h.PrepareHashType()
+		So(err, ShouldBeNil)
+		So(h.SupportedHashCodecs(), ShouldResemble, []uint64{mh.SHA1, mh.SHA2_256})
+	})
+}
+
+func TestCommitHashTypes(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	// a second DNA that hashes its entries with blake2b
+	d2, _, h2 := SetupTestChain("test2")
+	defer CleanupTestChain(h2, d2)
+	h2.nucleus.dna.DHTConfig.HashType = "blake2b-256"
+	if err := h2.PrepareHashType(); err != nil {
+		panic(err)
+	}
+	h2.chain.hashSpec = h2.hashSpec
+	prepareTestChain(h2)
+
+	Convey("entries committed under each DNA's hash type should validate", t, func() {
+		for _, x := range []*Holochain{h, h2} {
+			hash := commit(x, "evenNumbers", "2")
+			spec, err := hash.Codec()
+			So(err, ShouldBeNil)
+			So(spec.Code, ShouldEqual, x.hashSpec.Code)
+
+			header, err := x.chain.GetEntryHeader(hash)
+			So(err, ShouldBeNil)
+			spec, err = header.HeaderLink.Codec()
+			So(err, ShouldBeNil)
+			So(spec.Code, ShouldEqual, x.hashSpec.Code)
+
+			entry, _, err := x.chain.GetEntry(hash)
+			So(err, ShouldBeNil)
+			So(verifyEntryLink(header, entry), ShouldBeNil)
+			So(x.chain.Validate(false), ShouldBeNil)
+			So(x.chain.VerifyIntegrity(true), ShouldBeNil)
+
+			_, _, _, status, err := x.dht.Get(hash, StatusLive, GetMaskEntry)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, StatusLive)
+		}
+		So(h.chain.Top().EntryLink.String(), ShouldNotEqual, h2.chain.Top().EntryLink.String())
+	})
+
+	Convey("a migrate's EntryLink should be computed with the DNA's hash type", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+		response, err := fn.Call(h2)
+		So(err, ShouldBeNil)
+		spec, err := response.(Hash).Codec()
+		So(err, ShouldBeNil)
+		So(spec.Code, ShouldEqual, mh.Names["blake2b-256"])
+		So(fn.action.VerifyEntryLink(), ShouldBeNil)
+	})
+	Convey("a migrate to a DNA with another hash type should validate", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		entry.Type = MigrateEntryTypeClose
+		entry.DNAHash = h2.dnaHash
+		So(entry.DNAHash.Compatible(entry.Key), ShouldBeFalse)
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+		_, err = fn.Call(h)
+		So(err, ShouldBeNil)
+	})
 }
 
 func TestNewEntry(t *testing.T) {
@@ -434,6 +525,36 @@ func TestGenChain(t *testing.T) {
 	})
 }
 
+func TestChainLengthAndTopHeader(t *testing.T) {
+	Convey("TopHeader should return ErrChainEmpty before genesis", t, func() {
+		h := Holochain{chain: NewChain(HashSpec{})}
+		So(h.ChainLength(), ShouldEqual, 0)
+		_, _, err := h.TopHeader()
+		So(err, ShouldEqual, ErrChainEmpty)
+	})
+
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should return the length and top of the chain", t, func() {
+		So(h.ChainLength(), ShouldEqual, 2)
+		header, hash, err := h.TopHeader()
+		So(err, ShouldBeNil)
+		So(header.Type, ShouldEqual, AgentEntryType)
+		top, _ := h.Top()
+		So(hash.String(), ShouldEqual, top.String())
+	})
+
+	Convey("it should reflect the chain growing after a commit", t, func() {
+		entryHash := commit(h, "evenNumbers", "2")
+		So(h.ChainLength(), ShouldEqual, 3)
+		header, hash, err := h.TopHeader()
+		So(err, ShouldBeNil)
+		So(header.EntryLink.String(), ShouldEqual, entryHash.String())
+		So(hash.String(), ShouldEqual, h.chain.Hashes[2].String())
+	})
+}
+
 func TestWalk(t *testing.T) {
 	d, _, h := PrepareTestChain("test")
 	defer CleanupTestChain(h, d)
@@ -783,6 +904,43 @@ func TestGetEntryDef(t *testing.T) {
 	})
 }
 
+func TestEntryDefs(t *testing.T) {
+	d, _, h := SetupTestChain("test")
+	defer CleanupTestDir(d)
+
+	Convey("it should include the zome and sys entry definitions", t, func() {
+		defs := h.EntryDefs()
+		for _, z := range h.nucleus.dna.Zomes {
+			for _, e := range z.Entries {
+				So(defs[e.Name], ShouldNotBeNil)
+			}
+		}
+		_, def, err := h.GetEntryDef("evenNumbers")
+		So(err, ShouldBeNil)
+		So(*defs["evenNumbers"], ShouldResemble, *def)
+		for _, def := range builtInSysEntryDefs() {
+			So(*defs[def.Name], ShouldResemble, *def)
+		}
+	})
+
+	Convey("it should include registered sys entry types", t, func() {
+		entryType := SysEntryTypePrefix + "handoff"
+		So(RegisterSysEntryType(entryType, &EntryDef{DataFormat: DataFormatString, Sharing: Public}, nil), ShouldBeNil)
+		defer UnregisterSysEntryType(entryType)
+		So(h.EntryDefs()[entryType].DataFormat, ShouldEqual, DataFormatString)
+	})
+
+	Convey("changing the returned definitions shouldn't change the DNA", t, func() {
+		defs := h.EntryDefs()
+		defs["evenNumbers"].Sharing = Private
+		defs[MigrateEntryType].Sharing = Private
+		_, def, err := h.GetEntryDef("evenNumbers")
+		So(err, ShouldBeNil)
+		So(def.Sharing, ShouldEqual, Public)
+		So(MigrateEntryDef.Sharing, ShouldEqual, Public)
+	})
+}
+
 func TestGetPrivateEntryDefs(t *testing.T) {
 	d, _, h := SetupTestChain("test")
 	defer CleanupTestDir(d)
@@ -847,3 +1005,17 @@ func commit(h *Holochain, entryType, entryStr string) (entryHash Hash) {
 	}
 	return
 }
+
+func TestCountHolding(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should count the entries put to the DHT at genesis by type", t, func() {
+		counts, err := h.CountHolding()
+		So(err, ShouldBeNil)
+		So(counts[DNAEntryType], ShouldEqual, 1)
+		So(counts[AgentEntryType], ShouldEqual, 1)
+		So(counts[KeyEntryType], ShouldEqual, 1)
+		So(counts[MigrateEntryType], ShouldEqual, 0)
+	})
+}
@@ -19,14 +19,15 @@ const (
 	AddLinkAction = ""
 	DelLinkAction = "d"
 
-	// constants for the state of the data, they are bit flags
+	// constants for the state of the data, they are bit flags so they can be
+	// combined in the StatusMask of a get.  A held entry has exactly one.
 
-	StatusDefault  = 0x00
-	StatusLive     = 0x01
-	StatusRejected = 0x02
-	StatusDeleted  = 0x04
-	StatusModified = 0x08
-	StatusAny      = 0xFF
+	StatusDefault  = 0x00 // no status, as a StatusMask it means StatusLive
+	StatusLive     = 0x01 // the entry is valid and current
+	StatusRejected = 0x02 // the entry failed validation
+	StatusDeleted  = 0x04 // the entry was deleted, GetResp.DelReason says why
+	StatusModified = 0x08 // the entry was replaced, GetResp.FollowHash is the replacement
+	StatusAny      = 0xFF // as a StatusMask, matches entries of any status
 
 	// constants for the stored string status values in buntdb and for building code
 
@@ -46,6 +47,9 @@ const (
 	GetMaskEntry     = 0x01
 	GetMaskEntryType = 0x02
 	GetMaskSources   = 0x04
+	GetMaskHeader    = 0x08
+	GetMaskHolders   = 0x10
+	GetMaskStatus    = 0x20
 	GetMaskAll       = 0xFF
 
 	// constants for building code for GetMask
@@ -54,6 +58,9 @@ const (
 	GetMaskEntryStr     = "1"
 	GetMaskEntryTypeStr = "2"
 	GetMaskSourcesStr   = "4"
+	GetMaskHeaderStr    = "8"
+	GetMaskHoldersStr   = "16"
+	GetMaskStatusStr    = "32"
 	GetMaskAllStr       = "255"
 )
 
@@ -107,6 +114,18 @@ type HashTable interface {
 	// Get retrieves a value from the DHT store
 	Get(key Hash, statusMask int, getMask int) (data []byte, entryType string, sources []string, status int, err error)
 
+	// PutHeader stores the marshaled header of a held entry
+	PutHeader(key Hash, header []byte) (err error)
+
+	// GetHeader retrieves the marshaled header of a held entry
+	GetHeader(key Hash) (header []byte, err error)
+
+	// PutDelReason stores the reason a held entry was deleted
+	PutDelReason(key Hash, reason string) (err error)
+
+	// GetDelReason retrieves the reason a held entry was deleted
+	GetDelReason(key Hash) (reason string, err error)
+
 	// PutLink associates a link with a stored hash
 	PutLink(m *Message, base string, link string, tag string) (err error)
 
@@ -131,6 +150,10 @@ type HashTable interface {
 	// Iterate call fn on all the hashes in the table
 	Iterate(fn HashTableIterateFn)
 
+	// CountByType returns the number of entries matching the status mask
+	// in the table grouped by entry type
+	CountByType(statusMask int) (counts map[string]int, err error)
+
 	// GetReceipts returns a list of receipts that were generated regarding a hash
 	//GetReceipts()
 }
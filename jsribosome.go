@@ -25,15 +25,24 @@ const (
 
 // JSRibosome holds data needed for the Javascript VM
 type JSRibosome struct {
-	h          *Holochain
-	zome       *Zome
-	vm         *otto.Otto
-	lastResult *otto.Value
+	h             *Holochain
+	zome          *Zome
+	vm            *otto.Otto
+	lastResult    *otto.Value
+	validationCtx *ValidationContext
 }
 
 // Type returns the string value under which this ribosome is registered
 func (jsr *JSRibosome) Type() string { return JSRibosomeType }
 
+// interrupt stops the code the vm is running by unwinding it with a panic
+func (jsr *JSRibosome) interrupt() {
+	select {
+	case jsr.vm.Interrupt <- func() { panic(errValidationInterrupted) }:
+	default:
+	}
+}
+
 // ChainGenesis runs the application genesis function
 // this function gets called after the genesis entries are added to the chain
 func (jsr *JSRibosome) ChainGenesis() (err error) {
@@ -111,6 +120,22 @@ func (jsr *JSRibosome) BundleCanceled(reason string) (response string, err error
 	return
 }
 
+// ReceiveSignal calls the app receiveSignal function, if it has one, with the
+// signal's name and body
+func (jsr *JSRibosome) ReceiveSignal(signal Signal) (err error) {
+	fnName := "receiveSignal"
+	var body []byte
+	if body, err = json.Marshal(signal.Body); err != nil {
+		return
+	}
+	code := fmt.Sprintf(`if (typeof %s === "function") {%s("%s",JSON.parse("%s"))}`, fnName, fnName, jsSanitizeString(signal.Name), jsSanitizeString(string(body)))
+	jsr.h.Debug(code)
+	if _, err = jsr.vm.Run(code); err != nil {
+		err = fmt.Errorf("Error executing %s: %v", fnName, err)
+	}
+	return
+}
+
 // ValidatePackagingRequest calls the app for a validation packaging request for an action
 func (jsr *JSRibosome) ValidatePackagingRequest(action ValidatingAction, def *EntryDef) (req PackagingReq, err error) {
 	var code string
@@ -195,6 +220,13 @@ func prepareJSValidateArgs(action Action, def *EntryDef) (args string, err error
 }
 
 func buildJSValidateAction(action Action, def *EntryDef, pkg *ValidationPackage, sources []string) (code string, err error) {
+	code, err = buildJSValidateActionCtx(action, def, pkg, sources, "")
+	return
+}
+
+// buildJSValidateActionCtx builds the call of the validation function passing
+// the named variable as the validation context, if there is one
+func buildJSValidateActionCtx(action Action, def *EntryDef, pkg *ValidationPackage, sources []string, ctxVar string) (code string, err error) {
 	fnName := "validate" + strings.Title(action.Name())
 	var args string
 	args, err = prepareJSValidateArgs(action, def)
@@ -214,15 +246,31 @@ func buildJSValidateAction(action Action, def *EntryDef, pkg *ValidationPackage,
 		}
 		pkgObj = fmt.Sprintf(`{"Chain":%s}`, j)
 	}
+	if ctxVar != "" {
+		srcs += "," + ctxVar
+	}
 	code = fmt.Sprintf(`%s("%s",%s,%s,%s)`, fnName, def.Name, args, pkgObj, srcs)
 
 	return
 }
 
 // ValidateAction builds the correct validation function based on the action an calls it
+// If a validation context has been set it's passed as a final ctx argument.
 func (jsr *JSRibosome) ValidateAction(action Action, def *EntryDef, pkg *ValidationPackage, sources []string) (err error) {
+	var ctxVar string
+	if jsr.validationCtx != nil {
+		var ctx *otto.Object
+		ctx, err = jsr.makeJSValidationContext(jsr.validationCtx)
+		if err != nil {
+			return
+		}
+		if err = jsr.vm.Set(jsValidationContextVar, ctx); err != nil {
+			return
+		}
+		ctxVar = jsValidationContextVar
+	}
 	var code string
-	code, err = buildJSValidateAction(action, def, pkg, sources)
+	code, err = buildJSValidateActionCtx(action, def, pkg, sources, ctxVar)
 	if err != nil {
 		return
 	}
@@ -231,6 +279,89 @@ func (jsr *JSRibosome) ValidateAction(action Action, def *EntryDef, pkg *Validat
 	return
 }
 
+// jsValidationContextVar is the global the validation context is passed in
+const jsValidationContextVar = "__validationContext"
+
+// setValidationContext implements contextualRibosome
+func (jsr *JSRibosome) setValidationContext(ctx *ValidationContext) {
+	jsr.validationCtx = ctx
+}
+
+// makeJSValidationContext builds the ctx object passed to the app's validators,
+// whose Chain() has Length() and IterEntriesByType(entryType, fn).  fn is called
+// with the entry and header of each entry of the type in chain order, and the
+// iteration stops if it returns false.  Emit(name, payload) queues a signal
+// to be delivered if the validation passes.
+func (jsr *JSRibosome) makeJSValidationContext(ctx *ValidationContext) (obj *otto.Object, err error) {
+	var chain *otto.Object
+	if chain, err = jsr.vm.Object(`({})`); err != nil {
+		return
+	}
+	err = chain.Set("Length", func(call otto.FunctionCall) otto.Value {
+		v, _ := jsr.vm.ToValue(ctx.Chain().Length())
+		return v
+	})
+	if err != nil {
+		return
+	}
+	err = chain.Set("IterEntriesByType", func(call otto.FunctionCall) otto.Value {
+		entryType := call.Argument(0).String()
+		fn := call.Argument(1)
+		if !fn.IsFunction() {
+			panic(mkOttoErr(jsr, "IterEntriesByType expects a function"))
+		}
+		e := ctx.Chain().IterEntriesByType(entryType, func(header *Header, entry Entry) (err error) {
+			_, def, err := jsr.h.GetEntryDef(entryType)
+			if err != nil {
+				return
+			}
+			var args string
+			if args, err = prepareJSEntryArgs(def, entry, header); err != nil {
+				return
+			}
+			var v otto.Value
+			if v, err = jsr.vm.Run("[" + args + "]"); err != nil {
+				return
+			}
+			a := v.Object()
+			entryVal, _ := a.Get("0")
+			headerVal, _ := a.Get("1")
+			var r otto.Value
+			if r, err = fn.Call(otto.UndefinedValue(), entryVal, headerVal); err != nil {
+				return
+			}
+			if r.IsBoolean() {
+				if b, _ := r.ToBoolean(); !b {
+					err = ErrStopIteration
+				}
+			}
+			return
+		})
+		if e != nil {
+			panic(mkOttoErr(jsr, e.Error()))
+		}
+		return otto.UndefinedValue()
+	})
+	if err != nil {
+		return
+	}
+	if obj, err = jsr.vm.Object(`({})`); err != nil {
+		return
+	}
+	err = obj.Set("Chain", func(call otto.FunctionCall) otto.Value {
+		return chain.Value()
+	})
+	if err != nil {
+		return
+	}
+	err = obj.Set("Emit", func(call otto.FunctionCall) otto.Value {
+		payload, _ := call.Argument(1).Export()
+		ctx.Emit(call.Argument(0).String(), payload)
+		return otto.UndefinedValue()
+	})
+	return
+}
+
 func mkJSSources(sources []string) (srcs string) {
 	srcs = `["` + strings.Join(sources, `","`) + `"]`
 	return
@@ -328,6 +459,8 @@ const (
 		`,Entry:` + GetMaskEntryStr +
 		`,EntryType:` + GetMaskEntryTypeStr +
 		`,Sources:` + GetMaskSourcesStr +
+		`,Holders:` + GetMaskHoldersStr +
+		`,Status:` + GetMaskStatusStr +
 		`,All:` + GetMaskAllStr +
 		"}" +
 		`,LinkAction:{Add:"` + AddLinkAction + `",Del:"` + DelLinkAction + `"}` +
@@ -594,6 +727,8 @@ func NewJSRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 		zome: zome,
 		vm:   otto.New(),
 	}
+	// so that runaway code, i.e. a validation that timed out, can be stopped
+	jsr.vm.Interrupt = make(chan func(), 1)
 
 	funcs := map[string]fnData{
 		"property": fnData{
@@ -813,6 +948,22 @@ func NewJSRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 				return
 			},
 		},
+		"crossDNAGet": fnData{
+			apiFn: &APIFnCrossDNAGet{},
+			f: func(args []Arg, _f APIFunction, call otto.FunctionCall) (result otto.Value, err error) {
+				f := _f.(*APIFnCrossDNAGet)
+				f.dna = args[0].value.(Hash)
+				f.hash = args[1].value.(Hash)
+
+				var r interface{}
+				r, err = f.Call(h)
+				if err != nil {
+					return
+				}
+				result, err = jsr.vm.ToValue(r)
+				return
+			},
+		},
 		"commit": fnData{
 			apiFn: &APIFnCommit{},
 			f: func(args []Arg, _f APIFunction, call otto.FunctionCall) (result otto.Value, err error) {
@@ -840,6 +991,9 @@ func NewJSRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 			apiFn: &APIFnMigrate{},
 			f: func(args []Arg, _f APIFunction, call otto.FunctionCall) (result otto.Value, err error) {
 				f := _f.(*APIFnMigrate)
+				// the fn is shared by every call so nothing must carry over
+				// from the last one
+				*f = APIFnMigrate{}
 				migrationType := args[0].value.(string)
 				DNAHash := args[1].value.(Hash)
 				Key := args[2].value.(Hash)
@@ -849,6 +1003,77 @@ func NewJSRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 				f.action.entry.DNAHash = DNAHash
 				f.action.entry.Key = Key
 				f.action.entry.Data = Data
+				if args[4].value != nil {
+					f.quorum = int(args[4].value.(int64))
+				}
+				if args[5].value != nil {
+					f.createLink = args[5].value.(bool)
+				}
+				if args[6].value != nil {
+					f.idempotencyKey = args[6].value.(string)
+				}
+				r, err = f.Call(h)
+				if err != nil {
+					return
+				}
+				var entryHash Hash
+				if r != nil {
+					entryHash = r.(Hash)
+				}
+
+				result, err = jsr.vm.ToValue(entryHash.String())
+				return
+			},
+		},
+		"migrateCheck": fnData{
+			apiFn: &APIFnMigrateCheck{},
+			f: func(args []Arg, _f APIFunction, call otto.FunctionCall) (result otto.Value, err error) {
+				f := _f.(*APIFnMigrateCheck)
+				f.action.entry.Type = args[0].value.(string)
+				f.action.entry.DNAHash = args[1].value.(Hash)
+				f.action.entry.Key = args[2].value.(Hash)
+				f.action.entry.Data = args[3].value.(string)
+				var r interface{}
+				r, err = f.Call(h)
+				if err != nil {
+					return
+				}
+				var j []byte
+				j, err = json.Marshal(r.(MigrateCheckResult))
+				if err != nil {
+					return
+				}
+				object, _ := jsr.vm.Object(`(` + string(j) + `)`)
+				result, _ = jsr.vm.ToValue(object)
+				return
+			},
+		},
+		"migrateRollback": fnData{
+			apiFn: &APIFnMigrateRollback{},
+			f: func(args []Arg, _f APIFunction, call otto.FunctionCall) (result otto.Value, err error) {
+				f := _f.(*APIFnMigrateRollback)
+				var r interface{}
+				f.action.entry.MigrateHeaderHash = args[0].value.(Hash)
+				r, err = f.Call(h)
+				if err != nil {
+					return
+				}
+				var entryHash Hash
+				if r != nil {
+					entryHash = r.(Hash)
+				}
+
+				result, err = jsr.vm.ToValue(entryHash.String())
+				return
+			},
+		},
+		"revokeKey": fnData{
+			apiFn: &APIFnRevokeKey{},
+			f: func(args []Arg, _f APIFunction, call otto.FunctionCall) (result otto.Value, err error) {
+				f := _f.(*APIFnRevokeKey)
+				var r interface{}
+				f.newKey = args[0].value.(Hash)
+				f.proof = args[1].value.(string)
 				r, err = f.Call(h)
 				if err != nil {
 					return
@@ -863,6 +1088,71 @@ func NewJSRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 			},
 		},
 
+		"getMigrationHistory": fnData{
+			apiFn: &APIFnGetMigrationHistory{},
+			f: func(args []Arg, _f APIFunction, call otto.FunctionCall) (result otto.Value, err error) {
+				f := _f.(*APIFnGetMigrationHistory)
+				f.DNAHash = args[0].value.(Hash)
+				f.Key = args[1].value.(Hash)
+				f.Pagination = Pagination{}
+				if args[2].value != nil {
+					opts, ok := args[2].value.(map[string]interface{})
+					if ok {
+						for attr, val := range map[string]*int{"Offset": &f.Pagination.Offset, "Limit": &f.Pagination.Limit} {
+							v, ok := opts[attr]
+							if ok {
+								i, ok := numInterfaceToInt(v)
+								if !ok {
+									err = errors.New(fmt.Sprintf("expecting int %s attribute in object, got %T", attr, v))
+									return
+								}
+								*val = i
+							}
+						}
+					}
+				}
+				var r interface{}
+				r, err = f.Call(h)
+				if err != nil {
+					return
+				}
+				object, _ := jsr.vm.Object("(" + r.(string) + ")")
+				result, _ = jsr.vm.ToValue(object)
+				return
+			},
+		},
+
+		"getMigrationTargets": fnData{
+			apiFn: &APIFnGetMigrationTargets{},
+			f: func(args []Arg, _f APIFunction, call otto.FunctionCall) (result otto.Value, err error) {
+				f := _f.(*APIFnGetMigrationTargets)
+				f.SourceDNAHash = args[0].value.(Hash)
+				var r interface{}
+				r, err = f.Call(h)
+				if err != nil {
+					return
+				}
+				object, _ := jsr.vm.Object("(" + r.(string) + ")")
+				result, _ = jsr.vm.ToValue(object)
+				return
+			},
+		},
+
+		"selfTest": fnData{
+			apiFn: &APIFnSelfTest{},
+			f: func(args []Arg, _f APIFunction, call otto.FunctionCall) (result otto.Value, err error) {
+				f := _f.(*APIFnSelfTest)
+				var r interface{}
+				r, err = f.Call(h)
+				if err != nil {
+					return
+				}
+				object, _ := jsr.vm.Object("(" + r.(string) + ")")
+				result, _ = jsr.vm.ToValue(object)
+				return
+			},
+		},
+
 		"query": fnData{
 			apiFn: &APIFnQuery{},
 			f: func(args []Arg, _f APIFunction, call otto.FunctionCall) (result otto.Value, err error) {
@@ -1038,6 +1328,23 @@ func NewJSRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 							result, err = jsr.vm.ToValue(getResp.Sources)
 						}
 					}
+					var holders []string
+					if mask&GetMaskHolders != 0 {
+						holders = make([]string, len(getResp.Holders))
+						for i, id := range getResp.Holders {
+							holders[i] = peer.IDB58Encode(id)
+						}
+						if GetMaskHolders == mask {
+							singleValueReturn = true
+							result, err = jsr.vm.ToValue(holders)
+						}
+					}
+					if mask&GetMaskStatus != 0 {
+						if GetMaskStatus == mask {
+							singleValueReturn = true
+							result, err = jsr.vm.ToValue(getResp.Status)
+						}
+					}
 					if err == nil && !singleValueReturn {
 						respObj := make(map[string]interface{})
 						if mask&GetMaskEntry != 0 {
@@ -1054,6 +1361,12 @@ func NewJSRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 						if mask&GetMaskSources != 0 {
 							respObj["Sources"] = getResp.Sources
 						}
+						if mask&GetMaskHolders != 0 {
+							respObj["Holders"] = holders
+						}
+						if mask&GetMaskStatus != 0 {
+							respObj["Status"] = getResp.Status
+						}
 						result, err = jsr.vm.ToValue(respObj)
 					}
 
@@ -1187,11 +1500,22 @@ func NewJSRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 							}
 							options.StatusMask = int(maskval)
 						}
+						for attr, val := range map[string]*int{"Offset": &options.Offset, "Limit": &options.Limit} {
+							v, ok := opts[attr]
+							if ok {
+								i, ok := numInterfaceToInt(v)
+								if !ok {
+									err = errors.New(fmt.Sprintf("expecting int %s attribute in object, got %T", attr, v))
+									return
+								}
+								*val = int(i)
+							}
+						}
 					}
 				}
 				var response interface{}
 				f := _f.(*APIFnGetLinks)
-				f.action = *NewGetLinksAction(&LinkQuery{Base: base, T: tag, StatusMask: options.StatusMask}, &options)
+				f.action = *NewGetLinksAction(&LinkQuery{Base: base, T: tag, StatusMask: options.StatusMask, Pagination: Pagination{Offset: options.Offset, Limit: options.Limit}}, &options)
 				response, err = f.Call(h)
 
 				if err == nil {
@@ -1243,6 +1567,9 @@ func NewJSRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 					}
 					if err == nil {
 						js = `[` + js + `]`
+						if options.Offset != 0 || options.Limit != 0 {
+							js = fmt.Sprintf(`({Links:%s,NextOffset:%d})`, js, lqr.NextOffset)
+						}
 						var obj *otto.Object
 						jsr.h.Debugf("getLinks code:\n%s", js)
 						obj, err = jsr.vm.Object(js)
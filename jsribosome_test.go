@@ -421,11 +421,11 @@ func TestNewJSRibosome(t *testing.T) {
 			So(entry.Content(), ShouldEqual, "7")
 		})
 		Convey("migrate", func() {
-			dnaHash, err := genTestStringHash()
+			dnaHash, err := GenTestStringHash()
 			So(err, ShouldBeNil)
-			key, err := genTestStringHash()
+			key, err := GenTestStringHash()
 			So(err, ShouldBeNil)
-			data, err := genTestString()
+			data, err := GenTestString()
 
 			_, err = z.Run(`migrate(HC.Migrate.Close,"` + dnaHash.String() + `","` + key.String() + `","` + data + `")`)
 			So(err, ShouldBeNil)
@@ -436,6 +436,58 @@ func TestNewJSRibosome(t *testing.T) {
 	})
 }
 
+func TestJSMigrateOptionsDontCarryOver(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+	zome, _ := h.GetZome("jsSampleZome")
+	v, err := NewJSRibosome(h, zome)
+	if err != nil {
+		panic(err)
+	}
+	z := v.(*JSRibosome)
+
+	Convey("a migrate without an idempotency key should not reuse the last call's", t, func() {
+		dnaHash, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		key, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		args := `"split","` + dnaHash.String() + `","` + key.String() + `"`
+
+		_, err = z.Run(`migrate(` + args + `,"first",0,false,"once")`)
+		So(err, ShouldBeNil)
+		first := z.lastResult.String()
+		_, err = z.Run(`migrate(` + args + `,"second")`)
+		So(err, ShouldBeNil)
+		second := z.lastResult.String()
+		So(second, ShouldNotEqual, first)
+
+		hash, err := NewHash(second)
+		So(err, ShouldBeNil)
+		entry, _, err := h.chain.GetEntry(hash)
+		So(err, ShouldBeNil)
+		So(entry.Content(), ShouldContainSubstring, `"Data":"second"`)
+	})
+
+	Convey("a migration history without options should not reuse the last call's page", t, func() {
+		dnaHash, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		key, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		args := `"` + dnaHash.String() + `","` + key.String() + `"`
+		for _, data := range []string{"first", "second"} {
+			_, err = z.Run(`migrate("split",` + args + `,"` + data + `",0,true)`)
+			So(err, ShouldBeNil)
+		}
+
+		_, err = z.Run(`getMigrationHistory(` + args + `,{Offset:1,Limit:1}).Entries.length`)
+		So(err, ShouldBeNil)
+		So(z.lastResult.String(), ShouldEqual, "1")
+		_, err = z.Run(`getMigrationHistory(` + args + `).length`)
+		So(err, ShouldBeNil)
+		So(z.lastResult.String(), ShouldEqual, "2")
+	})
+}
+
 func TestJSQuery(t *testing.T) {
 	d, _, h := PrepareTestChain("test")
 	defer CleanupTestChain(h, d)
@@ -558,6 +610,21 @@ func TestJSBundleCanceled(t *testing.T) {
 	})
 }
 
+func TestJSReceiveSignal(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+	Convey("it should call a receiveSignal function with the signal", t, func() {
+		z, _ := NewJSRibosome(h, &Zome{RibosomeType: JSRibosomeType, Code: `function receiveSignal(name,body) {debug("signal:"+name+":"+body.hash)}`})
+		ShouldLog(h.nucleus.alog, func() {
+			So(z.ReceiveSignal(Signal{Name: SignalMigrateConfirmed, Body: map[string]string{"hash": "QmFoo"}}), ShouldBeNil)
+		}, `signal:`+SignalMigrateConfirmed+`:QmFoo`)
+	})
+	Convey("it should do nothing if the zome has no receiveSignal function", t, func() {
+		z, _ := NewJSRibosome(h, &Zome{RibosomeType: JSRibosomeType, Code: ``})
+		So(z.ReceiveSignal(Signal{Name: SignalMigrateConfirmed}), ShouldBeNil)
+	})
+}
+
 func TestJSbuildValidate(t *testing.T) {
 	d, _, h := PrepareTestChain("test")
 	defer CleanupTestChain(h, d)
@@ -28,9 +28,11 @@ var maxQueryConcurrency = AlphaValue
 
 type dhtQuery struct {
 	node        *Node
-	key         Hash      // the key we're querying for
-	qfunc       queryFunc // the function to execute per peer
-	concurrency int       // the concurrency parameter
+	key         Hash          // the key we're querying for
+	qfunc       queryFunc     // the function to execute per peer
+	concurrency int           // the concurrency parameter
+	collect     bool          // gather the results of all the peers that succeed rather than stopping at the first
+	exclude     *pset.PeerSet // peers not to query, i.e. ones an earlier query already tried
 	log         *Logger
 }
 
@@ -39,6 +41,8 @@ type dhtQueryResult struct {
 	peer        *pstore.PeerInfo   // FindPeer
 	closerPeers []*pstore.PeerInfo // *
 	success     bool
+	from        peer.ID           // the peer that succeeded
+	results     []*dhtQueryResult // the result of each peer that succeeded, when collecting
 
 	finalSet *pset.PeerSet
 }
@@ -82,8 +86,9 @@ type dhtQueryRunner struct {
 	peersToQuery   *queue.ChanQueue // peers remaining to be queried
 	peersRemaining todoctr.Counter  // peersToQuery + currently processing
 
-	result *dhtQueryResult // query result
-	errs   u.MultiErr      // result errors. maybe should be a map[peer.ID]error
+	result  *dhtQueryResult   // query result
+	results []*dhtQueryResult // collected query results
+	errs    u.MultiErr        // result errors. maybe should be a map[peer.ID]error
 
 	rateLimit chan struct{} // processing semaphore
 
@@ -162,6 +167,13 @@ func (r *dhtQueryRunner) Run(ctx context.Context, peers []peer.ID) (*dhtQueryRes
 	if r.result != nil && r.result.success {
 		return r.result, nil
 	}
+	if len(r.results) > 0 {
+		return &dhtQueryResult{
+			success:  true,
+			results:  r.results,
+			finalSet: r.peersSeen,
+		}, nil
+	}
 
 	return &dhtQueryResult{
 		finalSet: r.peersSeen,
@@ -175,6 +187,10 @@ func (r *dhtQueryRunner) addPeerToQuery(next peer.ID) {
 		return
 	}
 
+	if r.query.exclude != nil && r.query.exclude.Contains(next) {
+		return
+	}
+
 	if !r.peersSeen.TryAdd(next) {
 		return
 	}
@@ -282,8 +298,16 @@ func (r *dhtQueryRunner) queryPeer(proc process.Process, p peer.ID) {
 		r.errs = append(r.errs, err)
 		r.Unlock()
 
+	} else if res.success && r.query.collect {
+		r.query.log.Logf("SUCCESS worker for: %v %v (collecting)", p, res)
+		res.from = p
+		r.Lock()
+		r.results = append(r.results, res)
+		r.Unlock()
+
 	} else if res.success {
 		r.query.log.Logf("SUCCESS worker for: %v %v", p, res)
+		res.from = p
 		r.Lock()
 		r.result = res
 		r.Unlock()
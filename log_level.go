@@ -0,0 +1,121 @@
+package holochain
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogLevel is the severity of a structured log entry
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+// DefaultLogLevel is the level of the logger a holochain starts with
+const DefaultLogLevel = LogError
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "DEBUG"
+	case LogInfo:
+		return "INFO"
+	case LogWarn:
+		return "WARN"
+	case LogError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// LogField is a key and value attached to a structured log entry
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// LevelLogger is the interface of the structured, leveled logger used on the
+// action and DHT paths.  Callers check Enabled before building an entry, so
+// nothing is allocated for a disabled level.
+type LevelLogger interface {
+	Enabled(level LogLevel) bool
+	Log(level LogLevel, msg string, fields ...LogField)
+}
+
+// WriterLogger is a LevelLogger writing the entries at or above its level as
+// lines of text, i.e. "2006-01-02T15:04:05Z INFO dht put hash=Qm... type=%migrate"
+type WriterLogger struct {
+	level LogLevel
+	w     io.Writer
+	lk    sync.Mutex
+}
+
+// NewWriterLogger returns a logger writing the entries at or above level to w,
+// a nil w means stderr
+func NewWriterLogger(w io.Writer, level LogLevel) *WriterLogger {
+	if w == nil {
+		w = os.Stderr
+	}
+	return &WriterLogger{level: level, w: w}
+}
+
+func (l *WriterLogger) Enabled(level LogLevel) bool {
+	return level >= l.level
+}
+
+func (l *WriterLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	if !l.Enabled(level) {
+		return
+	}
+	var b bytes.Buffer
+	b.WriteString(time.Now().UTC().Format(time.RFC3339))
+	b.WriteByte(' ')
+	b.WriteString(level.String())
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %s=%v", f.Key, f.Value)
+	}
+	b.WriteByte('\n')
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	l.w.Write(b.Bytes())
+}
+
+// SetLevelLogger sets the structured logger, nil restores the default which
+// writes errors to stderr
+func (h *Holochain) SetLevelLogger(l LevelLogger) {
+	h.levelLoggerLk.Lock()
+	defer h.levelLoggerLk.Unlock()
+	h.levelLogger = l
+}
+
+// LevelLogger returns the structured logger
+func (h *Holochain) LevelLogger() LevelLogger {
+	h.levelLoggerLk.RLock()
+	defer h.levelLoggerLk.RUnlock()
+	if h.levelLogger == nil {
+		return defaultLevelLogger
+	}
+	return h.levelLogger
+}
+
+var defaultLevelLogger = NewWriterLogger(nil, DefaultLogLevel)
+
+// logEnabled returns true if entries at the level would be logged, it should
+// guard calls to log so that disabled entries don't allocate their fields
+func (h *Holochain) logEnabled(level LogLevel) bool {
+	return h.LevelLogger().Enabled(level)
+}
+
+func (h *Holochain) log(level LogLevel, msg string, fields ...LogField) {
+	h.LevelLogger().Log(level, msg, fields...)
+}
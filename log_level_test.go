@@ -0,0 +1,97 @@
+package holochain
+
+import (
+	"bytes"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync"
+	"testing"
+)
+
+type capturedLog struct {
+	level  LogLevel
+	msg    string
+	fields []LogField
+}
+
+// captureLogger is a LevelLogger recording the entries at or above its level
+type captureLogger struct {
+	level   LogLevel
+	entries []capturedLog
+	lk      sync.Mutex
+}
+
+func (l *captureLogger) Enabled(level LogLevel) bool {
+	return level >= l.level
+}
+
+func (l *captureLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	l.entries = append(l.entries, capturedLog{level: level, msg: msg, fields: fields})
+}
+
+func (l *captureLogger) find(level LogLevel, msg string, key string, value interface{}) bool {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	for _, e := range l.entries {
+		if e.level != level || e.msg != msg {
+			continue
+		}
+		for _, f := range e.fields {
+			if f.Key == key && f.Value == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestWriterLogger(t *testing.T) {
+	Convey("it should only write entries at or above its level", t, func() {
+		var buf bytes.Buffer
+		l := NewWriterLogger(&buf, LogInfo)
+		So(l.Enabled(LogDebug), ShouldBeFalse)
+		So(l.Enabled(LogWarn), ShouldBeTrue)
+		l.Log(LogDebug, "hidden")
+		So(buf.Len(), ShouldEqual, 0)
+		l.Log(LogWarn, "shown", LogField{"key", 1})
+		So(buf.String(), ShouldContainSubstring, " WARN shown key=1\n")
+	})
+}
+
+func TestLevelLogging(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should have a default logger", t, func() {
+		So(h.LevelLogger(), ShouldEqual, defaultLevelLogger)
+	})
+
+	l := &captureLogger{level: LogInfo}
+	h.SetLevelLogger(l)
+	defer h.SetLevelLogger(nil)
+
+	Convey("a migrate's PUT should be logged at Info", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		_, err = (&APIFnMigrate{action: ActionMigrate{entry: entry}}).Call(h)
+		So(err, ShouldBeNil)
+		So(l.find(LogInfo, "dht put", "type", MigrateEntryType), ShouldBeTrue)
+	})
+
+	Convey("validation failures should be logged at Warn", t, func() {
+		_, err := h.ValidateAction(&ActionMigrate{}, MigrateEntryType, nil, nil)
+		So(err, ShouldNotBeNil)
+		So(l.find(LogWarn, "validation failed", "type", MigrateEntryType), ShouldBeTrue)
+	})
+
+	Convey("disabled levels should not allocate", t, func() {
+		hash := commit(h, "evenNumbers", "2")
+		allocs := testing.AllocsPerRun(100, func() {
+			if h.logEnabled(LogDebug) {
+				h.log(LogDebug, "dht get", LogField{"hash", hash})
+			}
+		})
+		So(allocs, ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,416 @@
+package holochain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	"io"
+	"time"
+)
+
+// WireFormat identifies how messages are encoded on a stream
+type WireFormat int
+
+const (
+	// GobWireFormat is the default gob encoding every node understands
+	GobWireFormat WireFormat = iota
+
+	// CompactWireFormat is a length prefixed binary encoding that writes the
+	// bodies of the common DHT messages field by field, and entry contents as
+	// raw bytes, instead of as self describing gob
+	CompactWireFormat
+)
+
+// CompactWireSuffix is appended to the identifier of a protocol to make the
+// identifier of its compact variant.  Nodes that support the compact format
+// register both, and libp2p's protocol selection picks the compact one only
+// if both ends have it, so older nodes carry on with gob.
+const CompactWireSuffix = "/compact"
+
+const (
+	compactWireVersion = 2
+
+	// maxCompactFieldSize bounds the length prefixes we'll believe when
+	// decoding so a corrupt frame can't make us allocate arbitrarily
+	maxCompactFieldSize = 1 << 26
+)
+
+// kinds of message body in a compact frame
+const (
+	compactBodyNil = iota
+	compactBodyGob
+	compactBodyGetReq
+	compactBodyGetResp
+	compactBodyHoldReq
+	compactBodyHoldResp
+)
+
+// kinds of entry content in a compact GetResp
+const (
+	compactContentNil = iota
+	compactContentString
+	compactContentGob
+)
+
+var ErrCompactMessageMalformed = errors.New("malformed compact message")
+
+// gobBody wraps a value so that gob records its concrete type, for bodies and
+// contents that have no compact encoding of their own
+type gobBody struct {
+	V interface{}
+}
+
+// EncodeWire codes a message in the given wire format
+func (m *Message) EncodeWire(wire WireFormat) (data []byte, err error) {
+	if wire == CompactWireFormat {
+		data, err = m.EncodeCompact()
+		return
+	}
+	data, err = m.Encode()
+	return
+}
+
+// DecodeWire converts a message from the given wire format
+func (m *Message) DecodeWire(wire WireFormat, r io.Reader) (err error) {
+	if wire == CompactWireFormat {
+		err = m.DecodeCompact(r)
+		return
+	}
+	err = m.Decode(r)
+	return
+}
+
+// EncodeCompact codes a message to the compact wire format
+func (m *Message) EncodeCompact() (data []byte, err error) {
+	var w compactWriter
+	w.WriteByte(compactWireVersion)
+	w.WriteByte(byte(m.Type))
+	w.putVarint(m.Time.UnixNano())
+	w.putString(string(m.From))
+
+	switch body := m.Body.(type) {
+	case nil:
+		w.WriteByte(compactBodyNil)
+	case GetReq:
+		if len(body.Fields) > 0 {
+			// the compact format has no field selectors
+			w.WriteByte(compactBodyGob)
+			err = w.putGob(body)
+			break
+		}
+		w.WriteByte(compactBodyGetReq)
+		w.putString(string(body.H))
+		w.putVarint(int64(body.StatusMask))
+		w.putVarint(int64(body.GetMask))
+	case GetResp:
+		w.WriteByte(compactBodyGetResp)
+		err = w.putGetResp(&body)
+	case HoldReq:
+		w.WriteByte(compactBodyHoldReq)
+		w.putString(string(body.EntryHash))
+		w.putString(string(body.RelatedHash))
+	case HoldResp:
+		w.WriteByte(compactBodyHoldResp)
+		w.putVarint(int64(body.Code))
+		w.putBytes(body.Signature.S)
+	default:
+		w.WriteByte(compactBodyGob)
+		err = w.putGob(body)
+	}
+	if err != nil {
+		return
+	}
+	data = w.Bytes()
+	return
+}
+
+// DecodeCompact converts a message from the compact wire format
+func (m *Message) DecodeCompact(reader io.Reader) (err error) {
+	r := newCompactReader(reader)
+	var version, b byte
+	if version, err = r.ReadByte(); err != nil {
+		return
+	}
+	if version != compactWireVersion {
+		err = ErrCompactMessageMalformed
+		return
+	}
+	if b, err = r.ReadByte(); err != nil {
+		return
+	}
+	m.Type = MsgType(b)
+	var t int64
+	if t, err = binary.ReadVarint(r); err != nil {
+		return
+	}
+	m.Time = time.Unix(0, t)
+	var from string
+	if from, err = r.getString(); err != nil {
+		return
+	}
+	m.From = peer.ID(from)
+
+	if b, err = r.ReadByte(); err != nil {
+		return
+	}
+	switch b {
+	case compactBodyNil:
+		m.Body = nil
+	case compactBodyGetReq:
+		var body GetReq
+		var h string
+		var status, mask int64
+		if h, err = r.getString(); err != nil {
+			return
+		}
+		if status, err = binary.ReadVarint(r); err != nil {
+			return
+		}
+		if mask, err = binary.ReadVarint(r); err != nil {
+			return
+		}
+		body.H = Hash(h)
+		body.StatusMask = int(status)
+		body.GetMask = int(mask)
+		m.Body = body
+	case compactBodyGetResp:
+		var body GetResp
+		if err = r.getGetResp(&body); err != nil {
+			return
+		}
+		m.Body = body
+	case compactBodyHoldReq:
+		var body HoldReq
+		var h string
+		if h, err = r.getString(); err != nil {
+			return
+		}
+		body.EntryHash = Hash(h)
+		if h, err = r.getString(); err != nil {
+			return
+		}
+		body.RelatedHash = Hash(h)
+		m.Body = body
+	case compactBodyHoldResp:
+		var body HoldResp
+		var code int64
+		if code, err = binary.ReadVarint(r); err != nil {
+			return
+		}
+		body.Code = int(code)
+		if body.Signature.S, err = r.getBytes(); err != nil {
+			return
+		}
+		m.Body = body
+	case compactBodyGob:
+		m.Body, err = r.getGob()
+	default:
+		err = ErrCompactMessageMalformed
+	}
+	return
+}
+
+type compactWriter struct {
+	bytes.Buffer
+}
+
+func (w *compactWriter) putVarint(x int64) {
+	var b [binary.MaxVarintLen64]byte
+	w.Write(b[:binary.PutVarint(b[:], x)])
+}
+
+func (w *compactWriter) putUvarint(x uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.Write(b[:binary.PutUvarint(b[:], x)])
+}
+
+func (w *compactWriter) putBytes(b []byte) {
+	w.putUvarint(uint64(len(b)))
+	w.Write(b)
+}
+
+func (w *compactWriter) putString(s string) {
+	w.putUvarint(uint64(len(s)))
+	w.WriteString(s)
+}
+
+func (w *compactWriter) putStrings(ss []string) {
+	w.putUvarint(uint64(len(ss)))
+	for _, s := range ss {
+		w.putString(s)
+	}
+}
+
+func (w *compactWriter) putGob(v interface{}) (err error) {
+	var b []byte
+	b, err = ByteEncoder(gobBody{V: v})
+	if err != nil {
+		return
+	}
+	w.putBytes(b)
+	return
+}
+
+func (w *compactWriter) putGetResp(resp *GetResp) (err error) {
+	switch c := resp.Entry.C.(type) {
+	case nil:
+		w.WriteByte(compactContentNil)
+	case string:
+		w.WriteByte(compactContentString)
+		w.putString(c)
+	default:
+		w.WriteByte(compactContentGob)
+		if err = w.putGob(c); err != nil {
+			return
+		}
+	}
+	w.putString(resp.EntryType)
+	w.putStrings(resp.Sources)
+	w.putString(resp.FollowHash)
+	w.putString(resp.DelReason)
+	if resp.Header == nil {
+		w.putBytes(nil)
+	} else {
+		var b []byte
+		if b, err = resp.Header.Marshal(); err != nil {
+			return
+		}
+		w.putBytes(b)
+	}
+	w.putUvarint(uint64(len(resp.Holders)))
+	for _, id := range resp.Holders {
+		w.putString(string(id))
+	}
+	w.putVarint(int64(resp.Status))
+	return
+}
+
+type compactReader struct {
+	*bufio.Reader
+}
+
+func newCompactReader(r io.Reader) compactReader {
+	if br, ok := r.(*bufio.Reader); ok {
+		return compactReader{br}
+	}
+	return compactReader{bufio.NewReader(r)}
+}
+
+func (r compactReader) getLen() (l int, err error) {
+	var x uint64
+	if x, err = binary.ReadUvarint(r); err != nil {
+		return
+	}
+	if x > maxCompactFieldSize {
+		err = ErrCompactMessageMalformed
+		return
+	}
+	l = int(x)
+	return
+}
+
+func (r compactReader) getBytes() (b []byte, err error) {
+	var l int
+	if l, err = r.getLen(); err != nil || l == 0 {
+		return
+	}
+	b = make([]byte, l)
+	_, err = io.ReadFull(r, b)
+	return
+}
+
+func (r compactReader) getString() (s string, err error) {
+	var b []byte
+	b, err = r.getBytes()
+	s = string(b)
+	return
+}
+
+func (r compactReader) getStrings() (ss []string, err error) {
+	var l int
+	if l, err = r.getLen(); err != nil || l == 0 {
+		return
+	}
+	ss = make([]string, l)
+	for i := range ss {
+		if ss[i], err = r.getString(); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (r compactReader) getGob() (v interface{}, err error) {
+	var b []byte
+	if b, err = r.getBytes(); err != nil {
+		return
+	}
+	var g gobBody
+	if err = ByteDecoder(b, &g); err != nil {
+		return
+	}
+	v = g.V
+	return
+}
+
+func (r compactReader) getGetResp(resp *GetResp) (err error) {
+	var kind byte
+	if kind, err = r.ReadByte(); err != nil {
+		return
+	}
+	switch kind {
+	case compactContentNil:
+	case compactContentString:
+		var c string
+		if c, err = r.getString(); err != nil {
+			return
+		}
+		resp.Entry.C = c
+	case compactContentGob:
+		if resp.Entry.C, err = r.getGob(); err != nil {
+			return
+		}
+	default:
+		err = ErrCompactMessageMalformed
+		return
+	}
+	if resp.EntryType, err = r.getString(); err != nil {
+		return
+	}
+	if resp.Sources, err = r.getStrings(); err != nil {
+		return
+	}
+	if resp.FollowHash, err = r.getString(); err != nil {
+		return
+	}
+	if resp.DelReason, err = r.getString(); err != nil {
+		return
+	}
+	var b []byte
+	if b, err = r.getBytes(); err != nil {
+		return
+	}
+	if len(b) > 0 {
+		var hd Header
+		if err = hd.Unmarshal(b, 0); err != nil {
+			return
+		}
+		resp.Header = &hd
+	}
+	var holders []string
+	if holders, err = r.getStrings(); err != nil {
+		return
+	}
+	for _, id := range holders {
+		resp.Holders = append(resp.Holders, peer.ID(id))
+	}
+	var status int64
+	if status, err = binary.ReadVarint(r); err != nil {
+		return
+	}
+	resp.Status = int(status)
+	return
+}
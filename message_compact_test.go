@@ -0,0 +1,236 @@
+package holochain
+
+import (
+	"bytes"
+	"context"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	pstore "github.com/libp2p/go-libp2p-peerstore"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func compactRoundTrip(m *Message) (m2 Message, err error) {
+	var d []byte
+	d, err = m.EncodeCompact()
+	if err != nil {
+		return
+	}
+	err = m2.DecodeCompact(bytes.NewReader(d))
+	return
+}
+
+// genTestMigrateGetResp builds the typical response to a get of a migrate entry
+func genTestMigrateGetResp() (resp GetResp, err error) {
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		return
+	}
+	j, err := entry.ToJSON()
+	if err != nil {
+		return
+	}
+	header, err := GenTestHeader()
+	if err != nil {
+		return
+	}
+	header.Type = MigrateEntryType
+	source, err := GenTestStringHash()
+	if err != nil {
+		return
+	}
+	resp = GetResp{
+		Entry:     GobEntry{C: j},
+		EntryType: MigrateEntryType,
+		Sources:   []string{source.String()},
+		Header:    header,
+	}
+	return
+}
+
+func TestMessageCompactCoding(t *testing.T) {
+	node, err := makeNode(1234, "node1")
+	if err != nil {
+		panic(err)
+	}
+	defer node.Close()
+
+	hash, _ := NewHash("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
+
+	Convey("it should round trip the header fields of a message", t, func() {
+		m := node.NewMessage(GET_REQUEST, nil)
+		m2, err := compactRoundTrip(m)
+		So(err, ShouldBeNil)
+		So(m2.Type, ShouldEqual, m.Type)
+		So(m2.Time.Equal(m.Time), ShouldBeTrue)
+		So(m2.From, ShouldEqual, m.From)
+		So(m2.Body, ShouldBeNil)
+	})
+
+	Convey("it should round trip get and put requests", t, func() {
+		m2, err := compactRoundTrip(node.NewMessage(GET_REQUEST, GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry}))
+		So(err, ShouldBeNil)
+		So(m2.Body, ShouldResemble, GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry})
+
+		m2, err = compactRoundTrip(node.NewMessage(PUT_REQUEST, HoldReq{EntryHash: hash}))
+		So(err, ShouldBeNil)
+		So(m2.Body, ShouldResemble, HoldReq{EntryHash: hash})
+
+		m2, err = compactRoundTrip(node.NewMessage(OK_RESPONSE, HoldResp{Code: ReceiptOK, Signature: Signature{S: []byte("sig")}}))
+		So(err, ShouldBeNil)
+		So(m2.Body, ShouldResemble, HoldResp{Code: ReceiptOK, Signature: Signature{S: []byte("sig")}})
+	})
+
+	Convey("it should round trip get responses", t, func() {
+		resp, err := genTestMigrateGetResp()
+		So(err, ShouldBeNil)
+		resp.Holders = []peer.ID{node.HashAddr}
+		resp.Status = StatusModified
+		m2, err := compactRoundTrip(node.NewMessage(OK_RESPONSE, resp))
+		So(err, ShouldBeNil)
+		r := m2.Body.(GetResp)
+		So(r.Entry, ShouldResemble, resp.Entry)
+		So(r.EntryType, ShouldEqual, resp.EntryType)
+		So(r.Sources, ShouldResemble, resp.Sources)
+		So(r.Holders, ShouldResemble, resp.Holders)
+		So(r.Status, ShouldEqual, StatusModified)
+		So(r.Header.EntryLink.Equal(resp.Header.EntryLink), ShouldBeTrue)
+		So(r.Header.Sig, ShouldResemble, resp.Header.Sig)
+
+		m2, err = compactRoundTrip(node.NewMessage(OK_RESPONSE, GetResp{Entry: GobEntry{C: 3}}))
+		So(err, ShouldBeNil)
+		So(m2.Body.(GetResp).Entry.C, ShouldEqual, 3)
+		So(m2.Body.(GetResp).Header, ShouldBeNil)
+	})
+
+	Convey("other bodies should fall back to gob", t, func() {
+		m2, err := compactRoundTrip(node.NewMessage(ERROR_RESPONSE, NewErrorResponse(ErrHashNotFound)))
+		So(err, ShouldBeNil)
+		So(m2.Body.(ErrorResponse).DecodeResponseError(), ShouldEqual, ErrHashNotFound)
+	})
+
+	Convey("it should reject malformed frames", t, func() {
+		var m2 Message
+		err := m2.DecodeCompact(bytes.NewReader([]byte{compactWireVersion + 1}))
+		So(err, ShouldEqual, ErrCompactMessageMalformed)
+	})
+
+	Convey("it should be smaller than gob for a migrate entry", t, func() {
+		resp, err := genTestMigrateGetResp()
+		So(err, ShouldBeNil)
+		m := node.NewMessage(OK_RESPONSE, resp)
+		g, err := m.Encode()
+		So(err, ShouldBeNil)
+		c, err := m.EncodeCompact()
+		So(err, ShouldBeNil)
+		So(len(c), ShouldBeLessThan, len(g))
+	})
+}
+
+func TestCompactWireNegotiation(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+	hash := commit(h, "evenNumbers", "2")
+
+	compact, err := makeNode(1236, "compact")
+	if err != nil {
+		panic(err)
+	}
+	compact.EnableCompactWire()
+	h.node.Close()
+	h.node = compact
+
+	old, err := makeNode(1237, "old")
+	if err != nil {
+		panic(err)
+	}
+	defer old.Close()
+
+	sender, err := makeNode(1238, "sender")
+	if err != nil {
+		panic(err)
+	}
+	defer sender.Close()
+	sender.EnableCompactWire()
+
+	for _, n := range []*Node{compact, old, sender} {
+		for _, proto := range []int{ActionProtocol, ValidateProtocol} {
+			if err := n.StartProtocol(h, proto); err != nil {
+				panic(err)
+			}
+		}
+		for _, p := range []*Node{compact, old, sender} {
+			if p != n {
+				n.host.Peerstore().AddAddr(p.HashAddr, p.NetAddr, pstore.PermanentAddrTTL)
+			}
+		}
+	}
+
+	Convey("nodes that both support it should use the compact format", t, func() {
+		for _, proto := range []int{ActionProtocol, ValidateProtocol} {
+			s, wire, err := sender.openStream(context.Background(), proto, compact.HashAddr)
+			So(err, ShouldBeNil)
+			s.Close()
+			So(wire, ShouldEqual, CompactWireFormat)
+		}
+	})
+
+	Convey("nodes should fall back to gob when either end lacks it", t, func() {
+		s, wire, err := sender.openStream(context.Background(), ActionProtocol, old.HashAddr)
+		So(err, ShouldBeNil)
+		s.Close()
+		So(wire, ShouldEqual, GobWireFormat)
+
+		s, wire, err = old.openStream(context.Background(), ActionProtocol, compact.HashAddr)
+		So(err, ShouldBeNil)
+		s.Close()
+		So(wire, ShouldEqual, GobWireFormat)
+	})
+
+	Convey("sending should be unaffected by the format negotiated", t, func() {
+		for _, n := range []*Node{sender, old} {
+			m := n.NewMessage(GET_REQUEST, GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry})
+			r, err := n.Send(context.Background(), ActionProtocol, compact.HashAddr, m)
+			So(err, ShouldBeNil)
+			So(r.Type, ShouldEqual, OK_RESPONSE)
+			So(r.Body.(GetResp).Entry.C, ShouldEqual, "2")
+
+			m = n.NewMessage(VALIDATE_PUT_REQUEST, ValidateQuery{H: hash})
+			r, err = n.Send(context.Background(), ValidateProtocol, compact.HashAddr, m)
+			So(err, ShouldBeNil)
+			So(r.Type, ShouldEqual, OK_RESPONSE)
+			So(r.Body.(ValidateResponse).Type, ShouldEqual, "evenNumbers")
+		}
+	})
+}
+
+// BenchmarkMigrateEntryWireSize compares the size of a typical migrate entry
+// get response in each wire format, reported as bytes/msg
+func BenchmarkMigrateEntryWireSize(b *testing.B) {
+	resp, err := genTestMigrateGetResp()
+	if err != nil {
+		b.Fatal(err)
+	}
+	node, err := makeNode(1239, "bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer node.Close()
+	m := node.NewMessage(OK_RESPONSE, resp)
+
+	for _, wire := range []struct {
+		name   string
+		format WireFormat
+	}{{"gob", GobWireFormat}, {"compact", CompactWireFormat}} {
+		b.Run(wire.name, func(b *testing.B) {
+			var data []byte
+			for i := 0; i < b.N; i++ {
+				data, err = m.EncodeWire(wire.format)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(data)), "bytes/msg")
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package holochain
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Metrics receives observations of the latency of DHT operations, so that
+// they can be exported to a monitoring system
+type Metrics interface {
+	// ObservePut is called after an entry of the given type has been shared to the DHT
+	ObservePut(entryType string, d time.Duration)
+	// ObserveGet is called after a get from the DHT, hit is false if the get failed
+	ObserveGet(hit bool, d time.Duration)
+}
+
+// NoopMetrics is a Metrics that discards all observations
+type NoopMetrics struct{}
+
+func (m NoopMetrics) ObservePut(entryType string, d time.Duration) {}
+func (m NoopMetrics) ObserveGet(hit bool, d time.Duration)         {}
+
+// MetricStats summarizes a set of latency observations
+type MetricStats struct {
+	Count int
+	Total time.Duration
+	Max   time.Duration
+}
+
+func (s *MetricStats) observe(d time.Duration) {
+	s.Count++
+	s.Total += d
+	if d > s.Max {
+		s.Max = d
+	}
+}
+
+// Mean returns the average latency of the observations
+func (s MetricStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// MemoryMetrics is a Metrics that aggregates observations in memory
+type MemoryMetrics struct {
+	puts      map[string]MetricStats
+	getHits   MetricStats
+	getMisses MetricStats
+	lk        sync.Mutex
+}
+
+// NewMemoryMetrics creates an empty MemoryMetrics
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{puts: make(map[string]MetricStats)}
+}
+
+func (m *MemoryMetrics) ObservePut(entryType string, d time.Duration) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	s := m.puts[entryType]
+	s.observe(d)
+	m.puts[entryType] = s
+}
+
+func (m *MemoryMetrics) ObserveGet(hit bool, d time.Duration) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	if hit {
+		m.getHits.observe(d)
+	} else {
+		m.getMisses.observe(d)
+	}
+}
+
+// PutStats returns the put observations for an entry type
+func (m *MemoryMetrics) PutStats(entryType string) MetricStats {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	return m.puts[entryType]
+}
+
+// GetStats returns the observations of gets that hit or missed
+func (m *MemoryMetrics) GetStats(hit bool) MetricStats {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	if hit {
+		return m.getHits
+	}
+	return m.getMisses
+}
+
+// SetMetrics sets the sink for DHT latency observations, nil turns them off
+func (h *Holochain) SetMetrics(metrics Metrics) {
+	h.metrics = metrics
+}
+
+// share shares a committed entry to the DHT, reporting the latency if
+// metrics are set
+func (h *Holochain) share(a CommittingAction, def *EntryDef) (err error) {
+	if h.metrics == nil {
+		return a.Share(h, def)
+	}
+	start := time.Now()
+	err = a.Share(h, def)
+	h.metrics.ObservePut(a.EntryType(), time.Since(start))
+	return
+}
+
+// queryGet sends a get request to the DHT, reporting the latency if
+// metrics are set
+func (h *Holochain) queryGet(ctx context.Context, req GetReq) (response interface{}, err error) {
+	if h.metrics == nil {
+		return h.dht.QueryCtx(ctx, req.H, GET_REQUEST, req)
+	}
+	start := time.Now()
+	response, err = h.dht.QueryCtx(ctx, req.H, GET_REQUEST, req)
+	h.metrics.ObserveGet(err == nil, time.Since(start))
+	return
+}
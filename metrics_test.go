@@ -0,0 +1,64 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestMemoryMetrics(t *testing.T) {
+	Convey("it should aggregate observations", t, func() {
+		m := NewMemoryMetrics()
+		m.ObservePut("evenNumbers", 2*time.Millisecond)
+		m.ObservePut("evenNumbers", 4*time.Millisecond)
+		m.ObserveGet(false, time.Millisecond)
+
+		s := m.PutStats("evenNumbers")
+		So(s.Count, ShouldEqual, 2)
+		So(s.Total, ShouldEqual, 6*time.Millisecond)
+		So(s.Max, ShouldEqual, 4*time.Millisecond)
+		So(s.Mean(), ShouldEqual, 3*time.Millisecond)
+		So(m.PutStats("oddNumbers").Count, ShouldEqual, 0)
+		So(m.GetStats(true).Count, ShouldEqual, 0)
+		So(m.GetStats(false).Count, ShouldEqual, 1)
+	})
+}
+
+func TestMetricsObserveDHT(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	m := NewMemoryMetrics()
+	h.SetMetrics(m)
+
+	Convey("sharing a migrate should observe a put", t, func() {
+		entry, err := GenTestMigrateEntry()
+		So(err, ShouldBeNil)
+		_, err = h.commitAndShare(&ActionMigrate{entry: entry}, NullHash())
+		So(err, ShouldBeNil)
+		So(m.PutStats(MigrateEntryType).Count, ShouldEqual, 1)
+	})
+
+	Convey("gets should observe hits and misses", t, func() {
+		hash := commit(h, "evenNumbers", "2")
+		So(m.PutStats("evenNumbers").Count, ShouldEqual, 1)
+
+		req := GetReq{H: hash, GetMask: GetMaskEntry}
+		_, err := callGet(h, req, &GetOptions{GetMask: req.GetMask})
+		So(err, ShouldBeNil)
+		So(m.GetStats(true).Count, ShouldEqual, 1)
+
+		missing, _ := GenTestStringHash()
+		req = GetReq{H: missing, GetMask: GetMaskEntry}
+		_, err = callGet(h, req, &GetOptions{GetMask: req.GetMask})
+		So(err.Error(), ShouldEqual, "hash not found")
+		So(m.GetStats(false).Count, ShouldEqual, 1)
+	})
+
+	Convey("turning metrics off should stop observations", t, func() {
+		h.SetMetrics(nil)
+		commit(h, "evenNumbers", "4")
+		So(m.PutStats("evenNumbers").Count, ShouldEqual, 1)
+	})
+}
@@ -0,0 +1,93 @@
+package holochain
+
+import (
+	"encoding/json"
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	"github.com/tidwall/buntdb"
+)
+
+var ErrIdempotencyConflict = errors.New("idempotency key already used with different args")
+
+// migrateIdempotencyRecord is what's stored for an idempotency key, the args
+// of the call that used it and the hash of the migrate it committed, with the
+// hashes b58 encoded
+type migrateIdempotencyRecord struct {
+	Type       string
+	DNAHash    string
+	Key        string
+	Data       string
+	Recipient  string
+	Quorum     int
+	CreateLink bool
+	Hash       string
+}
+
+// idempotencyRecord returns the record of the function's args
+func (fn *APIFnMigrate) idempotencyRecord() migrateIdempotencyRecord {
+	e := fn.action.entry
+	return migrateIdempotencyRecord{
+		Type:       e.Type,
+		DNAHash:    e.DNAHash.String(),
+		Key:        e.Key.String(),
+		Data:       e.Data,
+		Recipient:  e.Recipient,
+		Quorum:     fn.quorum,
+		CreateLink: fn.createLink,
+	}
+}
+
+// idempotencyDBKey is the key under which the record for an idempotency key is
+// kept in the DHT's store, which lasts as long as the chain does
+func idempotencyDBKey(key string) string {
+	return "idem:" + key
+}
+
+// idempotentHash returns the hash the migrate committed by an earlier call
+// with the function's idempotency key, found is false if there was none.
+// It's an ErrIdempotencyConflict if that call's args weren't the same.
+func (fn *APIFnMigrate) idempotentHash(h *Holochain) (hash Hash, found bool, err error) {
+	db := h.dht.ht.(*BuntHT).db
+	var value string
+	err = db.View(func(tx *buntdb.Tx) (e error) {
+		value, e = tx.Get(idempotencyDBKey(fn.idempotencyKey))
+		return
+	})
+	if err == buntdb.ErrNotFound {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	var r migrateIdempotencyRecord
+	if err = json.Unmarshal([]byte(value), &r); err != nil {
+		return
+	}
+	hashStr := r.Hash
+	r.Hash = ""
+	if r != fn.idempotencyRecord() {
+		err = ErrIdempotencyConflict
+		return
+	}
+	hash, err = NewHash(hashStr)
+	found = err == nil
+	return
+}
+
+// recordIdempotencyKey stores the function's args and the hash of the migrate
+// it committed under its idempotency key
+func (fn *APIFnMigrate) recordIdempotencyKey(h *Holochain, hash Hash) (err error) {
+	r := fn.idempotencyRecord()
+	r.Hash = hash.String()
+	var b []byte
+	if b, err = json.Marshal(r); err != nil {
+		return
+	}
+	db := h.dht.ht.(*BuntHT).db
+	err = db.Update(func(tx *buntdb.Tx) (e error) {
+		_, _, e = tx.Set(idempotencyDBKey(fn.idempotencyKey), string(b), nil)
+		return
+	})
+	return
+}
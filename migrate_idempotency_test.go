@@ -0,0 +1,64 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateIdempotencyKey(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	migrate := func(e MigrateEntry, key string) (interface{}, error) {
+		fn := &APIFnMigrate{action: ActionMigrate{entry: e}, idempotencyKey: key}
+		return fn.Call(h)
+	}
+
+	var first Hash
+	Convey("a repeat call with the same key and args should not commit again", t, func() {
+		r, err := migrate(entry, "retry-1")
+		So(err, ShouldBeNil)
+		first = r.(Hash)
+		length := h.chain.Length()
+
+		r, err = migrate(entry, "retry-1")
+		So(err, ShouldBeNil)
+		So(r.(Hash), ShouldEqual, first)
+		So(h.chain.Length(), ShouldEqual, length)
+	})
+
+	Convey("the same key with different args should be a conflict", t, func() {
+		other := entry
+		other.Data = "something else"
+		_, err := migrate(other, "retry-1")
+		So(err, ShouldEqual, ErrIdempotencyConflict)
+	})
+
+	Convey("calls without a key or with another key should commit", t, func() {
+		length := h.chain.Length()
+		r, err := migrate(entry, "")
+		So(err, ShouldBeNil)
+		So(h.chain.Length(), ShouldEqual, length+1)
+
+		r, err = migrate(entry, "retry-2")
+		So(err, ShouldBeNil)
+		So(r.(Hash), ShouldNotEqual, first)
+		So(h.chain.Length(), ShouldEqual, length+2)
+	})
+
+	Convey("the key should survive reopening the store", t, func() {
+		h.dht.ht.Close()
+		So(h.dht.ht.Open(filepath.Join(h.DBPath(), DHTStoreFileName)), ShouldBeNil)
+		length := h.chain.Length()
+		r, err := migrate(entry, "retry-1")
+		So(err, ShouldBeNil)
+		So(r.(Hash), ShouldEqual, first)
+		So(h.chain.Length(), ShouldEqual, length)
+	})
+}
@@ -0,0 +1,200 @@
+package holochain
+
+import (
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+)
+
+var ErrMigrationClaimCloseNotFound = errors.New("migration claim: close migrate not found on the source DNA")
+var ErrMigrationClaimNotClose = errors.New("migration claim: entry on the source DNA is not a close migrate")
+var ErrMigrationClaimOpenNotFound = errors.New("migration claim: open migrate not found on the destination DNA")
+var ErrMigrationClaimNotOpen = errors.New("migration claim: entry on the destination DNA is not an open migrate")
+var ErrMigrationClaimHashMismatch = errors.New("migration claim: entry fetched does not hash to the claimed hash")
+var ErrMigrationClaimDestinationMismatch = errors.New("migration claim: close migrate is not to the destination DNA")
+var ErrMigrationClaimSourceMismatch = errors.New("migration claim: open migrate is not from the source DNA")
+var ErrMigrationClaimNotLinked = errors.New("migration claim: open migrate does not follow the close migrate")
+var ErrMigrationClaimKeyMismatch = errors.New("migration claim: migrate is not for the agent's key")
+var ErrMigrationClaimAgentKeyNotFound = errors.New("migration claim: agent's public key not found")
+var ErrMigrationClaimNoHeader = errors.New("migration claim: migrate header not available")
+var ErrMigrationClaimSignatureInvalid = errors.New("migration claim: migrate signature does not verify")
+var ErrMigrationClaimRolledBack = errors.New("migration claim: migrate has been rolled back")
+
+// VerifyMigrationClaim checks another agent's claim that it migrated from the
+// source DNA to the destination DNA, without being part of either, i.e. for a
+// third app that trusts the agent on one DNA because of its history on the
+// other.  The close and open migrates are fetched from the DHTs of their DNAs,
+// through dht if it's the DNA's and otherwise over a bridge to it, and checked
+// to be for the agent's key and to refer to each other, i.e. the close to the
+// destination, the open to the source and to the close.  Each must also be
+// signed by the agent, whose public key is fetched from the DNA's DHT.
+// A migrate that was modified is followed to what modified it: a rollback
+// fails the claim and a correction is checked in the migrate's place.
+// The error returned says which check failed, or is the error of a failed
+// fetch, i.e. ErrNoBridgeToDNA.
+// N.B. a migrate signed by a member of the agent's group doesn't verify, as
+// only the agent's own key is checked.
+func VerifyMigrationClaim(sourceDNA, destDNA, agentKey Hash, closeMigrateHash, openMigrateHash Hash, dht *DHT) (err error) {
+	var closing, opening MigrateEntry
+	var closeHash Hash
+	closing, closeHash, err = getClaimedMigrate(dht, sourceDNA, agentKey, closeMigrateHash, ErrMigrationClaimCloseNotFound)
+	if err != nil {
+		return
+	}
+	if closing.Type != MigrateEntryTypeClose {
+		err = ErrMigrationClaimNotClose
+		return
+	}
+	opening, _, err = getClaimedMigrate(dht, destDNA, agentKey, openMigrateHash, ErrMigrationClaimOpenNotFound)
+	if err != nil {
+		return
+	}
+	if opening.Type != MigrateEntryTypeOpen {
+		err = ErrMigrationClaimNotOpen
+		return
+	}
+	if !closing.DNAHash.Equal(destDNA) {
+		err = ErrMigrationClaimDestinationMismatch
+		return
+	}
+	if !opening.DNAHash.Equal(sourceDNA) {
+		err = ErrMigrationClaimSourceMismatch
+		return
+	}
+	if opening.Data != closeMigrateHash.String() && opening.Data != closeHash.String() {
+		err = ErrMigrationClaimNotLinked
+		return
+	}
+	if !closing.Key.Equal(agentKey) || !opening.Key.Equal(agentKey) {
+		err = ErrMigrationClaimKeyMismatch
+	}
+	return
+}
+
+// getClaimedMigrate fetches a migrate along with its header from the DHT of
+// the DNA and checks that the agent signed it, following any modifications
+// to the current migrate whose hash is returned as current
+func getClaimedMigrate(dht *DHT, dna Hash, agentKey Hash, hash Hash, notFound error) (migrate MigrateEntry, current Hash, err error) {
+	seen := make(map[string]bool)
+	current = hash
+	for {
+		if seen[current.String()] {
+			err = ErrModificationCycle
+			return
+		}
+		followed := len(seen) > 0
+		seen[current.String()] = true
+
+		var follow string
+		migrate, follow, err = getSignedMigrate(dht, dna, agentKey, current, followed, notFound)
+		if err != nil || follow == "" {
+			return
+		}
+		if current, err = NewHash(follow); err != nil {
+			return
+		}
+	}
+}
+
+// getSignedMigrate fetches a single migrate and checks that the agent signed
+// it, returning the hash of what modified it if anything did.  If the migrate
+// was reached by following a modification, what's fetched may instead be the
+// rollback of the migrate before it.
+func getSignedMigrate(dht *DHT, dna Hash, agentKey Hash, hash Hash, followed bool, notFound error) (migrate MigrateEntry, follow string, err error) {
+	content, header, follow, e := claimGet(dht, dna, hash, true)
+	if e != nil {
+		err = claimGetErr(e, notFound)
+		return
+	}
+	// a bridged DNA's answer is only trusted as far as it hashes right
+	var spec HashSpec
+	var sum Hash
+	if spec, err = hash.Codec(); err != nil {
+		return
+	}
+	entry := GobEntry{C: content}
+	if sum, e = entry.Sum(spec); e != nil || !sum.Equal(hash) {
+		err = ErrMigrationClaimHashMismatch
+		return
+	}
+	if header == nil {
+		err = ErrMigrationClaimNoHeader
+		return
+	}
+	if followed && header.Type == MigrateRollbackEntryType {
+		err = ErrMigrationClaimRolledBack
+		return
+	}
+	if migrate, e = MigrateEntryFromJSON(content); e != nil {
+		err = notFound
+		return
+	}
+	if header.Type != MigrateEntryType || !header.EntryLink.Equal(hash) {
+		err = ErrMigrationClaimHashMismatch
+		return
+	}
+
+	b58pk, _, _, e := claimGet(dht, dna, agentKey, false)
+	if e != nil {
+		err = claimGetErr(e, ErrMigrationClaimAgentKeyNotFound)
+		return
+	}
+	pubKey, e := DecodePubKey(b58pk)
+	if e != nil {
+		err = ErrMigrationClaimAgentKeyNotFound
+		return
+	}
+	// the key held must be the agent's, not just any key held under its hash
+	id, e := peer.IDFromPublicKey(pubKey)
+	if e != nil || id != PeerIDFromHash(agentKey) {
+		err = ErrMigrationClaimAgentKeyNotFound
+		return
+	}
+	matches, e := pubKey.Verify([]byte(header.EntryLink), header.Sig.S)
+	if e != nil || !matches {
+		err = ErrMigrationClaimSignatureInvalid
+	}
+	return
+}
+
+// claimGet gets an entry's content, and its header and what modified it if
+// asked, from our own DHT if the DNA is ours, or over the bridge to the DNA
+// otherwise
+func claimGet(dht *DHT, dna Hash, hash Hash, withHeader bool) (content string, header *Header, follow string, err error) {
+	h := dht.h
+	if dna.Equal(h.dnaHash) {
+		var resp GetResp
+		if withHeader {
+			resp, err = bridgeGetWithHeader(h, hash)
+		} else {
+			resp, err = bridgeGet(h, hash, StatusLive|StatusModified, GetMaskEntry)
+		}
+		if err != nil {
+			return
+		}
+		content, _ = resp.Entry.Content().(string)
+		header, follow = resp.Header, resp.FollowHash
+		return
+	}
+	var r interface{}
+	r, err = (&APIFnCrossDNAGet{dna: dna, hash: hash, header: withHeader}).Call(h)
+	if err != nil {
+		return
+	}
+	if withHeader {
+		bridged := r.(BridgedEntry)
+		content, header, follow = bridged.Content, bridged.Header, bridged.FollowHash
+		return
+	}
+	content = r.(string)
+	return
+}
+
+// claimGetErr reports a fetch that found nothing as the given error and
+// passes on any other failure, i.e. of the bridge
+func claimGetErr(e error, notFound error) error {
+	if e == ErrHashNotFound || e == ErrHashDeleted || e == ErrHashRejected {
+		return notFound
+	}
+	return e
+}
@@ -0,0 +1,112 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestVerifyMigrationClaim(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	dna := h.dnaHash
+	agentKey := HashFromPeerID(h.nodeID)
+	other, err := GenTestStringHash()
+	if err != nil {
+		panic(err)
+	}
+
+	// hold an entry in our DHT along with a header signed by the agent
+	holdEntry := func(entryType string, j string, signer Agent) Hash {
+		e := GobEntry{C: j}
+		hash, err := e.Sum(h.hashSpec)
+		So(err, ShouldBeNil)
+		b, err := e.Marshal()
+		So(err, ShouldBeNil)
+		So(h.dht.Put(nil, entryType, hash, h.nodeID, b, StatusLive), ShouldBeNil)
+		_, header, err := newHeader(h.hashSpec, h.Now(), entryType, &e, signer.PrivKey(), NullHash(), NullHash(), NullHash())
+		So(err, ShouldBeNil)
+		So(h.dht.putEntryHeader(h.nodeID, hash, header), ShouldBeNil)
+		return hash
+	}
+	hold := func(migrate MigrateEntry, signer Agent) Hash {
+		j, err := migrate.ToJSON()
+		So(err, ShouldBeNil)
+		return holdEntry(MigrateEntryType, j, signer)
+	}
+
+	Convey("a close and open of the agent that refer to each other should verify", t, func() {
+		closeHash := hold(MigrateEntry{Type: MigrateEntryTypeClose, DNAHash: dna, Key: agentKey}, h.agent)
+		openHash := hold(MigrateEntry{Type: MigrateEntryTypeOpen, DNAHash: dna, Key: agentKey, Data: closeHash.String()}, h.agent)
+		So(VerifyMigrationClaim(dna, dna, agentKey, closeHash, openHash, h.dht), ShouldBeNil)
+	})
+
+	Convey("it should say which check failed", t, func() {
+		closeHash := hold(MigrateEntry{Type: MigrateEntryTypeClose, DNAHash: dna, Key: agentKey}, h.agent)
+		openHash := hold(MigrateEntry{Type: MigrateEntryTypeOpen, DNAHash: dna, Key: agentKey, Data: closeHash.String()}, h.agent)
+
+		So(VerifyMigrationClaim(dna, dna, agentKey, other, openHash, h.dht), ShouldEqual, ErrMigrationClaimCloseNotFound)
+		So(VerifyMigrationClaim(dna, dna, agentKey, closeHash, other, h.dht), ShouldEqual, ErrMigrationClaimOpenNotFound)
+		So(VerifyMigrationClaim(dna, dna, agentKey, openHash, openHash, h.dht), ShouldEqual, ErrMigrationClaimNotClose)
+		So(VerifyMigrationClaim(dna, dna, agentKey, closeHash, closeHash, h.dht), ShouldEqual, ErrMigrationClaimNotOpen)
+
+		elsewhere := hold(MigrateEntry{Type: MigrateEntryTypeClose, DNAHash: other, Key: agentKey}, h.agent)
+		openElsewhere := hold(MigrateEntry{Type: MigrateEntryTypeOpen, DNAHash: dna, Key: agentKey, Data: elsewhere.String()}, h.agent)
+		So(VerifyMigrationClaim(dna, dna, agentKey, elsewhere, openElsewhere, h.dht), ShouldEqual, ErrMigrationClaimDestinationMismatch)
+
+		fromElsewhere := hold(MigrateEntry{Type: MigrateEntryTypeOpen, DNAHash: other, Key: agentKey, Data: closeHash.String()}, h.agent)
+		So(VerifyMigrationClaim(dna, dna, agentKey, closeHash, fromElsewhere, h.dht), ShouldEqual, ErrMigrationClaimSourceMismatch)
+
+		unlinked := hold(MigrateEntry{Type: MigrateEntryTypeOpen, DNAHash: dna, Key: agentKey, Data: other.String()}, h.agent)
+		So(VerifyMigrationClaim(dna, dna, agentKey, closeHash, unlinked, h.dht), ShouldEqual, ErrMigrationClaimNotLinked)
+
+		otherKey := hold(MigrateEntry{Type: MigrateEntryTypeOpen, DNAHash: dna, Key: other, Data: closeHash.String()}, h.agent)
+		So(VerifyMigrationClaim(dna, dna, agentKey, closeHash, otherKey, h.dht), ShouldEqual, ErrMigrationClaimKeyMismatch)
+	})
+
+	Convey("it should refuse a migrate signed by another key or an agent whose key isn't held", t, func() {
+		forger, err := NewAgent(LibP2P, "forger", MakeTestSeed("forger"))
+		So(err, ShouldBeNil)
+		closeHash := hold(MigrateEntry{Type: MigrateEntryTypeClose, DNAHash: dna, Key: agentKey}, h.agent)
+		forged := hold(MigrateEntry{Type: MigrateEntryTypeOpen, DNAHash: dna, Key: agentKey, Data: closeHash.String()}, forger)
+		So(VerifyMigrationClaim(dna, dna, agentKey, closeHash, forged, h.dht), ShouldEqual, ErrMigrationClaimSignatureInvalid)
+
+		_, forgerID, err := forger.NodeID()
+		So(err, ShouldBeNil)
+		forgerKey, err := NewHash(forgerID)
+		So(err, ShouldBeNil)
+		closing := hold(MigrateEntry{Type: MigrateEntryTypeClose, DNAHash: dna, Key: forgerKey}, forger)
+		opening := hold(MigrateEntry{Type: MigrateEntryTypeOpen, DNAHash: dna, Key: forgerKey, Data: closing.String()}, forger)
+		So(VerifyMigrationClaim(dna, dna, forgerKey, closing, opening, h.dht), ShouldEqual, ErrMigrationClaimAgentKeyNotFound)
+	})
+
+	Convey("a close that was rolled back should be refused", t, func() {
+		closeHash := hold(MigrateEntry{Type: MigrateEntryTypeClose, DNAHash: dna, Key: agentKey, Data: "rolled back"}, h.agent)
+		openHash := hold(MigrateEntry{Type: MigrateEntryTypeOpen, DNAHash: dna, Key: agentKey, Data: closeHash.String()}, h.agent)
+		So(VerifyMigrationClaim(dna, dna, agentKey, closeHash, openHash, h.dht), ShouldBeNil)
+
+		rollback := MigrateRollbackEntry{MigrateHeaderHash: other}
+		j, err := rollback.ToJSON()
+		So(err, ShouldBeNil)
+		rollbackHash := holdEntry(MigrateRollbackEntryType, j, h.agent)
+		So(h.dht.Mod(nil, closeHash, rollbackHash), ShouldBeNil)
+		So(VerifyMigrationClaim(dna, dna, agentKey, closeHash, openHash, h.dht), ShouldEqual, ErrMigrationClaimRolledBack)
+	})
+
+	Convey("a close that was corrected should be checked as corrected", t, func() {
+		closeHash := hold(MigrateEntry{Type: MigrateEntryTypeClose, DNAHash: dna, Key: agentKey, Data: "to correct"}, h.agent)
+		openHash := hold(MigrateEntry{Type: MigrateEntryTypeOpen, DNAHash: dna, Key: agentKey, Data: closeHash.String()}, h.agent)
+		corrected := hold(MigrateEntry{Type: MigrateEntryTypeClose, DNAHash: dna, Key: agentKey, Data: "corrected"}, h.agent)
+		So(h.dht.Mod(nil, closeHash, corrected), ShouldBeNil)
+		So(VerifyMigrationClaim(dna, dna, agentKey, closeHash, openHash, h.dht), ShouldBeNil)
+
+		elsewhere := hold(MigrateEntry{Type: MigrateEntryTypeClose, DNAHash: other, Key: agentKey, Data: "corrected elsewhere"}, h.agent)
+		So(h.dht.Mod(nil, corrected, elsewhere), ShouldBeNil)
+		So(VerifyMigrationClaim(dna, dna, agentKey, closeHash, openHash, h.dht), ShouldEqual, ErrMigrationClaimDestinationMismatch)
+	})
+
+	Convey("a DNA that isn't ours should need a bridge", t, func() {
+		So(VerifyMigrationClaim(other, dna, agentKey, other, other, h.dht), ShouldEqual, ErrNoBridgeToDNA)
+	})
+}
@@ -0,0 +1,191 @@
+package holochain
+
+import (
+	"encoding/json"
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+)
+
+var ErrMigrationReceiptOpenNotFound = errors.New("migration receipt: open migrate header not found on chain")
+var ErrMigrationReceiptNotOpen = errors.New("migration receipt: header is not an open migrate")
+var ErrMigrationReceiptSourceMismatch = errors.New("migration receipt: receipt is not for the given source migrate")
+var ErrMigrationReceiptSignatureInvalid = errors.New("migration receipt: signature does not verify")
+var ErrMigrationReceiptEncrypted = errors.New("migration receipt: open migrate Data is encrypted so can't be checked against the source")
+var ErrMigrationReceiptAgentMismatch = errors.New("migration receipt: receipt is not signed by the expected agent")
+
+// MigrationReceipt is the destination DNA's signed confirmation that an agent
+// opened on it.  It can be committed back on the source chain, i.e. in the
+// Data of a mod of the close migrate, as proof that the migration completed.
+type MigrationReceipt struct {
+	DNAHash    Hash   // the destination DNA
+	AgentKey   string // the b58 encoded public key of the agent on the destination
+	OpenHash   Hash   // the header hash of the open migrate on the destination chain
+	SourceHash Hash   // the hash of the close migrate on the source chain
+	Signature  Signature
+}
+
+// NewMigrationReceipt signs a receipt for the open migrate with the given
+// header hash on this chain, confirming the source chain's migrate
+func (h *Holochain) NewMigrationReceipt(openHash Hash, sourceMigrateHash Hash) (receipt MigrationReceipt, err error) {
+	var header *Header
+	header, err = h.chain.Get(openHash)
+	if err != nil {
+		err = ErrMigrationReceiptOpenNotFound
+		return
+	}
+	if header.Type != MigrateEntryType {
+		err = ErrMigrationReceiptNotOpen
+		return
+	}
+	var entry Entry
+	entry, _, err = h.chain.GetEntry(header.EntryLink)
+	if err != nil {
+		return
+	}
+	var migrate MigrateEntry
+	migrate, err = MigrateEntryFromJSON(entry.Content().(string))
+	if err != nil {
+		return
+	}
+	if migrate.Type != MigrateEntryTypeOpen {
+		err = ErrMigrationReceiptNotOpen
+		return
+	}
+	// the open's Data is the hash of the close it follows, which can't be
+	// checked once encrypted
+	if migrate.IsEncrypted() {
+		err = ErrMigrationReceiptEncrypted
+		return
+	}
+	if migrate.Data != sourceMigrateHash.String() {
+		err = ErrMigrationReceiptSourceMismatch
+		return
+	}
+
+	receipt.DNAHash = h.dnaHash
+	receipt.AgentKey, err = h.agent.EncodePubKey()
+	if err != nil {
+		return
+	}
+	receipt.OpenHash = openHash
+	receipt.SourceHash = sourceMigrateHash
+
+	var data []byte
+	data, err = receipt.signedData()
+	if err != nil {
+		return
+	}
+	receipt.Signature, err = h.Sign(data)
+	return
+}
+
+// VerifyMigrationReceipt checks that a receipt is for the given source
+// migrate and that it was signed by the expected agent on the destination DNA,
+// whose b58 encoded public key the verifier must know independently of the
+// receipt, i.e. from the signer of the open migrate's header on the
+// destination DHT, as a receipt's own key proves nothing.
+// N.B. it doesn't check that the agent's key is still trusted, for that use
+// VerifyTrustedMigrationReceipt.
+func VerifyMigrationReceipt(receipt MigrationReceipt, sourceMigrateHash Hash, agentKey string) (err error) {
+	if !receipt.SourceHash.Equal(sourceMigrateHash) {
+		err = ErrMigrationReceiptSourceMismatch
+		return
+	}
+	if receipt.AgentKey != agentKey {
+		err = ErrMigrationReceiptAgentMismatch
+		return
+	}
+	pubKey, err := DecodePubKey(agentKey)
+	if err != nil {
+		return
+	}
+	var data []byte
+	data, err = receipt.signedData()
+	if err != nil {
+		return
+	}
+	matches, err := pubKey.Verify(data, receipt.Signature.S)
+	if err != nil || !matches {
+		err = ErrMigrationReceiptSignatureInvalid
+	}
+	return
+}
+
+// VerifyTrustedMigrationReceipt is VerifyMigrationReceipt also checking that
+// the destination agent's key hasn't since been revoked, returning
+// ErrKeyRevoked if it has
+func (h *Holochain) VerifyTrustedMigrationReceipt(receipt MigrationReceipt, sourceMigrateHash Hash, agentKey string) (err error) {
+	if err = VerifyMigrationReceipt(receipt, sourceMigrateHash, agentKey); err != nil {
+		return
+	}
+	pubKey, err := DecodePubKey(agentKey)
+	if err != nil {
+		return
+	}
+	err = h.checkKeyTrusted(pubKey)
+	return
+}
+
+// signedData returns the bytes of the receipt covered by its signature
+func (r *MigrationReceipt) signedData() (data []byte, err error) {
+	var x struct {
+		DNAHash    string
+		AgentKey   string
+		OpenHash   string
+		SourceHash string
+	}
+	x.DNAHash = r.DNAHash.String()
+	x.AgentKey = r.AgentKey
+	x.OpenHash = r.OpenHash.String()
+	x.SourceHash = r.SourceHash.String()
+	data, err = json.Marshal(x)
+	return
+}
+
+func (r *MigrationReceipt) ToJSON() (encoded string, err error) {
+	var x struct {
+		DNAHash    string
+		AgentKey   string
+		OpenHash   string
+		SourceHash string
+		Signature  string
+	}
+	x.DNAHash = r.DNAHash.String()
+	x.AgentKey = r.AgentKey
+	x.OpenHash = r.OpenHash.String()
+	x.SourceHash = r.SourceHash.String()
+	x.Signature = r.Signature.B58String()
+	var j []byte
+	j, err = json.Marshal(x)
+	encoded = string(j)
+	return
+}
+
+func MigrationReceiptFromJSON(j string) (receipt MigrationReceipt, err error) {
+	var x struct {
+		DNAHash    string
+		AgentKey   string
+		OpenHash   string
+		SourceHash string
+		Signature  string
+	}
+	err = json.Unmarshal([]byte(j), &x)
+	if err != nil {
+		return
+	}
+	receipt.DNAHash, err = NewHash(x.DNAHash)
+	if err != nil {
+		return
+	}
+	receipt.OpenHash, err = NewHash(x.OpenHash)
+	if err != nil {
+		return
+	}
+	receipt.SourceHash, err = NewHash(x.SourceHash)
+	if err != nil {
+		return
+	}
+	receipt.AgentKey = x.AgentKey
+	receipt.Signature = SignatureFromB58String(x.Signature)
+	return
+}
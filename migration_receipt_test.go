@@ -0,0 +1,83 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestMigrationReceipt(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	sourceHash, _ := NewHash("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
+	otherHash, _ := NewHash("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh3")
+	entry.Data = sourceHash.String()
+	fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+	_, err = fn.Call(h)
+	if err != nil {
+		panic(err)
+	}
+	openHash, _ := h.chain.TopType(MigrateEntryType)
+	agentKey, _ := h.agent.EncodePubKey()
+
+	Convey("it should only sign receipts for open migrates on the chain", t, func() {
+		_, err := h.NewMigrationReceipt(otherHash, sourceHash)
+		So(err, ShouldEqual, ErrMigrationReceiptOpenNotFound)
+		_, err = h.NewMigrationReceipt(h.chain.Hashes[0], sourceHash)
+		So(err, ShouldEqual, ErrMigrationReceiptNotOpen)
+		_, err = h.NewMigrationReceipt(*openHash, otherHash)
+		So(err, ShouldEqual, ErrMigrationReceiptSourceMismatch)
+	})
+
+	Convey("a receipt should verify against its source migrate", t, func() {
+		receipt, err := h.NewMigrationReceipt(*openHash, sourceHash)
+		So(err, ShouldBeNil)
+		So(receipt.DNAHash.Equal(h.dnaHash), ShouldBeTrue)
+		So(VerifyMigrationReceipt(receipt, sourceHash, agentKey), ShouldBeNil)
+		So(VerifyMigrationReceipt(receipt, otherHash, agentKey), ShouldEqual, ErrMigrationReceiptSourceMismatch)
+
+		j, err := receipt.ToJSON()
+		So(err, ShouldBeNil)
+		decoded, err := MigrationReceiptFromJSON(j)
+		So(err, ShouldBeNil)
+		So(VerifyMigrationReceipt(decoded, sourceHash, agentKey), ShouldBeNil)
+	})
+
+	Convey("a receipt minted with another key should not verify", t, func() {
+		forger, err := NewAgent(LibP2P, "forger", MakeTestSeed("forger"))
+		So(err, ShouldBeNil)
+		receipt := MigrationReceipt{DNAHash: h.dnaHash, OpenHash: *openHash, SourceHash: sourceHash}
+		receipt.AgentKey, err = forger.EncodePubKey()
+		So(err, ShouldBeNil)
+		data, err := receipt.signedData()
+		So(err, ShouldBeNil)
+		sig, err := forger.PrivKey().Sign(data)
+		So(err, ShouldBeNil)
+		receipt.Signature = Signature{S: sig}
+		So(VerifyMigrationReceipt(receipt, sourceHash, receipt.AgentKey), ShouldBeNil)
+		So(VerifyMigrationReceipt(receipt, sourceHash, agentKey), ShouldEqual, ErrMigrationReceiptAgentMismatch)
+	})
+
+	Convey("a tampered receipt should not verify", t, func() {
+		receipt, err := h.NewMigrationReceipt(*openHash, sourceHash)
+		So(err, ShouldBeNil)
+		receipt.OpenHash = otherHash
+		So(VerifyMigrationReceipt(receipt, sourceHash, agentKey), ShouldEqual, ErrMigrationReceiptSignatureInvalid)
+	})
+
+	Convey("it should not sign receipts for an open with encrypted data", t, func() {
+		encrypted, err := NewEncryptedMigrateEntry(MigrateEntryTypeOpen, entry.DNAHash, entry.Key, sourceHash.String(), h.agent.PubKey())
+		So(err, ShouldBeNil)
+		fn := &APIFnMigrate{action: ActionMigrate{entry: encrypted}}
+		_, err = fn.Call(h)
+		So(err, ShouldBeNil)
+		encryptedHash, _ := h.chain.TopType(MigrateEntryType)
+		_, err = h.NewMigrationReceipt(*encryptedHash, sourceHash)
+		So(err, ShouldEqual, ErrMigrationReceiptEncrypted)
+	})
+}
@@ -0,0 +1,99 @@
+package holochain
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+var ErrMigrationCycle = errors.New("migration: close migrates redirect back to a DNA already visited")
+var ErrMigrationTooManyHops = errors.New("migration: more close migrate redirects than allowed")
+var ErrMigrationKeyMismatch = errors.New("migration: close migrate is not for the agent's key")
+
+// ResolveMigration follows the agent's close migrates from the start DNA to
+// the DNA each one closed to, over the bridges to them, returning the DNA the
+// agent ended up on, i.e. whose chain isn't closed.  The hops lists each DNA
+// visited, starting with startDNA and ending with finalDNA.  Following stops
+// with ErrMigrationCycle if a close points back to a DNA already visited, the
+// hops then ending with the revisited DNA, and with ErrMigrationTooManyHops if
+// the agent migrated more than maxHops times.
+func (h *Holochain) ResolveMigration(startDNA, agentKey Hash, maxHops int) (finalDNA Hash, hops []Hash, err error) {
+	visited := make(map[Hash]bool)
+	dna := startDNA
+	for {
+		hops = append(hops, dna)
+		visited[dna] = true
+		var closing *MigrateEntry
+		if closing, err = h.closeMigrateOn(dna); err != nil {
+			return
+		}
+		if closing == nil {
+			finalDNA = dna
+			return
+		}
+		if !closing.Key.Equal(agentKey) {
+			err = ErrMigrationKeyMismatch
+			return
+		}
+		if visited[closing.DNAHash] {
+			hops = append(hops, closing.DNAHash)
+			err = ErrMigrationCycle
+			return
+		}
+		if len(hops) > maxHops {
+			err = ErrMigrationTooManyHops
+			return
+		}
+		dna = closing.DNAHash
+	}
+}
+
+// closeMigrateOn returns the close migrate of the chain on the DNA, ours if
+// it's our DNA and otherwise as the bridged app reports it, or nil if the
+// chain isn't closed
+func (h *Holochain) closeMigrateOn(dna Hash) (closing *MigrateEntry, err error) {
+	if dna.Equal(h.dnaHash) {
+		_, closing, err = h.Chain().CloseMigrate()
+		return
+	}
+	if h.bridgeDB == nil {
+		err = ErrNoBridgeToDNA
+		return
+	}
+	var token, url string
+	token, url, err = h.GetBridgeToken(dna)
+	if err != nil {
+		if err == BridgeAppNotFoundErr {
+			err = ErrNoBridgeToDNA
+		}
+		return
+	}
+
+	var resp *http.Response
+	resp, err = http.Get(fmt.Sprintf("%s/bridge-migration/%s?%s", url, token, h.nextBridgeNonce().Query()))
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	var b []byte
+	b, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = errors.New(strings.TrimSpace(string(b)))
+		return
+	}
+	if len(b) == 0 {
+		return
+	}
+	var migrate MigrateEntry
+	if migrate, err = MigrateEntryFromJSON(string(b)); err != nil {
+		return
+	}
+	closing = &migrate
+	return
+}
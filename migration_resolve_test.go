@@ -0,0 +1,91 @@
+package holochain
+
+import (
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveMigration(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+	// the app on DNA B, bridged to from h
+	d2, _, h2 := PrepareTestChain("test2")
+	defer CleanupTestChain(h2, d2)
+
+	agentKey := HashFromPeerID(h.nodeID)
+	dnaA := h.dnaHash
+	dnaB, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHw")
+	closeTo := func(app *Holochain, dna Hash) {
+		fn := &APIFnMigrate{action: ActionMigrate{entry: MigrateEntry{Type: MigrateEntryTypeClose, DNAHash: dna, Key: agentKey}}}
+		if _, err := fn.Call(app); err != nil {
+			panic(err)
+		}
+	}
+
+	fakeFromApp, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHx")
+	if _, err := h2.AddBridgeAsCallee(fakeFromApp, "app data"); err != nil {
+		panic(err)
+	}
+	c, err := NewCapability(h2.bridgeDB, `{"jsSampleZome":{"`+BridgeGetFunc+`":true}}`, nil)
+	if err != nil {
+		panic(err)
+	}
+	// serve B's migration as the ui's webserver does
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Split(r.URL.Path, "/")
+		nonce, err := BridgeNonceFromQuery(r.URL.Query(), path[2])
+		if err == nil {
+			var result string
+			if result, err = h2.BridgeCloseMigrateNonced(path[2], nonce); err == nil {
+				fmt.Fprint(w, result)
+				return
+			}
+		}
+		http.Error(w, err.Error(), 400)
+	}))
+	defer server.Close()
+	if err := h.AddBridgeAsCaller("jsSampleZome", dnaB, "B", c.Token, server.URL, ""); err != nil {
+		panic(err)
+	}
+
+	Convey("an agent that hasn't migrated should end up where it started", t, func() {
+		final, hops, err := h.ResolveMigration(dnaA, agentKey, 5)
+		So(err, ShouldBeNil)
+		So(final.String(), ShouldEqual, dnaA.String())
+		So(hops, ShouldResemble, []Hash{dnaA})
+	})
+
+	Convey("it should follow a close over the bridge to the DNA it closed to", t, func() {
+		closeTo(h, dnaB)
+		final, hops, err := h.ResolveMigration(dnaA, agentKey, 5)
+		So(err, ShouldBeNil)
+		So(final.String(), ShouldEqual, dnaB.String())
+		So(hops, ShouldResemble, []Hash{dnaA, dnaB})
+
+		_, _, err = h.ResolveMigration(dnaA, agentKey, 0)
+		So(err, ShouldEqual, ErrMigrationTooManyHops)
+	})
+
+	Convey("it should refuse a close for another agent", t, func() {
+		other, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		_, _, err = h.ResolveMigration(dnaA, other, 5)
+		So(err, ShouldEqual, ErrMigrationKeyMismatch)
+	})
+
+	Convey("it should detect a close that points back to a DNA already visited", t, func() {
+		closeTo(h2, dnaA)
+		_, hops, err := h.ResolveMigration(dnaA, agentKey, 5)
+		So(err, ShouldEqual, ErrMigrationCycle)
+		So(hops, ShouldResemble, []Hash{dnaA, dnaB, dnaA})
+
+		_, hops, err = h.ResolveMigration(dnaB, agentKey, 5)
+		So(err, ShouldEqual, ErrMigrationCycle)
+		So(hops, ShouldResemble, []Hash{dnaB, dnaA, dnaB})
+	})
+}
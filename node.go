@@ -83,6 +83,20 @@ const (
 	// Kademlia messages
 
 	FIND_NODE_REQUEST
+
+	// Batched DHT messages
+
+	GETBATCH_REQUEST
+
+	// Messages for actions without their own message type
+
+	ACTION_REQUEST
+
+	// Migration messages
+
+	MIGRATION_STATUS_REQUEST
+	PUTIF_REQUEST
+	MIGRATION_TARGETS_REQUEST
 )
 
 func (msgType MsgType) String() string {
@@ -102,10 +116,16 @@ func (msgType MsgType) String() string {
 		"VALIDATE_MOD_REQUEST",
 		"APP_MESSAGE",
 		"LISTADD_REQUEST",
-		"FIND_NODE_REQUEST"}[msgType]
+		"FIND_NODE_REQUEST",
+		"GETBATCH_REQUEST",
+		"ACTION_REQUEST",
+		"MIGRATION_STATUS_REQUEST",
+		"PUTIF_REQUEST",
+		"MIGRATION_TARGETS_REQUEST"}[msgType]
 }
 
 var ErrBlockedListed = errors.New("node blockedlisted")
+var ErrMessageSourceMismatch = errors.New("message source doesn't match the peer that sent it")
 
 // Message represents data that can be sent to node in the network
 type Message struct {
@@ -113,6 +133,8 @@ type Message struct {
 	Time time.Time
 	From peer.ID
 	Body interface{}
+
+	replayed bool // a put replayed by gossip from a peer's log, not sent by From
 }
 
 type BytesSent struct {
@@ -128,6 +150,7 @@ const (
 	BootstrappingStopper
 	RefreshingStopper
 	HoldingStopper
+	ExpiringStopper
 	_StopperCount
 )
 
@@ -138,11 +161,13 @@ type Node struct {
 	host         *rhost.RoutedHost
 	mdnsSvc      discovery.Service
 	blockedlist  map[peer.ID]bool
+	blockedLk    sync.RWMutex
 	protocols    [_protocolCount]*Protocol
 	peerstore    pstore.Peerstore
 	routingTable *RoutingTable
 	nat          *nat.NAT
 	log          *Logger
+	compactWire  bool
 
 	// ticker task stoppers
 	stoppers []chan bool
@@ -158,6 +183,7 @@ type Node struct {
 type Protocol struct {
 	ID       protocol.ID
 	Receiver ReceiverFn
+	Compact  protocol.ID // the protocol's compact wire format variant, if any
 }
 
 const (
@@ -425,10 +451,10 @@ func NewNode(listenAddr string, protoMux string, agent *LibP2PAgent, enableNATUP
 	n.log.Logf("Action protocol identifier: " + actionProtocolString)
 	n.log.Logf("Kademlia protocol identifier: " + kademliaProtocolString)
 
-	n.protocols[ValidateProtocol] = &Protocol{protocol.ID(validateProtocolString), ValidateReceiver}
-	n.protocols[GossipProtocol] = &Protocol{protocol.ID(gossipProtocolString), GossipReceiver}
-	n.protocols[ActionProtocol] = &Protocol{protocol.ID(actionProtocolString), ActionReceiver}
-	n.protocols[KademliaProtocol] = &Protocol{protocol.ID(kademliaProtocolString), KademliaReceiver}
+	n.protocols[ValidateProtocol] = &Protocol{protocol.ID(validateProtocolString), ValidateReceiver, protocol.ID(validateProtocolString + CompactWireSuffix)}
+	n.protocols[GossipProtocol] = &Protocol{protocol.ID(gossipProtocolString), GossipReceiver, ""}
+	n.protocols[ActionProtocol] = &Protocol{protocol.ID(actionProtocolString), ActionReceiver, protocol.ID(actionProtocolString + CompactWireSuffix)}
+	n.protocols[KademliaProtocol] = &Protocol{protocol.ID(kademliaProtocolString), KademliaReceiver, ""}
 
 	n.stoppers = make([]chan bool, _StopperCount)
 
@@ -510,7 +536,7 @@ func (m Message) String() string {
 }
 
 // respondWith writes a message either error or otherwise, to the stream
-func (node *Node) respondWith(s net.Stream, err error, body interface{}) {
+func (node *Node) respondWith(s net.Stream, wire WireFormat, err error, body interface{}) {
 	var m *Message
 	if err != nil {
 		errResp := NewErrorResponse(err)
@@ -520,7 +546,7 @@ func (node *Node) respondWith(s net.Stream, err error, body interface{}) {
 		m = node.NewMessage(OK_RESPONSE, body)
 	}
 
-	data, err := m.Encode()
+	data, err := m.EncodeWire(wire)
 	if err != nil {
 		Infof("Response failed: unable to encode message: %v", m)
 	}
@@ -535,17 +561,39 @@ func (node *Node) respondWith(s net.Stream, err error, body interface{}) {
 	}
 }
 
+// EnableCompactWire makes the node offer the compact wire format for the
+// protocols that have one.  It must be called before the protocols are
+// started.  Peers that don't support the format are still talked to in gob.
+func (node *Node) EnableCompactWire() {
+	node.compactWire = true
+}
+
 // StartProtocol initiates listening for a protocol on the node
 func (node *Node) StartProtocol(h *Holochain, proto int) (err error) {
-	node.host.SetStreamHandler(node.protocols[proto].ID, func(s net.Stream) {
+	p := node.protocols[proto]
+	node.setStreamHandler(h, proto, p.ID, GobWireFormat)
+	if node.compactWire && p.Compact != "" {
+		node.setStreamHandler(h, proto, p.Compact, CompactWireFormat)
+	}
+	return
+}
+
+// setStreamHandler listens on a protocol identifier for messages in the given
+// wire format
+func (node *Node) setStreamHandler(h *Holochain, proto int, id protocol.ID, wire WireFormat) {
+	node.host.SetStreamHandler(id, func(s net.Stream) {
 		var m Message
-		err := m.Decode(s)
+		err := m.DecodeWire(wire, s)
 		var response interface{}
 		if m.From == "" {
 			// @todo other sanity checks on From?
 			err = errors.New("message must have a source")
 		} else {
-			if node.IsBlocked(s.Conn().RemotePeer()) {
+			// the stream's peer is authenticated by its connection, so
+			// receivers can rely on From being who actually sent the message
+			if m.From != s.Conn().RemotePeer() {
+				err = ErrMessageSourceMismatch
+			} else if node.IsBlocked(s.Conn().RemotePeer()) {
 				err = ErrBlockedListed
 			}
 
@@ -553,9 +601,8 @@ func (node *Node) StartProtocol(h *Holochain, proto int) (err error) {
 				response, err = node.protocols[proto].Receiver(h, &m)
 			}
 		}
-		node.respondWith(s, err, response)
+		node.respondWith(s, wire, err, response)
 	})
-	return
 }
 
 // Close shuts down the node
@@ -578,14 +625,14 @@ func (node *Node) Send(ctx context.Context, proto int, addr peer.ID, m *Message)
 		return
 	}
 
-	s, err := node.host.NewStream(ctx, addr, node.protocols[proto].ID)
+	s, wire, err := node.openStream(ctx, proto, addr)
 	if err != nil {
 		return
 	}
 	defer s.Close()
 
 	// encode the message and send it
-	data, err := m.Encode()
+	data, err := m.EncodeWire(wire)
 	if err != nil {
 		return
 	}
@@ -603,7 +650,7 @@ func (node *Node) Send(ctx context.Context, proto int, addr peer.ID, m *Message)
 	}
 
 	// decode the response
-	err = response.Decode(s)
+	err = response.DecodeWire(wire, s)
 	if err != nil {
 		node.log.Logf("failed to decode with err:%v ", err)
 		return
@@ -611,6 +658,24 @@ func (node *Node) Send(ctx context.Context, proto int, addr peer.ID, m *Message)
 	return
 }
 
+// openStream opens a stream to a node for a protocol, negotiating the compact
+// wire format if both ends support it and falling back to gob otherwise
+func (node *Node) openStream(ctx context.Context, proto int, addr peer.ID) (s net.Stream, wire WireFormat, err error) {
+	p := node.protocols[proto]
+	ids := []protocol.ID{p.ID}
+	if node.compactWire && p.Compact != "" {
+		ids = []protocol.ID{p.Compact, p.ID}
+	}
+	s, err = node.host.NewStream(ctx, addr, ids...)
+	if err != nil {
+		return
+	}
+	if p.Compact != "" && s.Protocol() == p.Compact {
+		wire = CompactWireFormat
+	}
+	return
+}
+
 // NewMessage creates a message from the node with a new current timestamp
 func (node *Node) NewMessage(t MsgType, body interface{}) (msg *Message) {
 	m := Message{Type: t, Time: time.Now().Round(0), Body: body, From: node.HashAddr}
@@ -620,13 +685,17 @@ func (node *Node) NewMessage(t MsgType, body interface{}) (msg *Message) {
 
 // IsBlockedListed checks to see if a node is on the blockedlist
 func (node *Node) IsBlocked(addr peer.ID) (ok bool) {
+	node.blockedLk.RLock()
+	defer node.blockedLk.RUnlock()
 	ok = node.blockedlist[addr]
 	return
 }
 
 // InitBlockedList sets up the blockedlist from a PeerList
 func (node *Node) InitBlockedList(list PeerList) {
+	node.blockedLk.Lock()
 	node.blockedlist = make(map[peer.ID]bool)
+	node.blockedLk.Unlock()
 	for _, r := range list.Records {
 		node.Block(r.ID)
 	}
@@ -634,6 +703,8 @@ func (node *Node) InitBlockedList(list PeerList) {
 
 // Block adds a peer to the blocklist
 func (node *Node) Block(addr peer.ID) {
+	node.blockedLk.Lock()
+	defer node.blockedLk.Unlock()
 	if node.blockedlist == nil {
 		node.blockedlist = make(map[peer.ID]bool)
 	}
@@ -642,6 +713,8 @@ func (node *Node) Block(addr peer.ID) {
 
 // Unblock removes a peer from the blocklist
 func (node *Node) Unblock(addr peer.ID) {
+	node.blockedLk.Lock()
+	defer node.blockedLk.Unlock()
 	if node.blockedlist != nil {
 		delete(node.blockedlist, addr)
 	}
@@ -662,6 +735,8 @@ const (
 	ErrLinkNotFoundCode
 	ErrEntryTypeMismatchCode
 	ErrBlockedListedCode
+	ErrRateLimitedCode
+	ErrStatusMismatchCode
 )
 
 // NewErrorResponse encodes standard errors for transmitting
@@ -681,6 +756,10 @@ func NewErrorResponse(err error) (errResp ErrorResponse) {
 		errResp.Code = ErrEntryTypeMismatchCode
 	case ErrBlockedListed:
 		errResp.Code = ErrBlockedListedCode
+	case ErrRateLimited:
+		errResp.Code = ErrRateLimitedCode
+	case ErrStatusMismatch:
+		errResp.Code = ErrStatusMismatchCode
 	default:
 		errResp.Message = err.Error() //Code will be set to ErrUnknown by default cus it's 0
 	}
@@ -704,6 +783,10 @@ func (errResp ErrorResponse) DecodeResponseError() (err error) {
 		err = ErrEntryTypeMismatch
 	case ErrBlockedListedCode:
 		err = ErrBlockedListed
+	case ErrRateLimitedCode:
+		err = ErrRateLimited
+	case ErrStatusMismatchCode:
+		err = ErrStatusMismatch
 	default:
 		err = errors.New(errResp.Message)
 	}
@@ -37,6 +37,11 @@ func TestNodeConstants(t *testing.T) {
 		So(APP_MESSAGE, ShouldEqual, 14)
 		So(LISTADD_REQUEST, ShouldEqual, 15)
 		So(FIND_NODE_REQUEST, ShouldEqual, 16)
+		So(GETBATCH_REQUEST, ShouldEqual, 17)
+		So(ACTION_REQUEST, ShouldEqual, 18)
+		So(MIGRATION_STATUS_REQUEST, ShouldEqual, 19)
+		So(PUTIF_REQUEST, ShouldEqual, 20)
+		So(MIGRATION_TARGETS_REQUEST, ShouldEqual, 21)
 	})
 }
 
@@ -204,6 +209,16 @@ func TestNodeSend(t *testing.T) {
 		So(r.Body.(ErrorResponse).Message, ShouldEqual, "message must have a source")
 	})
 
+	Convey("It should fail on messages claiming to be from another peer", t, func() {
+		spoofed, _ := makePeer("spoofed")
+		m := node2.NewMessage(GOSSIP_REQUEST, GossipReq{})
+		m.From = spoofed
+		r, err := node2.Send(context.Background(), GossipProtocol, node1.HashAddr, m)
+		So(err, ShouldBeNil)
+		So(r.Type, ShouldEqual, ERROR_RESPONSE)
+		So(r.Body.(ErrorResponse).Message, ShouldEqual, ErrMessageSourceMismatch.Error())
+	})
+
 	Convey("It should fail on incorrect message types", t, func() {
 		m := node1.NewMessage(PUT_REQUEST, "fish")
 		r, err := node1.Send(context.Background(), ValidateProtocol, node2.HashAddr, m)
@@ -2,13 +2,17 @@ package holochain
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
 	ic "github.com/libp2p/go-libp2p-crypto"
 	peer "github.com/libp2p/go-libp2p-peer"
 	pstore "github.com/libp2p/go-libp2p-peerstore"
 	"math/rand"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 // -------------------------------------------------------------------------------------------
@@ -122,10 +126,86 @@ func setupMultiNodeTesting(n int) (mt *multiNodeTest) {
 		d:      d,
 		count:  n,
 	}
-	mt.nodes = makeTestNodes(mt.ctx, mt.s, n)
+	mt.nodes = MakeTestNodes(mt.ctx, mt.s, n)
 	return
 }
 
+// setupMemMultiNodeTesting is setupMultiNodeTesting with the nodes on an
+// in-memory transport where the redundancy closest nodes hold each entry, 0
+// meaning all of them.  The nodes need no connecting and what they share is
+// held by the time the share returns, so there's nothing to wait for.
+func setupMemMultiNodeTesting(n int, redundancy int) (mt *multiNodeTest) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d, s := SetupTestService()
+	mt = &multiNodeTest{
+		ctx:    ctx,
+		cancel: cancel,
+		s:      s,
+		d:      d,
+		count:  n,
+	}
+	mt.nodes = makeTestNodes(mt.ctx, mt.s, n, NewMemTransport(redundancy))
+	return
+}
+
+// multiNodeWaitTimeout bounds how long the multi node test waits take
+const multiNodeWaitTimeout = time.Second * 5
+
+// knows returns true if a has discovered b, in its world model if it keeps
+// one, otherwise in its routing table
+func knows(a, b *Holochain) bool {
+	if a.world != nil {
+		return a.world.GetNodeRecord(b.nodeID) != nil
+	}
+	return a.node.routingTable.Find(b.nodeID) != ""
+}
+
+// WaitConnected blocks until every node has discovered every other node,
+// returning an error listing the ones that haven't if that takes too long
+func (mt *multiNodeTest) WaitConnected() (err error) {
+	deadline := time.Now().Add(multiNodeWaitTimeout)
+	for {
+		var missing []string
+		for i, a := range mt.nodes {
+			for j, b := range mt.nodes {
+				if i != j && !knows(a, b) {
+					missing = append(missing, fmt.Sprintf("%d->%d", i, j))
+				}
+			}
+		}
+		if len(missing) == 0 {
+			return
+		}
+		if !time.Now().Before(deadline) {
+			err = fmt.Errorf("timed out after %v waiting for nodes to connect, still missing: %s", multiNodeWaitTimeout, strings.Join(missing, " "))
+			return
+		}
+		time.Sleep(WaitForStatusPollInterval)
+	}
+}
+
+// WaitPropagated blocks until every node holds hash live, returning an error
+// listing the ones that don't if that takes too long
+func (mt *multiNodeTest) WaitPropagated(hash Hash) (err error) {
+	deadline := time.Now().Add(multiNodeWaitTimeout)
+	for {
+		var missing []string
+		for i, h := range mt.nodes {
+			if h.dht.Exists(hash, StatusLive) != nil {
+				missing = append(missing, fmt.Sprintf("%d", i))
+			}
+		}
+		if len(missing) == 0 {
+			return
+		}
+		if !time.Now().Before(deadline) {
+			err = fmt.Errorf("timed out after %v waiting for %v to propagate, not held by nodes: %s", multiNodeWaitTimeout, hash, strings.Join(missing, " "))
+			return
+		}
+		time.Sleep(WaitForStatusPollInterval)
+	}
+}
+
 func (mt *multiNodeTest) cleanupMultiNodeTesting() {
 	for i := 0; i < mt.count; i++ {
 		mt.nodes[i].Close()
@@ -134,13 +214,21 @@ func (mt *multiNodeTest) cleanupMultiNodeTesting() {
 	CleanupTestDir(mt.d)
 }
 
-func makeTestNodes(ctx context.Context, s *Service, n int) (nodes []*Holochain) {
+// MakeTestNodes sets up and prepares n test chains on the given service, each
+// with its own node
+func MakeTestNodes(ctx context.Context, s *Service, n int) (nodes []*Holochain) {
+	return makeTestNodes(ctx, s, n, nil)
+}
+
+// makeTestNodes makes n test nodes using the transport, or libp2p if it's nil
+func makeTestNodes(ctx context.Context, s *Service, n int, transport DHTTransport) (nodes []*Holochain) {
 	nodes = make([]*Holochain, n)
 	for i := 0; i < n; i++ {
 		nodeName := fmt.Sprintf("node%d", i)
 		os.Setenv("HCLOG_PREFIX", nodeName+"_")
 		nodes[i] = setupTestChain(nodeName, i, s)
 		nodes[i].Config.EnableMDNS = false
+		nodes[i].Config.DHTTransport = transport
 		prepareTestChain(nodes[i])
 	}
 	for i := 0; i < n; i++ {
@@ -152,23 +240,32 @@ func makeTestNodes(ctx context.Context, s *Service, n int) (nodes []*Holochain)
 }
 
 func connectNoSync(t *testing.T, ctx context.Context, ah, bh *Holochain) {
+	if err := ConnectTestNode(ctx, ah, bh); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var ErrTestNodeNoLocalAddr = errors.New("peers setup incorrectly: no local address")
+
+// ConnectTestNode adds bh as a peer of ah and connects ah's node to it
+func ConnectTestNode(ctx context.Context, ah, bh *Holochain) (err error) {
 	a := ah.node
 	b := bh.node
 	idB := b.HashAddr
 	addrB := b.peerstore.Addrs(idB)
 	if len(addrB) == 0 {
-		t.Fatal("peers setup incorrectly: no local address")
+		err = ErrTestNodeNoLocalAddr
+		return
 	}
 
 	pi := pstore.PeerInfo{ID: idB, Addrs: addrB}
-	err := ah.AddPeer(pi)
+	err = ah.AddPeer(pi)
 	if err != nil {
-		t.Fatal(err)
+		return
 	}
 
-	if err = a.host.Connect(ctx, pi); err != nil {
-		t.Fatal(err)
-	}
+	err = a.host.Connect(ctx, pi)
+	return
 }
 
 func connect(t *testing.T, ctx context.Context, a, b *Holochain) {
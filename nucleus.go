@@ -29,6 +29,33 @@ type DNA struct {
 func (dna *DNA) check() (err error) {
 	if dna.RequiresVersion > Version {
 		err = fmt.Errorf("Chain requires Holochain version %d", dna.RequiresVersion)
+		return
+	}
+	for _, z := range dna.Zomes {
+		for _, d := range z.Entries {
+			if d.Redundancy < 0 {
+				err = fmt.Errorf("%v: %s", ErrEntryRedundancyInvalid, d.Name)
+				return
+			}
+			if d.Redundancy > KValue {
+				err = fmt.Errorf("%v: %s", ErrEntryRedundancyTooHigh, d.Name)
+				return
+			}
+			if d.Name != MigrateEntryType && (d.DataSchema != "" || d.RequireMigrationChain) {
+				err = fmt.Errorf("%v: %s", ErrEntryDefNotMigrate, d.Name)
+				return
+			}
+			if d.Sharing == Encrypted && d.RequireMigrationChain {
+				err = fmt.Errorf("%v: %s", ErrEntryDefEncryptedChain, d.Name)
+				return
+			}
+			if d.DataSchema != "" {
+				if _, err = BuildJSONSchemaValidatorFromString(d.DataSchema); err != nil {
+					err = fmt.Errorf("error building data validator for %s: %v", d.Name, err)
+					return
+				}
+			}
+		}
 	}
 	return
 }
@@ -87,8 +114,15 @@ type AppMsg struct {
 	Body     string
 }
 
-// ActionReceiver handles messages on the action protocol
+// ActionReceiver handles messages on the action protocol.  Puts are rate
+// limited by their sender, which the node has checked is the stream's peer.
+// Puts replayed by gossip don't come through here, as their sender is the
+// original author rather than the gossiper.
 func ActionReceiver(h *Holochain, msg *Message) (response interface{}, err error) {
+	if (msg.Type == PUT_REQUEST || msg.Type == PUTIF_REQUEST) && !h.putLimiter.Allow(msg.From) {
+		err = ErrRateLimited
+		return
+	}
 	return actionReceiver(h, msg, MaxRetries)
 }
 
@@ -130,7 +164,13 @@ func actionReceiver(h *Holochain, msg *Message, retries int) (response interface
 		// N.B. a.Receive calls made to an Action whose values are NOT populated.
 		// The Receive functions understand this and use the values from the message body
 		// TODO, this indicates an architectural error, so fix!
-		response, err = a.Receive(dht, msg)
+		call := &ActionCall{Phase: PhaseReceive, Action: a, Msg: msg}
+		response, err = h.dispatchAction(call, func(h *Holochain, call *ActionCall) (interface{}, error) {
+			if fn, ok := getActionReceiver(a.Name()); ok {
+				return fn(dht, msg)
+			}
+			return a.Receive(dht, msg)
+		})
 	}
 	return
 }
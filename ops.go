@@ -0,0 +1,198 @@
+package holochain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrOpCanceled = errors.New("operation canceled")
+var ErrOpNotFound = errors.New("no pending operation with that id")
+
+// OpInfo describes an outgoing DHT operation that hasn't finished
+type OpInfo struct {
+	ID    string
+	Type  MsgType
+	Key   Hash
+	Peers []peer.ID // the peers it's waiting on, none while it's still queued
+	Age   time.Duration
+}
+
+// pendingOp is an outgoing change or query being tracked so that it can be
+// listed and canceled
+type pendingOp struct {
+	id       string
+	msgType  MsgType
+	key      Hash
+	started  time.Time
+	peers    map[peer.ID]int
+	cancel   context.CancelFunc
+	canceled bool
+	err      error         // the result, set once finished is closed
+	finished chan struct{} // closed when the operation is done
+}
+
+// opTracker holds the DHT's pending operations
+type opTracker struct {
+	next uint64
+	ops  map[string]*pendingOp
+	lk   sync.Mutex
+}
+
+func newOpTracker() *opTracker {
+	return &opTracker{ops: make(map[string]*pendingOp)}
+}
+
+// add starts tracking a new operation
+func (t *opTracker) add(msgType MsgType, key Hash) (op *pendingOp) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	t.next++
+	op = &pendingOp{
+		id:       fmt.Sprintf("%d", t.next),
+		msgType:  msgType,
+		key:      key,
+		started:  time.Now(),
+		peers:    make(map[peer.ID]int),
+		finished: make(chan struct{}),
+	}
+	t.ops[op.id] = op
+	return
+}
+
+// start returns a context for running the operation that is done when ctx is
+// or the operation is canceled, or ErrOpCanceled if it already has been
+func (t *opTracker) start(ctx context.Context, op *pendingOp) (context.Context, context.CancelFunc, error) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	if op.canceled {
+		return nil, nil, ErrOpCanceled
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	op.cancel = cancel
+	return ctx, cancel, nil
+}
+
+// done stops tracking the operation and reports result to anyone waiting on
+// it, returning ErrOpCanceled if it was canceled so that callers return that
+// rather than whatever error the cancellation caused
+func (t *opTracker) done(op *pendingOp, result error) (err error) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	delete(t.ops, op.id)
+	if op.cancel != nil {
+		op.cancel()
+	}
+	if op.canceled {
+		err = ErrOpCanceled
+	}
+	op.err = result
+	if err != nil {
+		op.err = err
+	}
+	close(op.finished)
+	return
+}
+
+// wait waits for the operation to be done and returns its result.  A canceled
+// operation can still be waited on until it's done.
+func (t *opTracker) wait(ctx context.Context, id string) (err error) {
+	t.lk.Lock()
+	op, ok := t.ops[id]
+	t.lk.Unlock()
+	if !ok {
+		err = ErrOpNotFound
+		return
+	}
+	select {
+	case <-op.finished:
+		err = op.err
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	return
+}
+
+// sending records that the operation is waiting on the peer, the returned
+// func records that it no longer is
+func (t *opTracker) sending(op *pendingOp, p peer.ID) func() {
+	if op == nil {
+		return func() {}
+	}
+	t.lk.Lock()
+	op.peers[p]++
+	t.lk.Unlock()
+	return func() {
+		t.lk.Lock()
+		defer t.lk.Unlock()
+		if op.peers[p]--; op.peers[p] <= 0 {
+			delete(op.peers, p)
+		}
+	}
+}
+
+// cancel cancels the operation, which stops it being listed, though it's
+// tracked until it's done so it can still be waited on
+func (t *opTracker) cancel(id string) (err error) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	op, ok := t.ops[id]
+	if !ok || op.canceled {
+		err = ErrOpNotFound
+		return
+	}
+	op.canceled = true
+	if op.cancel != nil {
+		op.cancel()
+	}
+	return
+}
+
+// list returns the operations oldest first
+func (t *opTracker) list(now time.Time) (infos []OpInfo) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	for _, op := range t.ops {
+		if op.canceled {
+			continue
+		}
+		info := OpInfo{ID: op.id, Type: op.msgType, Key: op.key, Age: now.Sub(op.started)}
+		for p := range op.peers {
+			info.Peers = append(info.Peers, p)
+		}
+		sort.Slice(info.Peers, func(i, j int) bool { return info.Peers[i] < info.Peers[j] })
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Age > infos[j].Age })
+	return
+}
+
+// PendingOps returns the outgoing DHT operations that haven't finished, i.e.
+// the PUTs and other changes queued or being sent to peers and the GETs and
+// other queries waiting on peers, oldest first
+func (h *Holochain) PendingOps() []OpInfo {
+	return h.dht.ops.list(time.Now())
+}
+
+// CancelOp cancels a pending operation by its OpInfo.ID, aborting any sends to
+// peers it's waiting on.  A canceled query returns ErrOpCanceled to its
+// caller.  A canceled change, i.e. the PUT of a share, isn't sent to any more
+// peers, but as changes are sent from a queue after the share has returned,
+// use WaitOp to find out that it ended with ErrOpCanceled, e.g. with the id
+// from APIFnMigrate.ShareOp for a migrate.
+func (h *Holochain) CancelOp(id string) error {
+	return h.dht.ops.cancel(id)
+}
+
+// WaitOp waits for a pending operation to be done, returning the error it
+// ended with, ErrOpCanceled if it was canceled, or ctx.Err() if ctx is done
+// first.  It can be called for a canceled operation until the queue gets to
+// it, after that or once an operation is done it returns ErrOpNotFound.
+func (h *Holochain) WaitOp(ctx context.Context, id string) error {
+	return h.dht.ops.wait(ctx, id)
+}
@@ -0,0 +1,115 @@
+package holochain
+
+import (
+	"context"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestPendingOps(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+	r, err := fn.Call(h)
+	if err != nil {
+		panic(err)
+	}
+	hash := r.(Hash)
+
+	find := func() *OpInfo {
+		for _, info := range h.PendingOps() {
+			if info.Key.Equal(hash) {
+				return &info
+			}
+		}
+		return nil
+	}
+
+	Convey("a queued migrate share should be listed", t, func() {
+		info := find()
+		So(info, ShouldNotBeNil)
+		So(info.Type, ShouldEqual, PUT_REQUEST)
+		So(len(info.Peers), ShouldEqual, 0)
+		So(info.Age, ShouldBeGreaterThanOrEqualTo, 0)
+	})
+
+	Convey("the migrate call should return the id of its share", t, func() {
+		So(fn.ShareOp(), ShouldEqual, find().ID)
+	})
+
+	Convey("canceling it should stop it being sent and be reported to a waiter", t, func() {
+		id := fn.ShareOp()
+		So(h.CancelOp(id), ShouldBeNil)
+		So(find(), ShouldBeNil)
+		So(h.CancelOp(id), ShouldEqual, ErrOpNotFound)
+		So(h.CancelOp("bogus"), ShouldEqual, ErrOpNotFound)
+
+		waited := make(chan error, 1)
+		go func() { waited <- h.WaitOp(context.Background(), id) }()
+		for len(h.dht.changeQueue) > 0 {
+			req := (<-h.dht.changeQueue).(changeReq)
+			err := h.dht.change(nil, req)
+			if req.key.Equal(hash) {
+				So(err, ShouldEqual, ErrOpCanceled)
+			}
+		}
+		So(<-waited, ShouldEqual, ErrOpCanceled)
+		So(h.WaitOp(context.Background(), id), ShouldEqual, ErrOpNotFound)
+	})
+}
+
+func TestOpTracker(t *testing.T) {
+	p, _ := peer.IDB58Decode("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
+	hash, _ := NewHash("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
+
+	Convey("it should list the peers an operation is waiting on", t, func() {
+		ops := newOpTracker()
+		op := ops.add(GET_REQUEST, hash)
+		_, _, err := ops.start(context.Background(), op)
+		So(err, ShouldBeNil)
+		sent := ops.sending(op, p)
+		So(ops.list(op.started)[0].Peers, ShouldResemble, []peer.ID{p})
+		sent()
+		So(len(ops.list(op.started)[0].Peers), ShouldEqual, 0)
+		So(ops.done(op, nil), ShouldBeNil)
+		So(len(ops.list(op.started)), ShouldEqual, 0)
+	})
+
+	Convey("canceling an operation in flight should abort its context", t, func() {
+		ops := newOpTracker()
+		op := ops.add(GET_REQUEST, hash)
+		ctx, _, err := ops.start(context.Background(), op)
+		So(err, ShouldBeNil)
+		So(ops.cancel(op.id), ShouldBeNil)
+		<-ctx.Done()
+		So(ops.done(op, ctx.Err()), ShouldEqual, ErrOpCanceled)
+		<-op.finished
+		So(op.err, ShouldEqual, ErrOpCanceled)
+	})
+
+	Convey("waiting on an operation should stop when ctx is done", t, func() {
+		ops := newOpTracker()
+		op := ops.add(PUT_REQUEST, hash)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		So(ops.wait(ctx, op.id), ShouldEqual, context.Canceled)
+		So(ops.done(op, ErrHashNotFound), ShouldBeNil)
+		<-op.finished
+		So(op.err, ShouldEqual, ErrHashNotFound)
+	})
+
+	Convey("canceling an operation before it starts should keep it from starting", t, func() {
+		ops := newOpTracker()
+		op := ops.add(PUT_REQUEST, hash)
+		So(ops.cancel(op.id), ShouldBeNil)
+		_, _, err := ops.start(context.Background(), op)
+		So(err, ShouldEqual, ErrOpCanceled)
+	})
+}
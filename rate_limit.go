@@ -0,0 +1,136 @@
+package holochain
+
+import (
+	"errors"
+	peer "github.com/libp2p/go-libp2p-peer"
+	"sync"
+	"time"
+)
+
+var ErrRateLimited = errors.New("rate limited")
+
+// maxRateLimitedPeers is how many peers' buckets are kept before the ones that
+// have refilled, and so carry no state worth keeping, are pruned
+const maxRateLimitedPeers = 1024
+
+// RateLimiter is a per peer token bucket limiter.  Each peer may make burst
+// requests at once, and after that rate requests per second.
+type RateLimiter struct {
+	rate    float64
+	burst   float64
+	clock   Clock
+	buckets map[peer.ID]*tokenBucket
+	exempt  map[peer.ID]bool
+	lk      sync.Mutex
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing each peer rate requests per second
+// with bursts of up to burst requests.  A burst of less than one means one.
+// A rate of zero or less means no limit, which is a nil limiter.
+func NewRateLimiter(rate float64, burst int) (l *RateLimiter) {
+	if rate <= 0 {
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	l = &RateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		clock:   RealClock{},
+		buckets: make(map[peer.ID]*tokenBucket),
+		exempt:  make(map[peer.ID]bool),
+	}
+	return
+}
+
+// Exempt removes the limit for a peer, i.e. a trusted or bootstrap peer
+func (l *RateLimiter) Exempt(id peer.ID) {
+	if l == nil {
+		return
+	}
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	l.exempt[id] = true
+	delete(l.buckets, id)
+}
+
+// Allow takes a token from the peer's bucket, returning false if it's empty
+// and the request should be refused.  A nil limiter allows everything.
+func (l *RateLimiter) Allow(id peer.ID) bool {
+	if l == nil {
+		return true
+	}
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	if l.exempt[id] {
+		return true
+	}
+	now := l.clock.Now()
+	b, ok := l.buckets[id]
+	if !ok {
+		if len(l.buckets) >= maxRateLimitedPeers {
+			l.prune(now)
+		}
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[id] = b
+	} else {
+		l.refill(b, now)
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// refill adds the tokens earned since the bucket was last looked at
+func (l *RateLimiter) refill(b *tokenBucket, now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * l.rate
+		if b.tokens > l.burst {
+			b.tokens = l.burst
+		}
+		b.last = now
+	}
+}
+
+// prune drops the buckets that have refilled, assumes the lock is held
+func (l *RateLimiter) prune(now time.Time) {
+	for id, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= l.burst {
+			delete(l.buckets, id)
+		}
+	}
+}
+
+// PutRateLimiter returns the limiter applied to the PUT_REQUESTs and
+// PUTIF_REQUESTs this node receives, nil if they aren't limited
+func (h *Holochain) PutRateLimiter() *RateLimiter {
+	return h.putLimiter
+}
+
+// makePutRateLimiter sets up the PUT_REQUEST limiter from the config,
+// exempting ourselves and any configured peers
+func (h *Holochain) makePutRateLimiter() (err error) {
+	h.putLimiter = NewRateLimiter(h.Config.PutRateLimit, h.Config.PutRateBurst)
+	if h.putLimiter == nil {
+		return
+	}
+	h.putLimiter.Exempt(h.nodeID)
+	for _, s := range h.Config.PutRateExemptPeers {
+		var id peer.ID
+		id, err = peer.IDB58Decode(s)
+		if err != nil {
+			return
+		}
+		h.putLimiter.Exempt(id)
+	}
+	return
+}
@@ -0,0 +1,128 @@
+package holochain
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+// stoppedClock is a clock that only moves when told to
+type stoppedClock struct {
+	now time.Time
+}
+
+func (c *stoppedClock) Now() time.Time {
+	return c.now
+}
+
+func TestRateLimiter(t *testing.T) {
+	clock := &stoppedClock{now: time.Unix(1, 0)}
+	p1, _ := makePeer("peer1")
+	p2, _ := makePeer("peer2")
+
+	Convey("a zero rate should mean no limit", t, func() {
+		l := NewRateLimiter(0, 10)
+		So(l, ShouldBeNil)
+		for i := 0; i < 100; i++ {
+			So(l.Allow(p1), ShouldBeTrue)
+		}
+	})
+
+	Convey("it should allow a burst and then refill at the rate", t, func() {
+		l := NewRateLimiter(2, 3)
+		l.clock = clock
+		for i := 0; i < 3; i++ {
+			So(l.Allow(p1), ShouldBeTrue)
+		}
+		So(l.Allow(p1), ShouldBeFalse)
+		So(l.Allow(p2), ShouldBeTrue)
+
+		clock.now = clock.now.Add(500 * time.Millisecond)
+		So(l.Allow(p1), ShouldBeTrue)
+		So(l.Allow(p1), ShouldBeFalse)
+
+		// tokens don't accumulate past the burst
+		clock.now = clock.now.Add(time.Hour)
+		for i := 0; i < 3; i++ {
+			So(l.Allow(p1), ShouldBeTrue)
+		}
+		So(l.Allow(p1), ShouldBeFalse)
+	})
+
+	Convey("exempt peers should not be limited", t, func() {
+		l := NewRateLimiter(1, 1)
+		l.clock = clock
+		l.Exempt(p1)
+		for i := 0; i < 10; i++ {
+			So(l.Allow(p1), ShouldBeTrue)
+		}
+	})
+}
+
+func TestPutRateLimiting(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	trusted, _ := makePeer("trusted")
+	h.Config.PutRateLimit = 1
+	h.Config.PutRateBurst = 5
+	h.Config.PutRateExemptPeers = []string{trusted.Pretty()}
+	defer func() { h.Config.PutRateLimit = 0 }()
+	if err := h.makePutRateLimiter(); err != nil {
+		panic(err)
+	}
+	defer func() { h.putLimiter = nil }()
+	h.PutRateLimiter().clock = &stoppedClock{now: time.Unix(1, 0)}
+
+	hash := commit(h, "evenNumbers", "2")
+	flood, _ := makePeer("flood")
+
+	put := func(from string) (err error) {
+		m := h.node.NewMessage(PUT_REQUEST, HoldReq{EntryHash: hash})
+		switch from {
+		case "flood":
+			m.From = flood
+		case "trusted":
+			m.From = trusted
+		}
+		_, err = ActionReceiver(h, m)
+		return
+	}
+
+	Convey("a peer flooding us with puts should be throttled after its burst", t, func() {
+		var limited int
+		for i := 0; i < 20; i++ {
+			if err := put("flood"); err == ErrRateLimited {
+				limited++
+			} else {
+				So(err, ShouldBeNil)
+			}
+		}
+		So(limited, ShouldEqual, 15)
+	})
+
+	Convey("conditional puts from the peer should be limited too", t, func() {
+		m := h.node.NewMessage(PUTIF_REQUEST, PutIfReq{H: hash, ExpectedStatus: StatusDefault})
+		m.From = flood
+		_, err := ActionReceiver(h, m)
+		So(err, ShouldEqual, ErrRateLimited)
+	})
+
+	Convey("other messages from the peer should not be limited", t, func() {
+		m := h.node.NewMessage(GET_REQUEST, GetReq{H: hash, StatusMask: StatusLive, GetMask: GetMaskEntry})
+		m.From = flood
+		_, err := ActionReceiver(h, m)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("ourselves and trusted peers should not be limited", t, func() {
+		for i := 0; i < 20; i++ {
+			So(put("self"), ShouldBeNil)
+			So(put("trusted"), ShouldBeNil)
+		}
+	})
+
+	Convey("the limit should survive the trip back to the sender", t, func() {
+		So(NewErrorResponse(ErrRateLimited).DecodeResponseError(), ShouldEqual, ErrRateLimited)
+	})
+}
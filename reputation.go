@@ -0,0 +1,118 @@
+package holochain
+
+import (
+	"errors"
+	peer "github.com/libp2p/go-libp2p-peer"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultReputationThreshold is how many validation failures it takes to
+	// block a peer by default
+	DefaultReputationThreshold = 10
+
+	// DefaultReputationDecay is how long a validation failure counts against
+	// a peer by default
+	DefaultReputationDecay = time.Hour
+)
+
+var ErrSourceBlocked = errors.New("source is blocked")
+
+// Reputation tracks the validation failures of what each peer sends us
+type Reputation struct {
+	threshold int
+	decay     time.Duration
+	failures  map[peer.ID][]time.Time
+	lk        sync.Mutex
+}
+
+// NewReputation returns a tracker for which a peer has a bad reputation once
+// it has failed validation threshold times within the decay.  A threshold of 0
+// means DefaultReputationThreshold and a negative one is never reached, a
+// decay of 0 means DefaultReputationDecay.
+func NewReputation(threshold int, decay time.Duration) *Reputation {
+	if threshold == 0 {
+		threshold = DefaultReputationThreshold
+	}
+	if decay <= 0 {
+		decay = DefaultReputationDecay
+	}
+	return &Reputation{threshold: threshold, decay: decay, failures: make(map[peer.ID][]time.Time)}
+}
+
+// Fail records a validation failure by the peer, returning true if the peer
+// has now reached the threshold
+func (r *Reputation) Fail(id peer.ID, now time.Time) (reached bool) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	failures := append(r.current(id, now), now)
+	r.failures[id] = failures
+	reached = r.threshold > 0 && len(failures) >= r.threshold
+	return
+}
+
+// Failures returns the number of validation failures counting against the peer
+func (r *Reputation) Failures(id peer.ID, now time.Time) int {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	return len(r.current(id, now))
+}
+
+// Forget clears the peer's validation failures
+func (r *Reputation) Forget(id peer.ID) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	delete(r.failures, id)
+}
+
+// current drops the peer's failures that have decayed and returns the rest
+func (r *Reputation) current(id peer.ID, now time.Time) (failures []time.Time) {
+	failures = r.failures[id]
+	for len(failures) > 0 && !failures[0].After(now.Add(-r.decay)) {
+		failures = failures[1:]
+	}
+	if len(failures) == 0 {
+		delete(r.failures, id)
+	}
+	return
+}
+
+// BlockPeer blocks the peer, actions whose only source it is are refused with
+// ErrSourceBlocked and we stop talking to it
+func (h *Holochain) BlockPeer(id peer.ID) {
+	h.node.Block(id)
+	h.dht.DeleteGossiper(id) // ignore error
+}
+
+// UnblockPeer unblocks the peer, clearing its validation failures
+func (h *Holochain) UnblockPeer(id peer.ID) {
+	h.node.Unblock(id)
+	if h.reputation != nil {
+		h.reputation.Forget(id)
+	}
+}
+
+// IsBlocked returns true if the peer is blocked
+func (h *Holochain) IsBlocked(id peer.ID) bool {
+	return h.node.IsBlocked(id)
+}
+
+// recordValidationFailure counts a validation failure against the sources of
+// what failed, blocking any that reach the reputation threshold.  We never
+// count against ourselves.
+func (h *Holochain) recordValidationFailure(sources []peer.ID) {
+	if h.reputation == nil {
+		return
+	}
+	now := h.Now()
+	for _, id := range sources {
+		if id == h.nodeID || id == "" {
+			continue
+		}
+		if h.reputation.Fail(id, now) && !h.IsBlocked(id) {
+			h.dht.dlog.Logf("blocking %v after repeated validation failures", id)
+			h.BlockPeer(id)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package holochain
+
+import (
+	peer "github.com/libp2p/go-libp2p-peer"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestReputation(t *testing.T) {
+	id, _ := peer.IDB58Decode("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
+	now := time.Unix(1000, 0)
+
+	Convey("it should default the threshold and decay", t, func() {
+		r := NewReputation(0, 0)
+		So(r.threshold, ShouldEqual, DefaultReputationThreshold)
+		So(r.decay, ShouldEqual, DefaultReputationDecay)
+	})
+
+	Convey("it should report reaching the threshold", t, func() {
+		r := NewReputation(3, time.Minute)
+		So(r.Fail(id, now), ShouldBeFalse)
+		So(r.Fail(id, now), ShouldBeFalse)
+		So(r.Fail(id, now), ShouldBeTrue)
+		So(r.Failures(id, now), ShouldEqual, 3)
+		r.Forget(id)
+		So(r.Failures(id, now), ShouldEqual, 0)
+	})
+
+	Convey("failures should decay", t, func() {
+		r := NewReputation(2, time.Minute)
+		So(r.Fail(id, now), ShouldBeFalse)
+		later := now.Add(2 * time.Minute)
+		So(r.Failures(id, later), ShouldEqual, 0)
+		So(r.Fail(id, later), ShouldBeFalse)
+	})
+
+	Convey("a negative threshold should never be reached", t, func() {
+		r := NewReputation(-1, time.Minute)
+		for i := 0; i < 20; i++ {
+			So(r.Fail(id, now), ShouldBeFalse)
+		}
+	})
+}
+
+func TestBlockingInvalidSources(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+	h.reputation = NewReputation(3, time.Minute)
+
+	bad, _ := peer.IDB58Decode("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
+	invalid := func() error {
+		// a migrate without a header never passes system validation
+		_, err := h.ValidateAction(&ActionMigrate{}, MigrateEntryType, nil, []peer.ID{bad})
+		return err
+	}
+
+	Convey("a peer should be blocked after repeatedly sending invalid entries", t, func() {
+		So(invalid(), ShouldEqual, ErrActionMissingHeader)
+		So(invalid(), ShouldEqual, ErrActionMissingHeader)
+		So(h.IsBlocked(bad), ShouldBeFalse)
+		So(invalid(), ShouldEqual, ErrActionMissingHeader)
+		So(h.IsBlocked(bad), ShouldBeTrue)
+	})
+
+	Convey("actions from a blocked peer should be refused early", t, func() {
+		So(invalid(), ShouldEqual, ErrSourceBlocked)
+		header, err := GenTestHeader()
+		So(err, ShouldBeNil)
+		_, err = h.ValidateAction(&ActionMigrate{header: header}, MigrateEntryType, nil, []peer.ID{bad})
+		So(err, ShouldEqual, ErrSourceBlocked)
+	})
+
+	Convey("unblocking should clear the peer's record", t, func() {
+		h.UnblockPeer(bad)
+		So(h.IsBlocked(bad), ShouldBeFalse)
+		So(h.reputation.Failures(bad, h.Now()), ShouldEqual, 0)
+		So(invalid(), ShouldEqual, ErrActionMissingHeader)
+		So(h.IsBlocked(bad), ShouldBeFalse)
+	})
+
+	Convey("failing to validate an action shouldn't count against its source", t, func() {
+		before := h.reputation.Failures(bad, h.Now())
+		_, err := h.ValidateAction(&ActionMigrate{}, "bogusType", nil, []peer.ID{bad})
+		So(err, ShouldNotBeNil)
+		So(h.reputation.Failures(bad, h.Now()), ShouldEqual, before)
+
+		So(isAppRejection(ValidationFailed("bad data")), ShouldBeTrue)
+		So(isAppRejection(ErrValidationTimeout), ShouldBeFalse)
+		So(isAppRejection(&ValidationError{Reason: ErrValidationTimeout.Error(), Underlying: ErrValidationTimeout}), ShouldBeFalse)
+	})
+
+	Convey("a put replayed by gossip shouldn't count against its author", t, func() {
+		before := h.reputation.Failures(bad, h.Now())
+		msg := &Message{Type: PUT_REQUEST, From: bad, replayed: true}
+		_, err := h.dht.validateReceived(msg, &ActionMigrate{}, MigrateEntryType, nil)
+		So(err, ShouldEqual, ErrActionMissingHeader)
+		So(h.reputation.Failures(bad, h.Now()), ShouldEqual, before)
+
+		msg.replayed = false
+		_, err = h.dht.validateReceived(msg, &ActionMigrate{}, MigrateEntryType, nil)
+		So(err, ShouldEqual, ErrActionMissingHeader)
+		So(h.reputation.Failures(bad, h.Now()), ShouldEqual, before+1)
+	})
+
+	Convey("our own failures should never count against us", t, func() {
+		for i := 0; i < 5; i++ {
+			h.ValidateAction(&ActionMigrate{}, MigrateEntryType, nil, []peer.ID{h.nodeID})
+		}
+		So(h.IsBlocked(h.nodeID), ShouldBeFalse)
+	})
+}
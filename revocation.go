@@ -9,7 +9,9 @@ package holochain
 import (
 	"encoding/json"
 	"errors"
+	. "github.com/holochain/holochain-proto/hash"
 	ic "github.com/libp2p/go-libp2p-crypto"
+	"time"
 )
 
 type Revocation interface {
@@ -116,3 +118,98 @@ func (r *SelfRevocation) Verify() (err error) {
 	}
 	return
 }
+
+// KeyRevocation returns the revocation of the key with the given hash, i.e. an
+// agent's node ID, or nil if the key hasn't been revoked.  Our own chain is
+// checked first and then the DHT, where a revoked key is modified by its
+// revocation.
+func (h *Holochain) KeyRevocation(key Hash) (revocation *RevocationEntry, err error) {
+	if h.chain != nil {
+		err = h.chain.IterEntriesByType(RevocationEntryType, func(header *Header, entry Entry) (e error) {
+			var r RevocationEntry
+			r, e = RevocationEntryFromJSON(entry.Content().(string))
+			if e != nil {
+				return
+			}
+			var oldKey Hash
+			oldKey, e = r.OldKeyHash()
+			if e == nil && oldKey.Equal(key) {
+				revocation = &r
+				e = ErrStopIteration
+			}
+			return
+		})
+		if err != nil || revocation != nil {
+			return
+		}
+	}
+	req := GetReq{H: key, StatusMask: StatusDefault, GetMask: GetMaskEntry | GetMaskEntryType}
+	var r interface{}
+	r, err = callGet(h, req, &GetOptions{StatusMask: req.StatusMask, GetMask: req.GetMask})
+	if err == ErrHashNotFound {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	resp := r.(GetResp)
+	if resp.EntryType != RevocationEntryType {
+		return
+	}
+	var found RevocationEntry
+	found, err = RevocationEntryFromJSON(resp.Entry.C.(string))
+	if err != nil {
+		return
+	}
+	// only the holder of the key can revoke it
+	if err = found.Verify(PeerIDFromHash(key)); err != nil {
+		return
+	}
+	revocation = &found
+	return
+}
+
+// KeyTrustCacheTTL is how long a key found not to be revoked is trusted
+// before its revocation is looked up again, revoked keys stay revoked
+const KeyTrustCacheTTL = time.Minute
+
+type keyTrust struct {
+	revoked bool
+	checked time.Time
+}
+
+// setKeyTrust caches whether the key with the given hash has been revoked
+func (h *Holochain) setKeyTrust(key Hash, revoked bool) {
+	h.keyTrustLk.Lock()
+	defer h.keyTrustLk.Unlock()
+	if h.keyTrust == nil {
+		h.keyTrust = make(map[Hash]keyTrust)
+	}
+	h.keyTrust[key] = keyTrust{revoked: revoked, checked: time.Now()}
+}
+
+// checkKeyTrusted returns ErrKeyRevoked if the public key has been revoked.
+// Lookups are cached so verifying many signatures by the same key doesn't
+// get its revocation from the DHT each time.
+func (h *Holochain) checkKeyTrusted(pubKey ic.PubKey) (err error) {
+	var key Hash
+	if key, err = keyHash(pubKey); err != nil {
+		return
+	}
+	h.keyTrustLk.Lock()
+	t, cached := h.keyTrust[key]
+	h.keyTrustLk.Unlock()
+	if !cached || !t.revoked && time.Since(t.checked) >= KeyTrustCacheTTL {
+		var revocation *RevocationEntry
+		if revocation, err = h.KeyRevocation(key); err != nil {
+			return
+		}
+		t.revoked = revocation != nil
+		h.setKeyTrust(key, t.revoked)
+	}
+	if t.revoked {
+		err = ErrKeyRevoked
+	}
+	return
+}
@@ -55,12 +55,37 @@ const (
 
 var ValidationFailedErr = errors.New(ValidationFailedErrMsg)
 
+// ValidationError holds the details of a validation failure
+type ValidationError struct {
+	Field      string // name of the entry field that failed, if any
+	Reason     string
+	Underlying error // error that caused the failure, if any
+}
+
+// Error returns the validation failure message
+func (e *ValidationError) Error() string {
+	if e.Reason == "" {
+		return ValidationFailedErrMsg
+	}
+	return ValidationFailedErrMsg + ": " + e.Reason
+}
+
+// Unwrap returns the error that caused the validation failure
+func (e *ValidationError) Unwrap() error {
+	return e.Underlying
+}
+
 // ValidationFailed creates a validation failed error message
 func ValidationFailed(msgs ...string) error {
 	if len(msgs) == 0 {
 		return ValidationFailedErr
 	}
-	return errors.New(ValidationFailedErrMsg + ": " + strings.Join(msgs, `, `))
+	return &ValidationError{Reason: strings.Join(msgs, `, `)}
+}
+
+// validationFieldFailed creates a validation failed error for a field of an entry
+func validationFieldFailed(field string, reason string, underlying error) error {
+	return &ValidationError{Field: field, Reason: reason, Underlying: underlying}
 }
 
 func IsValidationFailedErr(err error) bool {
@@ -94,6 +119,7 @@ type Ribosome interface {
 	Run(code string) (result interface{}, err error)
 	RunAsyncSendResponse(response AppMsg, callback string, callbackID string) (result interface{}, err error)
 	BundleCanceled(reason string) (response string, err error)
+	ReceiveSignal(signal Signal) (err error)
 }
 
 var ribosomeFactories = make(map[string]RibosomeFactory)
@@ -1,6 +1,7 @@
 package holochain
 
 import (
+	"errors"
 	"fmt"
 	. "github.com/smartystreets/goconvey/convey"
 	"testing"
@@ -44,5 +45,21 @@ func TestValidationFailedErr(t *testing.T) {
 		So(IsValidationFailedErr(ValidationFailed()), ShouldBeTrue)
 		So(IsValidationFailedErr(ErrHashNotFound), ShouldBeFalse)
 	})
+	Convey("it should build structured validation errors", t, func() {
+		underlying := errors.New("bad value")
+		err := validationFieldFailed("Key", "Error (bad value) when decoding Key value 'x'", underlying)
+		So(err.Error(), ShouldEqual, ValidationFailedErrMsg+": Error (bad value) when decoding Key value 'x'")
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+		So(errors.Is(err, underlying), ShouldBeTrue)
+
+		var validationErr *ValidationError
+		So(errors.As(err, &validationErr), ShouldBeTrue)
+		So(validationErr.Field, ShouldEqual, "Key")
+		So(validationErr.Underlying, ShouldEqual, underlying)
+
+		So(errors.As(ValidationFailed("just because"), &validationErr), ShouldBeTrue)
+		So(validationErr.Field, ShouldEqual, "")
+		So(validationErr.Reason, ShouldEqual, "just because")
+	})
 
 }
@@ -38,10 +38,12 @@ const (
 	SysFileName          string = "system.conf" // Server & System settings
 	AgentFileName        string = "agent.txt"   // User ID info
 	PrivKeyFileName      string = "priv.key"    // Signing key - private
+	NextKeyFileName      string = "next.key"    // Signing key revokeKey switches to - private
 	StoreFileName        string = "chain.db"    // Filename for local data store
 	DNAHashFileName      string = "dna.hash"    // Filename for storing the hash of the holochain
 	DHTStoreFileName     string = "dht.db"      // Filname for storing the dht
 	BridgeDBFileName     string = "bridge.db"   // Filname for storing bridge keys
+	AuditLogFileName     string = "audit.log"   // Filename for the action audit log
 
 	TestConfigFileName string = "_config.json"
 
@@ -91,6 +93,13 @@ type EntryDefFile struct {
 	Schema     string
 	SchemaFile string // file name of schema or language schema directive
 	Sharing    string
+	Format     string // serialization of the entries, gob if empty
+	Redundancy int    // peers a put is sent to, the DHT's default if 0
+
+	// for the migrate entry type only
+	DataSchema            string // schema the Data of migrates must conform to
+	DataSchemaFile        string // file name of the Data schema
+	RequireMigrationChain bool   // opens must continue a close
 }
 
 type ZomeFile struct {
@@ -176,6 +185,12 @@ func Init(root string, identity AgentIdentity, seed io.Reader) (service *Service
 	if MigrateEntryDef.validator == nil {
 		err = MigrateEntryDef.BuildJSONSchemaValidatorFromString(MigrateEntryDef.Schema)
 	}
+	if MigrateRollbackEntryDef.validator == nil {
+		err = MigrateRollbackEntryDef.BuildJSONSchemaValidatorFromString(MigrateRollbackEntryDef.Schema)
+	}
+	if RevocationEntryDef.validator == nil {
+		err = RevocationEntryDef.BuildJSONSchemaValidatorFromString(RevocationEntryDef.Schema)
+	}
 	if err != nil {
 		return
 	}
@@ -427,6 +442,21 @@ func (s *Service) loadDNA(path string, filename string, format string) (dnaP *DN
 			dna.Zomes[i].Entries[j].DataFormat = entry.DataFormat
 			dna.Zomes[i].Entries[j].Sharing = entry.Sharing
 			dna.Zomes[i].Entries[j].Schema = entry.Schema
+			if err = checkEntryEncoding(entry.Format); err != nil {
+				return nil, err
+			}
+			dna.Zomes[i].Entries[j].Format = entry.Format
+			dna.Zomes[i].Entries[j].Redundancy = entry.Redundancy
+			dna.Zomes[i].Entries[j].RequireMigrationChain = entry.RequireMigrationChain
+			dna.Zomes[i].Entries[j].DataSchema = entry.DataSchema
+			if entry.DataSchema == "" && entry.DataSchemaFile != "" {
+				var schema []byte
+				schema, err = ReadFile(zomePath, entry.DataSchemaFile)
+				if err != nil {
+					return
+				}
+				dna.Zomes[i].Entries[j].DataSchema = string(schema)
+			}
 			if entry.Schema == "" && entry.SchemaFile != "" {
 				schemaFilePath := filepath.Join(zomePath, entry.SchemaFile)
 				if !FileExists(schemaFilePath) {
@@ -582,12 +612,13 @@ func suffixByRibosomeType(ribosomeType string) (suffix string) {
 
 func _makeConfig(s *Service) (config Config, err error) {
 	config = Config{
-		DHTPort:         DefaultDHTPort,
-		PeerModeDHTNode: s.Settings.DefaultPeerModeDHTNode,
-		PeerModeAuthor:  s.Settings.DefaultPeerModeAuthor,
-		BootstrapServer: s.Settings.DefaultBootstrapServer,
-		EnableNATUPnP:   s.Settings.DefaultEnableNATUPnP,
-		EnableMDNS:      s.Settings.DefaultEnableMDNS,
+		DHTPort:             DefaultDHTPort,
+		PeerModeDHTNode:     s.Settings.DefaultPeerModeDHTNode,
+		PeerModeAuthor:      s.Settings.DefaultPeerModeAuthor,
+		BootstrapServer:     s.Settings.DefaultBootstrapServer,
+		EnableNATUPnP:       s.Settings.DefaultEnableNATUPnP,
+		EnableMDNS:          s.Settings.DefaultEnableMDNS,
+		ValidationCacheSize: DefaultValidationCacheSize,
 		Loggers: Loggers{
 			Debug:      Logger{Name: "Debug", Format: "HC: %{file}.%{line}: %{message}", Enabled: false},
 			App:        Logger{Name: "App", Format: "%{color:cyan}%{message}", Enabled: false},
@@ -855,7 +886,7 @@ func DNAHashofUngenedChain(h *Holochain) (DNAHash Hash, err error) {
 	}
 
 	var dnaHeader *Header
-	_, dnaHeader, err = newHeader(h.hashSpec, time.Now(), DNAEntryType, &e, h.agent.PrivKey(), NullHash(), NullHash(), NullHash())
+	_, dnaHeader, err = newHeader(h.hashSpec, h.Now(), DNAEntryType, &e, h.agent.PrivKey(), NullHash(), NullHash(), NullHash())
 	if err != nil {
 		return
 	}
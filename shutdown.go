@@ -0,0 +1,117 @@
+package holochain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ShutdownPollInterval = time.Millisecond * 10
+)
+
+var ErrShuttingDown = errors.New("holochain is shutting down")
+
+// ShutdownError reports the shares that couldn't be flushed before the
+// shutdown's context was done
+type ShutdownError struct {
+	Pending    int
+	Underlying error
+}
+
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("shutdown with %d shares still pending: %v", e.Pending, e.Underlying)
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Underlying
+}
+
+func (h *Holochain) isShuttingDown() bool {
+	return atomic.LoadInt32(&h.shuttingDown) == 1
+}
+
+// closingCh returns a channel that's closed when the holochain is closed, for
+// the go routines that watch for something in the background until then
+func (h *Holochain) closingCh() <-chan struct{} {
+	h.closingLk.Lock()
+	defer h.closingLk.Unlock()
+	if h.closing == nil {
+		h.closing = make(chan struct{})
+	}
+	return h.closing
+}
+
+// signalClosing closes the channel closingCh returned, a later closingCh
+// returns a new one as the holochain may be prepared again
+func (h *Holochain) signalClosing() {
+	h.closingLk.Lock()
+	defer h.closingLk.Unlock()
+	if h.closing != nil {
+		close(h.closing)
+		h.closing = nil
+	}
+}
+
+// Shutdown stops the holochain gracefully.  It stops accepting commits, sends
+// any queued shares to their responsible peers, and then closes the chain,
+// the DHT and the node.  If ctx is done before all the shares are sent, it
+// still closes everything but returns a *ShutdownError with the number left.
+func (h *Holochain) Shutdown(ctx context.Context) (err error) {
+	if !atomic.CompareAndSwapInt32(&h.shuttingDown, 0, 1) {
+		err = ErrShuttingDown
+		return
+	}
+	if h.dht != nil && h.node != nil {
+		if pending, e := h.dht.flushChanges(ctx); e != nil {
+			err = &ShutdownError{Pending: pending, Underlying: e}
+		}
+	}
+	h.Close()
+	return
+}
+
+// PendingChanges returns the number of changes still to be sent to peers
+func (dht *DHT) PendingChanges() int {
+	return len(dht.changeQueue) + int(atomic.LoadInt32(&dht.changesInFlight))
+}
+
+// flushChanges sends the queued changes until there are none pending, along
+// with the background change handler if it's running.  If ctx is done first
+// it returns the number still pending.
+func (dht *DHT) flushChanges(ctx context.Context) (pending int, err error) {
+	ctx, cancel := dht.withNodeContext(ctx)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			pending = dht.PendingChanges()
+			err = ctx.Err()
+			return
+		case x := <-dht.changeQueue:
+			atomic.AddInt32(&dht.changesInFlight, 1)
+			e := dht.change(ctx, x.(changeReq))
+			atomic.AddInt32(&dht.changesInFlight, -1)
+			if ctx.Err() != nil {
+				// cut short, so it may not have reached all its peers
+				pending = dht.PendingChanges() + 1
+				err = ctx.Err()
+				return
+			}
+			if e != nil {
+				dht.dlog.Logf("Shutdown: change got err: %v", e)
+			}
+		default:
+			if dht.PendingChanges() == 0 {
+				return
+			}
+			// the background handler is still sending some
+			select {
+			case <-ctx.Done():
+			case <-time.After(ShutdownPollInterval):
+			}
+		}
+	}
+}
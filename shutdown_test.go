@@ -0,0 +1,53 @@
+package holochain
+
+import (
+	"context"
+	"errors"
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should flush the queued shares and close the node", t, func() {
+		commit(h, "evenNumbers", "2")
+		So(h.dht.PendingChanges(), ShouldBeGreaterThan, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		So(h.Shutdown(ctx), ShouldBeNil)
+		So(h.node, ShouldBeNil)
+		So(h.dht, ShouldBeNil)
+	})
+
+	Convey("it should refuse commits and a second shutdown", t, func() {
+		_, err := h.commitAndShare(NewCommitAction("evenNumbers", &GobEntry{C: "4"}), NullHash())
+		So(err, ShouldEqual, ErrShuttingDown)
+		So(h.Shutdown(context.Background()), ShouldEqual, ErrShuttingDown)
+	})
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should report the shares it couldn't flush", t, func() {
+		commit(h, "evenNumbers", "2")
+		commit(h, "evenNumbers", "4")
+		pending := h.dht.PendingChanges()
+		So(pending, ShouldBeGreaterThan, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := h.Shutdown(ctx)
+		var shutdownErr *ShutdownError
+		So(errors.As(err, &shutdownErr), ShouldBeTrue)
+		So(shutdownErr.Pending, ShouldEqual, pending)
+		So(errors.Is(err, context.Canceled), ShouldBeTrue)
+		So(h.node, ShouldBeNil)
+	})
+}
@@ -0,0 +1,85 @@
+package holochain
+
+// Signal holds the name and body of a notification about an asynchronous event
+type Signal struct {
+	Name string
+	Body interface{}
+}
+
+// SignalHandler is the type of the functions that receive signals
+type SignalHandler func(signal Signal)
+
+const (
+	SignalMigrateConfirmed = "migrateConfirmed"
+	SignalMigrateTimeout   = "migrateTimeout"
+)
+
+// AddSignalHandler registers a function to be called for every signal the holochain emits
+func (h *Holochain) AddSignalHandler(fn SignalHandler) {
+	h.signalLk.Lock()
+	defer h.signalLk.Unlock()
+	h.signalHandlers = append(h.signalHandlers, fn)
+}
+
+// Signal delivers a signal to all the registered signal handlers and to the
+// zomes that define a receiveSignal function, straight away unless a signal
+// queue has been set, see SetSignalQueue.  Signal handlers should use
+// TrySignal instead.
+func (h *Holochain) Signal(name string, body interface{}) {
+	h.signal(name, body, true)
+}
+
+// TrySignal is Signal for signal handlers, which can't wait on the signal
+// queue they're being called from: with SignalBlock a signal that finds the
+// queue full is dropped rather than waiting for room.  It returns false if a
+// signal was dropped.
+func (h *Holochain) TrySignal(name string, body interface{}) (queued bool) {
+	return h.signal(name, body, false)
+}
+
+func (h *Holochain) signal(name string, body interface{}, wait bool) (queued bool) {
+	h.Debugf("signaling %s: %v", name, body)
+	signal := Signal{Name: name, Body: body}
+
+	if q := h.acquireSignalQueue(); q != nil {
+		queued = q.enqueue(signal, wait)
+		if !queued {
+			h.Debugf("signal queue full, dropped a %s signal", name)
+		}
+		return
+	}
+	h.deliverSignal(signal)
+	queued = true
+	return
+}
+
+// deliverSignal calls all the registered signal handlers with the signal, and
+// then the zomes' receiveSignal callbacks
+func (h *Holochain) deliverSignal(signal Signal) {
+	h.signalLk.RLock()
+	handlers := make([]SignalHandler, len(h.signalHandlers))
+	copy(handlers, h.signalHandlers)
+	h.signalLk.RUnlock()
+
+	for _, fn := range handlers {
+		fn(signal)
+	}
+	h.signalZomes(signal)
+}
+
+// signalZomes calls the receiveSignal callback of each zome that has one
+func (h *Holochain) signalZomes(signal Signal) {
+	if h.nucleus == nil {
+		return
+	}
+	for _, zome := range h.nucleus.dna.Zomes {
+		r, _, err := h.MakeRibosome(zome.Name)
+		if err != nil {
+			h.Debugf("error making ribosome for %s signal: %v", zome.Name, err)
+			continue
+		}
+		if err = r.ReceiveSignal(signal); err != nil {
+			h.Debugf("error in %s.receiveSignal(): %v", zome.Name, err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package holochain
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// SignalPolicy says what happens to a signal emitted when the signal queue is full
+type SignalPolicy int
+
+const (
+	SignalBlock      SignalPolicy = iota // wait for the handlers to make room
+	SignalDropOldest                     // drop the longest queued signal to make room
+	SignalDropNewest                     // drop the signal being emitted
+)
+
+// DefaultSignalQueueSize is the number of signals queued for the handlers if
+// SetSignalQueue isn't given a size
+const DefaultSignalQueueSize = 100
+
+var ErrSignalPolicyUnknown = errors.New("unknown signal policy")
+
+// signalQueue holds the signals waiting for a go routine that delivers them
+// to the handlers
+type signalQueue struct {
+	dropped int64 // first so it's aligned for atomic access on 32 bit platforms
+	policy  SignalPolicy
+	queue   chan Signal
+	done    chan struct{}
+	senders sync.WaitGroup // the signalers that got the queue and may still send to it
+	lk      sync.Mutex     // serializes making room in the queue
+}
+
+// SetSignalQueue makes the signals be delivered to the handlers from a queue
+// of size signals, so that a slow handler doesn't hold up whatever emitted
+// them, i.e. the migrate confirmation watcher, and a storm of signals can't
+// grow without bound.  The policy says what happens when the queue is full,
+// signals that are dropped are counted in DroppedSignals.  With SignalBlock a
+// handler that emits signals must do so with TrySignal, as with Signal it
+// would wait for itself to make room if the queue is full.  A size of 0 means
+// DefaultSignalQueueSize.  Signals already queued are delivered before the new
+// queue takes over.  It mustn't be called from a handler.
+func (h *Holochain) SetSignalQueue(size int, policy SignalPolicy) (err error) {
+	switch policy {
+	case SignalBlock, SignalDropOldest, SignalDropNewest:
+	default:
+		err = ErrSignalPolicyUnknown
+		return
+	}
+	if size <= 0 {
+		size = DefaultSignalQueueSize
+	}
+	q := &signalQueue{policy: policy, queue: make(chan Signal, size), done: make(chan struct{})}
+
+	h.signalQueueLk.Lock()
+	old := h.signalQueue
+	h.signalQueue = q
+	h.signalQueueLk.Unlock()
+
+	go func() {
+		defer close(q.done)
+		if old != nil {
+			<-old.done
+		}
+		for signal := range q.queue {
+			h.deliverSignal(signal)
+		}
+	}()
+	if old != nil {
+		old.stop()
+	}
+	return
+}
+
+// DroppedSignals returns the number of signals the signal queue dropped
+// because it was full
+func (h *Holochain) DroppedSignals() int64 {
+	h.signalQueueLk.RLock()
+	defer h.signalQueueLk.RUnlock()
+	if h.signalQueue == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&h.signalQueue.dropped)
+}
+
+// stopSignalQueue goes back to delivering signals straight away once the
+// ones queued have been delivered
+func (h *Holochain) stopSignalQueue() {
+	h.signalQueueLk.Lock()
+	q := h.signalQueue
+	h.signalQueue = nil
+	h.signalQueueLk.Unlock()
+	if q != nil {
+		q.stop()
+	}
+}
+
+// acquireSignalQueue returns the signal queue, if there is one, registered as
+// being sent to so that it isn't closed until the signal has been enqueued
+func (h *Holochain) acquireSignalQueue() (q *signalQueue) {
+	h.signalQueueLk.RLock()
+	defer h.signalQueueLk.RUnlock()
+	q = h.signalQueue
+	if q != nil {
+		q.senders.Add(1)
+	}
+	return
+}
+
+// stop waits for the queued signals to be delivered.  It must be called once
+// the queue has been replaced, so no more signalers can get it, and not from
+// the delivery go routine.
+func (q *signalQueue) stop() {
+	q.senders.Wait()
+	close(q.queue)
+	<-q.done
+}
+
+// enqueue queues the signal according to the policy, returning false if it
+// or another signal had to be dropped, and releases the queue.  With
+// SignalBlock it only waits for room if wait is set, otherwise it drops the
+// signal.
+func (q *signalQueue) enqueue(signal Signal, wait bool) (queued bool) {
+	defer q.senders.Done()
+	if q.policy == SignalBlock {
+		if wait {
+			q.queue <- signal
+			return true
+		}
+		select {
+		case q.queue <- signal:
+			return true
+		default:
+		}
+		atomic.AddInt64(&q.dropped, 1)
+		return false
+	}
+	q.lk.Lock()
+	defer q.lk.Unlock()
+	select {
+	case q.queue <- signal:
+		return true
+	default:
+	}
+	atomic.AddInt64(&q.dropped, 1)
+	if q.policy == SignalDropOldest {
+		select {
+		case <-q.queue:
+		default:
+		}
+		// only enqueue takes the room that was made as it's serialized
+		// by the lock, the delivery go routine can only make more
+		q.queue <- signal
+	}
+	return false
+}
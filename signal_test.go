@@ -0,0 +1,150 @@
+package holochain
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestSignal(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should deliver signals to all the handlers", t, func() {
+		var first, second []Signal
+		h.AddSignalHandler(func(signal Signal) {
+			first = append(first, signal)
+		})
+		h.Signal("foo", "bar")
+		h.AddSignalHandler(func(signal Signal) {
+			second = append(second, signal)
+		})
+		h.Signal("baz", 1)
+
+		So(first, ShouldResemble, []Signal{{Name: "foo", Body: "bar"}, {Name: "baz", Body: 1}})
+		So(second, ShouldResemble, []Signal{{Name: "baz", Body: 1}})
+	})
+
+	Convey("it should deliver signals to the zomes that receive them", t, func() {
+		zomes := h.nucleus.dna.Zomes
+		defer func() { h.nucleus.dna.Zomes = zomes }()
+		h.nucleus.dna.Zomes = append(zomes, Zome{
+			Name:         "signalReceiver",
+			RibosomeType: JSRibosomeType,
+			Code:         `function receiveSignal(name,body) {debug("got "+name+" "+body)}`,
+		})
+		ShouldLog(h.nucleus.alog, func() {
+			h.Signal("foo", "bar")
+		}, "got foo bar")
+	})
+}
+
+func TestSignalQueue(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	// the handler takes the first signal and then waits for the gate while
+	// the rest flood the queue
+	var received chan int
+	var started, gate chan struct{}
+	h.AddSignalHandler(func(signal Signal) {
+		if signal.Name != "flood" {
+			return
+		}
+		if signal.Body.(int) == 0 {
+			close(started)
+			<-gate
+		}
+		received <- signal.Body.(int)
+	})
+	flood := func(policy SignalPolicy, signals int) (got []int, dropped int64) {
+		received = make(chan int, signals)
+		started, gate = make(chan struct{}), make(chan struct{})
+		So(h.SetSignalQueue(2, policy), ShouldBeNil)
+		h.Signal("flood", 0)
+		<-started
+		for i := 1; i < signals; i++ {
+			h.Signal("flood", i)
+		}
+		dropped = h.DroppedSignals()
+		close(gate)
+		h.stopSignalQueue()
+		close(received)
+		for i := range received {
+			got = append(got, i)
+		}
+		return
+	}
+
+	Convey("it should reject an unknown policy", t, func() {
+		So(h.SetSignalQueue(2, SignalPolicy(99)), ShouldEqual, ErrSignalPolicyUnknown)
+	})
+
+	Convey("drop newest should keep what was queued first", t, func() {
+		got, dropped := flood(SignalDropNewest, 5)
+		So(got, ShouldResemble, []int{0, 1, 2})
+		So(dropped, ShouldEqual, 2)
+	})
+
+	Convey("drop oldest should keep the latest signals", t, func() {
+		got, dropped := flood(SignalDropOldest, 5)
+		So(got, ShouldResemble, []int{0, 3, 4})
+		So(dropped, ShouldEqual, 2)
+	})
+
+	Convey("block should hold up the signaler until there's room", t, func() {
+		received = make(chan int, 4)
+		started, gate = make(chan struct{}), make(chan struct{})
+		So(h.SetSignalQueue(2, SignalBlock), ShouldBeNil)
+		h.Signal("flood", 0)
+		<-started
+		h.Signal("flood", 1)
+		h.Signal("flood", 2)
+		blocked := make(chan struct{})
+		go func() {
+			h.Signal("flood", 3)
+			close(blocked)
+		}()
+		select {
+		case <-blocked:
+			t.Error("signal should have blocked")
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(gate)
+		<-blocked
+		h.stopSignalQueue()
+		close(received)
+		var got []int
+		for i := range received {
+			got = append(got, i)
+		}
+		So(got, ShouldResemble, []int{0, 1, 2, 3})
+		So(h.DroppedSignals(), ShouldEqual, 0)
+	})
+
+	Convey("block should drop a signal a handler tries to emit into a full queue rather than deadlock", t, func() {
+		gate := make(chan struct{})
+		started := make(chan struct{})
+		echoed := make(chan bool, 1)
+		h.AddSignalHandler(func(signal Signal) {
+			if signal.Name == "reemit" && signal.Body.(int) == 0 {
+				close(started)
+				<-gate
+				echoed <- h.TrySignal("echo", nil)
+			}
+		})
+		So(h.SetSignalQueue(1, SignalBlock), ShouldBeNil)
+		h.Signal("reemit", 0)
+		<-started
+		h.Signal("reemit", 1)
+		close(gate)
+		select {
+		case queued := <-echoed:
+			So(queued, ShouldBeFalse)
+		case <-time.After(5 * time.Second):
+			t.Error("handler deadlocked")
+		}
+		So(h.DroppedSignals(), ShouldEqual, 1)
+		h.stopSignalQueue()
+	})
+}
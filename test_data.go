@@ -35,8 +35,8 @@ func generateRandomString(s int) (string, error) {
 	return base64.URLEncoding.EncodeToString(b), err
 }
 
-// Generate a random Hash string for testing
-func genTestStringHash() (hash Hash, err error) {
+// GenTestStringHash generates a random Hash for testing
+func GenTestStringHash() (hash Hash, err error) {
 	mt := setupMultiNodeTesting(1)
 	defer mt.cleanupMultiNodeTesting()
 
@@ -48,32 +48,33 @@ func genTestStringHash() (hash Hash, err error) {
 	return
 }
 
-// Generate a random string for testing
-func genTestString() (s string, err error) {
-	h, err := genTestStringHash()
+// GenTestString generates a random string for testing
+func GenTestString() (s string, err error) {
+	h, err := GenTestStringHash()
 	s = h.String()
 	return
 }
 
-// Generate a random Header for testing
-func genTestHeader() (header *Header, err error) {
+// GenTestHeader generates a random Header for testing
+func GenTestHeader() (header *Header, err error) {
 	hashSpec, privKey, now := chainTestSetup()
-	headerType, err := genTestString()
-	entryString, err := genTestString()
+	headerType, err := GenTestString()
+	entryString, err := GenTestString()
 	entry := &GobEntry{C: entryString}
-	prevHash, err := genTestStringHash()
-	prevType, err := genTestStringHash()
-	change, err := genTestStringHash()
+	prevHash, err := GenTestStringHash()
+	prevType, err := GenTestStringHash()
+	change, err := GenTestStringHash()
 
 	_, header, err = newHeader(hashSpec, now, headerType, entry, privKey, prevHash, prevType, change)
 
 	return
 }
 
-func genTestMigrateEntry() (entry MigrateEntry, err error) {
-	dnaHash, err := genTestStringHash()
-	key, err := genTestStringHash()
-	data, err := genTestString()
+// GenTestMigrateEntry generates a random open MigrateEntry for testing
+func GenTestMigrateEntry() (entry MigrateEntry, err error) {
+	dnaHash, err := GenTestStringHash()
+	key, err := GenTestStringHash()
+	data, err := GenTestString()
 
 	// Note that the k/v order here is different from the resulting JSON.
 	// This is deliberate to test that k/v order in code does not influence data
@@ -38,24 +38,24 @@ func TestGenerateRandomString(t *testing.T) {
 
 func TestGenTestStringHash(t *testing.T) {
   Convey("random hash should be unique", t, func() {
-    a, err := genTestStringHash()
+    a, err := GenTestStringHash()
     So(err, ShouldBeNil)
 
-    b, err := genTestStringHash()
+    b, err := GenTestStringHash()
     So(err, ShouldBeNil)
 
     So(a, ShouldNotEqual, b)
   })
 
   Convey("random hash should start with Qm", t, func() {
-    a, err := genTestStringHash()
+    a, err := GenTestStringHash()
 
     So(err, ShouldBeNil)
     So(a.String()[0:2], ShouldEqual, "Qm")
   })
 
   Convey("random hash should roundtrip safely through strings", t, func() {
-    a, err := genTestStringHash()
+    a, err := GenTestStringHash()
     if err != nil {
       panic(err)
     }
@@ -66,7 +66,7 @@ func TestGenTestStringHash(t *testing.T) {
 }
 
 func TestGenTestMigrateEntry(t *testing.T) {
-  entry, err := genTestMigrateEntry()
+  entry, err := GenTestMigrateEntry()
   if err != nil {
     panic(err)
   }
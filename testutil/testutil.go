@@ -0,0 +1,84 @@
+// Testing helpers for applications building on holochain, so that their
+// integration tests can spin up a set of nodes and craft test entries
+
+package testutil
+
+import (
+	"context"
+	holo "github.com/holochain/holochain-proto"
+	. "github.com/holochain/holochain-proto/hash"
+)
+
+// MultiNode is a set of test nodes running on a shared test service
+type MultiNode struct {
+	Ctx     context.Context
+	Service *holo.Service
+	Dir     string
+	Nodes   []*holo.Holochain
+
+	cancel context.CancelFunc
+}
+
+// SetupMultiNode creates a test service with n prepared test chains, each
+// with its own node.  The nodes aren't connected, call Connect or
+// FullConnect to do so, and call Cleanup when done.
+func SetupMultiNode(n int) (mn *MultiNode) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d, s := holo.SetupTestService()
+	mn = &MultiNode{
+		Ctx:     ctx,
+		Service: s,
+		Dir:     d,
+		cancel:  cancel,
+	}
+	mn.Nodes = holo.MakeTestNodes(ctx, s, n)
+	return
+}
+
+// Connect connects node i to node j
+func (mn *MultiNode) Connect(i, j int) (err error) {
+	err = holo.ConnectTestNode(mn.Ctx, mn.Nodes[i], mn.Nodes[j])
+	return
+}
+
+// FullConnect connects every node to every other node
+func (mn *MultiNode) FullConnect() (err error) {
+	for i := range mn.Nodes {
+		for j := range mn.Nodes {
+			if i == j {
+				continue
+			}
+			if err = mn.Connect(i, j); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Cleanup closes all the nodes and removes the test service's directory
+func (mn *MultiNode) Cleanup() {
+	for _, h := range mn.Nodes {
+		h.Close()
+	}
+	mn.cancel()
+	holo.CleanupTestDir(mn.Dir)
+}
+
+// StringHash returns a random hash
+func StringHash() (hash Hash, err error) {
+	hash, err = holo.GenTestStringHash()
+	return
+}
+
+// Header returns a header for a random entry linked to random hashes
+func Header() (header *holo.Header, err error) {
+	header, err = holo.GenTestHeader()
+	return
+}
+
+// MigrateEntry returns an open migrate entry with a random DNA hash, key and data
+func MigrateEntry() (entry holo.MigrateEntry, err error) {
+	entry, err = holo.GenTestMigrateEntry()
+	return
+}
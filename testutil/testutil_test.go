@@ -0,0 +1,32 @@
+package testutil
+
+import (
+	holo "github.com/holochain/holochain-proto"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestMultiNode(t *testing.T) {
+	Convey("it should set up, connect and clean up nodes", t, func() {
+		mn := SetupMultiNode(3)
+		defer mn.Cleanup()
+		So(len(mn.Nodes), ShouldEqual, 3)
+		So(mn.FullConnect(), ShouldBeNil)
+	})
+}
+
+func TestEntryHelpers(t *testing.T) {
+	Convey("it should make test entries", t, func() {
+		hash, err := StringHash()
+		So(err, ShouldBeNil)
+		So(hash.IsNullHash(), ShouldBeFalse)
+
+		header, err := Header()
+		So(err, ShouldBeNil)
+		So(header, ShouldNotBeNil)
+
+		entry, err := MigrateEntry()
+		So(err, ShouldBeNil)
+		So(entry.Type, ShouldEqual, holo.MigrateEntryTypeOpen)
+	})
+}
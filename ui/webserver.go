@@ -173,12 +173,18 @@ func (ws *WebServer) Start() {
 				if err == nil {
 					err = ws.h.AddBridgeAsCaller(data["Zome"], DNAHash, data["Name"], data["Token"], fmt.Sprintf("http://localhost:%s", data["Port"]), data["Data"])
 				}
+				if err == nil {
+					err = ws.bridgeManifests(w, DNAHash, data["Manifest"])
+				}
 			case "ToCallee":
 				var DNAHash Hash
 				DNAHash, err = NewHash(data["DNA"])
 				if err == nil {
 					var token string
 					token, err = ws.h.AddBridgeAsCallee(DNAHash, data["Data"])
+					if err == nil {
+						err = ws.bridgeManifests(w, DNAHash, data["Manifest"])
+					}
 					if err == nil {
 						fmt.Fprint(w, token)
 					} else {
@@ -224,8 +230,14 @@ func (ws *WebServer) Start() {
 		function := path[4]
 		args := string(body)
 
+		nonce, err := holo.BridgeNonceFromQuery(r.URL.Query())
+		if err != nil {
+			errCode, err = mkErr(err.Error(), 400)
+			return
+		}
+
 		ws.log.Logf("bridge calling %s:%s(%s)\n", zome, function, args)
-		result, err := ws.h.BridgeCall(zome, function, args, token)
+		result, err := ws.h.BridgeCallNonced(zome, function, args, token, nonce)
 		if err != nil {
 			ws.log.Logf("call of %s:%s resulted in error: %v\n", zome, function, err)
 			errCode, err = mkErr(err.Error(), 400)
@@ -242,6 +254,112 @@ func (ws *WebServer) Start() {
 		}
 	})
 
+	mux.HandleFunc("/bridge-get/", func(w http.ResponseWriter, r *http.Request) {
+
+		var err error
+		var errCode = 400
+		defer func() {
+			if err != nil {
+				ws.log.Logf("ERROR:%s,code:%d", err.Error(), errCode)
+				http.Error(w, err.Error(), errCode)
+			}
+		}()
+
+		AddCors(w)
+		if r.Method == "OPTIONS" {
+			return
+		}
+
+		path := strings.Split(r.URL.Path, "/")
+		if len(path) != 4 {
+			errCode, err = mkErr("bad request", 400)
+			return
+		}
+		token := path[2]
+		hash, err := NewHash(path[3])
+		if err != nil {
+			errCode, err = mkErr("bad hash: "+err.Error(), 400)
+			return
+		}
+
+		nonce, err := holo.BridgeNonceFromQuery(r.URL.Query())
+		if err != nil {
+			errCode, err = mkErr(err.Error(), 400)
+			return
+		}
+
+		if r.URL.Query().Get("header") == "true" {
+			ws.log.Logf("bridge get with header %v\n", hash)
+			var bridged holo.BridgedEntry
+			bridged, err = ws.h.BridgeGetWithHeaderNonced(hash, token, nonce)
+			if err != nil {
+				ws.log.Logf("bridge get of %v resulted in error: %v\n", hash, err)
+				errCode, err = mkErr(err.Error(), 400)
+				return
+			}
+			var j []byte
+			j, err = json.Marshal(bridged)
+			if err != nil {
+				return
+			}
+			fmt.Fprint(w, string(j))
+			return
+		}
+
+		ws.log.Logf("bridge get %v\n", hash)
+		result, err := ws.h.BridgeGetNonced(hash, token, nonce)
+		if err != nil {
+			ws.log.Logf("bridge get of %v resulted in error: %v\n", hash, err)
+			errCode, err = mkErr(err.Error(), 400)
+			return
+		}
+		switch t := result.(type) {
+		case string:
+			fmt.Fprint(w, t)
+		default:
+			err = fmt.Errorf("Unknown entry content type from get of %v", hash)
+		}
+	})
+
+	mux.HandleFunc("/bridge-migration/", func(w http.ResponseWriter, r *http.Request) {
+
+		var err error
+		var errCode = 400
+		defer func() {
+			if err != nil {
+				ws.log.Logf("ERROR:%s,code:%d", err.Error(), errCode)
+				http.Error(w, err.Error(), errCode)
+			}
+		}()
+
+		AddCors(w)
+		if r.Method == "OPTIONS" {
+			return
+		}
+
+		path := strings.Split(r.URL.Path, "/")
+		if len(path) != 3 {
+			errCode, err = mkErr("bad request", 400)
+			return
+		}
+		token := path[2]
+
+		nonce, err := holo.BridgeNonceFromQuery(r.URL.Query())
+		if err != nil {
+			errCode, err = mkErr(err.Error(), 400)
+			return
+		}
+
+		ws.log.Logf("bridge migration\n")
+		result, err := ws.h.BridgeCloseMigrateNonced(token, nonce)
+		if err != nil {
+			ws.log.Logf("bridge migration resulted in error: %v\n", err)
+			errCode, err = mkErr(err.Error(), 400)
+			return
+		}
+		fmt.Fprint(w, result)
+	})
+
 	// set router
 	ws.log.Logf("Starting server on localhost:%s\n", ws.port)
 
@@ -288,3 +406,18 @@ func (ws *WebServer) call(zome string, function string, args string) (result int
 	}
 	return
 }
+
+// bridgeManifests records the manifest the other side of a bridge sent and
+// returns ours in the response's header
+func (ws *WebServer) bridgeManifests(w http.ResponseWriter, dna Hash, manifest string) (err error) {
+	err = ws.h.AddBridgeManifest(dna, manifest)
+	if err != nil {
+		return
+	}
+	manifest, err = ws.h.BridgeManifestJSON()
+	if err != nil {
+		return
+	}
+	w.Header().Set(holo.BridgeManifestHeader, manifest)
+	return
+}
@@ -98,7 +98,8 @@ func TestWebServer(t *testing.T) {
 
 	Convey("it should fail bridged functions without a good token", t, func() {
 		body := bytes.NewBuffer([]byte("language"))
-		resp, err := http.Post("http://0.0.0.0:31415/bridge/bogus_token/jsSampleZome/getProperty", "", body)
+		nonce := BridgeNonce{Nonce: 1, Time: time.Now()}
+		resp, err := http.Post("http://0.0.0.0:31415/bridge/bogus_token/jsSampleZome/getProperty?"+nonce.Query(), "", body)
 		So(err, ShouldBeNil)
 		defer resp.Body.Close()
 		var b []byte
@@ -107,7 +108,7 @@ func TestWebServer(t *testing.T) {
 		So(string(b), ShouldEqual, "bridging error: invalid capability\n")
 	})
 
-	Convey("it should called bridged functions", t, func() {
+	Convey("it should fail bridged functions without a nonce", t, func() {
 		body := bytes.NewBuffer([]byte("language"))
 		resp, err := http.Post("http://0.0.0.0:31415/bridge/"+token+"/jsSampleZome/getProperty", "", body)
 		So(err, ShouldBeNil)
@@ -115,6 +116,18 @@ func TestWebServer(t *testing.T) {
 		var b []byte
 		b, err = ioutil.ReadAll(resp.Body)
 		So(err, ShouldBeNil)
+		So(string(b), ShouldEqual, ErrBridgeNonceMissing.Error()+"\n")
+	})
+
+	Convey("it should called bridged functions", t, func() {
+		body := bytes.NewBuffer([]byte("language"))
+		nonce := BridgeNonce{Nonce: 1, Time: time.Now()}
+		resp, err := http.Post("http://0.0.0.0:31415/bridge/"+token+"/jsSampleZome/getProperty?"+nonce.Query(), "", body)
+		So(err, ShouldBeNil)
+		defer resp.Body.Close()
+		var b []byte
+		b, err = ioutil.ReadAll(resp.Body)
+		So(err, ShouldBeNil)
 		So(string(b), ShouldEqual, "en")
 	})
 	ws.Stop()
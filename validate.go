@@ -15,7 +15,9 @@ import (
 
 // Package holds app specified data needed for validation (wire package)
 type Package struct {
-	Chain []byte
+	Chain       []byte
+	Group       string  // the group entry in effect for an entry signed by a group member
+	GroupHeader *Header // the header the author committed the group entry with
 }
 
 // ValidationPackage holds app specified data needed for validation. This version
@@ -110,7 +112,10 @@ func MakeValidationPackage(h *Holochain, pkg *Package) (vpkg *ValidationPackage,
 		}
 		if flags&ChainMarshalFlagsNoEntries == 0 {
 			// restore the chain's DNA data
-			vp.Chain.Entries[0].(*GobEntry).C = h.chain.Entries[0].(*GobEntry).C
+			h.chain.lk.RLock()
+			dna := h.chain.Entries[0].(*GobEntry).C
+			h.chain.lk.RUnlock()
+			vp.Chain.Entries[0].(*GobEntry).C = dna
 		}
 		if flags&ChainMarshalFlagsNoHeaders == 0 {
 			err = vp.Chain.Validate(flags&ChainMarshalFlagsNoEntries != 0)
@@ -0,0 +1,142 @@
+package holochain
+
+import (
+	"container/list"
+	"encoding/json"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	"sync"
+)
+
+const (
+	// DefaultValidationCacheSize is the number of successful system validations
+	// remembered when the config doesn't specify a cache size
+	DefaultValidationCacheSize = 1024
+)
+
+// ValidationCache is a bounded least-recently-used record of entries that have
+// already passed system validation, so that an entry gossiped to us repeatedly
+// doesn't have to be re-validated every time it arrives
+type ValidationCache struct {
+	size    int
+	dnaHash Hash
+	order   *list.List
+	items   map[string]*list.Element
+	lk      sync.Mutex
+}
+
+// NewValidationCache creates a cache holding at most size results
+func NewValidationCache(size int) (c *ValidationCache) {
+	if size <= 0 {
+		size = DefaultValidationCacheSize
+	}
+	c = &ValidationCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+	return
+}
+
+// checkDNA purges the cache if the results it holds were recorded against a
+// different DNA, as they may no longer hold
+// assumes the lock is held
+func (c *ValidationCache) checkDNA(dnaHash Hash) {
+	if !c.dnaHash.Equal(dnaHash) {
+		c.order.Init()
+		c.items = make(map[string]*list.Element)
+		c.dnaHash = dnaHash.Clone()
+	}
+}
+
+// Contains returns true if the key was recorded as valid for the given DNA
+func (c *ValidationCache) Contains(dnaHash Hash, key string) (ok bool) {
+	if c == nil {
+		return
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.checkDNA(dnaHash)
+	var e *list.Element
+	e, ok = c.items[key]
+	if ok {
+		c.order.MoveToFront(e)
+	}
+	return
+}
+
+// Add records the key as valid for the given DNA, evicting the least recently
+// used result if the cache is full
+func (c *ValidationCache) Add(dnaHash Hash, key string) {
+	if c == nil {
+		return
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.checkDNA(dnaHash)
+	if e, ok := c.items[key]; ok {
+		c.order.MoveToFront(e)
+		return
+	}
+	c.items[key] = c.order.PushFront(key)
+	for c.order.Len() > c.size {
+		e := c.order.Back()
+		c.order.Remove(e)
+		delete(c.items, e.Value.(string))
+	}
+}
+
+// Len returns the number of results currently cached
+func (c *ValidationCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	return c.order.Len()
+}
+
+// cachedAction is an action whose entry and header identify what it validates
+type cachedAction interface {
+	Entry() Entry
+	GetHeader() *Header
+}
+
+// validationCacheKey builds the cache key for an action validating a received
+// entry from its type, its hash, the hash of the header it came with, the hash
+// of the package it came with and its sources, as system validation depends
+// on all of them, i.e. on the package's group and on who sent it.  ok is false
+// if the validation shouldn't be cached.
+func validationCacheKey(h *Holochain, a ValidatingAction, entryType string, pkg *Package, sources []peer.ID) (key string, ok bool) {
+	// only received entries are cached, since validating our own commits
+	// depends on the current state of our chain
+	if pkg == nil {
+		return
+	}
+	ca, isCached := a.(cachedAction)
+	if !isCached || ca.Entry() == nil || ca.GetHeader() == nil {
+		return
+	}
+	entryHash, err := ca.Entry().Sum(h.hashSpec)
+	if err != nil {
+		return
+	}
+	headerHash, _, err := ca.GetHeader().Sum(h.hashSpec)
+	if err != nil {
+		return
+	}
+	b, err := json.Marshal(pkg)
+	if err != nil {
+		return
+	}
+	pkgHash, err := Sum(h.hashSpec, b)
+	if err != nil {
+		return
+	}
+	key = a.Name() + ":" + entryType + ":" + entryHash.String() + ":" + headerHash.String() + ":" + pkgHash.String()
+	for _, source := range sources {
+		key += ":" + peer.IDB58Encode(source)
+	}
+	ok = true
+	return
+}
@@ -0,0 +1,168 @@
+package holochain
+
+import (
+	"fmt"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+// countingMigrateAction counts how often its system validation actually runs
+type countingMigrateAction struct {
+	ActionMigrate
+	calls int
+}
+
+func (a *countingMigrateAction) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	a.calls++
+	return a.ActionMigrate.SysValidation(h, def, pkg, sources)
+}
+
+// countingPutAction counts how often its system validation actually runs
+type countingPutAction struct {
+	ActionPut
+	calls int
+}
+
+func (a *countingPutAction) SysValidation(h *Holochain, def *EntryDef, pkg *Package, sources []peer.ID) (err error) {
+	a.calls++
+	return a.ActionPut.SysValidation(h, def, pkg, sources)
+}
+
+func TestValidationCache(t *testing.T) {
+	dna1, _ := NewHash("QmNiCwBNA8MWDADTFVq1BonUEJbS2SvjAoNkZZrhEwcuU2")
+	dna2, _ := NewHash("QmNiCwBNA8MWDADTFVq1BonUEJbS2SvjAoNkZZrhEwcuUi")
+
+	Convey("it should default the size", t, func() {
+		c := NewValidationCache(0)
+		So(c.size, ShouldEqual, DefaultValidationCacheSize)
+	})
+
+	Convey("it should remember added keys", t, func() {
+		c := NewValidationCache(10)
+		So(c.Contains(dna1, "a"), ShouldBeFalse)
+		c.Add(dna1, "a")
+		So(c.Contains(dna1, "a"), ShouldBeTrue)
+		So(c.Len(), ShouldEqual, 1)
+	})
+
+	Convey("it should evict the least recently used key when full", t, func() {
+		c := NewValidationCache(3)
+		for i := 0; i < 3; i++ {
+			c.Add(dna1, fmt.Sprintf("%d", i))
+		}
+		So(c.Contains(dna1, "0"), ShouldBeTrue)
+		c.Add(dna1, "3")
+		So(c.Len(), ShouldEqual, 3)
+		So(c.Contains(dna1, "1"), ShouldBeFalse)
+		So(c.Contains(dna1, "0"), ShouldBeTrue)
+		So(c.Contains(dna1, "3"), ShouldBeTrue)
+	})
+
+	Convey("it should be purged when the DNA changes", t, func() {
+		c := NewValidationCache(10)
+		c.Add(dna1, "a")
+		So(c.Contains(dna2, "a"), ShouldBeFalse)
+		So(c.Len(), ShouldEqual, 0)
+	})
+
+	Convey("a nil cache should remember nothing", t, func() {
+		var c *ValidationCache
+		c.Add(dna1, "a")
+		So(c.Contains(dna1, "a"), ShouldBeFalse)
+		So(c.Len(), ShouldEqual, 0)
+	})
+}
+
+func TestValidateActionUsesValidationCache(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	header, err := GenTestHeader()
+	if err != nil {
+		panic(err)
+	}
+	a := &countingMigrateAction{ActionMigrate: ActionMigrate{entry: entry, header: header}}
+	sources := []peer.ID{h.nodeID}
+
+	Convey("sys validation of a received entry should only run once for the same package", t, func() {
+		pkg := &Package{}
+		_, err := h.ValidateAction(a, MigrateEntryType, pkg, sources)
+		So(err, ShouldBeNil)
+		_, err = h.ValidateAction(a, MigrateEntryType, pkg, sources)
+		So(err, ShouldBeNil)
+		So(a.calls, ShouldEqual, 1)
+	})
+
+	Convey("the same entry with another header should be validated again", t, func() {
+		other, err := GenTestHeader()
+		So(err, ShouldBeNil)
+		b := &countingMigrateAction{ActionMigrate: ActionMigrate{entry: entry, header: other}}
+		_, err = h.ValidateAction(b, MigrateEntryType, &Package{Chain: []byte("other")}, sources)
+		So(err, ShouldBeNil)
+		So(b.calls, ShouldEqual, 1)
+		_, err = h.ValidateAction(a, MigrateEntryType, &Package{}, sources)
+		So(err, ShouldBeNil)
+		So(a.calls, ShouldEqual, 1)
+	})
+
+	Convey("the same entry with another package or from another source should be validated again", t, func() {
+		c := &countingMigrateAction{ActionMigrate: ActionMigrate{entry: entry, header: header}}
+		_, err := h.ValidateAction(c, MigrateEntryType, &Package{}, sources)
+		So(err, ShouldBeNil)
+		_, err = h.ValidateAction(c, MigrateEntryType, &Package{Chain: []byte("another")}, sources)
+		So(err, ShouldBeNil)
+		So(c.calls, ShouldEqual, 2)
+
+		key, ok := validationCacheKey(h, c, MigrateEntryType, &Package{}, sources)
+		So(ok, ShouldBeTrue)
+		grouped, _ := validationCacheKey(h, c, MigrateEntryType, &Package{Group: `{"Members":[]}`}, sources)
+		So(grouped, ShouldNotEqual, key)
+		elsewhere, _ := validationCacheKey(h, c, MigrateEntryType, &Package{}, []peer.ID{peer.ID("elsewhere")})
+		So(elsewhere, ShouldNotEqual, key)
+	})
+
+	Convey("sys validation of a received put should only run once", t, func() {
+		hash := commit(h, "evenNumbers", "2")
+		e, _, err := h.chain.GetEntry(hash)
+		So(err, ShouldBeNil)
+		hd, err := h.chain.GetEntryHeader(hash)
+		So(err, ShouldBeNil)
+		put := &countingPutAction{ActionPut: *NewPutAction("evenNumbers", e, hd)}
+		_, err = h.ValidateAction(put, "evenNumbers", &Package{}, sources)
+		So(err, ShouldBeNil)
+		_, err = h.ValidateAction(put, "evenNumbers", &Package{}, sources)
+		So(err, ShouldBeNil)
+		So(put.calls, ShouldEqual, 1)
+	})
+
+	Convey("a change of DNA should invalidate the cache", t, func() {
+		dnaHash := h.dnaHash
+		h.dnaHash, _ = NewHash("QmNiCwBNA8MWDADTFVq1BonUEJbS2SvjAoNkZZrhEwcuU2")
+		_, err := h.ValidateAction(a, MigrateEntryType, &Package{}, sources)
+		h.dnaHash = dnaHash
+		So(err, ShouldBeNil)
+		So(a.calls, ShouldEqual, 2)
+	})
+
+	Convey("local commits should not be cached", t, func() {
+		calls := a.calls
+		h.ValidateAction(a, MigrateEntryType, nil, sources)
+		h.ValidateAction(a, MigrateEntryType, nil, sources)
+		So(a.calls, ShouldEqual, calls+2)
+	})
+
+	Convey("failed validations should not be cached", t, func() {
+		bad := &countingMigrateAction{ActionMigrate: ActionMigrate{entry: entry}}
+		_, err := h.ValidateAction(bad, MigrateEntryType, &Package{}, sources)
+		So(err, ShouldEqual, ErrActionMissingHeader)
+		_, err = h.ValidateAction(bad, MigrateEntryType, &Package{}, sources)
+		So(err, ShouldEqual, ErrActionMissingHeader)
+		So(bad.calls, ShouldEqual, 2)
+	})
+}
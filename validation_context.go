@@ -0,0 +1,103 @@
+package holochain
+
+import (
+	"errors"
+	peer "github.com/libp2p/go-libp2p-peer"
+	"sync"
+)
+
+var ErrNoValidationContext = errors.New("no validation context, it's only given to validators")
+
+// ChainReader is the read only view of a source chain given to app validators
+type ChainReader interface {
+	// Length returns the number of entries in the chain
+	Length() int
+
+	// IterEntriesByType calls fn on each entry of the given type from the
+	// first to the most recent, returning ErrStopIteration from fn ends it
+	IterEntriesByType(entryType string, fn func(header *Header, entry Entry) error) error
+}
+
+// ValidationContext gives an app's validators access to more than the entry
+// being validated, i.e. so that a rule like "can't migrate twice" can check
+// the chain's prior entries
+type ValidationContext struct {
+	chain     ChainReader
+	signals   []Signal
+	delivered bool
+	lk        sync.Mutex
+}
+
+// NewValidationContext returns a context with a read only handle to a chain
+func NewValidationContext(chain ChainReader) *ValidationContext {
+	return &ValidationContext{chain: chain}
+}
+
+// Chain returns the source chain of the entry's author.  When validating a
+// commit that's the committing agent's chain as it was before the entry being
+// validated, and for an entry received from another node it's the chain in
+// its validation package, which is empty if the package has none.
+func (ctx *ValidationContext) Chain() ChainReader {
+	return ctx.chain
+}
+
+// bindChain sets the chain the validators are given for an action from the
+// sources with the validation package that came with it
+func (ctx *ValidationContext) bindChain(h *Holochain, vpkg *ValidationPackage, sources []peer.ID) {
+	if ctx == nil {
+		return
+	}
+	switch {
+	case len(sources) == 1 && sources[0] == h.nodeID:
+		ctx.chain = h.chain
+	case vpkg != nil && vpkg.Chain != nil:
+		ctx.chain = vpkg.Chain
+	default:
+		ctx.chain = NewChain(h.hashSpec)
+	}
+}
+
+// Emit queues a signal to be delivered to the holochain's signal handlers
+// once the validation has finished, so they aren't called from within the
+// validator, i.e. to tell the UI a migration is being evaluated.  The signals
+// are delivered in the order they were emitted if the validation passes and
+// dropped if it fails.
+func (ctx *ValidationContext) Emit(signalName string, payload interface{}) {
+	ctx.lk.Lock()
+	defer ctx.lk.Unlock()
+	if ctx.delivered {
+		return
+	}
+	ctx.signals = append(ctx.signals, Signal{Name: signalName, Body: payload})
+}
+
+// deliverSignals signals what was emitted, anything emitted afterwards, i.e.
+// by a validator left running after timing out, is dropped
+func (ctx *ValidationContext) deliverSignals(h *Holochain) {
+	if ctx == nil {
+		return
+	}
+	ctx.lk.Lock()
+	signals := ctx.signals
+	ctx.signals = nil
+	ctx.delivered = true
+	ctx.lk.Unlock()
+	for _, s := range signals {
+		h.Signal(s.Name, s.Body)
+	}
+}
+
+// contextualRibosome is implemented by ribosomes that pass a validation
+// context on to the app's validators
+type contextualRibosome interface {
+	setValidationContext(ctx *ValidationContext)
+}
+
+// validationContext returns the context for the validations run on this node,
+// or nil if it has no chain
+func (h *Holochain) validationContext() (ctx *ValidationContext) {
+	if h.chain != nil {
+		ctx = NewValidationContext(h.chain)
+	}
+	return
+}
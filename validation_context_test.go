@@ -0,0 +1,220 @@
+package holochain
+
+import (
+	zygo "github.com/glycerine/zygomys/zygo"
+	. "github.com/holochain/holochain-proto/hash"
+	peer "github.com/libp2p/go-libp2p-peer"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestValidationContext(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("it should give a read only view of the chain", t, func() {
+		ctx := h.validationContext()
+		So(ctx.Chain().Length(), ShouldEqual, h.chain.Length())
+		count := 0
+		err := ctx.Chain().IterEntriesByType(DNAEntryType, func(header *Header, entry Entry) error {
+			count++
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 1)
+	})
+
+	Convey("it should give the author's chain from the package for a received entry", t, func() {
+		other, err := GenTestStringHash()
+		So(err, ShouldBeNil)
+		from := []peer.ID{PeerIDFromHash(other)}
+
+		ctx := h.validationContext()
+		ctx.bindChain(h, &ValidationPackage{}, from)
+		So(ctx.Chain().Length(), ShouldEqual, 0)
+
+		pkgChain := NewChain(h.hashSpec)
+		So(pkgChain.AppendTrusted(h.chain.Headers[0], h.chain.Entries[0]), ShouldBeNil)
+		ctx.bindChain(h, &ValidationPackage{Chain: pkgChain}, from)
+		So(ctx.Chain(), ShouldEqual, pkgChain)
+
+		ctx.bindChain(h, &ValidationPackage{Chain: pkgChain}, []peer.ID{h.nodeID})
+		So(ctx.Chain(), ShouldEqual, h.chain)
+	})
+
+	Convey("it should not be given when there's no chain", t, func() {
+		var c Holochain
+		So(c.validationContext(), ShouldBeNil)
+	})
+}
+
+func TestMigrateAppValidationWithChain(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	zomes := h.nucleus.dna.Zomes
+	defer func() { h.nucleus.dna.Zomes = zomes }()
+	h.nucleus.dna.Zomes = append(zomes, Zome{
+		Name:         "migrationRules",
+		RibosomeType: JSRibosomeType,
+		Entries:      []EntryDef{{Name: MigrateEntryType, DataFormat: DataFormatJSON}},
+		Code: `function validateCommit(entryType,entry,header,pkg,sources,ctx) {
+  if (entryType != "` + MigrateEntryType + `" || entry.Type != "close") {
+    return true;
+  }
+  var closed = false;
+  ctx.Chain().IterEntriesByType("` + MigrateEntryType + `", function(e, hdr) {
+    if (e.Type == "close") {
+      closed = true;
+      return false;
+    }
+  });
+  return closed ? "can't migrate twice" : "";
+}
+function validatePut(entryType,entry,header,pkg,sources) {
+  return true;
+}`,
+	})
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+	entry.Type = MigrateEntryTypeClose
+
+	Convey("a first close migrate should be accepted", t, func() {
+		fn := &APIFnMigrate{action: ActionMigrate{entry: entry}}
+		_, err := fn.Call(h)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("a second close migrate should be rejected by the app", t, func() {
+		header, err := GenTestHeader()
+		So(err, ShouldBeNil)
+		second := ActionMigrate{header: header, entry: entry}
+		_, err = h.ValidateAction(&second, MigrateEntryType, nil, []peer.ID{h.nodeID})
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "can't migrate twice")
+	})
+}
+
+func TestValidationContextEmit(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	var received []Signal
+	h.AddSignalHandler(func(s Signal) {
+		received = append(received, s)
+	})
+
+	Convey("emitted signals should be delivered in order, once", t, func() {
+		received = nil
+		ctx := h.validationContext()
+		ctx.Emit("first", 1)
+		ctx.Emit("second", 2)
+		So(len(received), ShouldEqual, 0)
+		ctx.deliverSignals(h)
+		So(received, ShouldResemble, []Signal{{Name: "first", Body: 1}, {Name: "second", Body: 2}})
+		ctx.Emit("late", 3)
+		ctx.deliverSignals(h)
+		So(len(received), ShouldEqual, 2)
+	})
+
+	zomes := h.nucleus.dna.Zomes
+	defer func() { h.nucleus.dna.Zomes = zomes }()
+	h.nucleus.dna.Zomes = append(zomes, Zome{
+		Name:         "migrationSignals",
+		RibosomeType: JSRibosomeType,
+		Entries:      []EntryDef{{Name: MigrateEntryType, DataFormat: DataFormatJSON}},
+		Code: `function validateCommit(entryType,entry,header,pkg,sources,ctx) {
+  if (entryType != "` + MigrateEntryType + `") {
+    return true;
+  }
+  ctx.Emit("migrationEvaluating", {type: entry.Type});
+  ctx.Emit("migrationChecked", "done");
+  return entry.Type != "close";
+}
+function validatePut(entryType,entry,header,pkg,sources) {
+  return true;
+}`,
+	})
+
+	entry, err := GenTestMigrateEntry()
+	if err != nil {
+		panic(err)
+	}
+
+	Convey("signals a validator emits should be delivered after it passes", t, func() {
+		received = nil
+		entry.Type = MigrateEntryTypeOpen
+		_, err := (&APIFnMigrate{action: ActionMigrate{entry: entry}}).Call(h)
+		So(err, ShouldBeNil)
+		So(len(received), ShouldEqual, 2)
+		So(received[0].Name, ShouldEqual, "migrationEvaluating")
+		So(received[0].Body, ShouldResemble, map[string]interface{}{"type": MigrateEntryTypeOpen})
+		So(received[1], ShouldResemble, Signal{Name: "migrationChecked", Body: "done"})
+	})
+
+	Convey("signals should be dropped if the validation fails", t, func() {
+		received = nil
+		entry.Type = MigrateEntryTypeClose
+		_, err := (&APIFnMigrate{action: ActionMigrate{entry: entry}}).Call(h)
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+		So(len(received), ShouldEqual, 0)
+	})
+	Convey("signals should not be delivered for a migrate's dry run", t, func() {
+		received = nil
+		entry.Type = MigrateEntryTypeOpen
+		response, err := (&APIFnMigrateCheck{action: ActionMigrate{entry: entry}}).Call(h)
+		So(err, ShouldBeNil)
+		So(response.(MigrateCheckResult).Error, ShouldEqual, "")
+		So(len(received), ShouldEqual, 0)
+	})
+}
+
+func TestZygoValidationContext(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	var received []Signal
+	h.AddSignalHandler(func(s Signal) {
+		received = append(received, s)
+	})
+
+	v, err := NewZygoRibosome(h, &Zome{RibosomeType: ZygoRibosomeType,
+		Code: `(defn validateCommit [name entry header pkg sources] (validationEmit "checked" (hash count: (validationChainLength))) (== (len (validationChainEntries "` + DNAEntryType + `")) 1))`})
+	if err != nil {
+		panic(err)
+	}
+	z := v.(*ZygoRibosome)
+	hdr := mkTestHeader("evenNumbers")
+	def := EntryDef{Name: "evenNumbers", DataFormat: DataFormatString}
+	a := NewCommitAction("evenNumbers", &GobEntry{C: "2"})
+	a.header = &hdr
+
+	Convey("a zygo validator should read the chain and emit signals from its context", t, func() {
+		ctx := h.validationContext()
+		z.setValidationContext(ctx)
+		So(z.ValidateAction(a, &def, nil, []string{h.nodeIDStr}), ShouldBeNil)
+		ctx.deliverSignals(h)
+		So(received, ShouldResemble, []Signal{{Name: "checked", Body: map[string]interface{}{"count": float64(h.chain.Length())}}})
+	})
+
+	Convey("a zygo validator without a context should fail using it", t, func() {
+		z.setValidationContext(nil)
+		err := z.ValidateAction(a, &def, nil, []string{h.nodeIDStr})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, ErrNoValidationContext.Error())
+	})
+
+	Convey("chain entries should be converted by their content type", t, func() {
+		content, err := zyEntryContent(&GobEntry{C: "2"})
+		So(err, ShouldBeNil)
+		So(content.(*zygo.SexpStr).S, ShouldEqual, "2")
+		content, err = zyEntryContent(&GobEntry{C: []byte("dna")})
+		So(err, ShouldBeNil)
+		So(string(content.(*zygo.SexpRaw).Val), ShouldEqual, "dna")
+		_, err = zyEntryContent(&GobEntry{C: 2})
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,70 @@
+package holochain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultValidationTimeout is how long an app's validation callback may run
+// before it's aborted, if the DNA doesn't set DHTConfig.ValidationTimeout
+const DefaultValidationTimeout = 30 * time.Second
+
+var ErrValidationTimeout = errors.New("app validation timed out")
+
+// errValidationInterrupted is the panic used to unwind a ribosome interrupted
+// because its validation callback timed out
+var errValidationInterrupted = errors.New("validation interrupted")
+
+// interruptibleRibosome is implemented by ribosomes that can abort the code
+// they're running
+type interruptibleRibosome interface {
+	interrupt()
+}
+
+// validationTimeout returns the DNA's timeout for app validations
+func (h *Holochain) validationTimeout() time.Duration {
+	if t := h.nucleus.dna.DHTConfig.ValidationTimeout; t > 0 {
+		return time.Duration(t) * time.Millisecond
+	}
+	return DefaultValidationTimeout
+}
+
+// appValidateAction runs the ribosome's validation of the action, returning
+// ErrValidationTimeout if it doesn't finish within the validation timeout.
+// Ribosomes that can be interrupted are, otherwise the validation is left to
+// finish in the background and its result ignored.  A validator that panics
+// fails the validation rather than the node.  Ribosomes that take a
+// validation context are given vctx.
+func (h *Holochain) appValidateAction(vctx *ValidationContext, n Ribosome, a Action, def *EntryDef, pkg *ValidationPackage, sources []string) (err error) {
+	if c, ok := n.(contextualRibosome); ok {
+		c.setValidationContext(vctx)
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				if r == errValidationInterrupted {
+					done <- ErrValidationTimeout
+					return
+				}
+				h.Debugf("validation of %s %s panicked: %v", a.Name(), def.Name, r)
+				done <- ValidationFailed(fmt.Sprintf("validator panicked: %v", r))
+			}
+		}()
+		done <- n.ValidateAction(a, def, pkg, sources)
+	}()
+
+	timer := time.NewTimer(h.validationTimeout())
+	defer timer.Stop()
+	select {
+	case err = <-done:
+	case <-timer.C:
+		if i, ok := n.(interruptibleRibosome); ok {
+			i.interrupt()
+		}
+		h.Debugf("validation of %s %s timed out", a.Name(), def.Name)
+		err = ErrValidationTimeout
+	}
+	return
+}
@@ -0,0 +1,95 @@
+package holochain
+
+import (
+	. "github.com/holochain/holochain-proto/hash"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestValidationTimeout(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+
+	Convey("the timeout should default to something generous", t, func() {
+		So(h.validationTimeout(), ShouldEqual, DefaultValidationTimeout)
+		So(DefaultValidationTimeout, ShouldBeGreaterThanOrEqualTo, 10*time.Second)
+	})
+
+	zomes := h.nucleus.dna.Zomes
+	defer func() { h.nucleus.dna.Zomes = zomes }()
+	h.nucleus.dna.Zomes = append(zomes, Zome{
+		Name:         "slowRules",
+		RibosomeType: JSRibosomeType,
+		Entries:      []EntryDef{{Name: "slowEntry", DataFormat: DataFormatString, Sharing: Public}},
+		Code: `function validateCommit(entryType,entry,header,pkg,sources) {
+  if (entry == "hang") { while (true) {} }
+  return true;
+}`,
+	})
+	h.nucleus.dna.DHTConfig.ValidationTimeout = 100
+	defer func() { h.nucleus.dna.DHTConfig.ValidationTimeout = 0 }()
+
+	Convey("the timeout should be the DNA's", t, func() {
+		So(h.validationTimeout(), ShouldEqual, 100*time.Millisecond)
+	})
+
+	Convey("a hung validator should fail the commit rather than block it", t, func() {
+		l := h.ChainLength()
+		start := time.Now()
+		_, err := h.commitAndShare(NewCommitAction("slowEntry", &GobEntry{C: "hang"}), NullHash())
+		So(err, ShouldEqual, ErrValidationTimeout)
+		So(time.Since(start), ShouldBeLessThan, 5*time.Second)
+		So(h.ChainLength(), ShouldEqual, l)
+	})
+
+	Convey("validators that finish in time should be unaffected", t, func() {
+		_, err := h.commitAndShare(NewCommitAction("slowEntry", &GobEntry{C: "quick"}), NullHash())
+		So(err, ShouldBeNil)
+	})
+
+	Convey("an interrupted JS validator should stop running", t, func() {
+		z, def, err := h.GetEntryDef("slowEntry")
+		So(err, ShouldBeNil)
+		n, err := z.MakeRibosome(h)
+		So(err, ShouldBeNil)
+		header, err := GenTestHeader()
+		So(err, ShouldBeNil)
+		a := &ActionCommit{entryType: "slowEntry", entry: &GobEntry{C: "hang"}, header: header}
+		err = h.appValidateAction(h.validationContext(), n, a, def, nil, []string{})
+		So(err, ShouldEqual, ErrValidationTimeout)
+
+		// once unwound the vm can be used again
+		var r interface{}
+		for i := 0; i < 50; i++ {
+			if r, err = n.Run("1+1"); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		So(err, ShouldBeNil)
+		So(r, ShouldNotBeNil)
+	})
+
+	Convey("a validator that panics should fail the validation", t, func() {
+		z, def, err := h.GetEntryDef("slowEntry")
+		So(err, ShouldBeNil)
+		n, err := z.MakeRibosome(h)
+		So(err, ShouldBeNil)
+		header, err := GenTestHeader()
+		So(err, ShouldBeNil)
+		a := &ActionCommit{entryType: "slowEntry", entry: &GobEntry{C: "quick"}, header: header}
+		err = h.appValidateAction(h.validationContext(), panickyRibosome{n}, a, def, nil, []string{})
+		So(IsValidationFailedErr(err), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "validator panicked: boom")
+	})
+}
+
+// panickyRibosome is a ribosome whose validation panics
+type panickyRibosome struct {
+	Ribosome
+}
+
+func (r panickyRibosome) ValidateAction(action Action, def *EntryDef, pkg *ValidationPackage, sources []string) error {
+	panic("boom")
+}
@@ -11,7 +11,9 @@ import (
 	ic "github.com/libp2p/go-libp2p-crypto"
 	peer "github.com/libp2p/go-libp2p-peer"
 	pstore "github.com/libp2p/go-libp2p-peerstore"
+	"sort"
 	"sync"
+	"time"
 )
 
 // NodeRecord stores the necessary information about other nodes in the world model
@@ -19,6 +21,18 @@ type NodeRecord struct {
 	PeerInfo  pstore.PeerInfo
 	PubKey    ic.PubKey
 	IsHolding map[Hash]bool
+	LastSeen  time.Time
+}
+
+// WorldPeerRecord is a snapshot of what the world model knows about a peer
+type WorldPeerRecord struct {
+	ID       peer.ID
+	LastSeen time.Time
+	// Holding are the hashes the peer is known to hold
+	Holding []Hash
+	// Responsible are the hashes we are responsible for that the peer is
+	// believed to be responsible for holding too
+	Responsible []Hash
 }
 
 // World holds the data of a nodes' world model
@@ -33,6 +47,8 @@ type World struct {
 }
 
 var ErrNodeNotFound = errors.New("node not found")
+var ErrWorldModelNotEnabled = errors.New("world model not enabled")
+var ErrNegativeRedundancy = errors.New("redundancy can't be negative")
 
 // NewWorld creates and empty world model
 func NewWorld(me peer.ID, ht HashTable, logger *Logger) *World {
@@ -64,6 +80,7 @@ func (world *World) SetNodeHolding(ID peer.ID, hash Hash) (err error) {
 		return
 	}
 	record.IsHolding[hash] = true
+	record.LastSeen = time.Now()
 	return
 }
 
@@ -82,6 +99,26 @@ func (world *World) IsHolding(ID peer.ID, hash Hash) (holding bool, err error) {
 	return
 }
 
+// Holders returns the list of other nodes in the world model that are
+// recorded as holding a particular hash, sorted by ID so it's the same from
+// one call to the next
+func (world *World) Holders(hash Hash) (holders []peer.ID) {
+	if world == nil {
+		// the world model isn't enabled so we don't know of any holders
+		return make([]peer.ID, 0)
+	}
+	world.lk.RLock()
+	defer world.lk.RUnlock()
+	holders = make([]peer.ID, 0)
+	for id, record := range world.nodes {
+		if record.IsHolding[hash] {
+			holders = append(holders, id)
+		}
+	}
+	sort.Slice(holders, func(i, j int) bool { return holders[i] < holders[j] })
+	return
+}
+
 // AllNodes returns a list of all the nodes in the world model.
 func (world *World) AllNodes() (nodes []peer.ID, err error) {
 	world.lk.RLock()
@@ -105,7 +142,7 @@ func (world *World) allNodes() (nodes []peer.ID, err error) {
 func (world *World) AddNode(pi pstore.PeerInfo, pubKey ic.PubKey) (err error) {
 	world.lk.Lock()
 	defer world.lk.Unlock()
-	rec := NodeRecord{PeerInfo: pi, PubKey: pubKey, IsHolding: make(map[Hash]bool)}
+	rec := NodeRecord{PeerInfo: pi, PubKey: pubKey, IsHolding: make(map[Hash]bool), LastSeen: time.Now()}
 	world.nodes[pi.ID] = &rec
 	return
 }
@@ -194,6 +231,55 @@ func (world *World) Responsible() (entries []Hash, err error) {
 	return
 }
 
+// Snapshot returns a consistent copy of the world model's records sorted by
+// peer ID, with their hashes sorted too
+func (world *World) Snapshot() (records []WorldPeerRecord) {
+	world.lk.RLock()
+	defer world.lk.RUnlock()
+	records = make([]WorldPeerRecord, 0, len(world.nodes))
+	for id, node := range world.nodes {
+		rec := WorldPeerRecord{ID: id, LastSeen: node.LastSeen, Holding: make([]Hash, 0), Responsible: make([]Hash, 0)}
+		for hash, holding := range node.IsHolding {
+			if holding {
+				rec.Holding = append(rec.Holding, hash)
+			}
+		}
+		for hash, nodes := range world.responsible {
+			// no list means every node is responsible, i.e. redundancy of 0
+			if nodes == nil {
+				rec.Responsible = append(rec.Responsible, hash)
+				continue
+			}
+			for _, n := range nodes {
+				if n == id {
+					rec.Responsible = append(rec.Responsible, hash)
+					break
+				}
+			}
+		}
+		sortHashes(rec.Holding)
+		sortHashes(rec.Responsible)
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return
+}
+
+func sortHashes(hashes []Hash) {
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+}
+
+// WorldModel returns a snapshot of the peers in our world model and what
+// they are known to hold and believed to be responsible for
+func (h *Holochain) WorldModel() (records []WorldPeerRecord, err error) {
+	if h.world == nil {
+		err = ErrWorldModelNotEnabled
+		return
+	}
+	records = h.world.Snapshot()
+	return
+}
+
 // Overlap returns a list of all the nodes that overlap for a given hash
 func (h *Holochain) Overlap(hash Hash) (overlap []peer.ID, err error) {
 	h.world.lk.RLock()
@@ -206,6 +292,34 @@ func (h *Holochain) Overlap(hash Hash) (overlap []peer.ID, err error) {
 	return
 }
 
+// ResponsiblePeers returns the redundancy peers closest to a hash, which are
+// the ones that should hold it, closest first and including us if we're one
+// of them.  The peers come from the world model if it's enabled, otherwise
+// from the routing table.  If we know of fewer peers than redundancy they're
+// all returned, as they are if redundancy is 0, i.e. no sharding.
+func (h *Holochain) ResponsiblePeers(hash Hash, redundancy int) (peers []peer.ID, err error) {
+	if redundancy < 0 {
+		err = ErrNegativeRedundancy
+		return
+	}
+	if h.world != nil {
+		h.world.lk.RLock()
+		peers, err = h.world.nodesByHash(hash)
+		h.world.lk.RUnlock()
+		if err != nil {
+			return
+		}
+	} else {
+		// all of them rather than NearestPeers which only looks in the
+		// nearby buckets
+		peers = SortClosestPeers(append(h.node.routingTable.ListPeers(), h.nodeID), hash)
+	}
+	if redundancy > 0 && len(peers) > redundancy {
+		peers = peers[:redundancy]
+	}
+	return
+}
+
 func myHashes(h *Holochain) (hashes []Hash) {
 	h.dht.Iterate(func(hash Hash) bool {
 		hashes = append(hashes, hash)
@@ -248,7 +362,7 @@ func HoldingTask(h *Holochain) {
 				*/
 				h.world.log.Logf("HoldingTask: PUT_REQUEST sent to %v\n", node)
 				msg := h.node.NewMessage(PUT_REQUEST, HoldReq{EntryHash: hash})
-				h.dht.sendChange(node, msg)
+				h.dht.sendChange(nil, node, msg)
 			}
 		}
 	}
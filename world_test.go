@@ -76,6 +76,17 @@ func TestWorldNodes(t *testing.T) {
 		So(holding, ShouldBeTrue)
 	})
 
+	Convey("Holders should return the nodes holding a given hash", t, func() {
+		holders := world.Holders(hash)
+		So(len(holders), ShouldEqual, 1)
+		So(holders[0], ShouldEqual, n[0].HashAddr)
+
+		otherHash, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHx")
+		holders = world.Holders(otherHash)
+		So(holders, ShouldNotBeNil)
+		So(len(holders), ShouldEqual, 0)
+	})
+
 	Convey("nodes can be sorted by closeness to a hash", t, func() {
 		testAddNodesToWorld(world, 1, 5)
 		nodes, err := world.nodesByHash(hash)
@@ -91,6 +102,74 @@ func TestWorldNodes(t *testing.T) {
 
 }
 
+func TestWorldSnapshot(t *testing.T) {
+	b58 := "QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2"
+	me, _ := peer.IDB58Decode(b58)
+	ht := BuntHT{}
+	world := NewWorld(me, &ht, nil)
+
+	Convey("WorldModel should fail if the world model isn't enabled", t, func() {
+		h := &Holochain{}
+		_, err := h.WorldModel()
+		So(err, ShouldEqual, ErrWorldModelNotEnabled)
+	})
+
+	Convey("an empty world should have no records", t, func() {
+		So(len(world.Snapshot()), ShouldEqual, 0)
+	})
+
+	start := time.Now()
+	n := testAddNodesToWorld(world, 0, 3)
+	hash, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHw")
+
+	Convey("it should snapshot the peers sorted by ID with what they hold", t, func() {
+		So(world.SetNodeHolding(n[1].HashAddr, hash), ShouldBeNil)
+		records := world.Snapshot()
+		So(len(records), ShouldEqual, 3)
+		for i, rec := range records {
+			if i > 0 {
+				So(records[i-1].ID < rec.ID, ShouldBeTrue)
+			}
+			So(rec.LastSeen.Before(start), ShouldBeFalse)
+			if rec.ID == n[1].HashAddr {
+				So(rec.Holding, ShouldResemble, []Hash{hash})
+			} else {
+				So(len(rec.Holding), ShouldEqual, 0)
+			}
+			So(len(rec.Responsible), ShouldEqual, 0)
+		}
+	})
+
+	Convey("it should show the hashes peers are believed responsible for", t, func() {
+		_, err := world.UpdateResponsible(hash, 0)
+		So(err, ShouldBeNil)
+		for _, rec := range world.Snapshot() {
+			So(rec.Responsible, ShouldResemble, []Hash{hash})
+		}
+
+		other, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHx")
+		world.responsible[other] = []peer.ID{n[2].HashAddr}
+		for _, rec := range world.Snapshot() {
+			if rec.ID == n[2].HashAddr {
+				So(len(rec.Responsible), ShouldEqual, 2)
+			} else {
+				So(rec.Responsible, ShouldResemble, []Hash{hash})
+			}
+		}
+	})
+
+	Convey("the snapshot should not change with the world model", t, func() {
+		records := world.Snapshot()
+		other, _ := NewHash("QmVGtdTZdTFaLsaj2RwdVG8jcjNNcp1DE914DKZ2kHmXHx")
+		So(world.SetNodeHolding(n[0].HashAddr, other), ShouldBeNil)
+		for _, rec := range records {
+			if rec.ID == n[0].HashAddr {
+				So(len(rec.Holding), ShouldEqual, 0)
+			}
+		}
+	})
+}
+
 func TestWorldUpdateResponsible(t *testing.T) {
 	b58 := "QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2"
 	var p1, p2, p3, p4, p5 peer.ID
@@ -348,3 +427,47 @@ func checkPropigated(nodes []*Holochain, redundancy int) bool {
 
 	return propigated
 }
+
+func TestResponsiblePeers(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+	h.world = nil
+
+	hash, err := NewHash("QmY8Mzg9F69e5P9AoQPYat655HEhc1TVGs11tmfNSzkqh2")
+	if err != nil {
+		panic(err)
+	}
+	peers := addTestPeers(h, nil, 0, 5)
+	all := SortClosestPeers(append(peers, h.nodeID), hash)
+
+	Convey("it should return the closest peers from the routing table", t, func() {
+		responsible, err := h.ResponsiblePeers(hash, 3)
+		So(err, ShouldBeNil)
+		So(responsible, ShouldResemble, all[:3])
+	})
+
+	Convey("it should return all the peers if there are fewer than the redundancy", t, func() {
+		responsible, err := h.ResponsiblePeers(hash, 10)
+		So(err, ShouldBeNil)
+		So(responsible, ShouldResemble, all)
+		responsible, err = h.ResponsiblePeers(hash, 0)
+		So(err, ShouldBeNil)
+		So(responsible, ShouldResemble, all)
+	})
+
+	Convey("it should refuse a negative redundancy", t, func() {
+		_, err := h.ResponsiblePeers(hash, -1)
+		So(err, ShouldEqual, ErrNegativeRedundancy)
+	})
+
+	Convey("it should use the world model if it's enabled", t, func() {
+		h.world = NewWorld(h.nodeID, h.dht.ht, nil)
+		var addr ma.Multiaddr
+		for _, p := range peers[:2] {
+			testAddNodeToWorld(h.world, p, addr)
+		}
+		responsible, err := h.ResponsiblePeers(hash, 5)
+		So(err, ShouldBeNil)
+		So(responsible, ShouldResemble, SortClosestPeers(append([]peer.ID{h.nodeID}, peers[:2]...), hash))
+	})
+}
@@ -30,6 +30,8 @@ type ZygoRibosome struct {
 	env        *zygo.Zlisp
 	lastResult zygo.Sexp
 	library    string
+
+	validationCtx *ValidationContext
 }
 
 // Type returns the string value under which this ribosome is registered
@@ -108,6 +110,28 @@ func (z *ZygoRibosome) BundleCanceled(reason string) (response string, err error
 	return
 }
 
+// ReceiveSignal calls the app receiveSignal function, if it has one, with the
+// signal's name and body
+func (z *ZygoRibosome) ReceiveSignal(signal Signal) (err error) {
+	fnName := "receiveSignal"
+	if _, ok := z.env.FindObject(fnName); !ok {
+		return
+	}
+	var body []byte
+	if body, err = json.Marshal(signal.Body); err != nil {
+		return
+	}
+	code := fmt.Sprintf(`(%s "%s" (unjson (raw "%s")))`, fnName, sanitizeZyString(signal.Name), sanitizeZyString(string(body)))
+	z.h.Debug(code)
+	if err = z.env.LoadString(code); err != nil {
+		return
+	}
+	if _, err = z.env.Run(); err != nil {
+		err = fmt.Errorf("Error executing %s: %v", fnName, err)
+	}
+	return
+}
+
 // ValidatePackagingRequest calls the app for a validation packaging request for an action
 func (z *ZygoRibosome) ValidatePackagingRequest(action ValidatingAction, def *EntryDef) (req PackagingReq, err error) {
 	var code string
@@ -142,6 +166,20 @@ func (z *ZygoRibosome) ValidatePackagingRequest(action ValidatingAction, def *En
 	return
 }
 
+// zyEntryContent converts an entry's content to a zygo value by its type,
+// system entries like the DNA hold bytes rather than a string
+func zyEntryContent(entry Entry) (content zygo.Sexp, err error) {
+	switch c := entry.Content().(type) {
+	case string:
+		content = &zygo.SexpStr{S: c}
+	case []byte:
+		content = &zygo.SexpRaw{Val: c}
+	default:
+		err = fmt.Errorf("unsupported entry content type: %T", c)
+	}
+	return
+}
+
 func prepareZyEntryArgs(def *EntryDef, entry Entry, header *Header) (args string, err error) {
 	entryStr := entry.Content().(string)
 	switch def.DataFormat {
@@ -226,7 +264,15 @@ func buildZyValidateAction(action Action, def *EntryDef, pkg *ValidationPackage,
 	return
 }
 
+// setValidationContext implements contextualRibosome
+func (z *ZygoRibosome) setValidationContext(ctx *ValidationContext) {
+	z.validationCtx = ctx
+}
+
 // ValidateAction builds the correct validation function based on the action an calls it
+// If a validation context has been set the validator can read the chain with
+// validationChainLength and validationChainEntries, and emit signals with
+// validationEmit.
 func (z *ZygoRibosome) ValidateAction(action Action, def *EntryDef, pkg *ValidationPackage, sources []string) (err error) {
 	var code string
 	code, err = buildZyValidateAction(action, def, pkg, sources)
@@ -392,6 +438,8 @@ const (
 		`(def HC_GetMask_Entry ` + GetMaskEntryStr + ")" +
 		`(def HC_GetMask_EntryType ` + GetMaskEntryTypeStr + ")" +
 		`(def HC_GetMask_Sources ` + GetMaskSourcesStr + ")" +
+		`(def HC_GetMask_Holders ` + GetMaskHoldersStr + ")" +
+		`(def HC_GetMask_Status ` + GetMaskStatusStr + ")" +
 		`(def HC_GetMask_All ` + GetMaskAllStr + ")" +
 
 		`(def HC_Bridge_Caller ` + BridgeCallerStr + ")" +
@@ -656,6 +704,41 @@ func NewZygoRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 			return &result, nil
 		})
 
+	z.env.AddFunction("sign",
+		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
+			a := &APIFnSign{}
+			args := a.Args()
+			err := zyProcessArgs(&z, args, zyargs)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			a.data = []byte(args[0].value.(string))
+			r, err := a.Call(h)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			var result = zygo.SexpStr{S: r.(string)}
+			return &result, nil
+		})
+
+	z.env.AddFunction("verifySignature",
+		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
+			a := &APIFnVerifySignature{}
+			args := a.Args()
+			err := zyProcessArgs(&z, args, zyargs)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			a.b58signature = args[0].value.(string)
+			a.data = args[1].value.(string)
+			a.b58pubKey = args[2].value.(string)
+			r, err := a.Call(h)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			return &zygo.SexpBool{Val: r.(bool)}, nil
+		})
+
 	z.env.AddFunction("getBridges",
 		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
 			a := &APIFnGetBridges{}
@@ -837,6 +920,26 @@ func NewZygoRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 			return &zygo.SexpStr{S: r.(string)}, err
 		})
 
+	z.env.AddFunction("crossDNAGet",
+		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
+			a := &APIFnCrossDNAGet{}
+			args := a.Args()
+			err := zyProcessArgs(&z, args, zyargs)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			a.dna = args[0].value.(Hash)
+			a.hash = args[1].value.(Hash)
+
+			var r interface{}
+			r, err = a.Call(h)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+
+			return &zygo.SexpStr{S: r.(string)}, err
+		})
+
 	z.env.AddFunction("commit",
 		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
 			a := &APIFnCommit{}
@@ -881,6 +984,93 @@ func NewZygoRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 			fn.action.entry.DNAHash = DNAHash
 			fn.action.entry.Key = Key
 			fn.action.entry.Data = Data
+			if args[4].value != nil {
+				fn.quorum = int(args[4].value.(int64))
+			}
+			if args[5].value != nil {
+				fn.createLink = args[5].value.(bool)
+			}
+			if args[6].value != nil {
+				fn.idempotencyKey = args[6].value.(string)
+			}
+
+			r, err = fn.Call(h)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			var entryHash Hash
+			if r != nil {
+				entryHash = r.(Hash)
+			}
+
+			var result = zygo.SexpStr{S: entryHash.String()}
+			return &result, nil
+		})
+
+	z.env.AddFunction("migrateCheck",
+		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
+			fn := &APIFnMigrateCheck{}
+			args := fn.Args()
+			err := zyProcessArgs(&z, args, zyargs)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+
+			fn.action.entry.Type = args[0].value.(string)
+			fn.action.entry.DNAHash = args[1].value.(Hash)
+			fn.action.entry.Key = args[2].value.(Hash)
+			fn.action.entry.Data = args[3].value.(string)
+
+			r, err := fn.Call(h)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			j, err := json.Marshal(r.(MigrateCheckResult))
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+
+			var result = zygo.SexpStr{S: string(j)}
+			return &result, nil
+		})
+
+	z.env.AddFunction("migrateRollback",
+		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
+			fn := &APIFnMigrateRollback{}
+			args := fn.Args()
+			err := zyProcessArgs(&z, args, zyargs)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+
+			var r interface{}
+			fn.action.entry.MigrateHeaderHash = args[0].value.(Hash)
+
+			r, err = fn.Call(h)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			var entryHash Hash
+			if r != nil {
+				entryHash = r.(Hash)
+			}
+
+			var result = zygo.SexpStr{S: entryHash.String()}
+			return &result, nil
+		})
+
+	z.env.AddFunction("revokeKey",
+		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
+			fn := &APIFnRevokeKey{}
+			args := fn.Args()
+			err := zyProcessArgs(&z, args, zyargs)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+
+			var r interface{}
+			fn.newKey = args[0].value.(Hash)
+			fn.proof = args[1].value.(string)
 
 			r, err = fn.Call(h)
 			if err != nil {
@@ -895,6 +1085,70 @@ func NewZygoRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 			return &result, nil
 		})
 
+	z.env.AddFunction("getMigrationHistory",
+		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
+			fn := &APIFnGetMigrationHistory{}
+			args := fn.Args()
+			err := zyProcessArgs(&z, args, zyargs)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			fn.DNAHash = args[0].value.(Hash)
+			fn.Key = args[1].value.(Hash)
+			if args[2].value != nil {
+				opts, ok := args[2].value.(map[string]interface{})
+				if ok {
+					for attr, val := range map[string]*int{"Offset": &fn.Pagination.Offset, "Limit": &fn.Pagination.Limit} {
+						v, ok := opts[attr]
+						if ok {
+							i, ok := v.(float64)
+							if !ok {
+								return zygo.SexpNull,
+									fmt.Errorf("expecting int %s attribute in object, got %T", attr, v)
+							}
+							*val = int(i)
+						}
+					}
+				}
+			}
+
+			r, err := fn.Call(h)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			var result = zygo.SexpStr{S: r.(string)}
+			return &result, nil
+		})
+
+	z.env.AddFunction("getMigrationTargets",
+		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
+			fn := &APIFnGetMigrationTargets{}
+			args := fn.Args()
+			err := zyProcessArgs(&z, args, zyargs)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			fn.SourceDNAHash = args[0].value.(Hash)
+
+			r, err := fn.Call(h)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			var result = zygo.SexpStr{S: r.(string)}
+			return &result, nil
+		})
+
+	z.env.AddFunction("selfTest",
+		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
+			fn := &APIFnSelfTest{}
+			r, err := fn.Call(h)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			var result = zygo.SexpStr{S: r.(string)}
+			return &result, nil
+		})
+
 	z.env.AddFunction("query",
 		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
 			a := &APIFnQuery{}
@@ -1105,6 +1359,24 @@ func NewZygoRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 						resultValue = zSources
 					}
 				}
+				var zHolders *zygo.SexpArray
+				if mask&GetMaskHolders != 0 {
+					holders := make([]zygo.Sexp, len(getResp.Holders))
+					for i, id := range getResp.Holders {
+						holders[i] = &zygo.SexpStr{S: peer.IDB58Encode(id)}
+					}
+					zHolders = env.NewSexpArray(holders)
+					if GetMaskHolders == mask {
+						singleValueReturn = true
+						resultValue = zHolders
+					}
+				}
+				if mask&GetMaskStatus != 0 {
+					if GetMaskStatus == mask {
+						singleValueReturn = true
+						resultValue = &zygo.SexpInt{Val: int64(getResp.Status)}
+					}
+				}
 				if err == nil && !singleValueReturn {
 					// build the return object
 					var respObj *zygo.SexpHash
@@ -1120,6 +1392,12 @@ func NewZygoRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 						if err == nil && mask&GetMaskSources != 0 {
 							err = respObj.HashSet(env.MakeSymbol("Sources"), zSources)
 						}
+						if err == nil && mask&GetMaskHolders != 0 {
+							err = respObj.HashSet(env.MakeSymbol("Holders"), zHolders)
+						}
+						if err == nil && mask&GetMaskStatus != 0 {
+							err = respObj.HashSet(env.MakeSymbol("Status"), &zygo.SexpInt{Val: int64(getResp.Status)})
+						}
 					}
 				}
 			}
@@ -1254,17 +1532,32 @@ func NewZygoRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 					}
 					options.StatusMask = int(maskval)
 				}
+				for attr, val := range map[string]*int{"Offset": &options.Offset, "Limit": &options.Limit} {
+					v, ok := opts[attr]
+					if ok {
+						i, ok := v.(float64)
+						if !ok {
+							return zygo.SexpNull,
+								fmt.Errorf("expecting int %s attribute in object, got %T", attr, v)
+						}
+						*val = int(i)
+					}
+				}
 			}
 
 			var r interface{}
-			fn.action = *NewGetLinksAction(&LinkQuery{Base: base, T: tag, StatusMask: options.StatusMask}, &options)
+			fn.action = *NewGetLinksAction(&LinkQuery{Base: base, T: tag, StatusMask: options.StatusMask, Pagination: Pagination{Offset: options.Offset, Limit: options.Limit}}, &options)
 			r, err = fn.Call(h)
 			var resultValue zygo.Sexp
 			if err == nil {
 				response := r.(*LinkQueryResp)
 				resultValue = zygo.SexpNull
 				var j []byte
-				j, err = json.Marshal(response.Links)
+				if options.Offset != 0 || options.Limit != 0 {
+					j, err = json.Marshal(response)
+				} else {
+					j, err = json.Marshal(response.Links)
+				}
 				if err == nil {
 					resultValue = &zygo.SexpStr{S: string(j)}
 				}
@@ -1272,6 +1565,83 @@ func NewZygoRibosome(h *Holochain, zome *Zome) (n Ribosome, err error) {
 			return makeResult(env, resultValue, err)
 		})
 
+	z.env.AddFunction("validationChainLength",
+		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
+			if z.validationCtx == nil {
+				return zygo.SexpNull, ErrNoValidationContext
+			}
+			return &zygo.SexpInt{Val: int64(z.validationCtx.Chain().Length())}, nil
+		})
+
+	z.env.AddFunction("validationChainEntries",
+		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
+			if z.validationCtx == nil {
+				return zygo.SexpNull, ErrNoValidationContext
+			}
+			args := []Arg{{Name: "entryType", Type: StringArg}}
+			err := zyProcessArgs(&z, args, zyargs)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			results := []zygo.Sexp{}
+			err = z.validationCtx.Chain().IterEntriesByType(args[0].value.(string), func(header *Header, entry Entry) (e error) {
+				var result *zygo.SexpHash
+				result, e = zygo.MakeHash(nil, "hash", env)
+				if e != nil {
+					return
+				}
+				var hdr *zygo.SexpHash
+				hdr, e = zygo.MakeHash(nil, "hash", env)
+				if e == nil {
+					e = hdr.HashSet(env.MakeSymbol("EntryLink"), &zygo.SexpStr{S: header.EntryLink.String()})
+				}
+				if e == nil {
+					e = hdr.HashSet(env.MakeSymbol("Type"), &zygo.SexpStr{S: header.Type})
+				}
+				if e == nil {
+					e = hdr.HashSet(env.MakeSymbol("Time"), &zygo.SexpStr{S: header.Time.UTC().Format(time.RFC3339)})
+				}
+				if e == nil {
+					e = result.HashSet(env.MakeSymbol("Header"), hdr)
+				}
+				var content zygo.Sexp
+				if e == nil {
+					content, e = zyEntryContent(entry)
+				}
+				if e == nil {
+					e = result.HashSet(env.MakeSymbol("Entry"), content)
+				}
+				if e == nil {
+					results = append(results, result)
+				}
+				return
+			})
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			return env.NewSexpArray(results), nil
+		})
+
+	z.env.AddFunction("validationEmit",
+		func(env *zygo.Zlisp, name string, zyargs []zygo.Sexp) (zygo.Sexp, error) {
+			if z.validationCtx == nil {
+				return zygo.SexpNull, ErrNoValidationContext
+			}
+			args := []Arg{{Name: "signalName", Type: StringArg}, {Name: "payload", Type: ArgsArg}}
+			err := zyProcessArgs(&z, args, zyargs)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			var payload interface{} = args[1].value
+			if _, ok := zyargs[1].(*zygo.SexpHash); ok {
+				if err = json.Unmarshal([]byte(args[1].value.(string)), &payload); err != nil {
+					return zygo.SexpNull, err
+				}
+			}
+			z.validationCtx.Emit(args[0].value.(string), payload)
+			return zygo.SexpNull, nil
+		})
+
 	l := ZygoLibrary
 	if h != nil {
 		z.env.AddGlobal("App_Name", &zygo.SexpStr{S: h.Name()})
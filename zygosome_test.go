@@ -246,11 +246,11 @@ func TestNewZygoRibosome(t *testing.T) {
 			}, `async result of message with 123 was: (hash pong:"foobar")`)
 		})
 		Convey("migrate", func() {
-			dnaHash, err := genTestStringHash()
+			dnaHash, err := GenTestStringHash()
 			So(err, ShouldBeNil)
-			key, err := genTestStringHash()
+			key, err := GenTestStringHash()
 			So(err, ShouldBeNil)
-			data, err := genTestString()
+			data, err := GenTestString()
 			So(err, ShouldBeNil)
 
 			_, err = z.Run(`(migrate HC_Migrate_Close "` + dnaHash.String() + `" "` + key.String() + `" "` + data + `")`)
@@ -374,6 +374,21 @@ func TestZyReceive(t *testing.T) {
 	})
 }
 
+func TestZyReceiveSignal(t *testing.T) {
+	d, _, h := PrepareTestChain("test")
+	defer CleanupTestChain(h, d)
+	Convey("it should call a receiveSignal function with the signal", t, func() {
+		z, _ := NewZygoRibosome(h, &Zome{RibosomeType: ZygoRibosomeType, Code: `(defn receiveSignal [name body] (debug (concat "signal:" name ":" (hget body %hash))))`})
+		ShouldLog(h.nucleus.alog, func() {
+			So(z.ReceiveSignal(Signal{Name: SignalMigrateConfirmed, Body: map[string]string{"hash": "QmFoo"}}), ShouldBeNil)
+		}, `signal:`+SignalMigrateConfirmed+`:QmFoo`)
+	})
+	Convey("it should do nothing if the zome has no receiveSignal function", t, func() {
+		z, _ := NewZygoRibosome(h, &Zome{RibosomeType: ZygoRibosomeType, Code: ``})
+		So(z.ReceiveSignal(Signal{Name: SignalMigrateConfirmed}), ShouldBeNil)
+	})
+}
+
 func TestZybuildValidate(t *testing.T) {
 	d, _, h := PrepareTestChain("test")
 	defer CleanupTestChain(h, d)